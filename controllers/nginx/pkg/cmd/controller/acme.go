@@ -0,0 +1,155 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+
+	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
+	"k8s.io/ingress/core/pkg/k8s"
+)
+
+const (
+	acmeChallengePath     = "/.well-known/acme-challenge/"
+	acmeChallengeUpstream = "upstream-acme-challenge"
+)
+
+// getACMEChallengeBackend returns an upstream pointing to the ClusterIP of
+// the service used to solve ACME HTTP-01 challenges. The expected format
+// of the service is <namespace>/<name>:<port>
+func (n *NGINXController) getACMEChallengeBackend(svcPort string) (*ingress.Backend, error) {
+	nsSvcPort := strings.Split(svcPort, ":")
+	if len(nsSvcPort) != 2 {
+		return nil, fmt.Errorf("invalid format (namespace/name:port) found in '%v'", svcPort)
+	}
+
+	if _, _, err := k8s.ParseNameNS(nsSvcPort[0]); err != nil {
+		return nil, err
+	}
+
+	svcObj, exists, err := n.storeLister.Service.GetByKey(nsSvcPort[0])
+	if err != nil {
+		return nil, fmt.Errorf("error getting service %v: %v", nsSvcPort[0], err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("service %v does not exist", nsSvcPort[0])
+	}
+
+	svc := svcObj.(*api_v1.Service)
+	if svc.Spec.ClusterIP == "" {
+		return nil, fmt.Errorf("no ClusterIP found for service %v", nsSvcPort[0])
+	}
+
+	port := ""
+	for _, sp := range svc.Spec.Ports {
+		if sp.Name == nsSvcPort[1] || strconv.Itoa(int(sp.Port)) == nsSvcPort[1] {
+			port = strconv.Itoa(int(sp.Port))
+			break
+		}
+	}
+
+	if port == "" {
+		return nil, fmt.Errorf("service %v does not contain the port %v", nsSvcPort[0], nsSvcPort[1])
+	}
+
+	return &ingress.Backend{
+		Name: acmeChallengeUpstream,
+		Endpoints: []ingress.Endpoint{
+			{
+				Address: svc.Spec.ClusterIP,
+				Port:    port,
+			},
+		},
+	}, nil
+}
+
+// acmeChallengeAccessList allows the access to the challenge path from
+// any client, replacing the access list of the server
+var acmeChallengeAccessList = ipaccess.AccessList{
+	AllowCIDRs: []string{"0.0.0.0/0", "::/0"},
+}
+
+// injectACMEChallenge returns a copy of the servers where each server
+// without a SSL certificate contains a location for the ACME HTTP-01
+// challenge path pointing to the specified upstream.
+// The location uses the modifier ^~ so the challenge takes precedence over
+// the regular expressions used in rewrites.
+// Servers already exposing the challenge path in an Ingress rule are not
+// modified to avoid duplicated locations.
+// With override (compatibility mode) every server, including the servers
+// with TLS, routes the challenge path to the upstream: the locations of the
+// Ingress rules for the same path are replaced and the access list of the
+// server is ignored, so the redirects to HTTPS, the authentication and the
+// whitelists never block the challenges.
+// The original servers are not modified because they are used to detect
+// changes in the configuration.
+func injectACMEChallenge(servers []*ingress.Server, upstream string, override bool) []*ingress.Server {
+	res := make([]*ingress.Server, 0, len(servers))
+	for _, server := range servers {
+		if server.SSLCertificate != "" && !override {
+			res = append(res, server)
+			continue
+		}
+
+		var rootLoc *ingress.Location
+		locations := []*ingress.Location{}
+		exists := false
+		for _, loc := range server.Locations {
+			if loc.Path == "/" {
+				rootLoc = loc
+			}
+			if strings.TrimSuffix(loc.Path, "/") == strings.TrimSuffix(acmeChallengePath, "/") {
+				exists = true
+				if override {
+					glog.V(3).Infof("replacing location %v of server %v with the ACME challenge service", loc.Path, server.Hostname)
+					continue
+				}
+			}
+			locations = append(locations, loc)
+		}
+
+		if exists && !override {
+			glog.V(3).Infof("server %v already contains a location for %v", server.Hostname, acmeChallengePath)
+			res = append(res, server)
+			continue
+		}
+
+		loc := &ingress.Location{
+			Path:    fmt.Sprintf("^~ %v", acmeChallengePath),
+			Backend: upstream,
+		}
+		if rootLoc != nil {
+			loc.Proxy = rootLoc.Proxy
+		}
+		if override {
+			loc.AccessList = acmeChallengeAccessList
+		}
+
+		s := *server
+		s.Locations = append([]*ingress.Location{loc}, locations...)
+		res = append(res, &s)
+	}
+
+	return res
+}
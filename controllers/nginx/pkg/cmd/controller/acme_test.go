@@ -0,0 +1,132 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
+	ing_proxy "k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+)
+
+func TestInjectACMEChallenge(t *testing.T) {
+	servers := []*ingress.Server{
+		{
+			Hostname: "plain.bar.com",
+			Locations: []*ingress.Location{
+				{Path: "/", Backend: "default-plain-80", Proxy: ing_proxy.Configuration{ConnectTimeout: 5}},
+				{Path: "/api", Backend: "default-api-80"},
+			},
+		},
+		{
+			Hostname:       "tls.bar.com",
+			SSLCertificate: "/ingress-controller/ssl/default-tls.pem",
+			Locations: []*ingress.Location{
+				{Path: "/", Backend: "default-tls-80"},
+			},
+		},
+		{
+			Hostname: "solver.bar.com",
+			Locations: []*ingress.Location{
+				{Path: "/.well-known/acme-challenge", Backend: "default-solver-80"},
+				{Path: "/", Backend: "default-solver-80"},
+			},
+		},
+	}
+
+	res := injectACMEChallenge(servers, acmeChallengeUpstream, false)
+	if len(res) != len(servers) {
+		t.Fatalf("expected %v servers but returned %v", len(servers), len(res))
+	}
+
+	plain := res[0]
+	if len(plain.Locations) != 3 {
+		t.Fatalf("expected 3 locations but returned %v", len(plain.Locations))
+	}
+	loc := plain.Locations[0]
+	if loc.Path != "^~ /.well-known/acme-challenge/" {
+		t.Errorf("expected a location with precedence over regular expressions but returned %v", loc.Path)
+	}
+	if loc.Backend != acmeChallengeUpstream {
+		t.Errorf("expected upstream %v but returned %v", acmeChallengeUpstream, loc.Backend)
+	}
+	if loc.Proxy.ConnectTimeout != 5 {
+		t.Errorf("expected the proxy configuration of the root location but returned %v", loc.Proxy)
+	}
+	if len(servers[0].Locations) != 2 {
+		t.Errorf("expected the original server to remain unmodified")
+	}
+
+	if res[1] != servers[1] || len(res[1].Locations) != 1 {
+		t.Errorf("expected no changes in a server with TLS")
+	}
+
+	solver := res[2]
+	if len(solver.Locations) != 2 {
+		t.Fatalf("expected 2 locations but returned %v", len(solver.Locations))
+	}
+	if solver.Locations[0].Backend != "default-solver-80" {
+		t.Errorf("expected the location defined in the Ingress rule to take precedence but returned %v", solver.Locations[0].Backend)
+	}
+}
+
+func TestInjectACMEChallengeOverride(t *testing.T) {
+	servers := []*ingress.Server{
+		{
+			Hostname:       "tls.bar.com",
+			SSLCertificate: "/ingress-controller/ssl/default-tls.pem",
+			AccessList:     ipaccess.AccessList{AllowCIDRs: []string{"10.0.0.0/8"}},
+			Locations: []*ingress.Location{
+				{Path: "/", Backend: "default-tls-80"},
+			},
+		},
+		{
+			Hostname: "solver.bar.com",
+			Locations: []*ingress.Location{
+				{Path: "/.well-known/acme-challenge", Backend: "default-solver-80"},
+				{Path: "/", Backend: "default-solver-80"},
+			},
+		},
+	}
+
+	res := injectACMEChallenge(servers, acmeChallengeUpstream, true)
+	if len(res) != len(servers) {
+		t.Fatalf("expected %v servers but returned %v", len(servers), len(res))
+	}
+
+	for _, server := range res {
+		if len(server.Locations) != 2 {
+			t.Fatalf("%v: expected 2 locations but returned %v", server.Hostname, len(server.Locations))
+		}
+		loc := server.Locations[0]
+		if loc.Backend != acmeChallengeUpstream {
+			t.Errorf("%v: expected upstream %v but returned %v", server.Hostname, acmeChallengeUpstream, loc.Backend)
+		}
+		if !reflect.DeepEqual(loc.AccessList, acmeChallengeAccessList) {
+			t.Errorf("%v: expected the challenge to be allowed from any address but returned %v", server.Hostname, loc.AccessList)
+		}
+		if server.Locations[1].Path != "/" {
+			t.Errorf("%v: expected the root location but returned %v", server.Hostname, server.Locations[1].Path)
+		}
+	}
+
+	if len(servers[1].Locations) != 2 || servers[1].Locations[0].Backend != "default-solver-80" {
+		t.Errorf("expected the original server to remain unmodified")
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"gopkg.in/fsnotify.v1"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+	"k8s.io/ingress/core/pkg/net/ssl"
+)
+
+// certificateDebounce is the time to wait after the last change in a
+// certificate file before the reload. Tools like cert-manager or the
+// kubelet can write the same file several times in a short period
+const certificateDebounce = 2 * time.Second
+
+// certificateWatcher watches the certificate files used in the NGINX
+// configuration and invokes onChange when the content of a file changes.
+// NGINX only reads the certificates in a reload, so a change in a file
+// without changes in the Ingress rules would be ignored until the next
+// unrelated reload.
+type certificateWatcher struct {
+	mu sync.Mutex
+
+	debounce time.Duration
+	onChange func()
+
+	// checksums contains the SHA of the content of the watched files
+	checksums map[string]string
+	// watcher watches the directories of the files (one inotify
+	// instance for all the files, usually in the same directory)
+	watcher *fsnotify.Watcher
+	// dirs contains the directories added to the watcher
+	dirs map[string]bool
+
+	timer *time.Timer
+}
+
+// newCertificateWatcher creates a watcher without files
+func newCertificateWatcher(debounce time.Duration, onChange func()) *certificateWatcher {
+	return &certificateWatcher{
+		debounce:  debounce,
+		onChange:  onChange,
+		checksums: map[string]string{},
+		dirs:      map[string]bool{},
+	}
+}
+
+// Watch replaces the watched files with the files in the list
+func (w *certificateWatcher) Watch(files []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	checksums := map[string]string{}
+	dirs := map[string]bool{}
+	for _, file := range files {
+		file = filepath.Clean(file)
+		checksums[file] = ssl.PemSHA1(file)
+		dirs[filepath.Dir(file)] = true
+	}
+	w.checksums = checksums
+
+	if w.watcher == nil {
+		if len(dirs) == 0 {
+			return
+		}
+
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			glog.Warningf("unexpected error watching the certificate files: %v", err)
+			return
+		}
+		w.watcher = watcher
+		go w.run(watcher)
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+
+		if err := w.watcher.Add(dir); err != nil {
+			glog.Warningf("unexpected error watching the certificate files in %v: %v", dir, err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+
+	if len(w.dirs) == 0 {
+		// nothing to watch or the directories cannot be watched
+		w.watcher.Close()
+		w.watcher = nil
+	}
+}
+
+// Close ends the watch of all the files
+func (w *certificateWatcher) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	if w.watcher != nil {
+		w.watcher.Close()
+		w.watcher = nil
+	}
+	w.dirs = map[string]bool{}
+}
+
+// run processes the events of the watcher until it is closed. Only the
+// events of the watched files are considered, not the rest of the files
+// of the directories
+func (w *certificateWatcher) run(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				// the watcher was closed
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			w.mu.Lock()
+			_, watched := w.checksums[filepath.Clean(event.Name)]
+			w.mu.Unlock()
+			if watched {
+				w.onEvent()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			glog.Warningf("unexpected error watching the certificate files: %v", err)
+		}
+	}
+}
+
+// onEvent delays the check of the files until no new events
+// are received during the debounce period
+func (w *certificateWatcher) onEvent() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, w.check)
+}
+
+// check invokes onChange if the content of any of
+// the watched files changed and is a valid certificate
+func (w *certificateWatcher) check() {
+	w.mu.Lock()
+
+	changed := []string{}
+	for file, checksum := range w.checksums {
+		sha := ssl.PemSHA1(file)
+		if sha == "" || sha == checksum {
+			continue
+		}
+
+		if err := loadCertificate(file); err != nil {
+			glog.Warningf("ignoring change in certificate file %v: %v", file, err)
+			continue
+		}
+
+		w.checksums[file] = sha
+		changed = append(changed, file)
+	}
+
+	w.mu.Unlock()
+
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Strings(changed)
+	glog.Infof("certificate files %v changed", changed)
+	w.onChange()
+}
+
+// loadCertificate checks the file contains valid PEM encoded
+// certificates and, if present, a private key of the certificate
+func loadCertificate(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	hasKey := false
+	certs := 0
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				return err
+			}
+			certs++
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
+			hasKey = true
+		}
+	}
+
+	if certs == 0 {
+		return fmt.Errorf("no certificates found")
+	}
+
+	if hasKey {
+		_, err := tls.X509KeyPair(data, data)
+		return err
+	}
+
+	return nil
+}
+
+// certificateFiles returns the certificate files used in the configuration
+func certificateFiles(tc config.TemplateConfig) []string {
+	files := map[string]bool{}
+	for _, server := range tc.Servers {
+		if server.SSLCertificate != "" {
+			files[server.SSLCertificate] = true
+		}
+		for _, cert := range server.SSLAdditionalCertificates {
+			files[cert.PemFileName] = true
+		}
+		for _, location := range server.Locations {
+			if location.CertificateAuth.AuthSSLCert.CAFileName != "" {
+				files[location.CertificateAuth.AuthSSLCert.CAFileName] = true
+			}
+		}
+	}
+
+	res := make([]string, 0, len(files))
+	for file := range files {
+		res = append(res, file)
+	}
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPemCertificate returns a self-signed certificate and
+// its private key in the format used by the controller
+func newPemCertificate(t *testing.T, cn string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("unexpected error generating the private key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating the certificate: %v", err)
+	}
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return append(pemCert, pemKey...)
+}
+
+func TestCertificateWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pemFile := filepath.Join(dir, "default-foo.pem")
+	if err := ioutil.WriteFile(pemFile, newPemCertificate(t, "foo"), 0644); err != nil {
+		t.Fatalf("unexpected error writing certificate: %v", err)
+	}
+
+	var reloads int32
+	w := newCertificateWatcher(200*time.Millisecond, func() {
+		atomic.AddInt32(&reloads, 1)
+	})
+	defer w.Close()
+	w.Watch([]string{pemFile})
+
+	// a truncate and write followed by an atomic rename
+	// of the same file must produce only one reload
+	ioutil.WriteFile(pemFile, []byte{}, 0644)
+	ioutil.WriteFile(pemFile, newPemCertificate(t, "foo"), 0644)
+	tmpFile := filepath.Join(dir, ".default-foo.pem.tmp")
+	ioutil.WriteFile(tmpFile, newPemCertificate(t, "foo"), 0644)
+	if err := os.Rename(tmpFile, pemFile); err != nil {
+		t.Fatalf("unexpected error renaming certificate: %v", err)
+	}
+
+	time.Sleep(time.Second)
+	if r := atomic.LoadInt32(&reloads); r != 1 {
+		t.Errorf("expected one reload after updating the certificate but returned %v", r)
+	}
+
+	// an invalid certificate must not produce a reload
+	ioutil.WriteFile(pemFile, []byte("-----BEGIN CERTIFICATE-----\ninvalid\n-----END CERTIFICATE-----\n"), 0644)
+	time.Sleep(time.Second)
+	if r := atomic.LoadInt32(&reloads); r != 1 {
+		t.Errorf("unexpected reload with an invalid certificate")
+	}
+}
+
+func TestCertificateWatcherDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// more files than the default limit of inotify instances (128)
+	cert := newPemCertificate(t, "foo")
+	files := []string{}
+	for i := 0; i < 200; i++ {
+		pemFile := filepath.Join(dir, fmt.Sprintf("default-foo-%v.pem", i))
+		if err := ioutil.WriteFile(pemFile, cert, 0644); err != nil {
+			t.Fatalf("unexpected error writing certificate: %v", err)
+		}
+		files = append(files, pemFile)
+	}
+
+	var reloads int32
+	w := newCertificateWatcher(200*time.Millisecond, func() {
+		atomic.AddInt32(&reloads, 1)
+	})
+	defer w.Close()
+	w.Watch(files)
+
+	// the files in the same directory share the watch
+	if w.watcher == nil || len(w.dirs) != 1 || !w.dirs[dir] {
+		t.Fatalf("expected one watch of the directory %v but returned %v", dir, w.dirs)
+	}
+
+	// changes in other files of the directory are ignored
+	ioutil.WriteFile(filepath.Join(dir, "default-bar.pem"), newPemCertificate(t, "bar"), 0644)
+	time.Sleep(time.Second)
+	w.mu.Lock()
+	timer := w.timer
+	w.mu.Unlock()
+	if timer != nil || atomic.LoadInt32(&reloads) != 0 {
+		t.Errorf("unexpected check after a change in a file not watched")
+	}
+
+	ioutil.WriteFile(files[150], newPemCertificate(t, "foo"), 0644)
+	time.Sleep(time.Second)
+	if r := atomic.LoadInt32(&reloads); r != 1 {
+		t.Errorf("expected one reload after updating the certificate but returned %v", r)
+	}
+
+	w.Watch([]string{})
+	if w.watcher != nil || len(w.dirs) != 0 {
+		t.Errorf("expected the watch closed without files")
+	}
+}
+
+func TestLoadCertificate(t *testing.T) {
+	f, err := ioutil.TempFile("", "cert")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	ioutil.WriteFile(f.Name(), newPemCertificate(t, "foo"), 0644)
+	if err := loadCertificate(f.Name()); err != nil {
+		t.Errorf("unexpected error loading a valid certificate: %v", err)
+	}
+
+	// a certificate with the key of other certificate
+	cert := newPemCertificate(t, "foo")
+	other := newPemCertificate(t, "bar")
+	certBlock, _ := pem.Decode(cert)
+	_, keyRest := pem.Decode(other)
+	ioutil.WriteFile(f.Name(), append(pem.EncodeToMemory(certBlock), keyRest...), 0644)
+	if err := loadCertificate(f.Name()); err == nil {
+		t.Errorf("expected an error loading a certificate with a different key")
+	}
+
+	ioutil.WriteFile(f.Name(), []byte{}, 0644)
+	if err := loadCertificate(f.Name()); err == nil {
+		t.Errorf("expected an error loading an empty file")
+	}
+}
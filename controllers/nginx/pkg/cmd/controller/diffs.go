@@ -0,0 +1,70 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+const (
+	// number of changes in the configuration kept in memory
+	maxConfigDiffs = 20
+)
+
+// configDiffs contains the last changes in the NGINX configuration
+type configDiffs struct {
+	mu    sync.Mutex
+	diffs []ingress.ConfigurationDiff
+}
+
+// add records a change in the configuration, removing
+// the oldest change if there are more than max changes
+func (c *configDiffs) add(diff string, now time.Time, max int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.diffs = append(c.diffs, ingress.ConfigurationDiff{
+		Time: now,
+		Diff: diff,
+	})
+	if len(c.diffs) > max {
+		c.diffs = c.diffs[len(c.diffs)-max:]
+	}
+}
+
+// list returns the changes in the configuration, the newest first
+func (c *configDiffs) list() []ingress.ConfigurationDiff {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]ingress.ConfigurationDiff, 0, len(c.diffs))
+	for i := len(c.diffs) - 1; i >= 0; i-- {
+		res = append(res, c.diffs[i])
+	}
+	return res
+}
+
+// ConfigurationDiffs returns the last changes in the NGINX configuration
+func (n *NGINXController) ConfigurationDiffs() []ingress.ConfigurationDiff {
+	if n.diffs == nil {
+		return []ingress.ConfigurationDiff{}
+	}
+	return n.diffs.list()
+}
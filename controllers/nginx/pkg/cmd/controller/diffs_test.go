@@ -0,0 +1,48 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConfigDiffs(t *testing.T) {
+	c := &configDiffs{}
+	if diffs := c.list(); len(diffs) != 0 {
+		t.Errorf("expected no changes but returned %v", len(diffs))
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		c.add(fmt.Sprintf("diff %v", i), now.Add(time.Duration(i)*time.Second), 3)
+	}
+
+	diffs := c.list()
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 changes but returned %v", len(diffs))
+	}
+	for i, expected := range []string{"diff 4", "diff 3", "diff 2"} {
+		if diffs[i].Diff != expected {
+			t.Errorf("expected %v in the position %v but returned %v", expected, i, diffs[i].Diff)
+		}
+	}
+	if !diffs[0].Time.Equal(now.Add(4 * time.Second)) {
+		t.Errorf("unexpected time of the last change: %v", diffs[0].Time)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// default time to wait for the active connections
+	// to finish before NGINX is stopped
+	defaultDrainTimeout = 10 * time.Second
+
+	// interval used to check the number of active connections
+	drainInterval = 1 * time.Second
+
+	// default time to wait for the NGINX master process
+	// to exit after a graceful shutdown. The sum with the
+	// drain timeout must be lower than the default
+	// terminationGracePeriodSeconds of the pods (30s)
+	defaultGracePeriod = 15 * time.Second
+
+	// default terminationGracePeriodSeconds of the pods
+	defaultTerminationGracePeriod = 30 * time.Second
+)
+
+var activeConnectionsRegex = regexp.MustCompile(`Active connections: (\d+)`)
+
+// connectionCounter returns the number of active connections
+type connectionCounter func() (int, error)
+
+// Drain marks the controller as shutting down, so the readiness probe
+// fails and new configurations are ignored, and waits until the active
+// connections finish or the drain timeout expires
+func (n *NGINXController) Drain() {
+	atomic.StoreInt32(&n.shuttingDown, 1)
+	if n.reloads != nil {
+		n.reloads.Stop()
+	}
+
+	glog.Infof("draining connections (timeout %v)", n.drainTimeout)
+	if drainConnections(n.drainTimeout, drainInterval, activeConnections) {
+		glog.Infof("all the connections finished")
+		return
+	}
+	glog.Infof("drain timeout expired with active connections")
+}
+
+// Quit stops the NGINX master process gracefully and waits until
+// the in-flight requests finish or the grace period expires
+func (n *NGINXController) Quit() error {
+	atomic.StoreInt32(&n.shuttingDown, 1)
+
+	o, err := exec.Command(n.binary, "-s", "quit", "-c", cfgPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, string(o))
+	}
+
+	if n.stopped == nil {
+		return nil
+	}
+
+	glog.Infof("waiting for NGINX to stop (grace period %v)", n.gracePeriod)
+	if !waitForStop(n.stopped, n.gracePeriod) {
+		return fmt.Errorf("NGINX did not stop after %v", n.gracePeriod)
+	}
+
+	return nil
+}
+
+// waitForStop returns true if stopped is closed before the timeout expires
+func waitForStop(stopped <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// isShuttingDown returns true after the controller received SIGTERM
+func (n *NGINXController) isShuttingDown() bool {
+	return atomic.LoadInt32(&n.shuttingDown) == 1
+}
+
+// drainConnections waits until there are no active connections or the
+// timeout expires. Errors reading the number of connections (e.g. the
+// status module is not available) are ignored and the wait continues.
+// Returns false if the timeout expired
+func drainConnections(timeout, interval time.Duration, count connectionCounter) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		active, err := count()
+		if err != nil {
+			glog.V(3).Infof("unexpected error reading the active connections: %v", err)
+		} else if active == 0 {
+			return true
+		} else {
+			glog.V(3).Infof("waiting for %v active connections", active)
+		}
+
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return false
+		}
+		if remaining < interval {
+			interval = remaining
+		}
+		time.Sleep(interval)
+	}
+}
+
+// activeConnections returns the number of active connections in
+// NGINX, excluding the connection used to read the status
+func activeConnections() (int, error) {
+	res, err := http.Get(fmt.Sprintf("http://127.0.0.1:%v%v", ngxHealthPort, ngxStatusPath))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code %v reading NGINX status", res.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseActiveConnections(string(data))
+}
+
+// parseActiveConnections extracts the number of active connections
+// from the stub_status page, excluding the request of the page
+func parseActiveConnections(status string) (int, error) {
+	match := activeConnectionsRegex.FindStringSubmatch(status)
+	if len(match) != 2 {
+		return 0, fmt.Errorf("invalid NGINX status: %v", status)
+	}
+
+	active, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, err
+	}
+
+	if active > 0 {
+		active--
+	}
+
+	return active, nil
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+// fakeConnections returns the values in the list and
+// then the last one for every following call
+func fakeConnections(values ...int) connectionCounter {
+	i := 0
+	return func() (int, error) {
+		v := values[i]
+		if i < len(values)-1 {
+			i++
+		}
+		return v, nil
+	}
+}
+
+func TestDefaultShutdownPeriod(t *testing.T) {
+	if total := defaultDrainTimeout + defaultGracePeriod; total >= defaultTerminationGracePeriod {
+		t.Errorf("expected the default drain timeout and grace period (%v) lower than the default terminationGracePeriodSeconds (%v)",
+			total, defaultTerminationGracePeriod)
+	}
+}
+
+func TestDrainConnections(t *testing.T) {
+	start := time.Now()
+	if !drainConnections(time.Second, 10*time.Millisecond, fakeConnections(3, 2, 1, 0)) {
+		t.Errorf("expected the connections to finish before the timeout")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected to return when the connections finished but waited %v", elapsed)
+	}
+
+	start = time.Now()
+	if drainConnections(200*time.Millisecond, 10*time.Millisecond, fakeConnections(1)) {
+		t.Errorf("expected the timeout to expire with active connections")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected to wait the drain timeout but waited %v", elapsed)
+	}
+
+	start = time.Now()
+	failing := func() (int, error) {
+		return 0, fmt.Errorf("connection refused")
+	}
+	if drainConnections(200*time.Millisecond, 10*time.Millisecond, failing) {
+		t.Errorf("expected the timeout to expire when the connections cannot be read")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected to wait the drain timeout but waited %v", elapsed)
+	}
+}
+
+func TestParseActiveConnections(t *testing.T) {
+	status := `Active connections: 4 
+server accepts handled requests
+ 10 10 25 
+Reading: 0 Writing: 1 Waiting: 3 
+`
+	active, err := parseActiveConnections(status)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active != 3 {
+		t.Errorf("expected 3 active connections but returned %v", active)
+	}
+
+	if _, err := parseActiveConnections("invalid"); err == nil {
+		t.Errorf("expected an error parsing an invalid status")
+	}
+}
+
+func TestShuttingDown(t *testing.T) {
+	n := &NGINXController{shuttingDown: 1}
+
+	if err := n.Check(nil); err == nil {
+		t.Errorf("expected the health check to fail during the shutdown")
+	}
+
+	if err := n.OnUpdate(ingress.Configuration{}); err != nil {
+		t.Errorf("unexpected error ignoring the configuration during the shutdown: %v", err)
+	}
+}
+
+func TestWaitForStop(t *testing.T) {
+	stopped := make(chan struct{})
+	if waitForStop(stopped, 50*time.Millisecond) {
+		t.Errorf("expected the grace period to expire")
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(stopped)
+	}()
+	if !waitForStop(stopped, time.Second) {
+		t.Errorf("expected the process to stop before the grace period")
+	}
+}
+
+func TestStartDuringShutdown(t *testing.T) {
+	n := &NGINXController{
+		binary:       "true",
+		shuttingDown: 1,
+		stopped:      make(chan struct{}),
+	}
+
+	// the process must not be restarted during the shutdown
+	go n.Start()
+	if !waitForStop(n.stopped, 5*time.Second) {
+		t.Errorf("expected the controller to report the process stopped")
+	}
+}
+
+// TestHelperProcess is not a test. It runs the helper processes of the
+// tests of the process group of NGINX: "controller" starts a fake NGINX
+// master process with nginxCommand and exits after SIGTERM or SIGUSR2,
+// "nginx" serves HTTP in a random port and prints the received SIGUSR2
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("GO_HELPER_PROCESS") {
+	case "controller":
+		cmd := nginxCommand(os.Args[0], "-test.run=TestHelperProcess")
+		cmd.Env = append(os.Environ(), "GO_HELPER_PROCESS=nginx")
+		cmd.Stdout = os.Stdout
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, syscall.SIGUSR2)
+		if err := cmd.Start(); err != nil {
+			fmt.Printf("error %v\n", err)
+			os.Exit(1)
+		}
+		select {
+		case <-signals:
+		case <-time.After(time.Minute):
+		}
+		os.Exit(0)
+	case "nginx":
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR2)
+		go func() {
+			for range signals {
+				fmt.Println("USR2")
+			}
+		}()
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fmt.Printf("error %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%v %v\n", os.Getpid(), l.Addr().String())
+		http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		os.Exit(0)
+	}
+}
+
+// fakeNginx is a fake NGINX master process started by a fake
+// controller, both running the helper process of the tests
+type fakeNginx struct {
+	controller *exec.Cmd
+	pid        int
+	addr       string
+	lines      chan string
+}
+
+// startFakeNginx starts a fake controller in its own process group,
+// like the controller started by dumb-init, that starts a fake NGINX
+func startFakeNginx(t *testing.T) *fakeNginx {
+	controller := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	controller.Env = append(os.Environ(), "GO_HELPER_PROCESS=controller")
+	controller.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// the output of NGINX is read after the controller exits
+	out, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	controller.Stdout = w
+	err = controller.Start()
+	w.Close()
+	if err != nil {
+		out.Close()
+		t.Fatalf("unexpected error starting the fake controller: %v", err)
+	}
+
+	ngx := &fakeNginx{controller: controller, lines: make(chan string, 10)}
+	go func() {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			ngx.lines <- scanner.Text()
+		}
+		close(ngx.lines)
+		out.Close()
+	}()
+
+	select {
+	case line := <-ngx.lines:
+		if _, err := fmt.Sscanf(line, "%d %s", &ngx.pid, &ngx.addr); err != nil {
+			ngx.stop()
+			t.Fatalf("unexpected output of the fake NGINX: %v", line)
+		}
+	case <-time.After(10 * time.Second):
+		ngx.stop()
+		t.Fatalf("timeout waiting for the fake NGINX")
+	}
+
+	return ngx
+}
+
+// signalController sends the signal to the process group of the
+// controller, like dumb-init, and waits until the controller exits
+func (ngx *fakeNginx) signalController(t *testing.T, sig syscall.Signal) {
+	if err := syscall.Kill(-ngx.controller.Process.Pid, sig); err != nil {
+		t.Fatalf("unexpected error sending %v to the process group: %v", sig, err)
+	}
+	ngx.controller.Wait()
+}
+
+func (ngx *fakeNginx) stop() {
+	if ngx.pid != 0 {
+		syscall.Kill(ngx.pid, syscall.SIGKILL)
+	}
+	ngx.controller.Process.Kill()
+	ngx.controller.Wait()
+}
+
+func TestNginxServesDuringDrain(t *testing.T) {
+	ngx := startFakeNginx(t)
+	defer ngx.stop()
+
+	if pgid, _ := syscall.Getpgid(ngx.pid); pgid == ngx.controller.Process.Pid {
+		t.Fatalf("expected NGINX in its own process group")
+	}
+
+	// the SIGTERM of dumb-init stops the controller, that
+	// drains the connections, but must not stop NGINX
+	ngx.signalController(t, syscall.SIGTERM)
+
+	res, err := http.Get(fmt.Sprintf("http://%v/", ngx.addr))
+	if err != nil {
+		t.Fatalf("expected NGINX serving requests after the SIGTERM of the controller: %v", err)
+	}
+	res.Body.Close()
+}
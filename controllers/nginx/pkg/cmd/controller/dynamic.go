@@ -0,0 +1,121 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+const (
+	// location of the internal server that receives
+	// the endpoints of the upstreams (balancer.lua)
+	dynamicBackendsPath = "/configuration/backends"
+)
+
+var dynamicClient = &http.Client{Timeout: 10 * time.Second}
+
+// dynamicEndpoint is an endpoint of an upstream configured using Lua
+type dynamicEndpoint struct {
+	Address string `json:"address"`
+	Port    string `json:"port"`
+}
+
+// dynamicBackend contains the endpoints of an upstream configured using Lua
+type dynamicBackend struct {
+	Name      string            `json:"name"`
+	Endpoints []dynamicEndpoint `json:"endpoints"`
+}
+
+// dynamicBackends returns the endpoints of the backends
+// that can be updated without a reload of NGINX
+func dynamicBackends(backends []*ingress.Backend, algorithm string) []dynamicBackend {
+	res := []dynamicBackend{}
+	for _, backend := range backends {
+		if !ngx_template.IsDynamicUpstream(backend, algorithm) {
+			continue
+		}
+
+		endpoints := make([]dynamicEndpoint, 0, len(backend.Endpoints))
+		for _, endpoint := range backend.Endpoints {
+			endpoints = append(endpoints, dynamicEndpoint{
+				Address: endpoint.Address,
+				Port:    endpoint.Port,
+			})
+		}
+
+		res = append(res, dynamicBackend{
+			Name:      backend.Name,
+			Endpoints: endpoints,
+		})
+	}
+
+	return res
+}
+
+// withoutDynamicEndpoints returns a copy of the backends where the
+// endpoints of the dynamic backends are removed. The endpoints are
+// not rendered in the configuration file, so the result is used to
+// detect if a change requires a reload of NGINX
+func withoutDynamicEndpoints(backends []*ingress.Backend, algorithm string) []*ingress.Backend {
+	res := make([]*ingress.Backend, 0, len(backends))
+	for _, backend := range backends {
+		if !ngx_template.IsDynamicUpstream(backend, algorithm) {
+			res = append(res, backend)
+			continue
+		}
+
+		b := *backend
+		b.Endpoints = nil
+		res = append(res, &b)
+	}
+
+	return res
+}
+
+// configureDynamicBackends sends the endpoints of the dynamic
+// backends to NGINX using the internal server listening in port
+func configureDynamicBackends(port int, backends []dynamicBackend) error {
+	return postDynamicBackends(fmt.Sprintf("http://127.0.0.1:%v%v", port, dynamicBackendsPath), backends)
+}
+
+func postDynamicBackends(url string, backends []dynamicBackend) error {
+	b, err := json.Marshal(backends)
+	if err != nil {
+		return err
+	}
+
+	res, err := dynamicClient.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("unexpected error sending the endpoints to NGINX: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("unexpected status code %v sending the endpoints to NGINX: %s", res.StatusCode, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+func dynamicTestBackends() []*ingress.Backend {
+	sticky := &ingress.Backend{
+		Name:      "default-sticky-80",
+		Endpoints: []ingress.Endpoint{{Address: "10.0.0.3", Port: "80"}},
+	}
+	sticky.SessionAffinity.AffinityType = "cookie"
+
+	return []*ingress.Backend{
+		{
+			Name:      "default-foo-80",
+			Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "80", MaxFails: 1}, {Address: "10.0.0.2", Port: "80"}},
+		},
+		sticky,
+		{
+			Name:      "upstream-default-backend",
+			Endpoints: []ingress.Endpoint{{Address: "10.0.0.4", Port: "8080"}},
+		},
+	}
+}
+
+func TestDynamicBackends(t *testing.T) {
+	backends := dynamicBackends(dynamicTestBackends(), "round_robin")
+	expected := []dynamicBackend{
+		{
+			Name:      "default-foo-80",
+			Endpoints: []dynamicEndpoint{{Address: "10.0.0.1", Port: "80"}, {Address: "10.0.0.2", Port: "80"}},
+		},
+	}
+	if !reflect.DeepEqual(backends, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, backends)
+	}
+
+	backends = dynamicBackends(dynamicTestBackends(), "least_conn")
+	if len(backends) != 0 {
+		t.Errorf("expected no dynamic backends with least_conn but returned %+v", backends)
+	}
+}
+
+func TestWithoutDynamicEndpoints(t *testing.T) {
+	backends := dynamicTestBackends()
+	res := withoutDynamicEndpoints(backends, "round_robin")
+
+	if len(res) != len(backends) {
+		t.Fatalf("expected %v backends but returned %v", len(backends), len(res))
+	}
+	if len(res[0].Endpoints) != 0 {
+		t.Errorf("expected no endpoints in the dynamic backend but returned %v", res[0].Endpoints)
+	}
+	if len(backends[0].Endpoints) != 2 {
+		t.Errorf("unexpected change in the original backend")
+	}
+	if res[1] != backends[1] || res[2] != backends[2] {
+		t.Errorf("expected the same backends when the endpoints are rendered in the configuration")
+	}
+}
+
+func TestPostDynamicBackends(t *testing.T) {
+	var received []dynamicBackend
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST but %v was used", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("unexpected error decoding the backends: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	backends := dynamicBackends(dynamicTestBackends(), "round_robin")
+	err := postDynamicBackends(server.URL+dynamicBackendsPath, backends)
+	if err != nil {
+		t.Fatalf("unexpected error sending the backends: %v", err)
+	}
+	if !reflect.DeepEqual(received, backends) {
+		t.Errorf("expected %+v but the server received %+v", backends, received)
+	}
+
+	status = http.StatusBadRequest
+	err = postDynamicBackends(server.URL+dynamicBackendsPath, backends)
+	if err == nil {
+		t.Errorf("expected an error with status code %v", status)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -27,11 +28,21 @@ import (
 )
 
 func main() {
+	// test a template without a cluster
+	if len(os.Args) > 1 && os.Args[1] == templateTestCommand {
+		if err := templateTest(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// start a new nginx controller
 	ngx := newNGINXController()
 	// create a custom Ingress controller using NGINX as backend
 	ic := controller.NewIngressController(ngx)
-	go handleSigterm(ic)
+	go handleSigterm(ngx, ic)
+	go handleUpgrade(ngx)
 	// start the controller
 	ic.Start()
 	// wait
@@ -42,18 +53,40 @@ func main() {
 	}
 }
 
-func handleSigterm(ic *controller.GenericController) {
+func handleSigterm(ngx *NGINXController, ic *controller.GenericController) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGTERM)
 	<-signalChan
 	glog.Infof("Received SIGTERM, shutting down")
 
+	// the health check fails during the drain so the
+	// load balancer stops sending new connections
+	ngx.Drain()
+
 	exitCode := 0
 	if err := ic.Stop(); err != nil {
 		glog.Infof("Error during shutdown %v", err)
 		exitCode = 1
 	}
 
+	if err := ngx.Quit(); err != nil {
+		glog.Infof("Error stopping NGINX %v", err)
+		exitCode = 1
+	}
+
 	glog.Infof("Exiting with %v", exitCode)
 	os.Exit(exitCode)
 }
+
+// handleUpgrade replaces the NGINX master process with a new one
+// started from the binary after each SIGUSR2
+func handleUpgrade(ngx *NGINXController) {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGUSR2)
+	for range signalChan {
+		glog.Infof("Received SIGUSR2, upgrading the NGINX binary")
+		if err := ngx.Upgrade(); err != nil {
+			glog.Errorf("unexpected error upgrading the NGINX binary: %v", err)
+		}
+	}
+}
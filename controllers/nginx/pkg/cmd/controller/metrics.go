@@ -21,6 +21,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"k8s.io/ingress/controllers/nginx/pkg/metric/collector"
+	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/log"
 )
 
 const (
@@ -28,6 +30,69 @@ const (
 	ngxVtsPath    = "/nginx_status/format/json"
 )
 
+func init() {
+	prometheus.MustRegister(nginxRestarts)
+	prometheus.MustRegister(nginxShuttingDownWorkers)
+	prometheus.MustRegister(nginxConfigTestFailures)
+	prometheus.MustRegister(upstreamInfo)
+}
+
+var (
+	nginxRestarts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "ingress_controller",
+			Name:      "nginx_restarts",
+			Help:      "Cumulative number of restarts of the NGINX master process after an unexpected exit",
+		},
+	)
+	nginxShuttingDownWorkers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ingress_controller",
+			Name:      "nginx_shutting_down_workers",
+			Help:      "Number of NGINX worker processes shutting down after a reload, by generation (number of reloads) of the workers",
+		},
+		[]string{"generation"},
+	)
+	nginxConfigTestFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "ingress_controller",
+			Name:      "nginx_config_test_failures",
+			Help:      "Cumulative number of NGINX configurations rejected by the test (nginx -t)",
+		},
+	)
+	// upstreamInfo links the upstreams of the vts metrics (label upstream)
+	// with the Ingress rules and the services that use them
+	upstreamInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "nginx",
+			Name:      "upstream_info",
+			Help:      "Ingress rule and service of the locations using an upstream. The value is always 1",
+		},
+		[]string{"upstream", "server_zone", "ingress_namespace", "ingress", "service"},
+	)
+)
+
+// setUpstreamInfo replaces the information of the upstreams with
+// the locations of the servers defined in Ingress rules
+func setUpstreamInfo(servers []*ingress.Server) {
+	upstreamInfo.Reset()
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.Ingress == nil {
+				continue
+			}
+
+			service := ""
+			if location.Service != nil {
+				service = location.Service.Name
+			}
+
+			upstreamInfo.WithLabelValues(location.Backend, server.Hostname,
+				location.Ingress.Namespace, location.Ingress.Name, service).Set(1)
+		}
+	}
+}
+
 func (n *NGINXController) setupMonitor(sm statusModule) {
 	csm := n.statusModule
 	if csm != sm {
@@ -80,11 +145,11 @@ func newStatsCollector(ns, class, binary string) *statsCollector {
 		Binary: binary,
 	})
 	if err != nil {
-		glog.Fatalf("unexpected error registering nginx collector: %v", err)
+		log.Fatalf("unexpected error registering nginx collector: %v", err)
 	}
 	err = prometheus.Register(pc)
 	if err != nil {
-		glog.Fatalf("unexpected error registering nginx collector: %v", err)
+		log.Fatalf("unexpected error registering nginx collector: %v", err)
 	}
 
 	return &statsCollector{
@@ -0,0 +1,102 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+func collectUpstreamInfo(t *testing.T) []*dto.Metric {
+	ch := make(chan prometheus.Metric, 10)
+	upstreamInfo.Collect(ch)
+	close(ch)
+
+	metrics := []*dto.Metric{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatalf("unexpected error reading metric: %v", err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestSetUpstreamInfo(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "demo",
+			Namespace: "default",
+		},
+	}
+
+	servers := []*ingress.Server{
+		{
+			Hostname: "_",
+			Locations: []*ingress.Location{
+				{Path: "/", Backend: "upstream-default-backend", IsDefBackend: true},
+			},
+		},
+		{
+			Hostname: "demo.example.com",
+			Locations: []*ingress.Location{
+				{
+					Path:    "/",
+					Backend: "default-demo-80",
+					Service: &api_v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "demo"}},
+					Ingress: ing,
+				},
+			},
+		},
+	}
+
+	setUpstreamInfo(servers)
+	metrics := collectUpstreamInfo(t)
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric but returned %v", len(metrics))
+	}
+
+	expected := map[string]string{
+		"upstream":          "default-demo-80",
+		"server_zone":       "demo.example.com",
+		"ingress_namespace": "default",
+		"ingress":           "demo",
+		"service":           "demo",
+	}
+	for _, label := range metrics[0].GetLabel() {
+		if expected[label.GetName()] != label.GetValue() {
+			t.Errorf("expected %v for the label %v but returned %v", expected[label.GetName()], label.GetName(), label.GetValue())
+		}
+	}
+	if v := metrics[0].GetGauge().GetValue(); v != 1 {
+		t.Errorf("expected 1 but returned %v", v)
+	}
+
+	// the metrics of the previous configuration are removed
+	setUpstreamInfo([]*ingress.Server{})
+	if metrics := collectUpstreamInfo(t); len(metrics) != 0 {
+		t.Errorf("expected no metrics but returned %v", len(metrics))
+	}
+}
@@ -18,15 +18,18 @@ package main
 
 import (
 	"bytes"
-	"errors"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -40,7 +43,11 @@ import (
 	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
 	"k8s.io/ingress/controllers/nginx/pkg/version"
 	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/wwwredirect"
 	"k8s.io/ingress/core/pkg/ingress/defaults"
+	ing_errors "k8s.io/ingress/core/pkg/ingress/errors"
+	"k8s.io/ingress/core/pkg/log"
 	"k8s.io/ingress/core/pkg/net/dns"
 	"k8s.io/ingress/core/pkg/net/ssl"
 )
@@ -51,8 +58,43 @@ const (
 	ngxHealthPort = 18080
 	ngxHealthPath = "/healthz"
 
+	// tlsServicesPort is the port of the stream server of
+	// the TLS services routed by the server name (see the template)
+	tlsServicesPort = 444
+
+	// healthCheckTimeout is the maximum time to wait for
+	// the response of the health check location of NGINX
+	healthCheckTimeout = 5 * time.Second
+
 	defaultStatusModule statusModule = "default"
 	vtsStatusModule     statusModule = "vts"
+
+	// http3Feature is the feature reported by Info when the
+	// NGINX binary supports HTTP/3 (QUIC)
+	http3Feature = "http3"
+
+	// tlsv13 is the name of TLSv1.3 in ssl-protocols
+	tlsv13 = "TLSv1.3"
+
+	// tlsv13Feature is the feature reported by Info when the
+	// NGINX binary supports TLSv1.3
+	tlsv13Feature = "tlsv1.3"
+
+	// njsFeature is the feature reported by Info when the
+	// NGINX binary includes the njs (JavaScript) module
+	njsFeature = "njs"
+
+	// ajpFeature is the feature reported by Info when the
+	// NGINX binary includes the AJP module
+	ajpFeature = "ajp"
+
+	// comments that delimit the servers in the template
+	serverStartMarker = "## start server "
+	serverEndMarker   = "## end server "
+
+	// key of the secret of ssl-session-ticket-key
+	// with the key of the TLS session tickets
+	sessionTicketKey = "tls-session-ticket-key"
 )
 
 var (
@@ -64,8 +106,10 @@ var (
 
 // newNGINXController creates a new NGINX Ingress controller.
 // If the environment variable NGINX_BINARY exists it will be used
-// as source for nginx commands
-func newNGINXController() ingress.Controller {
+// as default source for nginx commands. The NGINX process and the
+// proxy of the port 443 are started after the parsing of the flags
+// (see OverrideFlags)
+func newNGINXController() *NGINXController {
 	ngx := os.Getenv("NGINX_BINARY")
 	if ngx == "" {
 		ngx = binary
@@ -81,6 +125,8 @@ func newNGINXController() ingress.Controller {
 		configmap:     &api_v1.ConfigMap{},
 		isIPV6Enabled: isIPv6Enabled(),
 		resolver:      h,
+		stopped:       make(chan struct{}),
+		diffs:         &configDiffs{},
 		proxy: &proxy{
 			Default: &server{
 				Hostname:      "localhost",
@@ -91,9 +137,15 @@ func newNGINXController() ingress.Controller {
 		},
 	}
 
+	return n
+}
+
+// startProxy accepts the connections in the port 443 and sends them
+// to NGINX or to the SSL passthrough backends (see passthroughServers)
+func (n *NGINXController) startProxy() {
 	listener, err := net.Listen("tcp", ":443")
 	if err != nil {
-		glog.Fatalf("%v", err)
+		log.Fatalf("%v", err)
 	}
 
 	proxyList := &proxyproto.Listener{Listener: listener}
@@ -121,40 +173,56 @@ func newNGINXController() ingress.Controller {
 			go n.proxy.Handle(conn)
 		}
 	}()
+}
 
-	var onChange func()
-	onChange = func() {
-		template, err := ngx_template.NewTemplate(tmplPath, onChange)
-		if err != nil {
-			// this error is different from the rest because it must be clear why nginx is not working
-			glog.Errorf(`
--------------------------------------------------------------------------------
-Error loading new template : %v
--------------------------------------------------------------------------------
-`, err)
-			return
-		}
-
-		n.t.Close()
-		n.t = template
-		glog.Info("new NGINX template loaded")
-	}
+// setup loads the template, checks the features of the NGINX binary
+// and starts the NGINX master process. The paths of the binary, the
+// template and the configuration file can be changed using flags
+func (n *NGINXController) setup() {
+	n.isHTTP3Supported = isHTTP3Supported(n.binary)
+	n.isNjsSupported = isNjsSupported(n.binary)
+	n.isAJPSupported = isAJPSupported(n.binary)
+	n.isTLSv13Supported = isTLSv13Supported(n.binary)
 
-	ngxTpl, err := ngx_template.NewTemplate(tmplPath, onChange)
+	ngxTpl, err := ngx_template.NewTemplate(tmplPath, n.onTemplateChange)
 	if err != nil {
-		glog.Fatalf("invalid NGINX template: %v", err)
+		log.Fatalf("invalid NGINX template: %v", err)
 	}
 
 	n.t = ngxTpl
 
-	go n.Start()
+	// the dry run only renders the configuration
+	if n.dryRun {
+		return
+	}
+
+	n.startProxy()
 
-	return ingress.Controller(n)
+	if n.reloadDebounce > 0 || n.maxReloads > 0 {
+		n.reloads = newReloadLimiter(n.reloadDebounce, n.reloadInterval, n.maxReloads, n.scheduledReload)
+	}
+
+	n.certWatcher = newCertificateWatcher(certificateDebounce, func() {
+		if n.reloads != nil {
+			n.reloads.Schedule()
+			return
+		}
+
+		err := n.reload()
+		if err != nil {
+			glog.Errorf("unexpected error reloading NGINX after a change in the certificates: %v", err)
+		}
+	})
+
+	go n.Start()
+	go n.monitorWorkers()
 }
 
 // NGINXController ...
 type NGINXController struct {
-	t *ngx_template.Template
+	// t renders the NGINX configuration file. By default the
+	// template located in tmplPath is used
+	t ngx_template.Renderer
 
 	configmap *api_v1.ConfigMap
 
@@ -174,7 +242,107 @@ type NGINXController struct {
 	// returns true if proxy protocol es enabled
 	isProxyProtocolEnabled bool
 
+	// returns true if the NGINX binary supports HTTP/3 (QUIC)
+	isHTTP3Supported bool
+
+	// returns true if the NGINX binary includes the njs module
+	isNjsSupported bool
+
+	// returns true if the NGINX binary includes the AJP module
+	isAJPSupported bool
+
+	// returns true if the NGINX binary supports TLSv1.3
+	isTLSv13Supported bool
+
 	proxy *proxy
+
+	// dryRun renders and tests the configuration without
+	// starting NGINX (flag --dry-run)
+	dryRun bool
+
+	// controllerPort is the port of the HTTP server of the ingress
+	// controller (healthz, metrics and profiling)
+	controllerPort int
+
+	// renderedUpstreams contains the backends used in the last reload
+	renderedUpstreams map[string]*ingress.Backend
+
+	// renderedChecksum is the checksum of the template configuration
+	// used in the last reload and renderedContent the NGINX configuration
+	// produced by the template
+	renderedChecksum string
+	renderedContent  []byte
+
+	// renderedStructureChecksum is the checksum of the template
+	// configuration without the endpoints of the backends. If only
+	// the endpoints change just the upstreams are rendered again
+	renderedStructureChecksum string
+
+	// discardRendered is 1 after a change in the template or a failure
+	// in a deferred reload, so the rendered configuration is not reused
+	// (accessed atomically)
+	discardRendered int32
+
+	// certWatcher reloads NGINX when the content of
+	// the certificates used in the configuration changes
+	certWatcher *certificateWatcher
+
+	// drainTimeout is the time to wait for the active
+	// connections to finish after SIGTERM
+	drainTimeout time.Duration
+
+	// shuttingDown is 1 after SIGTERM (accessed atomically)
+	shuttingDown int32
+
+	// gracePeriod is the time to wait for the NGINX master
+	// process to exit after nginx -s quit
+	gracePeriod time.Duration
+
+	// stopped is closed when the NGINX master process
+	// exits during the shutdown
+	stopped chan struct{}
+
+	// restarts is the number of restarts of the NGINX
+	// master process (accessed atomically)
+	restarts int32
+
+	// goodConfig contains the last configuration ([]byte)
+	// loaded without errors by NGINX
+	goodConfig atomic.Value
+
+	// diffs contains the last changes in the configuration
+	diffs *configDiffs
+
+	// rejectedConfig contains the last configuration ([]byte)
+	// rejected by nginx -t
+	rejectedConfig atomic.Value
+
+	// reloadDebounce is the window used to coalesce the reloads,
+	// maxReloads the maximum number of reloads in reloadInterval
+	// (zero means no limit)
+	reloadDebounce time.Duration
+	reloadInterval time.Duration
+	maxReloads     int
+
+	// upgrading is 1 during an upgrade of the binary and upgradePid
+	// the pid of the new master process (accessed atomically)
+	upgrading  int32
+	upgradePid int32
+
+	// generation is the number of reloads of NGINX (accessed atomically)
+	generation int32
+
+	// isDynamicConfigurationEnabled indicates the endpoints of the
+	// upstreams are updated using Lua without a reload of NGINX
+	isDynamicConfigurationEnabled bool
+
+	// reloads delays and coalesces the reloads of NGINX.
+	// nil if reloadDebounce and maxReloads are not configured
+	reloads *reloadLimiter
+
+	// reloadHandler receives the result of the reloads
+	// executed by the reload limiter
+	reloadHandler func(error)
 }
 
 // Start start a new NGINX master process running in foreground.
@@ -182,7 +350,7 @@ func (n *NGINXController) Start() {
 	glog.Info("starting NGINX process...")
 
 	done := make(chan error, 1)
-	cmd := exec.Command(n.binary, "-c", cfgPath)
+	cmd := nginxCommand(n.binary, "-c", cfgPath)
 	n.start(cmd, done)
 
 	// if the nginx master process dies the workers continue to process requests,
@@ -192,6 +360,21 @@ func (n *NGINXController) Start() {
 	// To avoid this issue we restart nginx in case of errors.
 	for {
 		err := <-done
+		if n.isShuttingDown() {
+			glog.Info("NGINX process stopped")
+			close(n.stopped)
+			return
+		}
+
+		// after an upgrade of the binary the old master process exits
+		// and the new one, not a child of the controller, is monitored
+		if pid := atomic.SwapInt32(&n.upgradePid, 0); pid != 0 {
+			glog.Infof("NGINX master process replaced by the process %v", pid)
+			cmd.Process.Release()
+			go waitForProcess(int(pid), processCheckInterval, done)
+			continue
+		}
+
 		if exitError, ok := err.(*exec.ExitError); ok {
 			waitStatus := exitError.Sys().(syscall.WaitStatus)
 			glog.Warningf(`
@@ -201,7 +384,7 @@ NGINX master process died (%v): %v
 `, waitStatus.ExitStatus(), err)
 		}
 		cmd.Process.Release()
-		cmd = exec.Command(n.binary, "-c", cfgPath)
+		cmd = nginxCommand(n.binary, "-c", cfgPath)
 		// we wait until the workers are killed
 		for {
 			conn, err := net.DialTimeout("tcp", "127.0.0.1:80", 1*time.Second)
@@ -211,16 +394,100 @@ NGINX master process died (%v): %v
 			conn.Close()
 			time.Sleep(1 * time.Second)
 		}
+
+		// the configuration in the file can be the reason of the crash
+		good, _ := n.goodConfig.Load().([]byte)
+		restored, err := restoreConfiguration(cfgPath, good, n.testTemplate)
+		if err != nil {
+			glog.Errorf("unexpected error restoring the last valid NGINX configuration: %v", err)
+		} else if restored {
+			glog.Warningf("invalid NGINX configuration in %v, using the last valid configuration", cfgPath)
+		}
+
 		// start a new nginx master process
+		n.incRestartCount()
 		n.start(cmd, done)
 	}
 }
 
+// incRestartCount increments the number of restarts
+// of the NGINX master process
+func (n *NGINXController) incRestartCount() {
+	restarts := atomic.AddInt32(&n.restarts, 1)
+	nginxRestarts.Inc()
+	glog.Infof("restarting NGINX master process (restart %v)", restarts)
+}
+
+// restoreConfiguration replaces the configuration in the file with the
+// last valid configuration if the content of the file is not valid
+func restoreConfiguration(file string, good []byte, test func([]byte) error) (bool, error) {
+	if len(good) == 0 {
+		return false, nil
+	}
+
+	current, err := ioutil.ReadFile(file)
+	if err == nil {
+		if bytes.Equal(current, good) {
+			return false, nil
+		}
+		if test(current) == nil {
+			return false, nil
+		}
+	}
+
+	err = ioutil.WriteFile(file, good, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// onTemplateChange loads the template after a change in the file. The
+// checksums of the last configuration do not include the template, so
+// the next configuration is rendered again with the new template
+func (n *NGINXController) onTemplateChange() {
+	template, err := ngx_template.NewTemplate(tmplPath, n.onTemplateChange)
+	if err != nil {
+		// this error is different from the rest because it must be clear why nginx is not working
+		glog.Errorf(`
+-------------------------------------------------------------------------------
+Error loading new template : %v
+-------------------------------------------------------------------------------
+`, err)
+		return
+	}
+
+	// a custom renderer must not be replaced by the template
+	current, ok := n.t.(*ngx_template.Template)
+	if !ok {
+		template.Close()
+		return
+	}
+
+	current.Close()
+	n.t = template
+	atomic.StoreInt32(&n.discardRendered, 1)
+	glog.Info("new NGINX template loaded")
+}
+
+// nginxCommand returns the command used to start a NGINX master process.
+// NGINX runs in its own process group: the init process of the image
+// (dumb-init) forwards the signals to the process group of the controller,
+// and NGINX must not receive the SIGTERM (fast shutdown) before the drain
+// or the SIGUSR2 (upgrade of the binary) handled by the controller
+func nginxCommand(binary string, args ...string) *exec.Cmd {
+	cmd := exec.Command(binary, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
 func (n *NGINXController) start(cmd *exec.Cmd, done chan error) {
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// the output of NGINX is not converted by --log-format=json
+	cmd.Stderr = log.Stderr()
 	if err := cmd.Start(); err != nil {
-		glog.Fatalf("nginx error: %v", err)
+		log.Fatalf("nginx error: %v", err)
 		done <- err
 		return
 	}
@@ -244,7 +511,7 @@ func (n NGINXController) BackendDefaults() defaults.Backend {
 
 // printDiff returns the difference between the running configuration
 // and the new one
-func (n NGINXController) printDiff(data []byte) {
+func (n *NGINXController) printDiff(data []byte) {
 	in, err := os.Open(cfgPath)
 	if err != nil {
 		return
@@ -273,6 +540,10 @@ func (n NGINXController) printDiff(data []byte) {
 			return
 		}
 
+		if n.diffs != nil {
+			n.diffs.add(string(diffOutput), time.Now(), maxConfigDiffs)
+		}
+
 		if glog.V(2) {
 			glog.Infof("NGINX configuration diff\n")
 			glog.Infof("%v", string(diffOutput))
@@ -283,17 +554,178 @@ func (n NGINXController) printDiff(data []byte) {
 
 // Info return build information
 func (n NGINXController) Info() *ingress.BackendInfo {
-	return &ingress.BackendInfo{
+	info := &ingress.BackendInfo{
 		Name:       "NGINX",
 		Release:    version.RELEASE,
 		Build:      version.COMMIT,
 		Repository: version.REPO,
 	}
+
+	if n.isHTTP3Supported {
+		info.Features = append(info.Features, http3Feature)
+	}
+
+	if n.isNjsSupported {
+		info.Features = append(info.Features, njsFeature)
+	}
+
+	if n.isAJPSupported {
+		info.Features = append(info.Features, ajpFeature)
+	}
+
+	if n.isTLSv13Supported {
+		info.Features = append(info.Features, tlsv13Feature)
+	}
+
+	return info
+}
+
+// checkHTTP3 returns an error if HTTP/3 is enabled in the
+// configuration and the backend does not support it
+func checkHTTP3(cfg config.Configuration, info *ingress.BackendInfo) error {
+	if !cfg.UseHTTP3 {
+		return nil
+	}
+
+	for _, feature := range info.Features {
+		if feature == http3Feature {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("HTTP/3 is enabled but the NGINX binary does not support QUIC (built without --with-http_v3_module)")
+}
+
+// checkTLSv13 returns an error if TLSv1.3 or the early data are
+// enabled in the configuration and the backend does not support it
+func checkTLSv13(cfg config.Configuration, info *ingress.BackendInfo) error {
+	if !hasSSLProtocol(cfg.SSLProtocols, tlsv13) && !cfg.SSLEarlyData {
+		return nil
+	}
+
+	for _, feature := range info.Features {
+		if feature == tlsv13Feature {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("TLSv1.3 is enabled but the NGINX binary was not built with OpenSSL 1.1.1 or newer")
+}
+
+// disableTLSv13 removes TLSv1.3 from the SSL protocols (using the
+// default protocols if no other protocol is enabled) and the early data
+func disableTLSv13(cfg *config.Configuration) {
+	protocols := []string{}
+	for _, protocol := range strings.Fields(cfg.SSLProtocols) {
+		if protocol != tlsv13 {
+			protocols = append(protocols, protocol)
+		}
+	}
+	if len(protocols) == 0 {
+		protocols = strings.Fields(config.NewDefault().SSLProtocols)
+	}
+
+	cfg.SSLProtocols = strings.Join(protocols, " ")
+	cfg.SSLEarlyData = false
+}
+
+func hasSSLProtocol(protocols, protocol string) bool {
+	for _, p := range strings.Fields(protocols) {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNjs returns an error if any of the backends uses a njs
+// health check script and the backend does not include the module
+func checkNjs(backends []*ingress.Backend, info *ingress.BackendInfo) error {
+	for _, feature := range info.Features {
+		if feature == njsFeature {
+			return nil
+		}
+	}
+
+	for _, backend := range backends {
+		if backend.HealthCheckScript.Path != "" {
+			return fmt.Errorf("upstream %v uses the health check script %v but the NGINX binary does not include the njs module",
+				backend.Name, backend.HealthCheckScript.Path)
+		}
+	}
+
+	return nil
+}
+
+// checkAJP returns an error if any of the locations uses the
+// AJP protocol and the backend does not include the module
+func checkAJP(servers []*ingress.Server, info *ingress.BackendInfo) error {
+	for _, feature := range info.Features {
+		if feature == ajpFeature {
+			return nil
+		}
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.BackendProtocol == backendprotocol.AJP {
+				return fmt.Errorf("location %v of server %v uses the AJP protocol but the NGINX binary does not include the AJP module",
+					location.Path, server.Hostname)
+			}
+		}
+	}
+
+	return nil
+}
+
+// disableAJP returns a copy of the servers where the
+// locations with the AJP protocol use HTTP instead
+func disableAJP(servers []*ingress.Server) []*ingress.Server {
+	res := make([]*ingress.Server, 0, len(servers))
+	for _, server := range servers {
+		locations := make([]*ingress.Location, 0, len(server.Locations))
+		for _, location := range server.Locations {
+			if location.BackendProtocol == backendprotocol.AJP {
+				loc := *location
+				loc.BackendProtocol = backendprotocol.HTTP
+				location = &loc
+			}
+			locations = append(locations, location)
+		}
+
+		srv := *server
+		srv.Locations = locations
+		res = append(res, &srv)
+	}
+
+	return res
 }
 
 // ConfigureFlags allow to configure more flags before the parsing of
 // command line arguments
 func (n *NGINXController) ConfigureFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&n.binary, "nginx-binary", n.binary,
+		`Path of the NGINX binary. The default value is the content of the environment variable NGINX_BINARY or /usr/sbin/nginx.`)
+	flags.StringVar(&tmplPath, "nginx-template", tmplPath,
+		`Path of the template used to render the NGINX configuration file.`)
+	flags.StringVar(&cfgPath, "nginx-config", cfgPath,
+		`Path of the NGINX configuration file.`)
+	flags.DurationVar(&n.drainTimeout, "drain-timeout", defaultDrainTimeout,
+		`Time to wait after SIGTERM for the active connections to finish before NGINX is stopped.
+		The health check fails during this period.`)
+	flags.DurationVar(&n.gracePeriod, "shutdown-grace-period", defaultGracePeriod,
+		`Time to wait after nginx -s quit for the in-flight requests to finish before the controller exits.`)
+	flags.DurationVar(&n.reloadDebounce, "reload-debounce", 0,
+		`Time to wait after a change in the configuration before NGINX is reloaded.
+		All the changes received in this period are applied with a single reload. Zero reloads NGINX immediately.`)
+	flags.IntVar(&n.maxReloads, "max-reloads-per-interval", 0,
+		`Maximum number of reloads of NGINX in the interval defined in --reload-interval.
+		Additional reloads are delayed until the end of the interval. Zero means no limit.`)
+	flags.DurationVar(&n.reloadInterval, "reload-interval", defaultReloadInterval,
+		`Interval used to count the reloads of NGINX for --max-reloads-per-interval.`)
+	flags.BoolVar(&n.isDynamicConfigurationEnabled, "enable-dynamic-configuration", false,
+		`Update the endpoints of the upstreams using Lua instead of reloading NGINX.
+		Only the changes in the rest of the configuration (servers, locations, certificates) require a reload.`)
 }
 
 // OverrideFlags customize NGINX controller flags
@@ -311,6 +743,11 @@ func (n *NGINXController) OverrideFlags(flags *pflag.FlagSet) {
 
 	flags.Set("ingress-class", ic)
 	n.stats = newStatsCollector(wc, ic, n.binary)
+
+	n.controllerPort, _ = flags.GetInt("healthz-port")
+	n.dryRun, _ = flags.GetBool("dry-run")
+
+	n.setup()
 }
 
 // DefaultIngressClass just return the default ingress class
@@ -318,9 +755,17 @@ func (n NGINXController) DefaultIngressClass() string {
 	return defIngressClass
 }
 
+// DumpConfiguration returns the last configuration loaded by NGINX
+// and the last configuration rejected by nginx -t
+func (n *NGINXController) DumpConfiguration() ([]byte, []byte) {
+	applied, _ := n.goodConfig.Load().([]byte)
+	rejected, _ := n.rejectedConfig.Load().([]byte)
+	return applied, rejected
+}
+
 // testTemplate checks if the NGINX configuration inside the byte array is valid
 // running the command "nginx -t" using a temporal file.
-func (n NGINXController) testTemplate(cfg []byte) error {
+func (n *NGINXController) testTemplate(cfg []byte) error {
 	if len(cfg) == 0 {
 		return fmt.Errorf("invalid nginx configuration (empty)")
 	}
@@ -335,6 +780,8 @@ func (n NGINXController) testTemplate(cfg []byte) error {
 	}
 	out, err := exec.Command(n.binary, "-t", "-c", tmpfile.Name()).CombinedOutput()
 	if err != nil {
+		nginxConfigTestFailures.Inc()
+		n.rejectedConfig.Store(cfg)
 		// this error is different from the rest because it must be clear why nginx is not working
 		oe := fmt.Sprintf(`
 -------------------------------------------------------------------------------
@@ -342,13 +789,60 @@ Error: %v
 %v
 -------------------------------------------------------------------------------
 `, err, string(out))
-		return errors.New(oe)
+		ce := ing_errors.InvalidConfiguration{
+			Reason: oe,
+			Hosts:  invalidServers(string(out), tmpfile.Name(), cfg),
+		}
+		if ee, ok := err.(*exec.ExitError); ok {
+			if ws, ok := ee.Sys().(syscall.WaitStatus); ok {
+				ce.ExitCode = ws.ExitStatus()
+			}
+		}
+		return ce
 	}
 
 	os.Remove(tmpfile.Name())
 	return nil
 }
 
+// invalidServers returns the servers of the configuration cfg that
+// contain the lines with errors in the output of nginx -t. The
+// servers are delimited by the comments "## start server <host>"
+// and "## end server <host>" in the template
+func invalidServers(out, file string, cfg []byte) []string {
+	re, err := regexp.Compile(regexp.QuoteMeta(file) + `:(\d+)`)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(string(cfg), "\n")
+	hosts := []string{}
+	found := map[string]bool{}
+	for _, match := range re.FindAllStringSubmatch(out, -1) {
+		line, err := strconv.Atoi(match[1])
+		if err != nil || line < 1 || line > len(lines) {
+			continue
+		}
+
+		host := ""
+		for _, l := range lines[:line] {
+			l = strings.TrimSpace(l)
+			if strings.HasPrefix(l, serverStartMarker) {
+				host = strings.TrimPrefix(l, serverStartMarker)
+			} else if strings.HasPrefix(l, serverEndMarker) {
+				host = ""
+			}
+		}
+
+		if host != "" && !found[host] {
+			found[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
 // SetConfig sets the configured configmap
 func (n *NGINXController) SetConfig(cmap *api_v1.ConfigMap) {
 	n.configmap = cmap
@@ -382,58 +876,210 @@ func (n *NGINXController) SetListers(lister ingress.StoreLister) {
 // returning nill implies the backend will be reloaded.
 // if an error is returned means requeue the update
 func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
+	if n.isShuttingDown() {
+		glog.Infof("ignoring configuration changes during the shutdown")
+		return nil
+	}
+
+	tc, err := n.templateConfig(ingressCfg)
+	if err != nil {
+		return err
+	}
+
+	if tc.Cfg.EnableOpentracing {
+		if err := writeOpentracingConfig(tc.Cfg, opentracingCfgPath); err != nil {
+			glog.Errorf("%v. Disabling opentracing", err)
+			tc.Cfg.EnableOpentracing = false
+		}
+	}
+
+	n.proxy.ServerList = append(passthroughServers(ingressCfg.PassthroughBackends), tlsServiceServers(ingressCfg.TLSEndpoints)...)
+
+	// we need to check if the status module configuration changed
+	if tc.Cfg.EnableVtsStatus {
+		n.setupMonitor(vtsStatusModule)
+	} else {
+		n.setupMonitor(defaultStatusModule)
+	}
+
+	cfg := tc.Cfg
+
+	// the endpoints of the dynamic backends are not rendered in the
+	// configuration file and a change only requires to send them to NGINX
+	checksumCfg := tc
+	var dynamicErr error
+	if n.isDynamicConfigurationEnabled {
+		checksumCfg.Backends = withoutDynamicEndpoints(tc.Backends, cfg.LoadBalanceAlgorithm)
+		dynamicErr = configureDynamicBackends(cfg.AdminPort, dynamicBackends(tc.Backends, cfg.LoadBalanceAlgorithm))
+	}
+
+	// periodic resyncs of the queue produce the same configuration. In that
+	// case the template and the test of the configuration are skipped
+	checksum, err := templateChecksum(checksumCfg)
+	if err != nil {
+		glog.Warningf("unexpected error computing the checksum of the configuration: %v", err)
+	}
+
+	structCfg := tc
+	structCfg.Backends = withoutEndpoints(tc.Backends)
+	structureChecksum, err := templateChecksum(structCfg)
+	if err != nil {
+		glog.Warningf("unexpected error computing the checksum of the configuration: %v", err)
+	}
+
+	// the content rendered with the previous template or rejected
+	// by a deferred reload is not reused
+	if atomic.CompareAndSwapInt32(&n.discardRendered, 1, 0) {
+		n.renderedChecksum = ""
+		n.renderedStructureChecksum = ""
+		n.renderedContent = nil
+	}
+
+	var content []byte
+	if checksum != "" && checksum == n.renderedChecksum {
+		glog.V(3).Infof("configuration with checksum %v already rendered", checksum)
+		content = n.renderedContent
+		if !isReloadRequired(content) {
+			if dynamicErr == nil && n.isReloadPending() {
+				return ing_errors.ErrReloadPending
+			}
+			return dynamicErr
+		}
+	} else if structureChecksum != "" && structureChecksum == n.renderedStructureChecksum {
+		content, err = n.renderUpstreams(tc)
+		if err != nil {
+			glog.Warningf("unexpected error rendering the upstreams: %v. Rendering the complete configuration", err)
+			content, err = n.t.Render(tc, n.testTemplate)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		content, err = n.t.Render(tc, n.testTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.printDiff(content)
+
+	err = ioutil.WriteFile(cfgPath, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	if n.reloads != nil {
+		n.reloads.Schedule()
+	} else {
+		err = n.reload()
+		if err != nil {
+			return err
+		}
+		n.goodConfig.Store(content)
+	}
+
+	n.renderedUpstreams = upstreamsByName(tc.Backends)
+	n.renderedChecksum = checksum
+	n.renderedStructureChecksum = structureChecksum
+	n.renderedContent = content
+
+	setUpstreamInfo(tc.Servers)
+
+	if n.certWatcher != nil {
+		n.certWatcher.Watch(certificateFiles(tc))
+	}
+
+	// the endpoints are sent again after the reload because NGINX
+	// could be not running or using a configuration without Lua
+	if dynamicErr != nil {
+		err = configureDynamicBackends(cfg.AdminPort, dynamicBackends(tc.Backends, cfg.LoadBalanceAlgorithm))
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.isReloadPending() {
+		return ing_errors.ErrReloadPending
+	}
+
+	return nil
+}
+
+// SetReloadHandler sets the function that receives the result
+// of the reloads delayed by --reload-debounce and
+// --max-reloads-per-interval
+func (n *NGINXController) SetReloadHandler(handler func(error)) {
+	n.reloadHandler = handler
+}
+
+// DryRun renders the configuration and tests it with nginx -t
+// without writing the configuration file or reloading NGINX
+func (n *NGINXController) DryRun(ingressCfg ingress.Configuration) ([]byte, error) {
+	tc, err := n.templateConfig(ingressCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.t.Render(tc, n.testTemplate)
+}
+
+// templateConfig returns the configuration used to render the template
+// from the configuration of the Ingress rules and the configmap
+func (n *NGINXController) templateConfig(ingressCfg ingress.Configuration) (config.TemplateConfig, error) {
 	var longestName int
 	var serverNameBytes int
 	for _, srv := range ingressCfg.Servers {
-		if longestName < len(srv.Hostname) {
-			longestName = len(srv.Hostname)
+		names := append([]string{srv.Hostname}, srv.Aliases...)
+		if srv.RedirectFromToWWW {
+			names = append(names, wwwredirect.Alias(srv.Hostname))
+		}
+		for _, name := range names {
+			if longestName < len(name) {
+				longestName = len(name)
+			}
+			serverNameBytes += len(name)
 		}
-		serverNameBytes += len(srv.Hostname)
 	}
 
 	cfg := ngx_template.ReadConfig(n.configmap.Data)
 	cfg.Resolver = n.resolver
 
-	servers := []*server{}
-	for _, pb := range ingressCfg.PassthroughBackends {
-		svc := pb.Service
-		if svc == nil {
-			glog.Warningf("missing service for PassthroughBackends %v", pb.Backend)
-			continue
-		}
-		port, err := strconv.Atoi(pb.Port.String())
-		if err != nil {
-			for _, sp := range svc.Spec.Ports {
-				if sp.Name == pb.Port.String() {
-					port = int(sp.Port)
-					break
-				}
-			}
-		} else {
-			for _, sp := range svc.Spec.Ports {
-				if sp.Port == int32(port) {
-					port = int(sp.Port)
-					break
-				}
-			}
-		}
+	// a configuration with njs directives fails without the module
+	if err := checkNjs(ingressCfg.Backends, n.Info()); err != nil {
+		return config.TemplateConfig{}, err
+	}
 
-		//TODO: Allow PassthroughBackends to specify they support proxy-protocol
-		servers = append(servers, &server{
-			Hostname:      pb.Hostname,
-			IP:            svc.Spec.ClusterIP,
-			Port:          port,
-			ProxyProtocol: false,
-		})
+	if err := checkHTTP3(cfg, n.Info()); err != nil {
+		glog.Errorf("%v. Disabling HTTP/3", err)
+		cfg.UseHTTP3 = false
 	}
 
-	n.proxy.ServerList = servers
+	if err := checkTLSv13(cfg, n.Info()); err != nil {
+		glog.Errorf("%v. Disabling TLSv1.3 and early data", err)
+		disableTLSv13(&cfg)
+	}
+	if cfg.SSLEarlyData && !hasSSLProtocol(cfg.SSLProtocols, tlsv13) {
+		glog.Warningf("ssl-early-data requires TLSv1.3 in ssl-protocols. Disabling early data")
+		cfg.SSLEarlyData = false
+	}
 
-	// we need to check if the status module configuration changed
-	if cfg.EnableVtsStatus {
-		n.setupMonitor(vtsStatusModule)
-	} else {
-		n.setupMonitor(defaultStatusModule)
+	if cfg.EnableOpentracing {
+		if err := checkOpentracing(cfg); err != nil {
+			glog.Errorf("%v. Disabling opentracing", err)
+			cfg.EnableOpentracing = false
+		}
+	}
+
+	if len(cfg.ProxyCacheZones) > 0 {
+		if err := createTempPath(cfg.ProxyCachePath); err != nil {
+			glog.Errorf("unexpected error creating the directory of the cache zones: %v. Disabling the cache", err)
+			cfg.ProxyCacheZones = []config.CacheZone{}
+		}
+	}
+
+	if err := createTempPath(cfg.ClientBodyTempPath); err != nil {
+		glog.Errorf("unexpected error creating the directory for client request bodies: %v. Using the default", err)
+		cfg.ClientBodyTempPath = ""
 	}
 
 	// NGINX cannot resize the has tables used to store server names.
@@ -491,72 +1137,284 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		}
 	}
 
-	sslDHParam := ""
+	sslDHParam, sslDHParamSHA := "", ""
 	if cfg.SSLDHParam != "" {
-		secretName := cfg.SSLDHParam
-		s, exists, err := n.storeLister.Secret.GetByKey(secretName)
+		sslDHParam, sslDHParamSHA = n.writeDHParam(cfg.SSLDHParam)
+	}
+
+	cfg.SSLDHParam = sslDHParam
+
+	sslSessionTicketKey, sslSessionTicketKeySHA := "", ""
+	if cfg.SSLSessionTicketKey != "" {
+		sslSessionTicketKey, sslSessionTicketKeySHA = n.writeSessionTicketKey(cfg.SSLSessionTicketKey)
+	}
+
+	cfg.SSLSessionTicketKey = sslSessionTicketKey
+
+	backends := ingressCfg.Backends
+	httpServers := ingressCfg.Servers
+	if cfg.ACMEChallengeService != "" {
+		acme, err := n.getACMEChallengeBackend(cfg.ACMEChallengeService)
 		if err != nil {
-			glog.Warningf("unexpected error reading secret %v: %v", secretName, err)
+			glog.Warningf("unexpected error configuring ACME challenge service %v: %v", cfg.ACMEChallengeService, err)
+		} else {
+			backends = append([]*ingress.Backend{acme}, ingressCfg.Backends...)
+			httpServers = injectACMEChallenge(ingressCfg.Servers, acme.Name, cfg.ACMEChallengeOverride)
 		}
+	}
 
-		if exists {
-			secret := s.(*api_v1.Secret)
-			nsSecName := strings.Replace(secretName, "/", "-", -1)
-
-			dh, ok := secret.Data["dhparam.pem"]
-			if ok {
-				pemFileName, err := ssl.AddOrUpdateDHParam(nsSecName, dh)
-				if err != nil {
-					glog.Warningf("unexpected error adding or updating dhparam %v file: %v", nsSecName, err)
-				} else {
-					sslDHParam = pemFileName
-				}
-			}
-		}
+	// a configuration with ajp_pass fails without the module
+	if err := checkAJP(httpServers, n.Info()); err != nil {
+		glog.Warningf("%v. Using HTTP", err)
+		httpServers = disableAJP(httpServers)
 	}
 
-	cfg.SSLDHParam = sslDHParam
+	backends = validateBackupEndpoints(backends, cfg.LoadBalanceAlgorithm)
+	backends = preserveUpstreams(n.renderedUpstreams, backends)
 
-	content, err := n.t.Write(config.TemplateConfig{
+	return config.TemplateConfig{
 		ProxySetHeaders:     setHeaders,
 		AddHeaders:          addHeaders,
 		MaxOpenFiles:        maxOpenFiles,
 		BacklogSize:         sysctlSomaxconn(),
-		Backends:            ingressCfg.Backends,
+		Backends:            backends,
 		PassthroughBackends: ingressCfg.PassthroughBackends,
-		Servers:             ingressCfg.Servers,
+		Servers:             httpServers,
 		TCPBackends:         ingressCfg.TCPEndpoints,
 		UDPBackends:         ingressCfg.UDPEndpoints,
+		TLSBackends:         ingressCfg.TLSEndpoints,
 		HealthzURI:          ngxHealthPath,
+		ControllerPort:      n.controllerPort,
+		ConfigPath:          cfgPath,
 		CustomErrors:        len(cfg.CustomHTTPErrors) > 0,
 		Cfg:                 cfg,
 		IsIPV6Enabled:       n.isIPV6Enabled && !cfg.DisableIpv6,
-	})
+		SSLDHParamSHA:       sslDHParamSHA,
 
+		SSLSessionTicketKeySHA:      sslSessionTicketKeySHA,
+		DynamicConfigurationEnabled: n.isDynamicConfigurationEnabled,
+	}, nil
+}
+
+// passthroughServers returns the servers of the TCP proxy
+// used by the SSL passthrough backends
+func passthroughServers(passthroughBackends []*ingress.SSLPassthroughBackend) []*server {
+	servers := []*server{}
+	for _, pb := range passthroughBackends {
+		svc := pb.Service
+		if svc == nil {
+			glog.Warningf("missing service for PassthroughBackends %v", pb.Backend)
+			continue
+		}
+		port, err := strconv.Atoi(pb.Port.String())
+		if err != nil {
+			for _, sp := range svc.Spec.Ports {
+				if sp.Name == pb.Port.String() {
+					port = int(sp.Port)
+					break
+				}
+			}
+		} else {
+			for _, sp := range svc.Spec.Ports {
+				if sp.Port == int32(port) {
+					port = int(sp.Port)
+					break
+				}
+			}
+		}
+
+		servers = append(servers, &server{
+			Hostname:      pb.Hostname,
+			IP:            svc.Spec.ClusterIP,
+			Port:          port,
+			ProxyProtocol: pb.ProxyProtocol,
+		})
+	}
+
+	return servers
+}
+
+// tlsServiceServers returns the servers of the TCP proxy used by the TLS
+// services, sent to the stream server of NGINX that selects the upstream
+// of the service using the server name (ssl_preread)
+func tlsServiceServers(tlsServices []ingress.L4Service) []*server {
+	servers := []*server{}
+	for _, svc := range tlsServices {
+		servers = append(servers, &server{
+			Hostname:      svc.Hostname,
+			IP:            "127.0.0.1",
+			Port:          tlsServicesPort,
+			ProxyProtocol: true,
+		})
+	}
+
+	return servers
+}
+
+// writeDHParam writes the Diffie-Hellman parameters of the key dhparam.pem
+// of the secret in a file. Returns the name of the file and the checksum
+// of the content, or empty values if the secret is not valid
+func (n *NGINXController) writeDHParam(secretName string) (string, string) {
+	s, exists, err := n.storeLister.Secret.GetByKey(secretName)
 	if err != nil {
-		return err
+		glog.Warningf("unexpected error reading secret %v: %v", secretName, err)
+		return "", ""
+	}
+	if !exists {
+		glog.Warningf("secret %v with the DH parameters does not exist", secretName)
+		return "", ""
 	}
 
-	err = n.testTemplate(content)
+	dh, ok := s.(*api_v1.Secret).Data["dhparam.pem"]
+	if !ok {
+		glog.Warningf("secret %v does not contain the DH parameters (dhparam.pem)", secretName)
+		return "", ""
+	}
+
+	nsSecName := strings.Replace(secretName, "/", "-", -1)
+	pemFileName, err := ssl.AddOrUpdateDHParam(nsSecName, dh)
 	if err != nil {
-		return err
+		glog.Warningf("unexpected error adding or updating dhparam %v file: %v", nsSecName, err)
+		return "", ""
 	}
 
-	n.printDiff(content)
+	return pemFileName, ssl.PemSHA1(pemFileName)
+}
 
-	err = ioutil.WriteFile(cfgPath, content, 0644)
+// writeSessionTicketKey writes the key of the TLS session tickets of the
+// key tls-session-ticket-key of the secret in a file. Returns the name
+// of the file and the checksum of the content, or empty values if the
+// secret is not valid
+func (n *NGINXController) writeSessionTicketKey(secretName string) (string, string) {
+	s, exists, err := n.storeLister.Secret.GetByKey(secretName)
 	if err != nil {
-		return err
+		glog.Warningf("unexpected error reading secret %v: %v", secretName, err)
+		return "", ""
+	}
+	if !exists {
+		glog.Warningf("secret %v with the session ticket key does not exist", secretName)
+		return "", ""
+	}
+
+	key, ok := s.(*api_v1.Secret).Data[sessionTicketKey]
+	if !ok {
+		glog.Warningf("secret %v does not contain the session ticket key (%v)", secretName, sessionTicketKey)
+		return "", ""
+	}
+	if len(key) != 48 && len(key) != 80 {
+		glog.Warningf("the session ticket key of the secret %v must contain 48 or 80 bytes (%v bytes)", secretName, len(key))
+		return "", ""
+	}
+
+	fileName := fmt.Sprintf("%v/%v-%v", ingress.DefaultSSLDirectory, strings.Replace(secretName, "/", "-", -1), sessionTicketKey)
+	if err := ioutil.WriteFile(fileName, key, 0600); err != nil {
+		glog.Warningf("unexpected error writing the session ticket key %v: %v", fileName, err)
+		return "", ""
 	}
 
+	return fileName, ssl.PemSHA1(fileName)
+}
+
+// ReferencedSecrets returns the secrets with the DH parameters
+// (ssl-dh-param) and the key of the session tickets
+// (ssl-session-ticket-key) of the configmap, so changes in
+// the secrets update the configuration of NGINX
+func (n *NGINXController) ReferencedSecrets() []string {
+	secrets := []string{}
+	if n.configmap == nil {
+		return secrets
+	}
+
+	for _, key := range []string{"ssl-dh-param", "ssl-dhparam"} {
+		if secret := n.configmap.Data[key]; secret != "" {
+			secrets = append(secrets, secret)
+			break
+		}
+	}
+	if secret := n.configmap.Data["ssl-session-ticket-key"]; secret != "" {
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+// reload sends the reload signal to the NGINX master process
+func (n *NGINXController) reload() error {
 	o, err := exec.Command(n.binary, "-s", "reload", "-c", cfgPath).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%v\n%v", err, string(o))
 	}
 
+	atomic.AddInt32(&n.generation, 1)
 	return nil
 }
 
+// renderUpstreams replaces the upstreams of the last rendered configuration.
+// Only valid when the rest of the configuration did not change
+func (n *NGINXController) renderUpstreams(tc config.TemplateConfig) ([]byte, error) {
+	r, ok := n.t.(ngx_template.UpstreamRenderer)
+	if !ok || n.renderedContent == nil {
+		return nil, fmt.Errorf("the renderer does not support the rendering of the upstreams")
+	}
+
+	glog.V(3).Infof("only the endpoints changed, rendering the upstreams")
+	return r.RenderUpstreams(n.renderedContent, tc, n.testTemplate)
+}
+
+// isReloadPending checks if the reload limiter delayed a reload
+// that reports the result to the reload handler
+func (n *NGINXController) isReloadPending() bool {
+	return n.reloads != nil && n.reloadHandler != nil && n.reloads.Pending()
+}
+
+// scheduledReload reloads NGINX with the content of the configuration
+// file when the reload limiter allows it. After a failure the configuration
+// is rendered again in the next sync
+func (n *NGINXController) scheduledReload() {
+	if n.isShuttingDown() {
+		return
+	}
+
+	content, err := ioutil.ReadFile(cfgPath)
+	if err != nil {
+		err = fmt.Errorf("unexpected error reading the NGINX configuration file: %v", err)
+	} else {
+		err = n.reload()
+	}
+
+	if err != nil {
+		glog.Errorf("unexpected error reloading NGINX: %v", err)
+		atomic.StoreInt32(&n.discardRendered, 1)
+	} else {
+		n.goodConfig.Store(content)
+	}
+
+	if n.reloadHandler != nil {
+		n.reloadHandler(err)
+	}
+}
+
+// templateChecksum returns the checksum of the configuration used to
+// render the template
+func templateChecksum(tc config.TemplateConfig) (string, error) {
+	b, err := json.Marshal(tc)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// isReloadRequired checks if the NGINX configuration file differs
+// from the content
+func isReloadRequired(content []byte) bool {
+	src, err := ioutil.ReadFile(cfgPath)
+	if err != nil {
+		return true
+	}
+
+	return !bytes.Equal(src, content)
+}
+
 // nginxHashBucketSize computes the correct nginx hash_bucket_size for a hash with the given longest key
 func nginxHashBucketSize(longestString int) int {
 	// See https://github.com/kubernetes/ingress/issues/623 for an explanation
@@ -572,9 +1430,42 @@ func (n NGINXController) Name() string {
 	return "Ingress Controller"
 }
 
-// Check returns if the nginx healthz endpoint is returning ok (status code 200)
-func (n NGINXController) Check(_ *http.Request) error {
-	res, err := http.Get(fmt.Sprintf("http://localhost:%v%v", ngxHealthPort, ngxHealthPath))
+// Check returns if the NGINX master process is running and
+// the nginx healthz endpoint is returning ok (status code 200)
+func (n *NGINXController) Check(_ *http.Request) error {
+	if n.isShuttingDown() {
+		return fmt.Errorf("ingress controller is shutting down")
+	}
+
+	if err := checkMasterProcess(pidFile); err != nil {
+		return err
+	}
+
+	return checkHealthURL(fmt.Sprintf("http://localhost:%v%v", ngxHealthPort, ngxHealthPath), healthCheckTimeout)
+}
+
+// checkMasterProcess returns an error if the NGINX master
+// process in the pid file is not running
+func checkMasterProcess(file string) error {
+	pid, err := readPid(file)
+	if err != nil {
+		return fmt.Errorf("unexpected error reading the pid of the NGINX master process: %v", err)
+	}
+
+	if !isProcessRunning(pid) {
+		return fmt.Errorf("the NGINX master process %v is not running", pid)
+	}
+
+	return nil
+}
+
+// checkHealthURL returns an error if the health check location of NGINX
+// does not return ok (status code 200) before the timeout. A NGINX
+// not processing requests (wedged) accepts the connections without
+// returning a response
+func checkHealthURL(url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	res, err := client.Get(url)
 	if err != nil {
 		return err
 	}
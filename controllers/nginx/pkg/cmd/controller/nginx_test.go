@@ -16,7 +16,68 @@ limitations under the License.
 
 package main
 
-import "testing"
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
+	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/healthcheck"
+	ing_errors "k8s.io/ingress/core/pkg/ingress/errors"
+	"k8s.io/ingress/core/pkg/ingress/store"
+)
+
+// fakeRenderer records the configuration used to render
+// the NGINX configuration file
+type fakeRenderer struct {
+	conf   *config.TemplateConfig
+	tested bool
+	err    error
+	calls  int
+}
+
+func (r *fakeRenderer) Render(conf config.TemplateConfig, testFn func([]byte) error) ([]byte, error) {
+	r.calls++
+	r.conf = &conf
+	r.tested = testFn != nil
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []byte("events {}"), nil
+}
+
+// fakeUpstreamRenderer also renders only the upstreams
+type fakeUpstreamRenderer struct {
+	fakeRenderer
+	upstreamErr   error
+	upstreamCalls int
+}
+
+func (r *fakeUpstreamRenderer) RenderUpstreams(content []byte, conf config.TemplateConfig, testFn func([]byte) error) ([]byte, error) {
+	r.upstreamCalls++
+	r.conf = &conf
+	if r.upstreamErr != nil {
+		return nil, r.upstreamErr
+	}
+	return []byte(fmt.Sprintf("events {} # %v endpoints", len(conf.Backends[0].Endpoints))), nil
+}
 
 func TestNginxHashBucketSize(t *testing.T) {
 	tests := []struct {
@@ -57,3 +118,722 @@ func TestNginxHashBucketSize(t *testing.T) {
 		}
 	}
 }
+
+func TestOnUpdateWithRenderer(t *testing.T) {
+	renderer := &fakeRenderer{err: fmt.Errorf("fake renderer error")}
+	n := &NGINXController{
+		t:            renderer,
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+
+	servers := []*ingress.Server{{Hostname: "foo.bar"}}
+	err := n.OnUpdate(ingress.Configuration{Servers: servers})
+	if err != renderer.err {
+		t.Errorf("expected the error of the renderer but returned %v", err)
+	}
+
+	if renderer.conf == nil {
+		t.Fatalf("expected the configuration to be rendered using the custom renderer")
+	}
+	if len(renderer.conf.Servers) != 1 || renderer.conf.Servers[0].Hostname != "foo.bar" {
+		t.Errorf("expected the servers of the configuration but returned %v", renderer.conf.Servers)
+	}
+	if !renderer.tested {
+		t.Errorf("expected a function to test the rendered configuration")
+	}
+}
+
+func TestOnUpdateWithSameConfiguration(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	defCfgPath := cfgPath
+	cfgPath = f.Name()
+	defer func() { cfgPath = defCfgPath }()
+
+	renderer := &fakeRenderer{}
+	n := &NGINXController{
+		t:            renderer,
+		binary:       "true",
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+
+	servers := []*ingress.Server{{Hostname: "foo.bar"}}
+	for i := 0; i < 3; i++ {
+		if err := n.OnUpdate(ingress.Configuration{Servers: servers}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if renderer.calls != 1 {
+		t.Errorf("expected one render of the same configuration but returned %v", renderer.calls)
+	}
+
+	// the file on disk does not contain the rendered configuration
+	ioutil.WriteFile(cfgPath, []byte(""), 0644)
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 1 {
+		t.Errorf("expected one render of the same configuration but returned %v", renderer.calls)
+	}
+	b, _ := ioutil.ReadFile(cfgPath)
+	if string(b) != "events {}" {
+		t.Errorf("expected the rendered configuration in the file but returned '%v'", string(b))
+	}
+
+	servers = append(servers, &ingress.Server{Hostname: "bar.foo"})
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 2 {
+		t.Errorf("expected a new render with a different configuration but returned %v", renderer.calls)
+	}
+}
+
+func TestOnUpdateAfterTemplateChange(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	dir, err := ioutil.TempDir("", "nginx-template")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := path.Join(dir, "nginx.tmpl")
+	ioutil.WriteFile(tmpl, []byte("events {}\n# template v1\n"), 0644)
+
+	defCfgPath, defTmplPath := cfgPath, tmplPath
+	cfgPath, tmplPath = f.Name(), tmpl
+	defer func() { cfgPath, tmplPath = defCfgPath, defTmplPath }()
+
+	n := &NGINXController{
+		binary:       "true",
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+	ngxTpl, err := ngx_template.NewTemplate(tmplPath, func() {})
+	if err != nil {
+		t.Fatalf("unexpected error loading the template: %v", err)
+	}
+	n.t = ngxTpl
+	defer func() { n.t.(*ngx_template.Template).Close() }()
+
+	servers := []*ingress.Server{{Hostname: "foo.bar"}}
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(cfgPath); !strings.Contains(string(b), "template v1") {
+		t.Fatalf("expected the configuration rendered with the template but returned '%v'", string(b))
+	}
+
+	ioutil.WriteFile(tmpl, []byte("events {}\n# template v2\n"), 0644)
+	n.onTemplateChange()
+
+	// the same configuration must be rendered with the new template
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(cfgPath); !strings.Contains(string(b), "template v2") {
+		t.Errorf("expected the configuration rendered with the new template but returned '%v'", string(b))
+	}
+}
+
+func TestCheckHTTP3(t *testing.T) {
+	cfg := config.NewDefault()
+	unsupported := NGINXController{}
+	if err := checkHTTP3(cfg, unsupported.Info()); err != nil {
+		t.Errorf("unexpected error with HTTP/3 disabled: %v", err)
+	}
+
+	cfg.UseHTTP3 = true
+	if err := checkHTTP3(cfg, unsupported.Info()); err == nil {
+		t.Errorf("expected an error using HTTP/3 with a NGINX binary without QUIC support")
+	}
+
+	supported := NGINXController{isHTTP3Supported: true}
+	if err := checkHTTP3(cfg, supported.Info()); err != nil {
+		t.Errorf("unexpected error with a NGINX binary with QUIC support: %v", err)
+	}
+}
+
+func TestCheckTLSv13(t *testing.T) {
+	cfg := config.NewDefault()
+	unsupported := NGINXController{}
+	if err := checkTLSv13(cfg, unsupported.Info()); err != nil {
+		t.Errorf("unexpected error with TLSv1.3 disabled: %v", err)
+	}
+
+	cfg.SSLProtocols = "TLSv1.2 TLSv1.3"
+	cfg.SSLEarlyData = true
+	if err := checkTLSv13(cfg, unsupported.Info()); err == nil {
+		t.Errorf("expected an error using TLSv1.3 with a NGINX binary without TLSv1.3 support")
+	}
+
+	supported := NGINXController{isTLSv13Supported: true}
+	if err := checkTLSv13(cfg, supported.Info()); err != nil {
+		t.Errorf("unexpected error with a NGINX binary with TLSv1.3 support: %v", err)
+	}
+
+	disableTLSv13(&cfg)
+	if cfg.SSLProtocols != "TLSv1.2" || cfg.SSLEarlyData {
+		t.Errorf("expected TLSv1.2 without early data but returned %v and %v", cfg.SSLProtocols, cfg.SSLEarlyData)
+	}
+
+	cfg.SSLProtocols = "TLSv1.3"
+	disableTLSv13(&cfg)
+	if cfg.SSLProtocols != config.NewDefault().SSLProtocols {
+		t.Errorf("expected the default protocols but returned %v", cfg.SSLProtocols)
+	}
+}
+
+func TestCheckNjs(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "default-foo-80"}}
+	unsupported := NGINXController{}
+	if err := checkNjs(backends, unsupported.Info()); err != nil {
+		t.Errorf("unexpected error without health check scripts: %v", err)
+	}
+
+	backends = append(backends, &ingress.Backend{
+		Name:              "default-bar-80",
+		HealthCheckScript: healthcheck.Script{Path: "/etc/nginx/njs/health.js", Function: "check"},
+	})
+	if err := checkNjs(backends, unsupported.Info()); err == nil {
+		t.Errorf("expected an error using a health check script with a NGINX binary without njs")
+	}
+
+	supported := NGINXController{isNjsSupported: true}
+	if err := checkNjs(backends, supported.Info()); err != nil {
+		t.Errorf("unexpected error with a NGINX binary with njs: %v", err)
+	}
+}
+
+func TestCheckAJP(t *testing.T) {
+	ajp := &ingress.Location{Path: "/app", BackendProtocol: backendprotocol.AJP}
+	servers := []*ingress.Server{
+		{Hostname: "foo.bar", Locations: []*ingress.Location{{Path: "/"}}},
+		{Hostname: "bar.foo", Locations: []*ingress.Location{{Path: "/"}, ajp}},
+	}
+
+	supported := NGINXController{isAJPSupported: true}
+	if err := checkAJP(servers, supported.Info()); err != nil {
+		t.Errorf("unexpected error with a NGINX binary with the AJP module: %v", err)
+	}
+
+	unsupported := NGINXController{}
+	if err := checkAJP(servers[:1], unsupported.Info()); err != nil {
+		t.Errorf("unexpected error without AJP locations: %v", err)
+	}
+	if err := checkAJP(servers, unsupported.Info()); err == nil {
+		t.Fatalf("expected an error using AJP with a NGINX binary without the AJP module")
+	}
+
+	res := disableAJP(servers)
+	if len(res) != 2 || res[1].Locations[1].BackendProtocol != backendprotocol.HTTP || res[1].Locations[1].Path != "/app" {
+		t.Errorf("expected the AJP location with HTTP but returned %+v", res[1].Locations[1])
+	}
+	if ajp.BackendProtocol != backendprotocol.AJP {
+		t.Errorf("unexpected change in the location of the Ingress rule")
+	}
+	if err := checkAJP(res, unsupported.Info()); err != nil {
+		t.Errorf("unexpected error after disabling AJP: %v", err)
+	}
+}
+
+func TestOnUpdateWithoutNjs(t *testing.T) {
+	renderer := &fakeRenderer{}
+	n := &NGINXController{
+		t:            renderer,
+		binary:       "true",
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+
+	backends := []*ingress.Backend{{
+		Name:              "default-bar-80",
+		HealthCheckScript: healthcheck.Script{Path: "/etc/nginx/njs/health.js", Function: "check"},
+	}}
+	if err := n.OnUpdate(ingress.Configuration{Backends: backends}); err == nil {
+		t.Errorf("expected an error updating a configuration with njs directives without the module")
+	}
+	if renderer.calls != 0 {
+		t.Errorf("unexpected render of a configuration rejected")
+	}
+}
+
+func TestRestoreConfiguration(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	good := []byte("events {}")
+	test := func(cfg []byte) error {
+		if string(cfg) == "invalid" {
+			return fmt.Errorf("invalid configuration")
+		}
+		return nil
+	}
+
+	ioutil.WriteFile(f.Name(), []byte("invalid"), 0644)
+	if restored, err := restoreConfiguration(f.Name(), nil, test); restored || err != nil {
+		t.Errorf("unexpected restore without a valid configuration (%v)", err)
+	}
+
+	if restored, err := restoreConfiguration(f.Name(), good, test); !restored || err != nil {
+		t.Errorf("expected the restore of an invalid configuration (%v)", err)
+	}
+	if b, _ := ioutil.ReadFile(f.Name()); string(b) != string(good) {
+		t.Errorf("expected the last valid configuration in the file but returned '%v'", string(b))
+	}
+
+	// a valid configuration newer than the last loaded is not replaced
+	ioutil.WriteFile(f.Name(), []byte("events { worker_connections 512; }"), 0644)
+	if restored, err := restoreConfiguration(f.Name(), good, test); restored || err != nil {
+		t.Errorf("unexpected restore of a valid configuration (%v)", err)
+	}
+}
+
+func TestRestartCount(t *testing.T) {
+	n := &NGINXController{}
+	n.incRestartCount()
+	n.incRestartCount()
+	if n.restarts != 2 {
+		t.Errorf("expected 2 restarts but returned %v", n.restarts)
+	}
+}
+
+func TestConfigureFlags(t *testing.T) {
+	defTmplPath, defCfgPath := tmplPath, cfgPath
+	defer func() {
+		tmplPath, cfgPath = defTmplPath, defCfgPath
+	}()
+
+	n := &NGINXController{binary: binary}
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	n.ConfigureFlags(flags)
+
+	err := flags.Parse([]string{
+		"--nginx-binary=/usr/local/sbin/nginx",
+		"--nginx-template=/tmp/nginx.tmpl",
+		"--nginx-config=/tmp/nginx.conf",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error parsing flags: %v", err)
+	}
+
+	if n.binary != "/usr/local/sbin/nginx" {
+		t.Errorf("expected /usr/local/sbin/nginx as binary but returned %v", n.binary)
+	}
+	if tmplPath != "/tmp/nginx.tmpl" {
+		t.Errorf("expected /tmp/nginx.tmpl as template but returned %v", tmplPath)
+	}
+	if cfgPath != "/tmp/nginx.conf" {
+		t.Errorf("expected /tmp/nginx.conf as configuration file but returned %v", cfgPath)
+	}
+}
+
+func TestOnUpdateWithDeferredReload(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	defCfgPath := cfgPath
+	cfgPath = f.Name()
+	defer func() { cfgPath = defCfgPath }()
+
+	renderer := &fakeRenderer{}
+	n := &NGINXController{
+		t:            renderer,
+		binary:       "false",
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+	results := []error{}
+	n.SetReloadHandler(func(err error) {
+		results = append(results, err)
+	})
+	n.reloads = newReloadLimiter(time.Hour, time.Minute, 0, n.scheduledReload)
+	defer n.reloads.Stop()
+
+	cfg := ingress.Configuration{Servers: []*ingress.Server{{Hostname: "foo.bar"}}}
+	if err := n.OnUpdate(cfg); err != ing_errors.ErrReloadPending {
+		t.Fatalf("expected a pending reload but returned %v", err)
+	}
+	// the reload is still pending after a sync without changes
+	if err := n.OnUpdate(cfg); err != ing_errors.ErrReloadPending {
+		t.Fatalf("expected a pending reload but returned %v", err)
+	}
+	if renderer.calls != 1 {
+		t.Errorf("expected a single render of the configuration but returned %v", renderer.calls)
+	}
+
+	n.reloads.Stop()
+	n.scheduledReload()
+	if len(results) != 1 || results[0] == nil {
+		t.Fatalf("expected the error of the reload in the handler but returned %v", results)
+	}
+	if n.goodConfig.Load() != nil {
+		t.Errorf("unexpected valid configuration after a failed reload")
+	}
+
+	// the configuration is rendered and the reload scheduled again
+	if err := n.OnUpdate(cfg); err != ing_errors.ErrReloadPending {
+		t.Fatalf("expected a pending reload but returned %v", err)
+	}
+	if renderer.calls != 2 {
+		t.Errorf("expected the render of the configuration after a failed reload but returned %v renders", renderer.calls)
+	}
+
+	n.reloads.Stop()
+	n.binary = "true"
+	n.scheduledReload()
+	if len(results) != 2 || results[1] != nil {
+		t.Errorf("expected a successful reload in the handler but returned %v", results)
+	}
+	if n.goodConfig.Load() == nil {
+		t.Errorf("expected the reloaded configuration as valid configuration")
+	}
+}
+
+func TestOnUpdateWithEndpointChanges(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	defCfgPath := cfgPath
+	cfgPath = f.Name()
+	defer func() { cfgPath = defCfgPath }()
+
+	renderer := &fakeUpstreamRenderer{}
+	n := &NGINXController{
+		t:            renderer,
+		binary:       "true",
+		configmap:    &api_v1.ConfigMap{},
+		statusModule: defaultStatusModule,
+		proxy:        &proxy{},
+	}
+
+	servers := []*ingress.Server{{Hostname: "foo.bar"}}
+	backend := func(endpoints ...ingress.Endpoint) []*ingress.Backend {
+		return []*ingress.Backend{{Name: "default-foo-80", Endpoints: endpoints}}
+	}
+	e1 := ingress.Endpoint{Address: "10.0.0.1", Port: "8080"}
+	e2 := ingress.Endpoint{Address: "10.0.0.2", Port: "8080"}
+
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers, Backends: backend(e1)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers, Backends: backend(e1, e2)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 1 || renderer.upstreamCalls != 1 {
+		t.Errorf("expected only the render of the upstreams after a change in the endpoints (render %v, upstreams %v)", renderer.calls, renderer.upstreamCalls)
+	}
+	b, _ := ioutil.ReadFile(cfgPath)
+	if string(b) != "events {} # 2 endpoints" {
+		t.Errorf("expected the configuration with the new upstreams in the file but returned '%v'", string(b))
+	}
+
+	renderer.upstreamErr = fmt.Errorf("fake upstream renderer error")
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers, Backends: backend(e2)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 2 || renderer.upstreamCalls != 2 {
+		t.Errorf("expected the render of the complete configuration after an error (render %v, upstreams %v)", renderer.calls, renderer.upstreamCalls)
+	}
+
+	servers = append(servers, &ingress.Server{Hostname: "bar.foo"})
+	if err := n.OnUpdate(ingress.Configuration{Servers: servers, Backends: backend(e1)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 3 || renderer.upstreamCalls != 2 {
+		t.Errorf("expected the render of the complete configuration after a change in the servers (render %v, upstreams %v)", renderer.calls, renderer.upstreamCalls)
+	}
+}
+
+func TestInvalidServers(t *testing.T) {
+	cfg := []byte(`http {
+    ## start server _
+    server {
+        server_name _;
+    }
+    ## end server _
+
+    ## start server foo.bar
+    server {
+        server_name foo.bar;
+        foo;
+        bar;
+    }
+    ## end server foo.bar
+
+    foobar;
+}
+`)
+
+	testCases := map[string]struct {
+		out      string
+		expected []string
+	}{
+		"no line": {
+			`nginx: [emerg] open() "/etc/nginx/nginx.conf" failed`,
+			[]string{},
+		},
+		"invalid server": {
+			"nginx: [emerg] unknown directive \"foo\" in /tmp/nginx-cfg123:11\nnginx: [emerg] unknown directive \"bar\" in /tmp/nginx-cfg123:12",
+			[]string{"foo.bar"},
+		},
+		"outside of the servers": {
+			`nginx: [emerg] unknown directive "foobar" in /tmp/nginx-cfg123:16`,
+			[]string{},
+		},
+		"other file": {
+			`nginx: [emerg] unknown directive "foo" in /tmp/other:11`,
+			[]string{},
+		},
+		"invalid line": {
+			`nginx: [emerg] unknown directive "foo" in /tmp/nginx-cfg123:100`,
+			[]string{},
+		},
+	}
+
+	for title, tc := range testCases {
+		hosts := invalidServers(tc.out, "/tmp/nginx-cfg123", cfg)
+		if fmt.Sprintf("%v", hosts) != fmt.Sprintf("%v", tc.expected) {
+			t.Errorf("%v: expected %v but returned %v", title, tc.expected, hosts)
+		}
+	}
+}
+
+func TestDumpConfiguration(t *testing.T) {
+	n := &NGINXController{binary: "/bin/false"}
+	if applied, rejected := n.DumpConfiguration(); applied != nil || rejected != nil {
+		t.Errorf("expected no configuration but returned '%s' and '%s'", applied, rejected)
+	}
+
+	n.goodConfig.Store([]byte("events {}"))
+	if err := n.testTemplate([]byte("invalid")); err == nil {
+		t.Fatalf("expected an error testing the configuration")
+	}
+
+	applied, rejected := n.DumpConfiguration()
+	if string(applied) != "events {}" {
+		t.Errorf("expected the applied configuration but returned '%s'", applied)
+	}
+	if string(rejected) != "invalid" {
+		t.Errorf("expected the rejected configuration but returned '%s'", rejected)
+	}
+}
+
+func TestCheckMasterProcess(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.pid")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	ioutil.WriteFile(f.Name(), []byte(fmt.Sprintf("%v\n", os.Getpid())), 0644)
+	if err := checkMasterProcess(f.Name()); err != nil {
+		t.Errorf("unexpected error checking a running process: %v", err)
+	}
+
+	// greater than the maximum pid of linux
+	ioutil.WriteFile(f.Name(), []byte("4194305\n"), 0644)
+	if err := checkMasterProcess(f.Name()); err == nil {
+		t.Errorf("expected an error checking a process not running")
+	}
+
+	if err := checkMasterProcess("/tmp/does-not-exist.pid"); err == nil {
+		t.Errorf("expected an error without pid file")
+	}
+}
+
+func TestCheckHealthURL(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/wedged":
+			<-unblock
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	if err := checkHealthURL(server.URL+"/healthz", time.Second); err != nil {
+		t.Errorf("unexpected error checking a healthy NGINX: %v", err)
+	}
+	if err := checkHealthURL(server.URL+"/error", time.Second); err == nil {
+		t.Errorf("expected an error checking an unhealthy NGINX")
+	}
+	if err := checkHealthURL(server.URL+"/wedged", 100*time.Millisecond); err == nil {
+		t.Errorf("expected an error checking a NGINX without response")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	renderer := &fakeRenderer{}
+	n := &NGINXController{
+		t:         renderer,
+		configmap: &api_v1.ConfigMap{},
+		proxy:     &proxy{},
+	}
+
+	servers := []*ingress.Server{{Hostname: "foo.bar"}}
+	content, err := n.DryRun(ingress.Configuration{Servers: servers})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "events {}" {
+		t.Errorf("expected the rendered configuration but returned '%s'", content)
+	}
+	if renderer.conf == nil || len(renderer.conf.Servers) != 1 || !renderer.tested {
+		t.Errorf("expected the configuration to be rendered and tested")
+	}
+	if n.renderedContent != nil || n.goodConfig.Load() != nil {
+		t.Errorf("unexpected configuration applied in the dry run")
+	}
+
+	renderer.err = fmt.Errorf("invalid configuration")
+	if _, err := n.DryRun(ingress.Configuration{Servers: servers}); err != renderer.err {
+		t.Errorf("expected the error of the renderer but returned %v", err)
+	}
+}
+
+func TestPassthroughServers(t *testing.T) {
+	svc := &api_v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Spec: api_v1.ServiceSpec{
+			ClusterIP: "10.0.0.10",
+			Ports:     []api_v1.ServicePort{{Name: "https", Port: 8443}},
+		},
+	}
+
+	servers := passthroughServers([]*ingress.SSLPassthroughBackend{
+		{Hostname: "foo.bar", Service: svc, Port: intstr.FromString("https")},
+		{Hostname: "proxy.foo.bar", Service: svc, Port: intstr.FromInt(8443), ProxyProtocol: true},
+		{Hostname: "no-service.foo.bar", Port: intstr.FromInt(443)},
+	})
+
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers but returned %v", len(servers))
+	}
+	for i, expected := range []server{
+		{Hostname: "foo.bar", IP: "10.0.0.10", Port: 8443},
+		{Hostname: "proxy.foo.bar", IP: "10.0.0.10", Port: 8443, ProxyProtocol: true},
+	} {
+		if *servers[i] != expected {
+			t.Errorf("expected %+v but returned %+v", expected, *servers[i])
+		}
+	}
+}
+
+func TestTLSServiceServers(t *testing.T) {
+	servers := tlsServiceServers([]ingress.L4Service{
+		{Port: 443, Hostname: "db.foo.bar"},
+		{Port: 443, Hostname: "mqtt.foo.bar"},
+	})
+
+	if len(servers) != 2 {
+		t.Fatalf("expected 2 servers but returned %v", len(servers))
+	}
+	for i, hostname := range []string{"db.foo.bar", "mqtt.foo.bar"} {
+		expected := server{Hostname: hostname, IP: "127.0.0.1", Port: tlsServicesPort, ProxyProtocol: true}
+		if *servers[i] != expected {
+			t.Errorf("expected %+v but returned %+v", expected, *servers[i])
+		}
+	}
+}
+
+func TestReferencedSecrets(t *testing.T) {
+	n := &NGINXController{}
+	if secrets := n.ReferencedSecrets(); len(secrets) != 0 {
+		t.Errorf("expected no secrets without configmap but returned %v", secrets)
+	}
+
+	for _, tc := range []struct {
+		data     map[string]string
+		expected []string
+	}{
+		{map[string]string{}, []string{}},
+		{map[string]string{"ssl-dh-param": "default/dhparam"}, []string{"default/dhparam"}},
+		{map[string]string{"ssl-dhparam": "kube-system/dh2048"}, []string{"kube-system/dh2048"}},
+		{map[string]string{"ssl-dh-param": "default/dhparam", "ssl-session-ticket-key": "default/ticket-key"},
+			[]string{"default/dhparam", "default/ticket-key"}},
+	} {
+		n.SetConfig(&api_v1.ConfigMap{Data: tc.data})
+		if secrets := n.ReferencedSecrets(); !reflect.DeepEqual(secrets, tc.expected) {
+			t.Errorf("expected the secrets %v with %v but returned %v", tc.expected, tc.data, secrets)
+		}
+	}
+}
+
+func TestWriteSessionTicketKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssl")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defaultSSLDirectory := ingress.DefaultSSLDirectory
+	ingress.DefaultSSLDirectory = dir
+	defer func() { ingress.DefaultSSLDirectory = defaultSSLDirectory }()
+
+	secrets := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for name, key := range map[string]string{
+		"ticket-key":  strings.Repeat("k", 80),
+		"invalid-key": strings.Repeat("k", 32),
+	} {
+		secrets.Add(&api_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default"},
+			Data:       map[string][]byte{sessionTicketKey: []byte(key)},
+		})
+	}
+	n := &NGINXController{
+		storeLister: ingress.StoreLister{Secret: store.SecretLister{Store: secrets}},
+	}
+
+	file, sha := n.writeSessionTicketKey("default/ticket-key")
+	if file != dir+"/default-ticket-key-tls-session-ticket-key" {
+		t.Errorf("unexpected session ticket key file '%v'", file)
+	}
+	if sha == "" {
+		t.Errorf("expected the checksum of the session ticket key")
+	}
+	if content, _ := ioutil.ReadFile(file); string(content) != strings.Repeat("k", 80) {
+		t.Errorf("unexpected content of the session ticket key file: %v", string(content))
+	}
+
+	for _, secret := range []string{"default/invalid-key", "default/missing"} {
+		if file, _ := n.writeSessionTicketKey(secret); file != "" {
+			t.Errorf("expected no session ticket key with the secret %v but returned %v", secret, file)
+		}
+	}
+}
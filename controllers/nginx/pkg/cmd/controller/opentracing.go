@@ -0,0 +1,128 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+)
+
+const (
+	// dynamic module loaded in the template (load_module)
+	opentracingModule = "/etc/nginx/modules/ngx_http_opentracing_module.so"
+
+	// configuration of the tracer (opentracing_load_tracer in the template)
+	opentracingCfgPath = "/etc/nginx/opentracing.json"
+)
+
+// zipkinConfig is the configuration of the zipkin tracer
+// https://github.com/rnburn/zipkin-cpp-opentracing
+type zipkinConfig struct {
+	ServiceName   string  `json:"service_name"`
+	CollectorHost string  `json:"collector_host"`
+	CollectorPort int     `json:"collector_port"`
+	SampleRate    float64 `json:"sample_rate"`
+}
+
+// jaegerConfig is the configuration of the jaeger tracer
+// https://github.com/jaegertracing/jaeger-client-cpp
+type jaegerConfig struct {
+	ServiceName string         `json:"service_name"`
+	Sampler     jaegerSampler  `json:"sampler"`
+	Reporter    jaegerReporter `json:"reporter"`
+}
+
+type jaegerSampler struct {
+	Type  string  `json:"type"`
+	Param float64 `json:"param"`
+}
+
+type jaegerReporter struct {
+	LocalAgentHostPort string `json:"localAgentHostPort"`
+}
+
+// checkOpentracing returns an error if the opentracing
+// module or the plugin of the tracer are not installed
+func checkOpentracing(cfg config.Configuration) error {
+	for _, file := range []string{opentracingModule, cfg.OpentracingTracerLibrary()} {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("opentracing is enabled but %v is not available: %v", file, err)
+		}
+	}
+
+	return nil
+}
+
+// opentracingConfig returns the configuration of the tracer in JSON
+func opentracingConfig(cfg config.Configuration) ([]byte, error) {
+	address := cfg.OpentracingCollectorAddress()
+
+	switch cfg.OpentracingTracer {
+	case config.ZipkinTracer:
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(zipkinConfig{
+			ServiceName:   cfg.OpentracingServiceName,
+			CollectorHost: host,
+			CollectorPort: p,
+			SampleRate:    cfg.OpentracingSampleRate,
+		})
+	case config.JaegerTracer:
+		return json.Marshal(jaegerConfig{
+			ServiceName: cfg.OpentracingServiceName,
+			Sampler: jaegerSampler{
+				Type:  "probabilistic",
+				Param: cfg.OpentracingSampleRate,
+			},
+			Reporter: jaegerReporter{
+				LocalAgentHostPort: address,
+			},
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported tracer %v", cfg.OpentracingTracer)
+}
+
+// writeOpentracingConfig writes the configuration of the tracer in
+// file. The file is not modified if the content did not change
+func writeOpentracingConfig(cfg config.Configuration, file string) error {
+	content, err := opentracingConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	current, err := ioutil.ReadFile(file)
+	if err == nil && bytes.Equal(current, content) {
+		return nil
+	}
+
+	return ioutil.WriteFile(file, content, 0644)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+)
+
+func TestOpentracingConfig(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.OpentracingCollectorHost = "zipkin.tracing"
+	cfg.OpentracingSampleRate = 0.5
+
+	b, err := opentracingConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"service_name":"nginx","collector_host":"zipkin.tracing","collector_port":9411,"sample_rate":0.5}`
+	if string(b) != expected {
+		t.Errorf("expected %v but returned %v", expected, string(b))
+	}
+
+	cfg.OpentracingTracer = config.JaegerTracer
+	cfg.OpentracingCollectorHost = "jaeger-agent"
+	cfg.OpentracingCollectorPort = 5775
+	cfg.OpentracingServiceName = "ingress"
+	b, err = opentracingConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `{"service_name":"ingress","sampler":{"type":"probabilistic","param":0.5},"reporter":{"localAgentHostPort":"jaeger-agent:5775"}}`
+	if string(b) != expected {
+		t.Errorf("expected %v but returned %v", expected, string(b))
+	}
+
+	cfg.OpentracingTracer = "lightstep"
+	if _, err := opentracingConfig(cfg); err == nil {
+		t.Errorf("expected an error with an unsupported tracer")
+	}
+}
+
+func TestWriteOpentracingConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "opentracing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := config.NewDefault()
+	cfg.OpentracingCollectorHost = "10.0.0.1"
+	file := filepath.Join(dir, "opentracing.json")
+
+	err = writeOpentracingConfig(cfg, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"service_name":"nginx","collector_host":"10.0.0.1","collector_port":9411,"sample_rate":1}`
+	if string(b) != expected {
+		t.Errorf("expected %v but returned %v", expected, string(b))
+	}
+}
+
+func TestCheckOpentracing(t *testing.T) {
+	// the sandbox does not contain the opentracing module
+	if _, err := os.Stat(opentracingModule); err == nil {
+		t.Skip("the opentracing module is installed")
+	}
+
+	if err := checkOpentracing(config.NewDefault()); err == nil {
+		t.Errorf("expected an error without the opentracing module")
+	}
+}
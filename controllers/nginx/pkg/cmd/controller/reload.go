@@ -0,0 +1,141 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// default interval used to count the reloads of NGINX
+	// when --max-reloads-per-interval is configured
+	defaultReloadInterval = 1 * time.Minute
+)
+
+// reloadLimiter coalesces the reloads of NGINX. The first request starts
+// a window of length debounce and all the requests received before the
+// end of the window produce a single reload. If max is greater than zero
+// no more than max reloads are executed in interval and the pending
+// reload is delayed until the budget is available again.
+type reloadLimiter struct {
+	mu sync.Mutex
+
+	debounce time.Duration
+	interval time.Duration
+	max      int
+
+	reload func()
+
+	// history contains the time of the reloads executed in the last interval
+	history []time.Time
+	timer   *time.Timer
+
+	// running serializes the executions of reload
+	running sync.Mutex
+}
+
+// newReloadLimiter creates a limiter that invokes reload for each coalesced group of requests
+func newReloadLimiter(debounce, interval time.Duration, max int, reload func()) *reloadLimiter {
+	return &reloadLimiter{
+		debounce: debounce,
+		interval: interval,
+		max:      max,
+		reload:   reload,
+	}
+}
+
+// Schedule requests a reload of NGINX. The request is ignored if
+// there is already a pending reload
+func (r *reloadLimiter) Schedule() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		glog.V(3).Infof("reload of NGINX already scheduled")
+		return
+	}
+
+	now := time.Now()
+	r.history = pruneReloads(r.history, now, r.interval)
+
+	delay := nextReloadDelay(now, r.debounce, r.interval, r.max, r.history)
+	if delay > r.debounce {
+		glog.Infof("maximum number of reloads (%v in %v) reached. Delaying the reload of NGINX %v", r.max, r.interval, delay)
+	}
+
+	r.timer = time.AfterFunc(delay, r.fire)
+}
+
+// Stop discards the pending reload
+func (r *reloadLimiter) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+// Pending checks if there is a reload waiting to be executed
+func (r *reloadLimiter) Pending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.timer != nil
+}
+
+func (r *reloadLimiter) fire() {
+	r.mu.Lock()
+	r.timer = nil
+	r.history = append(r.history, time.Now())
+	r.mu.Unlock()
+
+	r.running.Lock()
+	defer r.running.Unlock()
+	r.reload()
+}
+
+// pruneReloads removes the reloads older than interval
+func pruneReloads(history []time.Time, now time.Time, interval time.Duration) []time.Time {
+	i := 0
+	for i < len(history) && now.Sub(history[i]) >= interval {
+		i++
+	}
+	return history[i:]
+}
+
+// nextReloadDelay returns the time to wait before the next reload. The
+// delay is the debounce window unless the reloads in history exhausted
+// the budget, in which case the reload waits until the oldest reload
+// leaves the interval
+func nextReloadDelay(now time.Time, debounce, interval time.Duration, max int, history []time.Time) time.Duration {
+	delay := debounce
+	if max <= 0 || len(history) < max {
+		return delay
+	}
+
+	available := history[len(history)-max].Add(interval).Sub(now)
+	if available > delay {
+		delay = available
+	}
+
+	return delay
+}
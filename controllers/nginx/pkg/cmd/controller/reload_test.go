@@ -0,0 +1,111 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNextReloadDelay(t *testing.T) {
+	now := time.Now()
+	history := []time.Time{now.Add(-50 * time.Second), now.Add(-10 * time.Second)}
+
+	fooTests := []struct {
+		debounce time.Duration
+		max      int
+		history  []time.Time
+		delay    time.Duration
+	}{
+		{0, 0, history, 0},
+		{2 * time.Second, 0, history, 2 * time.Second},
+		{2 * time.Second, 3, history, 2 * time.Second},
+		{2 * time.Second, 2, history, 10 * time.Second},
+		{2 * time.Second, 1, history, 50 * time.Second},
+		{20 * time.Second, 2, history, 20 * time.Second},
+		{0, 1, nil, 0},
+	}
+
+	for _, ft := range fooTests {
+		delay := nextReloadDelay(now, ft.debounce, time.Minute, ft.max, ft.history)
+		if delay != ft.delay {
+			t.Errorf("expected a delay of %v (debounce %v, max %v) but returned %v", ft.delay, ft.debounce, ft.max, delay)
+		}
+	}
+}
+
+func TestPruneReloads(t *testing.T) {
+	now := time.Now()
+	history := []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute), now.Add(-time.Second)}
+
+	h := pruneReloads(history, now, time.Minute)
+	if len(h) != 1 || !h[0].Equal(history[2]) {
+		t.Errorf("expected only the last reload but returned %v", h)
+	}
+
+	h = pruneReloads(nil, now, time.Minute)
+	if len(h) != 0 {
+		t.Errorf("expected no reloads but returned %v", h)
+	}
+}
+
+func TestReloadLimiterCoalesce(t *testing.T) {
+	var count int32
+	done := make(chan struct{}, 10)
+	r := newReloadLimiter(50*time.Millisecond, time.Minute, 0, func() {
+		atomic.AddInt32(&count, 1)
+		done <- struct{}{}
+	})
+
+	for i := 0; i < 5; i++ {
+		r.Schedule()
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a reload")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if c := atomic.LoadInt32(&count); c != 1 {
+		t.Errorf("expected one reload but %v were executed", c)
+	}
+
+	r.Schedule()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a second reload")
+	}
+}
+
+func TestReloadLimiterStop(t *testing.T) {
+	var count int32
+	r := newReloadLimiter(50*time.Millisecond, time.Minute, 0, func() {
+		atomic.AddInt32(&count, 1)
+	})
+
+	r.Schedule()
+	r.Stop()
+
+	time.Sleep(150 * time.Millisecond)
+	if c := atomic.LoadInt32(&count); c != 0 {
+		t.Errorf("expected no reloads after Stop but %v were executed", c)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
+)
+
+const (
+	// templateTestCommand is the subcommand used to test a template without a cluster
+	templateTestCommand = "template-test"
+)
+
+// templateTest renders a template with the configuration of a fixture (a
+// config.TemplateConfig in JSON, like test/data/config.json) and tests the
+// result with nginx -t. The rendered configuration is written in out or in
+// the file defined in --output
+func templateTest(args []string, out io.Writer) error {
+	ngx := os.Getenv("NGINX_BINARY")
+	if ngx == "" {
+		ngx = binary
+	}
+
+	flags := pflag.NewFlagSet(templateTestCommand, pflag.ContinueOnError)
+	tmpl := flags.String("template", tmplPath, `Path of the template used to render the NGINX configuration file.`)
+	fixture := flags.String("fixture", "", `Path of the JSON file with the configuration used to render the template.`)
+	output := flags.String("output", "", `Path of the file where the rendered configuration is written.
+		The configuration is printed in the standard output by default.`)
+	flags.StringVar(&ngx, "nginx-binary", ngx, `Path of the NGINX binary used to test the configuration.`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *fixture == "" {
+		return fmt.Errorf("the flag --fixture is required")
+	}
+
+	data, err := ioutil.ReadFile(*fixture)
+	if err != nil {
+		return fmt.Errorf("unexpected error reading the fixture: %v", err)
+	}
+	var tc config.TemplateConfig
+	if err := json.Unmarshal(data, &tc); err != nil {
+		return fmt.Errorf("invalid fixture %v: %v", *fixture, err)
+	}
+
+	t, err := ngx_template.NewTemplate(*tmpl, func() {})
+	if err != nil {
+		return fmt.Errorf("invalid NGINX template: %v", err)
+	}
+	defer t.Close()
+
+	content, err := t.Write(tc)
+	if err != nil {
+		return fmt.Errorf("unexpected error rendering the template: %v", err)
+	}
+
+	// the configuration is written before the test to
+	// find the lines with errors in the output of nginx -t
+	if *output != "" {
+		err = ioutil.WriteFile(*output, content, 0644)
+	} else {
+		_, err = out.Write(content)
+	}
+	if err != nil {
+		return err
+	}
+
+	n := &NGINXController{binary: ngx}
+	return n.testTemplate(content)
+}
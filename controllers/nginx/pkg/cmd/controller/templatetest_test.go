@@ -0,0 +1,77 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	templateTestFile    = "../../../rootfs/etc/nginx/template/nginx.tmpl"
+	templateTestFixture = "../../../test/data/config.json"
+)
+
+func TestTemplateTest(t *testing.T) {
+	var out bytes.Buffer
+	err := templateTest([]string{"--template", templateTestFile, "--fixture", templateTestFixture, "--nginx-binary", "/bin/true"}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error testing the template: %v", err)
+	}
+	if !strings.Contains(out.String(), "http {") {
+		t.Errorf("expected the rendered configuration in the output")
+	}
+
+	// the configuration is written even if it is not valid
+	f, err := ioutil.TempFile("", "nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	out.Reset()
+	err = templateTest([]string{"--template", templateTestFile, "--fixture", templateTestFixture, "--nginx-binary", "/bin/false", "--output", f.Name()}, &out)
+	if err == nil {
+		t.Errorf("expected an error testing an invalid configuration")
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output with --output")
+	}
+	if b, _ := ioutil.ReadFile(f.Name()); !strings.Contains(string(b), "http {") {
+		t.Errorf("expected the rendered configuration in %v", f.Name())
+	}
+}
+
+func TestTemplateTestInvalidArguments(t *testing.T) {
+	tests := map[string][]string{
+		"without fixture":  {"--template", templateTestFile},
+		"missing fixture":  {"--template", templateTestFile, "--fixture", "/tmp/does-not-exist.json"},
+		"invalid fixture":  {"--template", templateTestFile, "--fixture", templateTestFile},
+		"missing template": {"--template", "/tmp/does-not-exist.tmpl", "--fixture", templateTestFixture},
+		"unknown flag":     {"--foo"},
+	}
+
+	for title, args := range tests {
+		if err := templateTest(args, ioutil.Discard); err == nil {
+			t.Errorf("%v: expected an error", title)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// pid file of the NGINX master process (pid directive in the template)
+	pidFile = "/run/nginx.pid"
+
+	// time to wait for the new NGINX master process after USR2
+	upgradeTimeout = 30 * time.Second
+
+	// interval used to check the state of the NGINX master process
+	processCheckInterval = 1 * time.Second
+)
+
+// Upgrade replaces the running NGINX master process with a new master
+// process started from the binary (hot swap of the executable):
+// USR2 starts the new master process using the current listening sockets,
+// and once it is running WINCH and QUIT stop gracefully the workers and
+// the old master process. The connections are not dropped.
+// The new master process is started from the same path of the binary.
+func (n *NGINXController) Upgrade() error {
+	if n.isShuttingDown() {
+		return fmt.Errorf("the controller is shutting down")
+	}
+
+	if !atomic.CompareAndSwapInt32(&n.upgrading, 0, 1) {
+		return fmt.Errorf("upgrade of the NGINX binary already in progress")
+	}
+	defer atomic.StoreInt32(&n.upgrading, 0)
+
+	oldPid, err := readPid(pidFile)
+	if err != nil {
+		return fmt.Errorf("unexpected error reading the pid of the NGINX master process: %v", err)
+	}
+
+	// the new binary must accept the running configuration
+	content, err := ioutil.ReadFile(cfgPath)
+	if err != nil {
+		return err
+	}
+	err = n.testTemplate(content)
+	if err != nil {
+		return fmt.Errorf("the NGINX binary does not accept the configuration: %v", err)
+	}
+
+	glog.Infof("upgrading the NGINX binary (master process %v)", oldPid)
+	err = syscall.Kill(oldPid, syscall.SIGUSR2)
+	if err != nil {
+		return err
+	}
+
+	newPid, err := waitForNewMaster(pidFile, oldPid, upgradeTimeout, processCheckInterval)
+	if err != nil {
+		// NGINX restores the pid file and the old master
+		// process continues running if the new one fails
+		return fmt.Errorf("the new NGINX master process did not start: %v", err)
+	}
+
+	// Start monitors the new master process when the old one exits
+	atomic.StoreInt32(&n.upgradePid, int32(newPid))
+
+	glog.Infof("new NGINX master process %v running. Stopping the old master process %v", newPid, oldPid)
+	err = syscall.Kill(oldPid, syscall.SIGWINCH)
+	if err != nil {
+		return err
+	}
+
+	return syscall.Kill(oldPid, syscall.SIGQUIT)
+}
+
+// readPid returns the pid contained in the file
+func readPid(file string) (int, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file %v: %v", file, err)
+	}
+
+	return pid, nil
+}
+
+// isProcessRunning returns true if the process exists
+func isProcessRunning(pid int) bool {
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// waitForNewMaster waits until the pid file contains the pid of a
+// running process different than oldPid or the timeout expires
+func waitForNewMaster(file string, oldPid int, timeout, interval time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		pid, err := readPid(file)
+		if err == nil && pid != oldPid && isProcessRunning(pid) {
+			return pid, nil
+		}
+
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("timeout (%v) waiting for the new pid in %v", timeout, file)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// waitForProcess sends nil to done when the process exits. Used with
+// processes that are not children of the controller, like the master
+// process started by an upgrade of the binary
+func waitForProcess(pid int, interval time.Duration, done chan error) {
+	for isProcessRunning(pid) {
+		time.Sleep(interval)
+	}
+
+	done <- nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadPid(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.pid")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	ioutil.WriteFile(f.Name(), []byte("1234\n"), 0644)
+	pid, err := readPid(f.Name())
+	if err != nil || pid != 1234 {
+		t.Errorf("expected the pid 1234 but returned %v (%v)", pid, err)
+	}
+
+	ioutil.WriteFile(f.Name(), []byte("invalid"), 0644)
+	if _, err := readPid(f.Name()); err == nil {
+		t.Errorf("expected an error reading an invalid pid file")
+	}
+
+	if _, err := readPid("/invalid/nginx.pid"); err == nil {
+		t.Errorf("expected an error reading a missing pid file")
+	}
+}
+
+func TestWaitForNewMaster(t *testing.T) {
+	f, err := ioutil.TempFile("", "nginx.pid")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	current := os.Getpid()
+	ioutil.WriteFile(f.Name(), []byte(fmt.Sprintf("%v\n", current)), 0644)
+
+	if _, err := waitForNewMaster(f.Name(), current, 50*time.Millisecond, 10*time.Millisecond); err == nil {
+		t.Errorf("expected an error without a new master process")
+	}
+
+	pid, err := waitForNewMaster(f.Name(), current+1, time.Second, 10*time.Millisecond)
+	if err != nil || pid != current {
+		t.Errorf("expected the pid %v but returned %v (%v)", current, pid, err)
+	}
+}
+
+// startProcess starts a process that runs for the duration
+func startProcess(t *testing.T, d time.Duration) int {
+	cmd := exec.Command("sleep", fmt.Sprintf("%v", d.Seconds()))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error starting process: %v", err)
+	}
+	go cmd.Wait()
+	return cmd.Process.Pid
+}
+
+func TestWaitForProcess(t *testing.T) {
+	pid := startProcess(t, 100*time.Millisecond)
+	if !isProcessRunning(pid) {
+		t.Errorf("expected the process %v running", pid)
+	}
+
+	done := make(chan error, 1)
+	go waitForProcess(pid, 10*time.Millisecond, done)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the end of the process %v", pid)
+	}
+
+	if isProcessRunning(pid) {
+		t.Errorf("unexpected process %v running", pid)
+	}
+}
+
+func TestStartAfterUpgrade(t *testing.T) {
+	pid := startProcess(t, 500*time.Millisecond)
+	n := &NGINXController{
+		binary:     "true",
+		stopped:    make(chan struct{}),
+		upgradePid: int32(pid),
+	}
+
+	// the master process exits and the new one is monitored
+	go n.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&n.upgradePid) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the new master process to be monitored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	atomic.StoreInt32(&n.shuttingDown, 1)
+
+	if waitForStop(n.stopped, 100*time.Millisecond) && isProcessRunning(pid) {
+		t.Errorf("unexpected stop while the new master process is running")
+	}
+	if !waitForStop(n.stopped, 5*time.Second) {
+		t.Errorf("expected the controller to report the process stopped")
+	}
+	if n.restarts != 0 {
+		t.Errorf("unexpected restart of the master process after the upgrade")
+	}
+}
+
+func TestUpgradeInProgress(t *testing.T) {
+	n := &NGINXController{upgrading: 1}
+	if err := n.Upgrade(); err == nil {
+		t.Errorf("expected an error with an upgrade in progress")
+	}
+
+	n = &NGINXController{shuttingDown: 1}
+	if err := n.Upgrade(); err == nil {
+		t.Errorf("expected an error during the shutdown")
+	}
+}
+
+func TestUpgradeSignalNotForwarded(t *testing.T) {
+	ngx := startFakeNginx(t)
+	defer ngx.stop()
+
+	// the SIGUSR2 of dumb-init to the process group of the controller
+	// must not reach NGINX, otherwise NGINX starts a new master process
+	// before the controller checks the new binary and sends its own USR2
+	ngx.signalController(t, syscall.SIGUSR2)
+
+	// the USR2 sent by Upgrade to the NGINX master process
+	if err := syscall.Kill(ngx.pid, syscall.SIGUSR2); err != nil {
+		t.Fatalf("unexpected error sending USR2 to NGINX: %v", err)
+	}
+
+	// each USR2 received by the NGINX master process starts a new one
+	received := 0
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case line := <-ngx.lines:
+			if line == "USR2" {
+				received++
+			}
+		case <-timeout:
+			done = true
+		}
+	}
+	if received != 1 {
+		t.Errorf("expected exactly one USR2 in NGINX (one new master process) but received %v", received)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+
+	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+// preserveUpstreams returns a copy of the backends sorted by name where each
+// backend without changes since the last reload is replaced by the rendered
+// one. This keeps the order of the endpoints (shuffled in each sync when the
+// backends are not sorted) and produces identical upstream blocks, so NGINX
+// does not reset the state of the balancer of those upstreams in a reload.
+// NGINX (without the commercial API module) cannot persist the state of the
+// upstreams across reloads.
+func preserveUpstreams(rendered map[string]*ingress.Backend, backends []*ingress.Backend) []*ingress.Backend {
+	res := make([]*ingress.Backend, 0, len(backends))
+	for _, backend := range backends {
+		prev, ok := rendered[backend.Name]
+		if ok && prev.Equal(backend) {
+			glog.V(5).Infof("upstream %v did not change, preserving the rendered configuration", backend.Name)
+			res = append(res, prev)
+			continue
+		}
+
+		res = append(res, backend)
+	}
+
+	sort.Sort(ingress.BackendByNameServers(res))
+
+	return res
+}
+
+// upstreamsByName returns a map of the backends indexed by name
+func upstreamsByName(backends []*ingress.Backend) map[string]*ingress.Backend {
+	res := make(map[string]*ingress.Backend, len(backends))
+	for _, backend := range backends {
+		res[backend.Name] = backend
+	}
+
+	return res
+}
+
+// withoutEndpoints returns a copy of the backends without endpoints
+func withoutEndpoints(backends []*ingress.Backend) []*ingress.Backend {
+	res := make([]*ingress.Backend, 0, len(backends))
+	for _, backend := range backends {
+		b := *backend
+		b.Endpoints = nil
+		res = append(res, &b)
+	}
+
+	return res
+}
+
+// checkBackupEndpoints returns an error if the endpoints of the backend
+// marked as backup cannot be used by NGINX: an upstream requires at least
+// one primary server and the hash balancing methods do not support backups
+func checkBackupEndpoints(backend *ingress.Backend, algorithm string) error {
+	algorithm = ngx_template.UpstreamAlgorithm(backend, algorithm)
+
+	backups := 0
+	for _, endpoint := range backend.Endpoints {
+		if endpoint.Backup {
+			backups++
+		}
+	}
+
+	if backups == 0 {
+		return nil
+	}
+
+	if backups == len(backend.Endpoints) {
+		return fmt.Errorf("all the endpoints of the upstream %v are marked as backup", backend.Name)
+	}
+
+	if algorithm == "ip_hash" || strings.HasPrefix(algorithm, "hash ") {
+		return fmt.Errorf("the load balance algorithm %v does not support backup endpoints (upstream %v)", algorithm, backend.Name)
+	}
+
+	return nil
+}
+
+// validateBackupEndpoints returns the backends where the invalid
+// backends are replaced by a copy without backup endpoints
+func validateBackupEndpoints(backends []*ingress.Backend, algorithm string) []*ingress.Backend {
+	res := make([]*ingress.Backend, 0, len(backends))
+	for _, backend := range backends {
+		err := checkBackupEndpoints(backend, algorithm)
+		if err == nil {
+			res = append(res, backend)
+			continue
+		}
+
+		glog.Errorf("%v. Using all the endpoints as primary", err)
+
+		b := *backend
+		b.Endpoints = make([]ingress.Endpoint, len(backend.Endpoints))
+		for i, endpoint := range backend.Endpoints {
+			endpoint.Backup = false
+			b.Endpoints[i] = endpoint
+		}
+		res = append(res, &b)
+	}
+
+	return res
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress/controllers/nginx/pkg/config"
+	ngx_template "k8s.io/ingress/controllers/nginx/pkg/template"
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+func newBackends(fooEndpoints ...ingress.Endpoint) []*ingress.Backend {
+	return []*ingress.Backend{
+		{
+			Name:      "default-foo-80",
+			Endpoints: fooEndpoints,
+		},
+		{
+			Name: "default-bar-80",
+			Endpoints: []ingress.Endpoint{
+				{Address: "10.0.0.10", Port: "8080"},
+			},
+		},
+	}
+}
+
+// upstreamSection extracts the content of the upstream block with the
+// specified name from a NGINX configuration
+func upstreamSection(t *testing.T, cfg, name string) string {
+	start := strings.Index(cfg, "upstream "+name+" {")
+	if start == -1 {
+		t.Fatalf("upstream %v not found in the configuration", name)
+	}
+
+	end := strings.Index(cfg[start:], "}")
+	return cfg[start : start+end+1]
+}
+
+func TestPreserveUpstreams(t *testing.T) {
+	e1 := ingress.Endpoint{Address: "10.0.0.1", Port: "8080"}
+	e2 := ingress.Endpoint{Address: "10.0.0.2", Port: "8080"}
+	e3 := ingress.Endpoint{Address: "10.0.0.3", Port: "8080"}
+
+	rendered := preserveUpstreams(nil, newBackends(e1, e2, e3))
+	if rendered[0].Name != "default-bar-80" || rendered[1].Name != "default-foo-80" {
+		t.Errorf("expected upstreams sorted by name but returned %v and %v", rendered[0].Name, rendered[1].Name)
+	}
+
+	// same endpoints in a different order
+	backends := preserveUpstreams(upstreamsByName(rendered), newBackends(e3, e1, e2))
+
+	pwd, _ := os.Getwd()
+	ngxTpl, err := ngx_template.NewTemplate(path.Join(pwd, "../../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	defer ngxTpl.Close()
+
+	render := func(backends []*ingress.Backend) string {
+		b, err := ngxTpl.Write(config.TemplateConfig{
+			Backends: backends,
+			Cfg:      config.NewDefault(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error rendering the template: %v", err)
+		}
+		return string(b)
+	}
+
+	before := render(rendered)
+	after := render(backends)
+	for _, name := range []string{"default-foo-80", "default-bar-80"} {
+		if upstreamSection(t, before, name) != upstreamSection(t, after, name) {
+			t.Errorf("expected identical sections for upstream %v\n%v\n%v",
+				name, upstreamSection(t, before, name), upstreamSection(t, after, name))
+		}
+	}
+
+	// a new endpoint in the upstream must be used
+	backends = preserveUpstreams(upstreamsByName(rendered), newBackends(e1, e2))
+	if len(backends[1].Endpoints) != 2 {
+		t.Errorf("expected 2 endpoints but returned %v", len(backends[1].Endpoints))
+	}
+	if backends[0] != rendered[0] {
+		t.Errorf("expected the rendered upstream default-bar-80 to be preserved")
+	}
+}
+
+func TestValidateBackupEndpoints(t *testing.T) {
+	primary := ingress.Endpoint{Address: "10.0.0.1", Port: "8080"}
+	backup := ingress.Endpoint{Address: "10.0.0.2", Port: "8080", Backup: true}
+
+	valid := &ingress.Backend{Name: "default-foo-80", Endpoints: []ingress.Endpoint{primary, backup}}
+	if err := checkBackupEndpoints(valid, "least_conn"); err != nil {
+		t.Errorf("unexpected error with primary and backup endpoints: %v", err)
+	}
+	if err := checkBackupEndpoints(valid, "ip_hash"); err == nil {
+		t.Errorf("expected an error using backup endpoints with ip_hash")
+	}
+
+	hashBy := &ingress.Backend{Name: "default-foo-80", Endpoints: []ingress.Endpoint{primary, backup}, UpstreamHashBy: "$request_uri"}
+	if err := checkBackupEndpoints(hashBy, "least_conn"); err == nil {
+		t.Errorf("expected an error using backup endpoints with upstream-hash-by")
+	}
+	ipHash := &ingress.Backend{Name: "default-foo-80", Endpoints: []ingress.Endpoint{primary, backup}, LoadBalance: "ip_hash"}
+	if err := checkBackupEndpoints(ipHash, "least_conn"); err == nil {
+		t.Errorf("expected an error using backup endpoints with the load-balance annotation ip_hash")
+	}
+
+	allBackup := &ingress.Backend{Name: "default-bar-80", Endpoints: []ingress.Endpoint{backup}}
+	if err := checkBackupEndpoints(allBackup, "least_conn"); err == nil {
+		t.Errorf("expected an error with all the endpoints marked as backup")
+	}
+
+	backends := validateBackupEndpoints([]*ingress.Backend{valid, allBackup}, "least_conn")
+	if backends[0] != valid {
+		t.Errorf("expected the valid backend without changes")
+	}
+	if backends[1].Endpoints[0].Backup {
+		t.Errorf("expected the endpoints of the invalid backend used as primary")
+	}
+	if !allBackup.Endpoints[0].Backup {
+		t.Errorf("unexpected change in the original backend")
+	}
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"k8s.io/kubernetes/pkg/util/sysctl"
@@ -53,6 +57,106 @@ func sysctlFSFileMax() int {
 	return int(rLimit.Max)
 }
 
+// isHTTP3Supported checks if the NGINX binary was built
+// with the module that provides HTTP/3 (QUIC)
+func isHTTP3Supported(binary string) bool {
+	// nginx -V prints the configure arguments to stderr
+	out, err := exec.Command(binary, "-V").CombinedOutput()
+	if err != nil {
+		glog.Warningf("unexpected error reading the NGINX build information: %v", err)
+		return false
+	}
+
+	return strings.Contains(string(out), "--with-http_v3_module")
+}
+
+// opensslVersionRegex matches the version of OpenSSL in the NGINX build
+// information, like "built with OpenSSL 1.1.1d  10 Sep 2019"
+var opensslVersionRegex = regexp.MustCompile(`built with OpenSSL (\d+)\.(\d+)\.(\d+)`)
+
+// isTLSv13Supported checks if the NGINX binary was built with
+// a SSL library that provides TLSv1.3
+func isTLSv13Supported(binary string) bool {
+	out, err := exec.Command(binary, "-V").CombinedOutput()
+	if err != nil {
+		glog.Warningf("unexpected error reading the NGINX build information: %v", err)
+		return false
+	}
+
+	return hasTLSv13Library(string(out))
+}
+
+// hasTLSv13Library checks the SSL library of the NGINX build information
+// is BoringSSL or OpenSSL 1.1.1 (the first release with TLSv1.3) or newer
+func hasTLSv13Library(buildInfo string) bool {
+	if strings.Contains(buildInfo, "built with BoringSSL") {
+		return true
+	}
+
+	match := opensslVersionRegex.FindStringSubmatch(buildInfo)
+	if match == nil {
+		return false
+	}
+
+	minimum := []int{1, 1, 1}
+	for i, v := range match[1:] {
+		n, _ := strconv.Atoi(v)
+		if n != minimum[i] {
+			return n > minimum[i]
+		}
+	}
+
+	return true
+}
+
+// isNjsSupported checks if the NGINX binary was built
+// with the njs module (ngx_http_js_module)
+func isNjsSupported(binary string) bool {
+	return hasModule(binary, "njs")
+}
+
+// isAJPSupported checks if the NGINX binary was built
+// with the AJP module (nginx_ajp_module)
+func isAJPSupported(binary string) bool {
+	return hasModule(binary, "ajp")
+}
+
+// hasModule checks if the NGINX binary was built with a
+// third party module whose path contains name
+func hasModule(binary, name string) bool {
+	out, err := exec.Command(binary, "-V").CombinedOutput()
+	if err != nil {
+		glog.Warningf("unexpected error reading the NGINX build information: %v", err)
+		return false
+	}
+
+	for _, arg := range strings.Fields(string(out)) {
+		if strings.HasPrefix(arg, "--add-module=") && strings.Contains(arg, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createTempPath creates the directory used by NGINX to store temporary
+// files if it does not exist. NGINX only creates the last level of the path
+func createTempPath(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err == nil {
+		if !fi.IsDir() {
+			return fmt.Errorf("%v is not a directory", path)
+		}
+		return nil
+	}
+
+	return os.MkdirAll(path, 0755)
+}
+
 func diff(b1, b2 []byte) ([]byte, error) {
 	f1, err := ioutil.TempFile("", "a")
 	if err != nil {
@@ -16,7 +16,12 @@ limitations under the License.
 
 package main
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestDiff(t *testing.T) {
 	tests := []struct {
@@ -39,3 +44,75 @@ func TestDiff(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateTempPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := createTempPath(""); err != nil {
+		t.Errorf("unexpected error without a path: %v", err)
+	}
+
+	path := filepath.Join(dir, "cache", "client-body")
+	for i := 0; i < 2; i++ {
+		if err := createTempPath(path); err != nil {
+			t.Fatalf("unexpected error creating %v: %v", path, err)
+		}
+		if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+			t.Errorf("expected the directory %v to exist", path)
+		}
+	}
+
+	file := filepath.Join(dir, "file")
+	ioutil.WriteFile(file, []byte{}, 0644)
+	if err := createTempPath(file); err == nil {
+		t.Errorf("expected an error using a file as temporal directory")
+	}
+}
+
+func TestHasTLSv13Library(t *testing.T) {
+	for buildInfo, expected := range map[string]bool{
+		"built with OpenSSL 1.0.2g  1 Mar 2016":           false,
+		"built with OpenSSL 1.1.0f  25 May 2017":          false,
+		"built with OpenSSL 1.1.1  11 Sep 2018":           true,
+		"built with OpenSSL 1.1.1d  10 Sep 2019":          true,
+		"built with OpenSSL 3.0.2 15 Mar 2022":            true,
+		"built with BoringSSL":                            true,
+		"built with LibreSSL 2.5.4":                       false,
+		"nginx version: nginx/1.13.3\nbuilt by gcc 6.3.0": false,
+	} {
+		if hasTLSv13Library(buildInfo) != expected {
+			t.Errorf("expected %v for '%v'", expected, buildInfo)
+		}
+	}
+}
+
+func TestIsAJPSupported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nginx")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "nginx")
+	for buildInfo, expected := range map[string]bool{
+		"--with-http_ssl_module --add-module=/tmp/build/nginx_ajp_module-0.3.0": true,
+		"--with-http_ssl_module --add-module=/tmp/build/njs-0.2.3/nginx":        false,
+		"--with-http_ssl_module": false,
+	} {
+		script := "#!/bin/sh\necho 'configure arguments: " + buildInfo + "' >&2\n"
+		if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+			t.Fatalf("unexpected error writing the fake NGINX binary: %v", err)
+		}
+		if isAJPSupported(binary) != expected {
+			t.Errorf("expected %v for '%v'", expected, buildInfo)
+		}
+	}
+
+	if isAJPSupported(filepath.Join(dir, "missing")) {
+		t.Errorf("expected no AJP module without a NGINX binary")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// title of the worker processes after a reload, while
+	// the active connections finish
+	workerShuttingDownTitle = "nginx: worker process is shutting down"
+	workerTitle             = "nginx: worker process"
+
+	// interval used to check the state of the worker processes
+	workerCheckInterval = 10 * time.Second
+)
+
+// nginxWorker is a worker process of the NGINX master process
+type nginxWorker struct {
+	pid          int
+	shuttingDown bool
+}
+
+// listWorkers returns the worker processes of the master
+// process using the information of the processes in procDir
+func listWorkers(procDir string, master int) ([]nginxWorker, error) {
+	dirs, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := []nginxWorker{}
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+
+		stat, err := ioutil.ReadFile(filepath.Join(procDir, dir.Name(), "stat"))
+		if err != nil {
+			// the process finished
+			continue
+		}
+		ppid, err := parentPid(string(stat))
+		if err != nil || ppid != master {
+			continue
+		}
+
+		cmdline, err := ioutil.ReadFile(filepath.Join(procDir, dir.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		title := strings.TrimRight(strings.Replace(string(cmdline), "\x00", " ", -1), " ")
+		if !strings.HasPrefix(title, workerTitle) {
+			continue
+		}
+
+		workers = append(workers, nginxWorker{
+			pid:          pid,
+			shuttingDown: title == workerShuttingDownTitle,
+		})
+	}
+
+	return workers, nil
+}
+
+// parentPid returns the parent process of the content of /proc/<pid>/stat.
+// The name of the command (second field) is enclosed in parentheses
+// and can contain spaces
+func parentPid(stat string) (int, error) {
+	i := strings.LastIndex(stat, ")")
+	if i == -1 {
+		return 0, fmt.Errorf("invalid process stat: %v", stat)
+	}
+
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid process stat: %v", stat)
+	}
+
+	return strconv.Atoi(fields[1])
+}
+
+// workerTracker follows the worker processes of a NGINX master process
+// and the generation of each worker (number of reloads when the worker
+// was found)
+type workerTracker struct {
+	master  int
+	workers map[int]int32
+}
+
+func newWorkerTracker() *workerTracker {
+	return &workerTracker{
+		workers: map[int]int32{},
+	}
+}
+
+// update refreshes the state of the workers of the master process.
+// Returns the number of workers shutting down by generation
+func (t *workerTracker) update(master int, workers []nginxWorker, generation int32) map[int32]int {
+	if master != t.master {
+		// new master process (restart or upgrade of the binary)
+		t.master = master
+		t.workers = map[int]int32{}
+	}
+
+	current := map[int]int32{}
+	shuttingDown := map[int32]int{}
+	for _, worker := range workers {
+		gen, ok := t.workers[worker.pid]
+		if !ok {
+			gen = generation
+		}
+		current[worker.pid] = gen
+
+		if worker.shuttingDown {
+			shuttingDown[gen]++
+		}
+	}
+	t.workers = current
+
+	return shuttingDown
+}
+
+// monitorWorkers checks periodically the worker processes of NGINX,
+// updating the number of workers shutting down by generation. The
+// workers are stopped by NGINX after worker-shutdown-timeout
+func (n *NGINXController) monitorWorkers() {
+	tracker := newWorkerTracker()
+	for !n.isShuttingDown() {
+		time.Sleep(workerCheckInterval)
+
+		master, err := readPid(pidFile)
+		if err != nil {
+			glog.V(3).Infof("unexpected error reading the pid of the NGINX master process: %v", err)
+			continue
+		}
+
+		workers, err := listWorkers("/proc", master)
+		if err != nil {
+			glog.Warningf("unexpected error reading the NGINX worker processes: %v", err)
+			continue
+		}
+
+		shuttingDown := tracker.update(master, workers, atomic.LoadInt32(&n.generation))
+		nginxShuttingDownWorkers.Reset()
+		for generation, count := range shuttingDown {
+			nginxShuttingDownWorkers.WithLabelValues(fmt.Sprintf("%v", generation)).Set(float64(count))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeProcess(t *testing.T, dir, pid, stat, cmdline string) {
+	p := filepath.Join(dir, pid)
+	if err := os.MkdirAll(p, 0755); err != nil {
+		t.Fatalf("unexpected error creating directory: %v", err)
+	}
+	ioutil.WriteFile(filepath.Join(p, "stat"), []byte(stat), 0644)
+	ioutil.WriteFile(filepath.Join(p, "cmdline"), []byte(cmdline), 0644)
+}
+
+func TestListWorkers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeProcess(t, dir, "10", "10 (nginx) S 1 10 10 0 -1", "nginx: master process /usr/sbin/nginx -c /etc/nginx/nginx.conf\x00")
+	writeProcess(t, dir, "11", "11 (nginx) S 10 10 10 0 -1", "nginx: worker process\x00\x00\x00")
+	writeProcess(t, dir, "12", "12 (nginx) S 10 10 10 0 -1", "nginx: worker process is shutting down\x00")
+	writeProcess(t, dir, "13", "13 (nginx) S 10 10 10 0 -1", "nginx: cache manager process\x00")
+	writeProcess(t, dir, "14", "14 (nginx) S 20 20 20 0 -1", "nginx: worker process\x00")
+	writeProcess(t, dir, "15", "15 (my (odd) cmd) S 10 10 10 0 -1", "nginx: worker process\x00")
+	os.MkdirAll(filepath.Join(dir, "self"), 0755)
+
+	workers, err := listWorkers(dir, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []nginxWorker{{11, false}, {12, true}, {15, false}}
+	if !reflect.DeepEqual(workers, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, workers)
+	}
+}
+
+func TestWorkerTracker(t *testing.T) {
+	tracker := newWorkerTracker()
+
+	shuttingDown := tracker.update(10, []nginxWorker{{11, false}}, 0)
+	if len(shuttingDown) != 0 {
+		t.Errorf("unexpected shutting down workers: %v", shuttingDown)
+	}
+
+	// reload: the worker 11 starts the shut down
+	shuttingDown = tracker.update(10, []nginxWorker{{11, true}, {12, false}}, 1)
+	if !reflect.DeepEqual(shuttingDown, map[int32]int{0: 1}) {
+		t.Errorf("expected one worker of the generation 0 shutting down but returned %v", shuttingDown)
+	}
+	if tracker.workers[11] != 0 || tracker.workers[12] != 1 {
+		t.Errorf("unexpected generation of the workers: %v", tracker.workers)
+	}
+
+	// reload: the worker 12 starts the shut down
+	shuttingDown = tracker.update(10, []nginxWorker{{11, true}, {12, true}, {13, false}}, 2)
+	if !reflect.DeepEqual(shuttingDown, map[int32]int{0: 1, 1: 1}) {
+		t.Errorf("expected one worker of the generations 0 and 1 shutting down but returned %v", shuttingDown)
+	}
+
+	// the workers of a new master process are tracked from scratch
+	shuttingDown = tracker.update(20, []nginxWorker{{21, true}}, 3)
+	if !reflect.DeepEqual(shuttingDown, map[int32]int{3: 1}) || len(tracker.workers) != 1 {
+		t.Errorf("unexpected state after a change of the master process: %v %v", shuttingDown, tracker.workers)
+	}
+}
+
+func TestParentPid(t *testing.T) {
+	ppid, err := parentPid("42 (nginx: worker) S 7 42 42 0 -1")
+	if err != nil || ppid != 7 {
+		t.Errorf("expected the parent 7 but returned %v (%v)", ppid, err)
+	}
+
+	if _, err := parentPid("invalid"); err == nil {
+		t.Errorf("expected an error with an invalid stat")
+	}
+}
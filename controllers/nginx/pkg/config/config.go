@@ -18,6 +18,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"runtime"
 	"strconv"
 
@@ -45,7 +46,10 @@ const (
 
 	gzipTypes = "application/atom+xml application/javascript application/x-javascript application/json application/rss+xml application/vnd.ms-fontobject application/x-font-ttf application/x-web-app-manifest+json application/xhtml+xml application/xml font/opentype image/svg+xml image/x-icon text/css text/plain text/x-component"
 
-	logFormatUpstream = `%v - [$the_real_ip] - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" $request_length $request_time [$proxy_upstream_name] $upstream_addr $upstream_response_length $upstream_response_time $upstream_status`
+	logFormatUpstream = `%v - [$the_real_ip] - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" $request_length $request_time [$proxy_upstream_name] $upstream_addr $upstream_response_length $upstream_response_time $upstream_status $req_id`
+
+	// default log_format upstream when log-format-escape-json is enabled
+	logFormatUpstreamJSON = `{ "time": "$time_iso8601", "remote_addr": "$the_real_ip", "remote_user": "$remote_user", "request_id": "$req_id", "host": "$host", "request": "$request", "method": "$request_method", "path": "$uri", "request_query": "$args", "request_proto": "$server_protocol", "status": $status, "body_bytes_sent": $body_bytes_sent, "http_referer": "$http_referer", "http_user_agent": "$http_user_agent", "request_length": $request_length, "request_time": $request_time, "proxy_upstream_name": "$proxy_upstream_name", "upstream_addr": "$upstream_addr", "upstream_response_length": "$upstream_response_length", "upstream_response_time": "$upstream_response_time", "upstream_status": "$upstream_status" }`
 
 	logFormatStream = `[$time_local] $protocol $status $bytes_sent $bytes_received $session_time`
 
@@ -77,12 +81,86 @@ const (
 	// Parameters for a shared memory zone that will keep states for various keys.
 	// http://nginx.org/en/docs/http/ngx_http_limit_conn_module.html#limit_conn_zone
 	defaultLimitConnZoneVariable = "$binary_remote_addr"
+
+	// Port of the internal server with the health check, status and debug endpoints
+	defaultAdminPort = 10246
+
+	// Requests to unknown hostnames are sent to the default backend
+	defaultServerAction = "default-backend"
+
+	// Size of the shared memory zones used by the global limits
+	defaultGlobalLimitZoneSize = "10m"
+
+	// Status code returned to requests rejected by the global limits
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html#limit_req_status
+	defaultGlobalLimitStatusCode = 503
+
+	// Port of memcached used by the global rate limits
+	defaultGlobalRateLimitMemcachedPort = 11211
+
+	// Status code returned to requests rejected by the global rate limits
+	defaultGlobalRateLimitStatusCode = 429
+
+	// Timeouts of the TCP and UDP services (same values as the NGINX defaults)
+	// http://nginx.org/en/docs/stream/ngx_stream_proxy_module.html#proxy_timeout
+	defaultProxyStreamTimeout        = "600s"
+	defaultProxyStreamConnectTimeout = "60s"
+
+	// Directory of the cache zones
+	defaultProxyCachePath = "/tmp/nginx-cache"
+
+	// Default port of the syslog server
+	// http://nginx.org/en/docs/syslog.html
+	defaultSyslogPort = 514
+
+	// Tracers supported by the opentracing module
+	// https://github.com/opentracing-contrib/nginx-opentracing
+	ZipkinTracer = "zipkin"
+	JaegerTracer = "jaeger"
+
+	defaultOpentracingServiceName = "nginx"
 )
 
+// opentracingTracerLibraries contains the plugins (shared libraries) of the tracers
+var opentracingTracerLibraries = map[string]string{
+	ZipkinTracer: "/usr/local/lib/libzipkin_opentracing_plugin.so",
+	JaegerTracer: "/usr/local/lib/libjaegertracing_plugin.so",
+}
+
+// default port of the collector (zipkin) or agent (jaeger) of each tracer
+var opentracingCollectorPorts = map[string]int{
+	ZipkinTracer: 9411,
+	JaegerTracer: 6831,
+}
+
 // Configuration represents the content of nginx.conf file
 type Configuration struct {
 	defaults.Backend `json:",squash"`
 
+	// ACMEChallengeService defines the service (namespace/name:port) used to
+	// solve ACME HTTP-01 challenges. When set, the path /.well-known/acme-challenge/
+	// of every server without TLS is routed to this service.
+	// https://tools.ietf.org/html/draft-ietf-acme-acme-07#section-8.3
+	ACMEChallengeService string `json:"acme-challenge-service,omitempty"`
+
+	// ACMEChallengeOverride routes the ACME challenge path of every server,
+	// including the servers with TLS, to ACMEChallengeService, ignoring the
+	// Ingress rules, redirects and access lists defined for the path
+	// By default this is disabled
+	ACMEChallengeOverride bool `json:"acme-challenge-override,omitempty"`
+
+	// AdminPort defines the port of the internal server bound to localhost
+	// that exposes the NGINX health check, status and debug endpoints.
+	// This server is only reachable from the pod
+	AdminPort int `json:"admin-port,omitempty"`
+
+	// AllowSnippetAnnotations enables the configuration-snippet annotation,
+	// rendered verbatim in the locations of the Ingress rules. Disabling it
+	// prevents the users that create Ingress rules from injecting arbitrary
+	// directives in the configuration
+	// Default: true
+	AllowSnippetAnnotations bool `json:"allow-snippet-annotations"`
+
 	// Sets the name of the configmap that contains the headers to pass to the client
 	AddHeaders string `json:"add-headers,omitempty"`
 
@@ -102,9 +180,25 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_header_buffer_size
 	ClientHeaderBufferSize string `json:"client-header-buffer-size"`
 
-	// Sets buffer size for reading client request body
-	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_body_buffer_size
-	ClientBodyBufferSize string `json:"client-body-buffer-size,omitempty"`
+	// Determines whether NGINX should save the entire client request body
+	// into a file (on, clean or off). With clean the files are removed
+	// after the request is processed
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_body_in_file_only
+	ClientBodyInFileOnly string `json:"client-body-in-file-only,omitempty"`
+
+	// Defines a directory for storing temporary files holding client request
+	// bodies. If the directory does not exist it is created by the controller.
+	// By default the directory compiled in the NGINX binary is used
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_body_temp_path
+	ClientBodyTempPath string `json:"client-body-temp-path,omitempty"`
+
+	// DefaultServerAction defines how the default server handles requests to
+	// hostnames not matching any Ingress rule (without a default backend
+	// defined in an Ingress rule without host).
+	// Valid values: default-backend (proxy to the default backend), 404 or 444
+	// (close the connection without a response)
+	// By default the request is sent to the default backend
+	DefaultServerAction string `json:"default-server-action,omitempty"`
 
 	// DisableAccessLog disables the Access Log globally from NGINX ingress controller
 	//http://nginx.org/en/docs/http/ngx_http_log_module.html
@@ -130,6 +224,46 @@ type Configuration struct {
 
 	VtsStatusZoneSize string `json:"vts-status-zone-size,omitempty"`
 
+	// EnableSyslog sends the access and error logs to the syslog server
+	// defined in SyslogHost and SyslogPort instead of the log files
+	// http://nginx.org/en/docs/syslog.html
+	// By default this is disabled
+	EnableSyslog bool `json:"enable-syslog,omitempty"`
+
+	// SyslogHost is the address or hostname of the syslog server
+	SyslogHost string `json:"syslog-host,omitempty"`
+
+	// SyslogPort is the UDP port of the syslog server
+	// By default this is 514
+	SyslogPort int `json:"syslog-port,omitempty"`
+
+	// EnableOpentracing enables the tracing of the requests using the
+	// opentracing module. The spans contain the Ingress rule and the
+	// service of the location as tags
+	// https://github.com/opentracing-contrib/nginx-opentracing
+	// By default this is disabled
+	EnableOpentracing bool `json:"enable-opentracing,omitempty"`
+
+	// OpentracingTracer is the tracer used to send the spans (zipkin or jaeger)
+	// By default zipkin
+	OpentracingTracer string `json:"opentracing-tracer,omitempty"`
+
+	// OpentracingCollectorHost is the host of the zipkin collector
+	// or the jaeger agent that receives the spans
+	OpentracingCollectorHost string `json:"opentracing-collector-host,omitempty"`
+
+	// OpentracingCollectorPort is the port of the collector. By default
+	// the port of the tracer (9411 for zipkin and 6831 for jaeger)
+	OpentracingCollectorPort int `json:"opentracing-collector-port,omitempty"`
+
+	// OpentracingServiceName is the name of the service in the spans
+	// By default nginx
+	OpentracingServiceName string `json:"opentracing-service-name,omitempty"`
+
+	// OpentracingSampleRate is the fraction of the requests traced (between 0 and 1)
+	// By default all the requests are traced
+	OpentracingSampleRate float64 `json:"opentracing-sample-rate,omitempty"`
+
 	// RetryNonIdempotent since 1.9.13 NGINX will not retry non-idempotent requests (POST, LOCK, PATCH)
 	// in case of an error. The previous behavior can be restored using the value true
 	RetryNonIdempotent bool `json:"retry-non-idempotent"`
@@ -147,23 +281,6 @@ type Configuration struct {
 	// HTTP2MaxHeaderSize Limits the maximum size of the entire request header list after HPACK decompression
 	HTTP2MaxHeaderSize string `json:"http2-max-header-size,omitempty"`
 
-	// Enables or disables the header HSTS in servers running SSL
-	HSTS bool `json:"hsts,omitempty"`
-
-	// Enables or disables the use of HSTS in all the subdomains of the servername
-	// Default: true
-	HSTSIncludeSubdomains bool `json:"hsts-include-subdomains,omitempty"`
-
-	// HTTP Strict Transport Security (often abbreviated as HSTS) is a security feature (HTTP header)
-	// that tell browsers that it should only be communicated with using HTTPS, instead of using HTTP.
-	// https://developer.mozilla.org/en-US/docs/Web/Security/HTTP_strict_transport_security
-	// max-age is the time, in seconds, that the browser should remember that this site is only to be
-	// accessed using HTTPS.
-	HSTSMaxAge string `json:"hsts-max-age,omitempty"`
-
-	// Enables or disables the preload attribute in HSTS feature
-	HSTSPreload bool `json:"hsts-preload,omitempty"`
-
 	// Time during which a keep-alive client connection will stay open on the server side.
 	// The zero value disables keep-alive client connections
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#keepalive_timeout
@@ -180,6 +297,8 @@ type Configuration struct {
 	LargeClientHeaderBuffers string `json:"large-client-header-buffers"`
 
 	// Enable json escaping
+	// If the log-format-upstream is not customized the access
+	// log uses a default format in JSON
 	// http://nginx.org/en/docs/http/ngx_http_log_module.html#log_format
 	LogFormatEscapeJSON bool `json:"log-format-escape-json,omitempty"`
 
@@ -207,6 +326,38 @@ type Configuration struct {
 	// Sets the name of the configmap that contains the headers to pass to the backend
 	ProxySetHeaders string `json:"proxy-set-headers,omitempty"`
 
+	// Defines the directory that contains the cache zones. If the directory
+	// does not exist it is created by the controller.
+	// Default: /tmp/nginx-cache
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	ProxyCachePath string `json:"proxy-cache-path,omitempty"`
+
+	// ProxyCacheZones contains the cache zones that can be used in the
+	// Ingress rules to cache the responses of the backends.
+	// By default this list is empty
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	ProxyCacheZones []CacheZone `json:"proxy-cache-zones,omitempty"`
+
+	// Sets the timeout between two successive read or write operations on client
+	// or proxied server connections of TCP and UDP services
+	// http://nginx.org/en/docs/stream/ngx_stream_proxy_module.html#proxy_timeout
+	ProxyStreamTimeout string `json:"proxy-stream-timeout,omitempty"`
+
+	// Sets the timeout for establishing a connection with the proxied server of TCP services
+	// http://nginx.org/en/docs/stream/ngx_stream_proxy_module.html#proxy_connect_timeout
+	ProxyStreamConnectTimeout string `json:"proxy-stream-connect-timeout,omitempty"`
+
+	// Sets the number of datagrams expected from the proxied server in response
+	// to a client datagram of UDP services
+	// http://nginx.org/en/docs/stream/ngx_stream_proxy_module.html#proxy_responses
+	ProxyStreamResponses int `json:"proxy-stream-responses,omitempty"`
+
+	// RedirectRules contains a list of redirects from an URI to a different
+	// location. The list is rendered as a map to avoid the creation of one
+	// location per redirect.
+	// http://nginx.org/en/docs/http/ngx_http_map_module.html
+	RedirectRules []Redirect `json:"redirect-rules,omitempty"`
+
 	// Maximum size of the server names hash tables used in server names, map directive’s values,
 	// MIME types, names of request header strings, etcd.
 	// http://nginx.org/en/docs/hash.html
@@ -238,6 +389,12 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_ciphers
 	SSLCiphers string `json:"ssl-ciphers,omitempty"`
 
+	// Specifies that the server ciphers should be preferred over the client
+	// ciphers when using the SSLv3 and TLS protocols
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_prefer_server_ciphers
+	// Default: true
+	SSLPreferServerCiphers bool `json:"ssl-prefer-server-ciphers"`
+
 	// Specifies a curve for ECDHE ciphers.
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_ecdh_curve
 	SSLECDHCurve string `json:"ssl-ecdh-curve,omitempty"`
@@ -252,6 +409,20 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_protocols
 	SSLProtocols string `json:"ssl-protocols,omitempty"`
 
+	// Enables the stapling of the OCSP responses of the certificates
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_stapling
+	EnableOCSPStapling bool `json:"enable-ocsp-stapling,omitempty"`
+
+	// Enables the verification of the OCSP responses. The CA chain of the
+	// certificate (ca.crt in the secret) is used to verify the responses
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_stapling_verify
+	OCSPStaplingVerify bool `json:"ocsp-stapling-verify,omitempty"`
+
+	// Name servers used to resolve the hostnames of the OCSP responders.
+	// The default is the name servers of /etc/resolv.conf
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#resolver
+	OCSPResolver string `json:"ocsp-resolver,omitempty"`
+
 	// Enables or disables the use of shared SSL cache among worker processes.
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_session_cache
 	SSLSessionCache bool `json:"ssl-session-cache,omitempty"`
@@ -264,6 +435,12 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_session_tickets
 	SSLSessionTickets bool `json:"ssl-session-tickets,omitempty"`
 
+	// The secret (namespace/name) with the key used to encrypt and decrypt
+	// the TLS session tickets (tls-session-ticket-key), shared by the replicas
+	// of the controller. The key must contain 48 or 80 bytes
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_session_ticket_key
+	SSLSessionTicketKey string `json:"ssl-session-ticket-key,omitempty"`
+
 	// Time during which a client may reuse the session parameters stored in a cache.
 	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_session_timeout
 	SSLSessionTimeout string `json:"ssl-session-timeout,omitempty"`
@@ -289,6 +466,12 @@ type Configuration struct {
 	// Default: true
 	UseHTTP2 bool `json:"use-http2,omitempty"`
 
+	// Enables or disables the HTTP/3 (QUIC) support in secure connections.
+	// Requires a NGINX binary built with the http_v3 module
+	// http://nginx.org/en/docs/http/ngx_http_v3_module.html
+	// Default: false
+	UseHTTP3 bool `json:"use-http3,omitempty"`
+
 	// MIME types in addition to "text/html" to compress. The special value “*” matches any MIME type.
 	// Responses with the “text/html” type are always compressed if UseGzip is enabled
 	GzipTypes string `json:"gzip-types,omitempty"`
@@ -297,6 +480,12 @@ type Configuration struct {
 	// http://nginx.org/en/docs/ngx_core_module.html#worker_processes
 	WorkerProcesses string `json:"worker-processes,omitempty"`
 
+	// Defines a timeout for a graceful shutdown of worker processes. When the time
+	// expires the open connections of the old workers (e.g. websockets) are closed
+	// after a reload
+	// http://nginx.org/en/docs/ngx_core_module.html#worker_shutdown_timeout
+	WorkerShutdownTimeout string `json:"worker-shutdown-timeout,omitempty"`
+
 	// Defines the load balancing algorithm to use. The deault is round-robin
 	LoadBalanceAlgorithm string `json:"load-balance,omitempty"`
 
@@ -319,6 +508,68 @@ type Configuration struct {
 	// Sets the maximum size of the variables hash table.
 	// http://nginx.org/en/docs/http/ngx_http_map_module.html#variables_hash_max_size
 	LimitConnZoneVariable string `json:"limit-conn-zone-variable,omitempty"`
+
+	// LimitWhitelist list of client addresses or networks exempt from the
+	// rate limits defined with the annotations
+	LimitWhitelist []string `json:"limit-whitelist,omitempty"`
+
+	// GlobalLimitConnections sets the maximum number of concurrent connections
+	// allowed per client in every location. This limit is a safety ceiling
+	// applied before the limits defined in Ingress annotations.
+	// http://nginx.org/en/docs/http/ngx_http_limit_conn_module.html#limit_conn
+	// Default: 0 (disabled)
+	GlobalLimitConnections int `json:"global-limit-connections,omitempty"`
+
+	// GlobalLimitRPS sets the maximum number of requests per second allowed
+	// per client in every location. This limit is a safety ceiling applied
+	// before the limits defined in Ingress annotations.
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html#limit_req
+	// Default: 0 (disabled)
+	GlobalLimitRPS int `json:"global-limit-rps,omitempty"`
+
+	// GlobalLimitBurst sets the maximum burst size of requests allowed
+	// over GlobalLimitRPS
+	GlobalLimitBurst int `json:"global-limit-burst,omitempty"`
+
+	// GlobalLimitZoneSize sets the size of the shared memory zones used
+	// to keep the state of the global limits
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html#limit_req_zone
+	GlobalLimitZoneSize string `json:"global-limit-zone-size,omitempty"`
+
+	// GlobalLimitStatusCode sets the status code returned to rejected
+	// requests. It must be a value between 400 and 599
+	// http://nginx.org/en/docs/http/ngx_http_limit_req_module.html#limit_req_status
+	GlobalLimitStatusCode int `json:"global-limit-status-code,omitempty"`
+
+	// GlobalRateLimitMemcachedHost sets the host of the memcached server
+	// where the requests of the rate limits defined with the annotation
+	// global-rate-limit are counted, shared by all the replicas of the
+	// controller. The global rate limits are disabled without a host
+	GlobalRateLimitMemcachedHost string `json:"global-rate-limit-memcached-host,omitempty"`
+
+	// GlobalRateLimitMemcachedPort sets the port of the memcached server
+	// Default: 11211
+	GlobalRateLimitMemcachedPort int `json:"global-rate-limit-memcached-port,omitempty"`
+
+	// GlobalRateLimitMemcachedConnectTimeout sets the timeout in milliseconds
+	// of the connections and the commands sent to memcached
+	// Default: 50
+	GlobalRateLimitMemcachedConnectTimeout int `json:"global-rate-limit-memcached-connect-timeout,omitempty"`
+
+	// GlobalRateLimitMemcachedMaxIdleTimeout sets the time in milliseconds
+	// an idle connection to memcached is kept in the pool of each worker
+	// Default: 10000
+	GlobalRateLimitMemcachedMaxIdleTimeout int `json:"global-rate-limit-memcached-max-idle-timeout,omitempty"`
+
+	// GlobalRateLimitMemcachedPoolSize sets the maximum number of idle
+	// connections to memcached in the pool of each worker
+	// Default: 50
+	GlobalRateLimitMemcachedPoolSize int `json:"global-rate-limit-memcached-pool-size,omitempty"`
+
+	// GlobalRateLimitStatusCode sets the status code returned to the requests
+	// rejected by the global rate limits. It must be a value between 400 and 599
+	// Default: 429
+	GlobalRateLimitStatusCode int `json:"global-rate-limit-status-code,omitempty"`
 }
 
 // NewDefault returns the default nginx configuration
@@ -328,16 +579,12 @@ func NewDefault() Configuration {
 	cfg := Configuration{
 		AllowBackendServerHeader:   false,
 		ClientHeaderBufferSize:     "1k",
-		ClientBodyBufferSize:       "8k",
+		ClientBodyInFileOnly:       "off",
 		EnableDynamicTLSRecords:    true,
 		EnableUnderscoresInHeaders: false,
 		ErrorLogLevel:              errorLevel,
 		HTTP2MaxFieldSize:          "4k",
 		HTTP2MaxHeaderSize:         "16k",
-		HSTS:                       true,
-		HSTSIncludeSubdomains:    true,
-		HSTSMaxAge:               hstsMaxAge,
-		HSTSPreload:              false,
 		IgnoreInvalidHeaders:     true,
 		GzipTypes:                gzipTypes,
 		KeepAlive:                75,
@@ -355,35 +602,73 @@ func NewDefault() Configuration {
 		ShowServerTokens:         true,
 		SSLBufferSize:            sslBufferSize,
 		SSLCiphers:               sslCiphers,
+		SSLPreferServerCiphers:   true,
 		SSLECDHCurve:             "secp384r1",
 		SSLProtocols:             sslProtocols,
 		SSLSessionCache:          true,
 		SSLSessionCacheSize:      sslSessionCacheSize,
 		SSLSessionTickets:        true,
+		OCSPStaplingVerify:       true,
 		SSLSessionTimeout:        sslSessionTimeout,
 		UseGzip:                  true,
 		WorkerProcesses:          strconv.Itoa(runtime.NumCPU()),
+		WorkerShutdownTimeout:    "10s",
 		LoadBalanceAlgorithm:     defaultLoadBalancerAlgorithm,
 		VtsStatusZoneSize:        "10m",
 		VariablesHashBucketSize:  64,
 		VariablesHashMaxSize:     2048,
 		UseHTTP2:                 true,
 		Backend: defaults.Backend{
-			ProxyBodySize:        bodySize,
-			ProxyConnectTimeout:  5,
-			ProxyReadTimeout:     60,
-			ProxySendTimeout:     60,
-			ProxyBufferSize:      "4k",
-			ProxyCookieDomain:    "off",
-			ProxyCookiePath:      "off",
-			ProxyNextUpstream:    "error timeout invalid_header http_502 http_503 http_504",
-			SSLRedirect:          true,
-			CustomHTTPErrors:     []int{},
-			WhitelistSourceRange: []string{},
-			SkipAccessLogURLs:    []string{},
+			ClientBodyBufferSize:  "8k",
+			HSTS:                  true,
+			HSTSIncludeSubdomains: true,
+			HSTSMaxAge:            hstsMaxAge,
+			HSTSPreload:           false,
+			ProxyBodySize:         bodySize,
+			ProxyConnectTimeout:   5,
+			ProxyReadTimeout:      60,
+			ProxySendTimeout:      60,
+			ProxyBufferSize:       "4k",
+			ProxyCookieDomain:     "off",
+			ProxyCookiePath:       "off",
+			ProxyNextUpstream:     "error timeout invalid_header http_502 http_503 http_504",
+			ProxyRedirect:         "off",
+			ProxyBuffering:        "off",
+			ProxyRequestBuffering: "on",
+			SSLRedirect:           true,
+			SSLRedirectCode:       301,
+			GenerateRequestID:     true,
+			LimitBurstMultiplier:  5,
+			UpstreamMaxFails:      1,
+			UpstreamFailTimeout:   10,
+			CustomHTTPErrors:      []int{},
+			WhitelistSourceRange:  []string{},
+			SkipAccessLogURLs:     []string{},
 		},
 		UpstreamKeepaliveConnections: 0,
 		LimitConnZoneVariable:        defaultLimitConnZoneVariable,
+		LimitWhitelist:               []string{},
+		AdminPort:                    defaultAdminPort,
+		AllowSnippetAnnotations:      true,
+		DefaultServerAction:          defaultServerAction,
+		GlobalLimitZoneSize:          defaultGlobalLimitZoneSize,
+		GlobalLimitStatusCode:        defaultGlobalLimitStatusCode,
+		ProxyStreamTimeout:           defaultProxyStreamTimeout,
+		ProxyStreamConnectTimeout:    defaultProxyStreamConnectTimeout,
+		ProxyStreamResponses:         1,
+		RedirectRules:                []Redirect{},
+		ProxyCachePath:               defaultProxyCachePath,
+		ProxyCacheZones:              []CacheZone{},
+		SyslogPort:                   defaultSyslogPort,
+		OpentracingTracer:            ZipkinTracer,
+		OpentracingServiceName:       defaultOpentracingServiceName,
+		OpentracingSampleRate:        1,
+
+		GlobalRateLimitMemcachedPort:           defaultGlobalRateLimitMemcachedPort,
+		GlobalRateLimitMemcachedConnectTimeout: 50,
+		GlobalRateLimitMemcachedMaxIdleTimeout: 10000,
+		GlobalRateLimitMemcachedPoolSize:       50,
+		GlobalRateLimitStatusCode:              defaultGlobalRateLimitStatusCode,
 	}
 
 	if glog.V(5) {
@@ -395,15 +680,66 @@ func NewDefault() Configuration {
 
 // BuildLogFormatUpstream format the log_format upstream using
 // proxy_protocol_addr as remote client address if UseProxyProtocol
-// is enabled.
+// is enabled. The default format is replaced with a JSON format
+// if LogFormatEscapeJSON is enabled.
 func (cfg Configuration) BuildLogFormatUpstream() string {
 	if cfg.LogFormatUpstream == logFormatUpstream {
+		if cfg.LogFormatEscapeJSON {
+			return logFormatUpstreamJSON
+		}
 		return fmt.Sprintf(cfg.LogFormatUpstream, "$the_real_ip")
 	}
 
 	return cfg.LogFormatUpstream
 }
 
+// SyslogServer returns the syslog server used by the access_log
+// and error_log directives when EnableSyslog is enabled
+func (cfg Configuration) SyslogServer() string {
+	return fmt.Sprintf("syslog:server=%v", net.JoinHostPort(cfg.SyslogHost, strconv.Itoa(cfg.SyslogPort)))
+}
+
+// OpentracingTracerLibrary returns the plugin of the tracer loaded
+// by the opentracing module or an empty string if the tracer is
+// not supported
+func (cfg Configuration) OpentracingTracerLibrary() string {
+	return opentracingTracerLibraries[cfg.OpentracingTracer]
+}
+
+// OpentracingCollectorAddress returns the address (host:port) of the
+// collector using the default port of the tracer if the port is not set
+func (cfg Configuration) OpentracingCollectorAddress() string {
+	port := cfg.OpentracingCollectorPort
+	if port == 0 {
+		port = opentracingCollectorPorts[cfg.OpentracingTracer]
+	}
+
+	return net.JoinHostPort(cfg.OpentracingCollectorHost, strconv.Itoa(port))
+}
+
+// Redirect describes a redirect from the request URI to a different location
+type Redirect struct {
+	// From is the request URI (path and arguments) to redirect
+	From string `json:"from"`
+	// To is the absolute URL or path where the request is redirected
+	To string `json:"to"`
+	// Code is the HTTP status code used in the redirect (301 or 302)
+	Code int `json:"code"`
+}
+
+// CacheZone describes a zone used to cache the responses of the backends
+type CacheZone struct {
+	// Name of the zone used in the annotation proxy-cache
+	Name string `json:"name"`
+	// Size of the shared memory zone that stores the keys
+	Size string `json:"size"`
+	// MaxSize is the maximum size of the cached responses in the disk
+	MaxSize string `json:"maxSize"`
+	// Inactive is the time after which the responses not accessed
+	// are removed from the cache
+	Inactive string `json:"inactive"`
+}
+
 // TemplateConfig contains the nginx configuration to render the file nginx.conf
 type TemplateConfig struct {
 	ProxySetHeaders     map[string]string
@@ -415,8 +751,20 @@ type TemplateConfig struct {
 	Servers             []*ingress.Server
 	TCPBackends         []ingress.L4Service
 	UDPBackends         []ingress.L4Service
+	TLSBackends         []ingress.L4Service
 	HealthzURI          string
+	ControllerPort      int
+	ConfigPath          string
 	CustomErrors        bool
 	Cfg                 Configuration
 	IsIPV6Enabled       bool
+	// SSLDHParamSHA is the checksum of the file with the DH parameters,
+	// to reload NGINX when the content of the secret changes
+	SSLDHParamSHA string
+	// SSLSessionTicketKeySHA is the checksum of the file with the
+	// key of the session tickets, to reload NGINX when it changes
+	SSLSessionTicketKeySHA string
+	// DynamicConfigurationEnabled indicates the endpoints of the
+	// upstreams are configured using Lua instead of a reload
+	DynamicConfigurationEnabled bool
 }
@@ -25,18 +25,22 @@ func TestBuildLogFormatUpstream(t *testing.T) {
 
 	testCases := []struct {
 		useProxyProtocol bool // use proxy protocol
+		escapeJSON       bool
 		curLogFormat     string
 		expected         string
 	}{
-		{true, logFormatUpstream, fmt.Sprintf(logFormatUpstream, "$the_real_ip")},
-		{false, logFormatUpstream, fmt.Sprintf(logFormatUpstream, "$the_real_ip")},
-		{true, "my-log-format", "my-log-format"},
-		{false, "john-log-format", "john-log-format"},
+		{true, false, logFormatUpstream, fmt.Sprintf(logFormatUpstream, "$the_real_ip")},
+		{false, false, logFormatUpstream, fmt.Sprintf(logFormatUpstream, "$the_real_ip")},
+		{true, false, "my-log-format", "my-log-format"},
+		{false, false, "john-log-format", "john-log-format"},
+		{false, true, logFormatUpstream, logFormatUpstreamJSON},
+		{false, true, `{ "status": $status }`, `{ "status": $status }`},
 	}
 
 	for _, testCase := range testCases {
 		cfg := NewDefault()
 		cfg.UseProxyProtocol = testCase.useProxyProtocol
+		cfg.LogFormatEscapeJSON = testCase.escapeJSON
 		cfg.LogFormatUpstream = testCase.curLogFormat
 		result := cfg.BuildLogFormatUpstream()
 		if result != testCase.expected {
@@ -44,3 +48,14 @@ func TestBuildLogFormatUpstream(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultUpstreamChecks(t *testing.T) {
+	cfg := NewDefault()
+	// same values used by NGINX when max_fails and fail_timeout are not defined
+	if cfg.UpstreamMaxFails != 1 {
+		t.Errorf("expected 1 as default max_fails but returned %v", cfg.UpstreamMaxFails)
+	}
+	if cfg.UpstreamFailTimeout != 10 {
+		t.Errorf("expected 10 as default fail_timeout but returned %v", cfg.UpstreamFailTimeout)
+	}
+}
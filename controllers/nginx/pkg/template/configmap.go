@@ -17,20 +17,95 @@ limitations under the License.
 package template
 
 import (
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/golang/glog"
 	"github.com/mitchellh/mapstructure"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"k8s.io/ingress/controllers/nginx/pkg/config"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxycache"
+	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/k8s"
 )
 
 const (
 	customHTTPErrors     = "custom-http-errors"
 	skipAccessLogUrls    = "skip-access-log-urls"
 	whitelistSourceRange = "whitelist-source-range"
+	limitWhitelist       = "limit-whitelist"
 	proxyRealIPCIDR      = "proxy-real-ip-cidr"
+	redirectRules        = "redirect-rules"
+	proxyCacheZones      = "proxy-cache-zones"
+	sslDHParam           = "ssl-dh-param"
+	// alias of ssl-dh-param
+	sslDHParamAlias = "ssl-dhparam"
+
+	// nginx requires at least 8 pages of shared memory in a zone
+	minZoneSize = 32 * 1024
+)
+
+var (
+	// ports used by NGINX for traffic and internal servers that
+	// cannot be used by the admin server
+	reservedPorts = map[int]bool{
+		80:    true,
+		442:   true,
+		443:   true,
+		444:   true,
+		8181:  true,
+		18080: true,
+	}
+
+	// time with the NGINX syntax, like 30s or 1h30m
+	// http://nginx.org/en/docs/syntax.html
+	timeRegex = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|M|y)?)+$`)
+
+	// valid values of the client-body-in-file-only setting
+	clientBodyInFileOnlyValues = map[string]bool{
+		"on":    true,
+		"clean": true,
+		"off":   true,
+	}
+
+	// valid values of the default-server-action setting
+	defaultServerActions = map[string]bool{
+		"default-backend": true,
+		"404":             true,
+		"444":             true,
+	}
+
+	// levels of the error_log directive
+	// http://nginx.org/en/docs/ngx_core_module.html#error_log
+	errorLogLevels = map[string]bool{
+		"debug":  true,
+		"info":   true,
+		"notice": true,
+		"warn":   true,
+		"error":  true,
+		"crit":   true,
+		"alert":  true,
+		"emerg":  true,
+	}
+
+	// protocols of the ssl_protocols directive
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_protocols
+	sslProtocols = map[string]bool{
+		"SSLv2":   true,
+		"SSLv3":   true,
+		"TLSv1":   true,
+		"TLSv1.1": true,
+		"TLSv1.2": true,
+		"TLSv1.3": true,
+	}
 )
 
 // ReadConfig obtains the configuration defined by the user merged with the defaults.
@@ -46,7 +121,10 @@ func ReadConfig(src map[string]string) config.Configuration {
 	errors := make([]int, 0)
 	skipUrls := make([]string, 0)
 	whitelist := make([]string, 0)
+	limitlist := make([]string, 0)
 	proxylist := make([]string, 0)
+	redirects := make([]config.Redirect, 0)
+	cacheZones := make([]config.CacheZone, 0)
 
 	if val, ok := conf[customHTTPErrors]; ok {
 		delete(conf, customHTTPErrors)
@@ -65,7 +143,21 @@ func ReadConfig(src map[string]string) config.Configuration {
 	}
 	if val, ok := conf[whitelistSourceRange]; ok {
 		delete(conf, whitelistSourceRange)
-		whitelist = append(whitelist, strings.Split(val, ",")...)
+		cidrs, err := ipwhitelist.ParseCIDRs(val)
+		if err != nil {
+			glog.Warningf("%v is not a valid value for whitelist-source-range (a list of addresses or networks), ignoring it: %v", val, err)
+		} else {
+			whitelist = append(whitelist, cidrs...)
+		}
+	}
+	if val, ok := conf[limitWhitelist]; ok {
+		delete(conf, limitWhitelist)
+		cidrs, err := ipwhitelist.ParseCIDRs(val)
+		if err != nil {
+			glog.Warningf("%v is not a valid value for limit-whitelist (a list of addresses or networks), ignoring it: %v", val, err)
+		} else {
+			limitlist = append(limitlist, cidrs...)
+		}
 	}
 	if val, ok := conf[proxyRealIPCIDR]; ok {
 		delete(conf, proxyRealIPCIDR)
@@ -73,12 +165,30 @@ func ReadConfig(src map[string]string) config.Configuration {
 	} else {
 		proxylist = append(proxylist, "0.0.0.0/0")
 	}
+	if val, ok := conf[redirectRules]; ok {
+		delete(conf, redirectRules)
+		redirects = parseRedirectRules(val)
+	}
+	if val, ok := conf[proxyCacheZones]; ok {
+		delete(conf, proxyCacheZones)
+		cacheZones = parseCacheZones(val)
+	}
+	if val, ok := conf[sslDHParamAlias]; ok {
+		delete(conf, sslDHParamAlias)
+		if _, ok := conf[sslDHParam]; !ok {
+			conf[sslDHParam] = val
+		}
+	}
 
 	to := config.NewDefault()
+	def := config.NewDefault()
 	to.CustomHTTPErrors = filterErrors(errors)
 	to.SkipAccessLogURLs = skipUrls
 	to.WhitelistSourceRange = whitelist
+	to.LimitWhitelist = limitlist
 	to.ProxyRealIPCIDR = proxylist
+	to.RedirectRules = redirects
+	to.ProxyCacheZones = cacheZones
 
 	config := &mapstructure.DecoderConfig{
 		Metadata:         nil,
@@ -96,9 +206,318 @@ func ReadConfig(src map[string]string) config.Configuration {
 		glog.Warningf("unexpected error merging defaults: %v", err)
 	}
 
+	if !isValidZoneSize(to.GlobalLimitZoneSize) {
+		glog.Warningf("%v is not a valid size for the global limit zones, using the default (%v)",
+			to.GlobalLimitZoneSize, def.GlobalLimitZoneSize)
+		to.GlobalLimitZoneSize = def.GlobalLimitZoneSize
+	}
+	if to.GlobalLimitStatusCode < 400 || to.GlobalLimitStatusCode > 599 {
+		glog.Warningf("%v is not a valid status code for the global limits, using the default (%v)",
+			to.GlobalLimitStatusCode, def.GlobalLimitStatusCode)
+		to.GlobalLimitStatusCode = def.GlobalLimitStatusCode
+	}
+
+	if to.GlobalRateLimitMemcachedHost != "" && !isValidHost(to.GlobalRateLimitMemcachedHost) {
+		glog.Warningf("%v is not a valid value for global-rate-limit-memcached-host (an address or DNS name), the global rate limits are disabled",
+			to.GlobalRateLimitMemcachedHost)
+		to.GlobalRateLimitMemcachedHost = def.GlobalRateLimitMemcachedHost
+	}
+	if to.GlobalRateLimitMemcachedPort < 1 || to.GlobalRateLimitMemcachedPort > 65535 {
+		glog.Warningf("%v is not a valid port for global-rate-limit-memcached-port, using the default (%v)",
+			to.GlobalRateLimitMemcachedPort, def.GlobalRateLimitMemcachedPort)
+		to.GlobalRateLimitMemcachedPort = def.GlobalRateLimitMemcachedPort
+	}
+	if to.GlobalRateLimitMemcachedConnectTimeout < 1 {
+		glog.Warningf("%v is not a valid value for global-rate-limit-memcached-connect-timeout, using the default (%v)",
+			to.GlobalRateLimitMemcachedConnectTimeout, def.GlobalRateLimitMemcachedConnectTimeout)
+		to.GlobalRateLimitMemcachedConnectTimeout = def.GlobalRateLimitMemcachedConnectTimeout
+	}
+	if to.GlobalRateLimitMemcachedMaxIdleTimeout < 1 {
+		glog.Warningf("%v is not a valid value for global-rate-limit-memcached-max-idle-timeout, using the default (%v)",
+			to.GlobalRateLimitMemcachedMaxIdleTimeout, def.GlobalRateLimitMemcachedMaxIdleTimeout)
+		to.GlobalRateLimitMemcachedMaxIdleTimeout = def.GlobalRateLimitMemcachedMaxIdleTimeout
+	}
+	if to.GlobalRateLimitMemcachedPoolSize < 1 {
+		glog.Warningf("%v is not a valid value for global-rate-limit-memcached-pool-size, using the default (%v)",
+			to.GlobalRateLimitMemcachedPoolSize, def.GlobalRateLimitMemcachedPoolSize)
+		to.GlobalRateLimitMemcachedPoolSize = def.GlobalRateLimitMemcachedPoolSize
+	}
+	if to.GlobalRateLimitStatusCode < 400 || to.GlobalRateLimitStatusCode > 599 {
+		glog.Warningf("%v is not a valid status code for the global rate limits, using the default (%v)",
+			to.GlobalRateLimitStatusCode, def.GlobalRateLimitStatusCode)
+		to.GlobalRateLimitStatusCode = def.GlobalRateLimitStatusCode
+	}
+
+	if !isValidAdminPort(to.AdminPort) {
+		glog.Warningf("%v is not a valid port for the admin server, using the default (%v)",
+			to.AdminPort, def.AdminPort)
+		to.AdminPort = def.AdminPort
+	}
+	if to.UpstreamMaxFails < 0 {
+		glog.Warningf("%v is not a valid value for upstream-max-fails, using the default (%v)",
+			to.UpstreamMaxFails, def.UpstreamMaxFails)
+		to.UpstreamMaxFails = def.UpstreamMaxFails
+	}
+	if to.UpstreamFailTimeout < 0 {
+		glog.Warningf("%v is not a valid value for upstream-fail-timeout, using the default (%v)",
+			to.UpstreamFailTimeout, def.UpstreamFailTimeout)
+		to.UpstreamFailTimeout = def.UpstreamFailTimeout
+	}
+	if !proxy.IsValidProxyRedirect(to.ProxyRedirect) {
+		glog.Warningf("%v is not a valid value for proxy-redirect (off, default or <redirect> <replacement>), using the default (%v)",
+			to.ProxyRedirect, def.ProxyRedirect)
+		to.ProxyRedirect = def.ProxyRedirect
+	}
+	if !proxy.IsValidBuffering(to.ProxyBuffering) {
+		glog.Warningf("%v is not a valid value for proxy-buffering (on or off), using the default (%v)",
+			to.ProxyBuffering, def.ProxyBuffering)
+		to.ProxyBuffering = def.ProxyBuffering
+	}
+	if !proxy.IsValidBuffering(to.ProxyRequestBuffering) {
+		glog.Warningf("%v is not a valid value for proxy-request-buffering (on or off), using the default (%v)",
+			to.ProxyRequestBuffering, def.ProxyRequestBuffering)
+		to.ProxyRequestBuffering = def.ProxyRequestBuffering
+	}
+	if !isValidTime(to.ProxyStreamTimeout) {
+		glog.Warningf("%v is not a valid value for proxy-stream-timeout, using the default (%v)",
+			to.ProxyStreamTimeout, def.ProxyStreamTimeout)
+		to.ProxyStreamTimeout = def.ProxyStreamTimeout
+	}
+	if !isValidTime(to.ProxyStreamConnectTimeout) {
+		glog.Warningf("%v is not a valid value for proxy-stream-connect-timeout, using the default (%v)",
+			to.ProxyStreamConnectTimeout, def.ProxyStreamConnectTimeout)
+		to.ProxyStreamConnectTimeout = def.ProxyStreamConnectTimeout
+	}
+	if to.ProxyStreamResponses < 1 {
+		glog.Warningf("%v is not a valid value for proxy-stream-responses, using the default (%v)",
+			to.ProxyStreamResponses, def.ProxyStreamResponses)
+		to.ProxyStreamResponses = def.ProxyStreamResponses
+	}
+	if !hsts.IsValidMaxAge(to.HSTSMaxAge) {
+		glog.Warningf("%v is not a valid value for hsts-max-age (a number of seconds), using the default (%v)",
+			to.HSTSMaxAge, def.HSTSMaxAge)
+		to.HSTSMaxAge = def.HSTSMaxAge
+	}
+	if !rewrite.IsValidRedirectCode(to.SSLRedirectCode) {
+		glog.Warningf("%v is not a valid value for ssl-redirect-code (301, 302, 307 or 308), using the default (%v)",
+			to.SSLRedirectCode, def.SSLRedirectCode)
+		to.SSLRedirectCode = def.SSLRedirectCode
+	}
+	if to.SSLRedirectHost != "" && !rewrite.IsValidRedirectHost(to.SSLRedirectHost) {
+		glog.Warningf("%v is not a valid value for ssl-redirect-host (a host without scheme, port or path), ignoring it",
+			to.SSLRedirectHost)
+		to.SSLRedirectHost = def.SSLRedirectHost
+	}
+	if to.SSLRedirectPort != 0 && !rewrite.IsValidRedirectPort(to.SSLRedirectPort) {
+		glog.Warningf("%v is not a valid value for ssl-redirect-port, ignoring it", to.SSLRedirectPort)
+		to.SSLRedirectPort = def.SSLRedirectPort
+	}
+	if to.WorkerShutdownTimeout != "" && !isValidTime(to.WorkerShutdownTimeout) {
+		glog.Warningf("%v is not a valid value for worker-shutdown-timeout, using the default (%v)",
+			to.WorkerShutdownTimeout, def.WorkerShutdownTimeout)
+		to.WorkerShutdownTimeout = def.WorkerShutdownTimeout
+	}
+	if !proxy.IsValidSize(to.ClientBodyBufferSize) {
+		glog.Warningf("%v is not a valid value for client-body-buffer-size, using the default (%v)",
+			to.ClientBodyBufferSize, def.ClientBodyBufferSize)
+		to.ClientBodyBufferSize = def.ClientBodyBufferSize
+	}
+	if !clientBodyInFileOnlyValues[to.ClientBodyInFileOnly] {
+		glog.Warningf("%v is not a valid value for client-body-in-file-only (on, clean or off), using the default (%v)",
+			to.ClientBodyInFileOnly, def.ClientBodyInFileOnly)
+		to.ClientBodyInFileOnly = def.ClientBodyInFileOnly
+	}
+	if !isValidTempPath(to.ProxyCachePath) {
+		glog.Warningf("%v is not a valid value for proxy-cache-path (an absolute path), using the default (%v)",
+			to.ProxyCachePath, def.ProxyCachePath)
+		to.ProxyCachePath = def.ProxyCachePath
+	}
+	if to.ClientBodyTempPath != "" && !isValidTempPath(to.ClientBodyTempPath) {
+		glog.Warningf("%v is not a valid value for client-body-temp-path (an absolute path), using the default",
+			to.ClientBodyTempPath)
+		to.ClientBodyTempPath = def.ClientBodyTempPath
+	}
+	if to.EnableSyslog && !isValidHost(to.SyslogHost) {
+		glog.Warningf("%v is not a valid value for syslog-host, disabling syslog", to.SyslogHost)
+		to.EnableSyslog = false
+	}
+	if to.SyslogPort <= 0 || to.SyslogPort > 65535 {
+		glog.Warningf("%v is not a valid value for syslog-port, using the default (%v)",
+			to.SyslogPort, def.SyslogPort)
+		to.SyslogPort = def.SyslogPort
+	}
+	if to.OpentracingTracerLibrary() == "" {
+		glog.Warningf("%v is not a valid value for opentracing-tracer (zipkin or jaeger), using the default (%v)",
+			to.OpentracingTracer, def.OpentracingTracer)
+		to.OpentracingTracer = def.OpentracingTracer
+	}
+	if to.EnableOpentracing && !isValidHost(to.OpentracingCollectorHost) {
+		glog.Warningf("%v is not a valid value for opentracing-collector-host, disabling opentracing", to.OpentracingCollectorHost)
+		to.EnableOpentracing = false
+	}
+	if to.OpentracingCollectorPort < 0 || to.OpentracingCollectorPort > 65535 {
+		glog.Warningf("%v is not a valid value for opentracing-collector-port, using the default of the tracer", to.OpentracingCollectorPort)
+		to.OpentracingCollectorPort = def.OpentracingCollectorPort
+	}
+	if to.OpentracingSampleRate < 0 || to.OpentracingSampleRate > 1 {
+		glog.Warningf("%v is not a valid value for opentracing-sample-rate (between 0 and 1), using the default (%v)",
+			to.OpentracingSampleRate, def.OpentracingSampleRate)
+		to.OpentracingSampleRate = def.OpentracingSampleRate
+	}
+	if to.OpentracingServiceName == "" {
+		to.OpentracingServiceName = def.OpentracingServiceName
+	}
+	if err := validateLogFormat(to.LogFormatUpstream, httpLogVariables, httpLogVariablePrefixes, true); err != nil {
+		glog.Warningf("%v is not a valid value for log-format-upstream (%v), using the default", to.LogFormatUpstream, err)
+		to.LogFormatUpstream = def.LogFormatUpstream
+	}
+	if err := validateLogFormat(to.LogFormatStream, streamLogVariables, nil, false); err != nil {
+		glog.Warningf("%v is not a valid value for log-format-stream (%v), using the default", to.LogFormatStream, err)
+		to.LogFormatStream = def.LogFormatStream
+	}
+	if !defaultServerActions[to.DefaultServerAction] {
+		glog.Warningf("%v is not a valid action for the default server (default-backend, 404 or 444), using the default (%v)",
+			to.DefaultServerAction, def.DefaultServerAction)
+		to.DefaultServerAction = def.DefaultServerAction
+	}
+	if to.OCSPResolver != "" && !isValidResolver(to.OCSPResolver) {
+		glog.Warningf("%v is not a valid value for ocsp-resolver (addresses or hostnames with an optional port), using the name servers of /etc/resolv.conf",
+			to.OCSPResolver)
+		to.OCSPResolver = def.OCSPResolver
+	}
+	if !errorLogLevels[to.ErrorLogLevel] {
+		glog.Warningf("%v is not a valid value for error-log-level (debug, info, notice, warn, error, crit, alert or emerg), using the default (%v)",
+			to.ErrorLogLevel, def.ErrorLogLevel)
+		to.ErrorLogLevel = def.ErrorLogLevel
+	}
+	if to.SSLDHParam != "" && !isValidSecretName(to.SSLDHParam) {
+		glog.Warningf("%v is not a valid value for ssl-dh-param (the name of a secret, namespace/name), ignoring it",
+			to.SSLDHParam)
+		to.SSLDHParam = def.SSLDHParam
+	}
+	if to.SSLSessionTicketKey != "" && !isValidSecretName(to.SSLSessionTicketKey) {
+		glog.Warningf("%v is not a valid value for ssl-session-ticket-key (the name of a secret, namespace/name), ignoring it",
+			to.SSLSessionTicketKey)
+		to.SSLSessionTicketKey = def.SSLSessionTicketKey
+	}
+	if !isValidSSLProtocols(to.SSLProtocols) {
+		glog.Warningf("%v is not a valid value for ssl-protocols (SSLv2, SSLv3, TLSv1, TLSv1.1, TLSv1.2 or TLSv1.3 separated by spaces), using the default (%v)",
+			to.SSLProtocols, def.SSLProtocols)
+		to.SSLProtocols = def.SSLProtocols
+	}
+	if strings.ContainsAny(to.SSLCiphers, "'; \t\n{}") {
+		glog.Warningf("%v is not a valid value for ssl-ciphers (a list of ciphers in the format of OpenSSL), using the default",
+			to.SSLCiphers)
+		to.SSLCiphers = def.SSLCiphers
+	}
+
 	return to
 }
 
+// isValidSecretName checks the value is the name of a secret (namespace/name)
+func isValidSecretName(value string) bool {
+	_, _, err := k8s.ParseNameNS(value)
+	return err == nil
+}
+
+// isValidSSLProtocols checks the value is a list of protocols separated by spaces
+func isValidSSLProtocols(value string) bool {
+	protocols := strings.Fields(value)
+	if len(protocols) == 0 {
+		return false
+	}
+
+	for _, protocol := range protocols {
+		if !sslProtocols[protocol] {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidTime checks the value is a time with the NGINX syntax
+func isValidTime(value string) bool {
+	return timeRegex.MatchString(value)
+}
+
+// isValidTempPath checks the value is an absolute path
+// without characters with a special meaning for NGINX
+func isValidTempPath(value string) bool {
+	return filepath.IsAbs(value) && !strings.ContainsAny(value, " \t\n;{}\"'$")
+}
+
+// isValidHost checks the value is an IP address or a hostname
+func isValidHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	return len(validation.IsDNS1123Subdomain(host)) == 0
+}
+
+// isValidResolver checks the value is a list of name servers separated
+// by spaces. Each name server is an address (IPv6 addresses enclosed in
+// brackets) or a hostname with an optional port
+func isValidResolver(value string) bool {
+	servers := strings.Fields(value)
+	if len(servers) == 0 {
+		return false
+	}
+
+	for _, server := range servers {
+		host := server
+		if h, port, err := net.SplitHostPort(server); err == nil {
+			p, err := strconv.Atoi(port)
+			if err != nil || p < 1 || p > 65535 {
+				return false
+			}
+			host = h
+		} else if strings.Contains(server, ":") {
+			if !strings.HasPrefix(server, "[") || !strings.HasSuffix(server, "]") {
+				return false
+			}
+			host = strings.Trim(server, "[]")
+		}
+
+		if !isValidHost(host) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isValidAdminPort checks the port is valid and not used by other servers
+func isValidAdminPort(port int) bool {
+	return port > 0 && port < 65536 && !reservedPorts[port]
+}
+
+// isValidZoneSize checks the size of a shared memory zone uses the nginx
+// format (a number with an optional k or m suffix) and is big enough to
+// be accepted by nginx (at least 32k)
+func isValidZoneSize(size string) bool {
+	if size == "" {
+		return false
+	}
+
+	multiplier := 1
+	switch size[len(size)-1] {
+	case 'k', 'K':
+		multiplier = 1024
+		size = size[:len(size)-1]
+	case 'm', 'M':
+		multiplier = 1024 * 1024
+		size = size[:len(size)-1]
+	}
+
+	n, err := strconv.Atoi(size)
+	if err != nil || n <= 0 {
+		return false
+	}
+
+	return n*multiplier >= minZoneSize
+}
+
 func filterErrors(codes []int) []int {
 	var fa []int
 	for _, code := range codes {
@@ -111,3 +530,125 @@ func filterErrors(codes []int) []int {
 
 	return fa
 }
+
+// parseRedirectRules parses a list of redirects separated by new lines or
+// commas with the format <from> <to> [code]. If the code is not specified
+// a permanent redirect (301) is used.
+// Invalid redirects are ignored.
+func parseRedirectRules(val string) []config.Redirect {
+	redirects := make([]config.Redirect, 0)
+	rules := strings.FieldsFunc(val, func(r rune) bool {
+		return r == '\n' || r == ','
+	})
+
+	for _, rule := range rules {
+		parts := strings.Fields(rule)
+		if len(parts) == 0 {
+			continue
+		}
+
+		if len(parts) < 2 || len(parts) > 3 {
+			glog.Warningf("invalid format (from to [code]) in redirect '%v'", rule)
+			continue
+		}
+
+		code := 301
+		if len(parts) == 3 {
+			c, err := strconv.Atoi(parts[2])
+			if err != nil || (c != 301 && c != 302) {
+				glog.Warningf("%v is not a valid redirect code (301 or 302) in redirect '%v'", parts[2], rule)
+				continue
+			}
+			code = c
+		}
+
+		if !strings.HasPrefix(parts[0], "/") {
+			glog.Warningf("%v is not a valid request URI in redirect '%v'", parts[0], rule)
+			continue
+		}
+
+		if !isValidRedirectTarget(parts[1]) {
+			glog.Warningf("%v is not an absolute URL or path in redirect '%v'", parts[1], rule)
+			continue
+		}
+
+		redirects = append(redirects, config.Redirect{
+			From: parts[0],
+			To:   parts[1],
+			Code: code,
+		})
+	}
+
+	return redirects
+}
+
+// isValidRedirectTarget checks the target of a redirect is an
+// absolute URL or a path
+func isValidRedirectTarget(target string) bool {
+	if strings.HasPrefix(target, "/") {
+		return true
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// parseCacheZones parses a list of cache zones separated by new lines or
+// commas with the format <name> <size> [max_size=<size>] [inactive=<time>].
+// Invalid zones are ignored.
+func parseCacheZones(val string) []config.CacheZone {
+	zones := make([]config.CacheZone, 0)
+	names := map[string]bool{}
+	for _, rule := range strings.FieldsFunc(val, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		parts := strings.Fields(rule)
+		if len(parts) == 0 {
+			continue
+		}
+
+		if len(parts) < 2 || len(parts) > 4 {
+			glog.Warningf("invalid format (name size [max_size=size] [inactive=time]) in cache zone '%v'", rule)
+			continue
+		}
+
+		zone := config.CacheZone{Name: parts[0], Size: parts[1]}
+		if !proxycache.IsValidZone(zone.Name) {
+			glog.Warningf("%v is not a valid name (letters, numbers and underscores) in cache zone '%v'", zone.Name, rule)
+			continue
+		}
+		if names[zone.Name] {
+			glog.Warningf("duplicated name %v in cache zone '%v'", zone.Name, rule)
+			continue
+		}
+		if !isValidZoneSize(zone.Size) {
+			glog.Warningf("%v is not a valid size (at least 32k) in cache zone '%v'", zone.Size, rule)
+			continue
+		}
+
+		valid := true
+		for _, param := range parts[2:] {
+			switch {
+			case strings.HasPrefix(param, "max_size=") && proxy.IsValidBodySize(strings.TrimPrefix(param, "max_size=")):
+				zone.MaxSize = strings.TrimPrefix(param, "max_size=")
+			case strings.HasPrefix(param, "inactive=") && isValidTime(strings.TrimPrefix(param, "inactive=")):
+				zone.Inactive = strings.TrimPrefix(param, "inactive=")
+			default:
+				glog.Warningf("%v is not a valid parameter (max_size=size or inactive=time) in cache zone '%v'", param, rule)
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		names[zone.Name] = true
+		zones = append(zones, zone)
+	}
+
+	return zones
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package template
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
@@ -84,3 +85,577 @@ func TestDefaultLoadBalance(t *testing.T) {
 		t.Errorf("default load balance algorithm wrong")
 	}
 }
+
+func TestParseRedirectRules(t *testing.T) {
+	rules := `/old https://www.bar.com/new
+/foo /bar 302, /tmp /other 307
+invalid
+foo /bar
+/invalid-target ftp://bar.com
+/invalid-code /bar code`
+
+	redirects := parseRedirectRules(rules)
+	expected := []config.Redirect{
+		{From: "/old", To: "https://www.bar.com/new", Code: 301},
+		{From: "/foo", To: "/bar", Code: 302},
+	}
+
+	if diff := pretty.Compare(redirects, expected); diff != "" {
+		t.Errorf("unexpected diff: (-got +want)\n%s", diff)
+	}
+
+	to := ReadConfig(map[string]string{
+		"redirect-rules": "/old https://www.bar.com/new",
+	})
+	if len(to.RedirectRules) != 1 {
+		t.Errorf("expected one redirect but %v returned", len(to.RedirectRules))
+	}
+}
+
+func TestParseCacheZones(t *testing.T) {
+	zones := `static 10m max_size=1g inactive=1h
+api 1m, small 16k
+invalid-name 10m
+static 20m
+other 10m max_size=1t
+other 10m expires=1h`
+
+	expected := []config.CacheZone{
+		{Name: "static", Size: "10m", MaxSize: "1g", Inactive: "1h"},
+		{Name: "api", Size: "1m"},
+	}
+
+	if diff := pretty.Compare(parseCacheZones(zones), expected); diff != "" {
+		t.Errorf("unexpected diff: (-got +want)\n%s", diff)
+	}
+
+	to := ReadConfig(map[string]string{
+		"proxy-cache-zones": "static 10m",
+		"proxy-cache-path":  "/var/cache/nginx",
+	})
+	if len(to.ProxyCacheZones) != 1 || to.ProxyCachePath != "/var/cache/nginx" {
+		t.Errorf("expected one cache zone in /var/cache/nginx but returned %v in %v", to.ProxyCacheZones, to.ProxyCachePath)
+	}
+
+	to = ReadConfig(map[string]string{
+		"proxy-cache-path": "cache; foo",
+	})
+	if to.ProxyCachePath != config.NewDefault().ProxyCachePath {
+		t.Errorf("expected the default cache path but returned %v", to.ProxyCachePath)
+	}
+}
+
+func TestGlobalLimitValidation(t *testing.T) {
+	def := config.NewDefault()
+
+	to := ReadConfig(map[string]string{
+		"global-limit-zone-size":   "1k",
+		"global-limit-status-code": "200",
+	})
+	if to.GlobalLimitZoneSize != def.GlobalLimitZoneSize {
+		t.Errorf("expected the default zone size but %v returned", to.GlobalLimitZoneSize)
+	}
+	if to.GlobalLimitStatusCode != def.GlobalLimitStatusCode {
+		t.Errorf("expected the default status code but %v returned", to.GlobalLimitStatusCode)
+	}
+
+	to = ReadConfig(map[string]string{
+		"global-limit-zone-size":   "64k",
+		"global-limit-status-code": "429",
+	})
+	if to.GlobalLimitZoneSize != "64k" {
+		t.Errorf("expected 64k as zone size but %v returned", to.GlobalLimitZoneSize)
+	}
+	if to.GlobalLimitStatusCode != 429 {
+		t.Errorf("expected 429 as status code but %v returned", to.GlobalLimitStatusCode)
+	}
+
+	for _, size := range []string{"", "m", "-1m", "10g", "abc", "16k"} {
+		if isValidZoneSize(size) {
+			t.Errorf("expected %v to be an invalid zone size", size)
+		}
+	}
+	for _, size := range []string{"32k", "1m", "10M", "65536"} {
+		if !isValidZoneSize(size) {
+			t.Errorf("expected %v to be a valid zone size", size)
+		}
+	}
+}
+
+func TestDefaultServerAction(t *testing.T) {
+	for action, expected := range map[string]string{
+		"":                "default-backend",
+		"default-backend": "default-backend",
+		"404":             "404",
+		"444":             "444",
+		"500":             "default-backend",
+		"drop":            "default-backend",
+	} {
+		to := ReadConfig(map[string]string{
+			"default-server-action": action,
+		})
+		if to.DefaultServerAction != expected {
+			t.Errorf("expected %v as default server action for '%v' but %v returned", expected, action, to.DefaultServerAction)
+		}
+	}
+}
+
+func TestErrorLogLevel(t *testing.T) {
+	for level, expected := range map[string]string{
+		"":        "notice",
+		"debug":   "debug",
+		"warn":    "warn",
+		"crit":    "crit",
+		"warning": "notice",
+		"debug;":  "notice",
+	} {
+		to := ReadConfig(map[string]string{
+			"error-log-level": level,
+		})
+		if to.ErrorLogLevel != expected {
+			t.Errorf("expected %v as error log level for '%v' but %v returned", expected, level, to.ErrorLogLevel)
+		}
+	}
+}
+
+func TestSSLProtocols(t *testing.T) {
+	def := config.NewDefault()
+	for protocols, expected := range map[string]string{
+		"":                "TLSv1 TLSv1.1 TLSv1.2",
+		"TLSv1.2":         "TLSv1.2",
+		"TLSv1.2 TLSv1.3": "TLSv1.2 TLSv1.3",
+		"TLSv1.2,TLSv1.3": "TLSv1 TLSv1.1 TLSv1.2",
+		"tlsv1.2":         "TLSv1 TLSv1.1 TLSv1.2",
+		"TLSv1.2;":        "TLSv1 TLSv1.1 TLSv1.2",
+	} {
+		to := ReadConfig(map[string]string{
+			"ssl-protocols": protocols,
+		})
+		if to.SSLProtocols != expected {
+			t.Errorf("expected %v as ssl protocols for '%v' but %v returned", expected, protocols, to.SSLProtocols)
+		}
+	}
+
+	for ciphers, expected := range map[string]string{
+		"ECDHE-RSA-AES128-GCM-SHA256:!aNULL": "ECDHE-RSA-AES128-GCM-SHA256:!aNULL",
+		"ECDHE-RSA-AES128-GCM-SHA256';":      def.SSLCiphers,
+	} {
+		to := ReadConfig(map[string]string{
+			"ssl-ciphers": ciphers,
+		})
+		if to.SSLCiphers != expected {
+			t.Errorf("expected %v as ssl ciphers for '%v' but %v returned", expected, ciphers, to.SSLCiphers)
+		}
+	}
+
+	to := ReadConfig(map[string]string{
+		"ssl-prefer-server-ciphers": "false",
+	})
+	if to.SSLPreferServerCiphers {
+		t.Errorf("expected ssl-prefer-server-ciphers disabled")
+	}
+}
+
+func TestSSLSecretNames(t *testing.T) {
+	for _, tc := range []struct {
+		data     map[string]string
+		expected string
+	}{
+		{map[string]string{"ssl-dh-param": "default/dhparam"}, "default/dhparam"},
+		{map[string]string{"ssl-dhparam": "default/dhparam"}, "default/dhparam"},
+		{map[string]string{"ssl-dh-param": "default/dhparam", "ssl-dhparam": "default/other"}, "default/dhparam"},
+		{map[string]string{"ssl-dh-param": "dhparam"}, ""},
+	} {
+		to := ReadConfig(tc.data)
+		if to.SSLDHParam != tc.expected {
+			t.Errorf("expected %v as DH param secret for %v but %v returned", tc.expected, tc.data, to.SSLDHParam)
+		}
+	}
+
+	for secret, expected := range map[string]string{
+		"default/ticket-key": "default/ticket-key",
+		"ticket-key":         "",
+	} {
+		to := ReadConfig(map[string]string{
+			"ssl-session-ticket-key": secret,
+		})
+		if to.SSLSessionTicketKey != expected {
+			t.Errorf("expected %v as session ticket key secret for '%v' but %v returned", expected, secret, to.SSLSessionTicketKey)
+		}
+	}
+}
+
+func TestOCSPResolver(t *testing.T) {
+	for resolver, expected := range map[string]string{
+		"":                              "",
+		"10.0.0.10":                     "10.0.0.10",
+		"10.0.0.10:5353 kube-dns.local": "10.0.0.10:5353 kube-dns.local",
+		"[fd00::10] [fd00::11]:53":      "[fd00::10] [fd00::11]:53",
+		"fd00::10":                      "",
+		"10.0.0.10:99999":               "",
+		"10.0.0.10;":                    "",
+	} {
+		to := ReadConfig(map[string]string{
+			"ocsp-resolver": resolver,
+		})
+		if to.OCSPResolver != expected {
+			t.Errorf("expected '%v' as OCSP resolver for '%v' but '%v' returned", expected, resolver, to.OCSPResolver)
+		}
+	}
+}
+
+func TestUpstreamChecksValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"upstream-max-fails":    "3",
+		"upstream-fail-timeout": "30",
+	})
+	if to.UpstreamMaxFails != 3 || to.UpstreamFailTimeout != 30 {
+		t.Errorf("expected max-fails 3 and fail-timeout 30 but returned %v and %v", to.UpstreamMaxFails, to.UpstreamFailTimeout)
+	}
+
+	to = ReadConfig(map[string]string{
+		"upstream-max-fails":    "-1",
+		"upstream-fail-timeout": "-10",
+	})
+	def := config.NewDefault()
+	if to.UpstreamMaxFails != def.UpstreamMaxFails || to.UpstreamFailTimeout != def.UpstreamFailTimeout {
+		t.Errorf("expected default values but returned %v and %v", to.UpstreamMaxFails, to.UpstreamFailTimeout)
+	}
+}
+
+func TestAdminPort(t *testing.T) {
+	def := config.NewDefault()
+	for port, expected := range map[string]int{
+		"":      def.AdminPort,
+		"9000":  9000,
+		"0":     def.AdminPort,
+		"80":    def.AdminPort,
+		"18080": def.AdminPort,
+		"70000": def.AdminPort,
+	} {
+		to := ReadConfig(map[string]string{
+			"admin-port": port,
+		})
+		if to.AdminPort != expected {
+			t.Errorf("expected %v as admin port for '%v' but %v returned", expected, port, to.AdminPort)
+		}
+	}
+}
+
+func TestProxyStreamValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"proxy-stream-timeout":         "1h",
+		"proxy-stream-connect-timeout": "1m30s",
+		"proxy-stream-responses":       "2",
+	})
+	if to.ProxyStreamTimeout != "1h" || to.ProxyStreamConnectTimeout != "1m30s" || to.ProxyStreamResponses != 2 {
+		t.Errorf("expected 1h, 1m30s and 2 but returned %v, %v and %v",
+			to.ProxyStreamTimeout, to.ProxyStreamConnectTimeout, to.ProxyStreamResponses)
+	}
+
+	to = ReadConfig(map[string]string{
+		"proxy-stream-timeout":         "1 hour",
+		"proxy-stream-connect-timeout": "-5s",
+		"proxy-stream-responses":       "0",
+	})
+	def := config.NewDefault()
+	if to.ProxyStreamTimeout != def.ProxyStreamTimeout ||
+		to.ProxyStreamConnectTimeout != def.ProxyStreamConnectTimeout ||
+		to.ProxyStreamResponses != def.ProxyStreamResponses {
+		t.Errorf("expected default values but returned %v, %v and %v",
+			to.ProxyStreamTimeout, to.ProxyStreamConnectTimeout, to.ProxyStreamResponses)
+	}
+}
+
+func TestProxyBufferingValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"proxy-buffering":         "on",
+		"proxy-request-buffering": "off",
+	})
+	if to.ProxyBuffering != "on" || to.ProxyRequestBuffering != "off" {
+		t.Errorf("expected on and off but returned %v and %v", to.ProxyBuffering, to.ProxyRequestBuffering)
+	}
+
+	to = ReadConfig(map[string]string{
+		"proxy-buffering":         "yes",
+		"proxy-request-buffering": "",
+	})
+	def := config.NewDefault()
+	if to.ProxyBuffering != def.ProxyBuffering || to.ProxyRequestBuffering != def.ProxyRequestBuffering {
+		t.Errorf("expected default values but returned %v and %v", to.ProxyBuffering, to.ProxyRequestBuffering)
+	}
+}
+
+func TestSSLRedirectValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"ssl-redirect-code": "308",
+		"ssl-redirect-host": "foo.bar.com",
+		"ssl-redirect-port": "8443",
+	})
+	if to.SSLRedirectCode != 308 || to.SSLRedirectHost != "foo.bar.com" || to.SSLRedirectPort != 8443 {
+		t.Errorf("expected 308, foo.bar.com and 8443 but returned %v, %v and %v",
+			to.SSLRedirectCode, to.SSLRedirectHost, to.SSLRedirectPort)
+	}
+
+	to = ReadConfig(map[string]string{
+		"ssl-redirect-code": "200",
+		"ssl-redirect-host": "foo.bar.com:8443",
+		"ssl-redirect-port": "-1",
+	})
+	def := config.NewDefault()
+	if to.SSLRedirectCode != def.SSLRedirectCode || to.SSLRedirectHost != "" || to.SSLRedirectPort != 0 {
+		t.Errorf("expected default values but returned %v, %v and %v",
+			to.SSLRedirectCode, to.SSLRedirectHost, to.SSLRedirectPort)
+	}
+}
+
+func TestHSTSMaxAgeValidation(t *testing.T) {
+	def := config.NewDefault()
+	for maxAge, expected := range map[string]string{
+		"0":        "0",
+		"31536000": "31536000",
+		"-1":       def.HSTSMaxAge,
+		"1y":       def.HSTSMaxAge,
+		"100;":     def.HSTSMaxAge,
+	} {
+		to := ReadConfig(map[string]string{
+			"hsts-max-age": maxAge,
+		})
+		if to.HSTSMaxAge != expected {
+			t.Errorf("expected %v as hsts-max-age for '%v' but %v returned", expected, maxAge, to.HSTSMaxAge)
+		}
+	}
+}
+
+func TestWhitelistSourceRangeValidation(t *testing.T) {
+	for value, expected := range map[string][]string{
+		"1.1.1.1/32":                   {"1.1.1.1/32"},
+		"10.0.0.0/24, 172.10.0.1":      {"10.0.0.0/24", "172.10.0.1/32"},
+		"10.0.0.0/24,192.168.0.300/16": {},
+		"www":                          {},
+	} {
+		to := ReadConfig(map[string]string{
+			"whitelist-source-range": value,
+		})
+		if !reflect.DeepEqual(to.WhitelistSourceRange, expected) {
+			t.Errorf("expected %v as whitelist-source-range for '%v' but %v returned", expected, value, to.WhitelistSourceRange)
+		}
+	}
+}
+
+func TestLimitWhitelistValidation(t *testing.T) {
+	for value, expected := range map[string][]string{
+		"10.0.0.0/8":              {"10.0.0.0/8"},
+		"192.168.0.1, 10.0.0.0/8": {"10.0.0.0/8", "192.168.0.1/32"},
+		"10.0.0.0/33":             {},
+	} {
+		to := ReadConfig(map[string]string{
+			"limit-whitelist": value,
+		})
+		if !reflect.DeepEqual(to.LimitWhitelist, expected) {
+			t.Errorf("expected %v as limit-whitelist for '%v' but %v returned", expected, value, to.LimitWhitelist)
+		}
+	}
+}
+
+func TestGlobalRateLimitValidation(t *testing.T) {
+	def := config.NewDefault()
+
+	to := ReadConfig(map[string]string{
+		"global-rate-limit-memcached-host":            "memcached.kube-system.svc.cluster.local",
+		"global-rate-limit-memcached-port":            "11212",
+		"global-rate-limit-memcached-connect-timeout": "100",
+		"global-rate-limit-memcached-pool-size":       "10",
+		"global-rate-limit-status-code":               "503",
+	})
+	if to.GlobalRateLimitMemcachedHost != "memcached.kube-system.svc.cluster.local" {
+		t.Errorf("unexpected global-rate-limit-memcached-host %v", to.GlobalRateLimitMemcachedHost)
+	}
+	if to.GlobalRateLimitMemcachedPort != 11212 {
+		t.Errorf("expected 11212 as global-rate-limit-memcached-port but %v returned", to.GlobalRateLimitMemcachedPort)
+	}
+	if to.GlobalRateLimitMemcachedConnectTimeout != 100 {
+		t.Errorf("expected 100 as global-rate-limit-memcached-connect-timeout but %v returned", to.GlobalRateLimitMemcachedConnectTimeout)
+	}
+	if to.GlobalRateLimitMemcachedPoolSize != 10 {
+		t.Errorf("expected 10 as global-rate-limit-memcached-pool-size but %v returned", to.GlobalRateLimitMemcachedPoolSize)
+	}
+	if to.GlobalRateLimitStatusCode != 503 {
+		t.Errorf("expected 503 as global-rate-limit-status-code but %v returned", to.GlobalRateLimitStatusCode)
+	}
+
+	to = ReadConfig(map[string]string{
+		"global-rate-limit-memcached-host":             `memcached"; lua`,
+		"global-rate-limit-memcached-port":             "70000",
+		"global-rate-limit-memcached-connect-timeout":  "0",
+		"global-rate-limit-memcached-max-idle-timeout": "-1",
+		"global-rate-limit-memcached-pool-size":        "0",
+		"global-rate-limit-status-code":                "200",
+	})
+	if to.GlobalRateLimitMemcachedHost != "" {
+		t.Errorf("expected an empty global-rate-limit-memcached-host but %v returned", to.GlobalRateLimitMemcachedHost)
+	}
+	if to.GlobalRateLimitMemcachedPort != def.GlobalRateLimitMemcachedPort {
+		t.Errorf("expected the default global-rate-limit-memcached-port but %v returned", to.GlobalRateLimitMemcachedPort)
+	}
+	if to.GlobalRateLimitMemcachedConnectTimeout != def.GlobalRateLimitMemcachedConnectTimeout {
+		t.Errorf("expected the default global-rate-limit-memcached-connect-timeout but %v returned", to.GlobalRateLimitMemcachedConnectTimeout)
+	}
+	if to.GlobalRateLimitMemcachedMaxIdleTimeout != def.GlobalRateLimitMemcachedMaxIdleTimeout {
+		t.Errorf("expected the default global-rate-limit-memcached-max-idle-timeout but %v returned", to.GlobalRateLimitMemcachedMaxIdleTimeout)
+	}
+	if to.GlobalRateLimitMemcachedPoolSize != def.GlobalRateLimitMemcachedPoolSize {
+		t.Errorf("expected the default global-rate-limit-memcached-pool-size but %v returned", to.GlobalRateLimitMemcachedPoolSize)
+	}
+	if to.GlobalRateLimitStatusCode != def.GlobalRateLimitStatusCode {
+		t.Errorf("expected the default global-rate-limit-status-code but %v returned", to.GlobalRateLimitStatusCode)
+	}
+}
+
+func TestClientBodyValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"client-body-buffer-size":  "1m",
+		"client-body-in-file-only": "clean",
+		"client-body-temp-path":    "/tmp/nginx/client-body",
+	})
+	if to.ClientBodyBufferSize != "1m" || to.ClientBodyInFileOnly != "clean" || to.ClientBodyTempPath != "/tmp/nginx/client-body" {
+		t.Errorf("expected 1m, clean and /tmp/nginx/client-body but returned %v, %v and %v",
+			to.ClientBodyBufferSize, to.ClientBodyInFileOnly, to.ClientBodyTempPath)
+	}
+
+	to = ReadConfig(map[string]string{
+		"client-body-buffer-size":  "1 MB",
+		"client-body-in-file-only": "always",
+		"client-body-temp-path":    "tmp/client-body; include /etc/passwd",
+	})
+	def := config.NewDefault()
+	if to.ClientBodyBufferSize != def.ClientBodyBufferSize ||
+		to.ClientBodyInFileOnly != def.ClientBodyInFileOnly ||
+		to.ClientBodyTempPath != "" {
+		t.Errorf("expected default values but returned %v, %v and %v",
+			to.ClientBodyBufferSize, to.ClientBodyInFileOnly, to.ClientBodyTempPath)
+	}
+}
+
+func TestWorkerShutdownTimeout(t *testing.T) {
+	to := ReadConfig(map[string]string{"worker-shutdown-timeout": "5m"})
+	if to.WorkerShutdownTimeout != "5m" {
+		t.Errorf("expected 5m but returned %v", to.WorkerShutdownTimeout)
+	}
+
+	to = ReadConfig(map[string]string{"worker-shutdown-timeout": "5 minutes"})
+	if to.WorkerShutdownTimeout != config.NewDefault().WorkerShutdownTimeout {
+		t.Errorf("expected the default value with an invalid timeout but returned %v", to.WorkerShutdownTimeout)
+	}
+
+	to = ReadConfig(map[string]string{"worker-shutdown-timeout": ""})
+	if to.WorkerShutdownTimeout != "" {
+		t.Errorf("expected no timeout but returned %v", to.WorkerShutdownTimeout)
+	}
+}
+
+func TestLogFormatValidation(t *testing.T) {
+	def := config.NewDefault()
+
+	to := ReadConfig(map[string]string{
+		"log-format-upstream": `{ "time": "$time_iso8601", "agent": "$http_user_agent", "upstream": "${proxy_upstream_name}" }`,
+		"log-format-stream":   `$remote_addr [$time_local] $protocol $status $upstream_addr`,
+	})
+	if to.LogFormatUpstream == def.LogFormatUpstream || to.LogFormatStream == def.LogFormatStream {
+		t.Errorf("expected the custom log formats but returned %v and %v", to.LogFormatUpstream, to.LogFormatStream)
+	}
+
+	invalid := []map[string]string{
+		{"log-format-upstream": `$remote_addr $unknown_variable`},
+		{"log-format-upstream": `'$remote_addr'`},
+		{"log-format-upstream": `$remote_addr ${}`},
+		{"log-format-upstream": ``},
+		{"log-format-stream": `$remote_addr $request_uri`},
+		{"log-format-stream": `$remote_addr; access_log off`},
+	}
+	for _, conf := range invalid {
+		to := ReadConfig(conf)
+		if to.LogFormatUpstream != def.LogFormatUpstream || to.LogFormatStream != def.LogFormatStream {
+			t.Errorf("expected the default log formats with %v but returned %v and %v", conf, to.LogFormatUpstream, to.LogFormatStream)
+		}
+	}
+}
+
+func TestValidateLogFormat(t *testing.T) {
+	if err := validateLogFormat(config.NewDefault().LogFormatUpstream, httpLogVariables, httpLogVariablePrefixes, true); err != nil {
+		t.Errorf("unexpected error validating the default log format: %v", err)
+	}
+	if err := validateLogFormat(config.NewDefault().LogFormatStream, streamLogVariables, nil, false); err != nil {
+		t.Errorf("unexpected error validating the default stream log format: %v", err)
+	}
+	if err := validateLogFormat(`$http_`, httpLogVariables, httpLogVariablePrefixes, true); err == nil {
+		t.Errorf("expected an error with a prefix without name")
+	}
+}
+
+func TestSyslogValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"enable-syslog": "true",
+		"syslog-host":   "syslog.example.com",
+		"syslog-port":   "1514",
+	})
+	if !to.EnableSyslog || to.SyslogServer() != "syslog:server=syslog.example.com:1514" {
+		t.Errorf("expected syslog enabled with syslog.example.com:1514 but returned %v (%v)", to.EnableSyslog, to.SyslogServer())
+	}
+
+	to = ReadConfig(map[string]string{
+		"enable-syslog": "true",
+		"syslog-host":   "fd00::1",
+	})
+	if !to.EnableSyslog || to.SyslogServer() != "syslog:server=[fd00::1]:514" {
+		t.Errorf("expected syslog enabled with [fd00::1]:514 but returned %v (%v)", to.EnableSyslog, to.SyslogServer())
+	}
+
+	to = ReadConfig(map[string]string{"enable-syslog": "true"})
+	if to.EnableSyslog {
+		t.Errorf("expected syslog disabled without a host")
+	}
+
+	to = ReadConfig(map[string]string{"enable-syslog": "true", "syslog-host": "10.0.0.1;"})
+	if to.EnableSyslog {
+		t.Errorf("expected syslog disabled with an invalid host")
+	}
+
+	to = ReadConfig(map[string]string{"syslog-port": "70000"})
+	if to.SyslogPort != config.NewDefault().SyslogPort {
+		t.Errorf("expected the default port with an invalid value but returned %v", to.SyslogPort)
+	}
+}
+
+func TestOpentracingValidation(t *testing.T) {
+	to := ReadConfig(map[string]string{
+		"enable-opentracing":         "true",
+		"opentracing-tracer":         "jaeger",
+		"opentracing-collector-host": "jaeger-agent.tracing",
+		"opentracing-sample-rate":    "0.25",
+	})
+	if !to.EnableOpentracing || to.OpentracingTracer != "jaeger" || to.OpentracingSampleRate != 0.25 {
+		t.Errorf("expected opentracing enabled using jaeger with a sample rate of 0.25 but returned %v, %v and %v",
+			to.EnableOpentracing, to.OpentracingTracer, to.OpentracingSampleRate)
+	}
+	if to.OpentracingCollectorAddress() != "jaeger-agent.tracing:6831" {
+		t.Errorf("expected the default port of jaeger but returned %v", to.OpentracingCollectorAddress())
+	}
+
+	to = ReadConfig(map[string]string{"enable-opentracing": "true"})
+	if to.EnableOpentracing {
+		t.Errorf("expected opentracing disabled without a collector")
+	}
+
+	def := config.NewDefault()
+	to = ReadConfig(map[string]string{
+		"opentracing-tracer":         "lightstep",
+		"opentracing-collector-port": "-1",
+		"opentracing-sample-rate":    "2",
+	})
+	if to.OpentracingTracer != def.OpentracingTracer || to.OpentracingCollectorPort != def.OpentracingCollectorPort ||
+		to.OpentracingSampleRate != def.OpentracingSampleRate {
+		t.Errorf("expected the default values but returned %v, %v and %v",
+			to.OpentracingTracer, to.OpentracingCollectorPort, to.OpentracingSampleRate)
+	}
+}
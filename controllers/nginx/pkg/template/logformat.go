@@ -0,0 +1,139 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// variable of NGINX in a log format, like $status or ${status}
+	logVariableRegex = regexp.MustCompile(`\$(\{([a-zA-Z0-9_]*)\}|[a-zA-Z0-9_]*)`)
+
+	// variables available in the access log of the http block
+	// http://nginx.org/en/docs/varindex.html
+	httpLogVariables = toSet(
+		// ngx_http_core_module
+		"args", "binary_remote_addr", "body_bytes_sent", "bytes_sent", "connection",
+		"connection_requests", "content_length", "content_type", "document_root",
+		"document_uri", "host", "hostname", "https", "is_args", "limit_rate", "msec",
+		"nginx_version", "pid", "pipe", "proxy_protocol_addr", "proxy_protocol_port",
+		"query_string", "realpath_root", "remote_addr", "remote_port", "remote_user",
+		"request", "request_body", "request_body_file", "request_completion",
+		"request_filename", "request_id", "request_length", "request_method",
+		"request_time", "request_uri", "scheme", "server_addr", "server_name",
+		"server_port", "server_protocol", "status", "tcpinfo_rtt", "tcpinfo_rttvar",
+		"tcpinfo_snd_cwnd", "tcpinfo_rcv_space", "time_iso8601", "time_local", "uri",
+		// ngx_http_upstream_module
+		"upstream_addr", "upstream_bytes_received", "upstream_cache_status",
+		"upstream_connect_time", "upstream_header_time", "upstream_response_length",
+		"upstream_response_time", "upstream_status",
+		// ngx_http_proxy_module
+		"proxy_add_x_forwarded_for", "proxy_host", "proxy_port",
+		// ngx_http_ssl_module
+		"ssl_cipher", "ssl_ciphers", "ssl_client_cert", "ssl_client_escaped_cert",
+		"ssl_client_fingerprint", "ssl_client_i_dn", "ssl_client_raw_cert",
+		"ssl_client_s_dn", "ssl_client_serial", "ssl_client_v_end", "ssl_client_v_remain",
+		"ssl_client_v_start", "ssl_client_verify", "ssl_curves", "ssl_protocol",
+		"ssl_server_name", "ssl_session_id", "ssl_session_reused",
+		// ngx_http_realip_module and ngx_http_gzip_module
+		"realip_remote_addr", "realip_remote_port", "gzip_ratio",
+		// variables defined in the template
+		"the_real_ip", "proxy_upstream_name", "pass_access_scheme", "pass_server_port",
+		"pass_port", "best_http_host", "this_host", "connection_upgrade", "loggable",
+		"req_id",
+	)
+
+	// prefixes of the variables with the name of a header, cookie or argument
+	httpLogVariablePrefixes = []string{
+		"arg_", "cookie_", "http_", "sent_http_", "sent_trailer_",
+		"upstream_cookie_", "upstream_http_", "upstream_trailer_", "geoip_",
+	}
+
+	// variables available in the access log of the stream block
+	// http://nginx.org/en/docs/stream/ngx_stream_core_module.html#variables
+	streamLogVariables = toSet(
+		"binary_remote_addr", "bytes_received", "bytes_sent", "connection", "hostname",
+		"msec", "nginx_version", "pid", "protocol", "proxy_protocol_addr",
+		"proxy_protocol_port", "remote_addr", "remote_port", "server_addr",
+		"server_port", "session_time", "status", "time_iso8601", "time_local",
+		// ngx_stream_upstream_module
+		"upstream_addr", "upstream_bytes_received", "upstream_bytes_sent",
+		"upstream_connect_time", "upstream_first_byte_time", "upstream_session_time",
+		// ngx_stream_ssl_module and ngx_stream_realip_module
+		"ssl_cipher", "ssl_ciphers", "ssl_client_cert", "ssl_client_fingerprint",
+		"ssl_client_i_dn", "ssl_client_raw_cert", "ssl_client_s_dn", "ssl_client_serial",
+		"ssl_client_v_end", "ssl_client_v_remain", "ssl_client_v_start",
+		"ssl_client_verify", "ssl_curves", "ssl_protocol", "ssl_server_name",
+		"ssl_session_id", "ssl_session_reused", "realip_remote_addr", "realip_remote_port",
+	)
+)
+
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// validateLogFormat checks the variables used in the log format exist.
+// The format of the http block is enclosed in single quotes in the
+// template and cannot contain them. The format of the stream block is
+// not quoted and cannot contain the end of the directive or a block
+func validateLogFormat(format string, variables map[string]bool, prefixes []string, quoted bool) error {
+	if strings.TrimSpace(format) == "" {
+		return fmt.Errorf("the log format is empty")
+	}
+	if quoted && strings.Contains(format, "'") {
+		return fmt.Errorf("the log format cannot contain single quotes")
+	}
+	if !quoted && strings.ContainsAny(logVariableRegex.ReplaceAllString(format, ""), ";{}") {
+		return fmt.Errorf("the log format cannot contain the characters ';', '{' or '}'")
+	}
+
+	for _, match := range logVariableRegex.FindAllStringSubmatch(format, -1) {
+		name := match[1]
+		if strings.HasPrefix(name, "{") {
+			name = match[2]
+		}
+		if name == "" {
+			return fmt.Errorf("invalid variable in the log format: %v", match[0])
+		}
+		if !isKnownLogVariable(name, variables, prefixes) {
+			return fmt.Errorf("unknown variable $%v in the log format", name)
+		}
+	}
+
+	return nil
+}
+
+func isKnownLogVariable(name string, variables map[string]bool, prefixes []string) bool {
+	if variables[name] {
+		return true
+	}
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -22,8 +22,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	text_template "text/template"
 
@@ -34,6 +37,11 @@ import (
 	"github.com/pborman/uuid"
 	"k8s.io/ingress/controllers/nginx/pkg/config"
 	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
+	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/ingress/annotations/satisfy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/wwwredirect"
 	ing_net "k8s.io/ingress/core/pkg/net"
 	"k8s.io/ingress/core/pkg/watch"
 )
@@ -41,8 +49,38 @@ import (
 const (
 	slash         = "/"
 	defBufferSize = 65535
+
+	// names of the zones used by the global limits. The names cannot
+	// collide with the zones of the Ingress annotations (<namespace>_<name>_<type>)
+	globalLimitConnZone = "global-limit-conn"
+	globalLimitRPSZone  = "global-limit-rps"
+
+	// name of the template with the upstreams and the comments
+	// used in the configuration file to delimit the section
+	upstreamsTemplate = "UPSTREAMS"
+	upstreamsBegin    = "# begin of the upstreams"
+	upstreamsEnd      = "# end of the upstreams"
+
+	// defaultBackendUpstream is the upstream of the default backend. The
+	// servers of this upstream are used by the custom error pages (Lua)
+	defaultBackendUpstream = "upstream-default-backend"
 )
 
+// Renderer produces the NGINX configuration file from the
+// configuration obtained from Ingress rules
+type Renderer interface {
+	// Render returns the content of the NGINX configuration file.
+	// The content is validated with testFn before being returned.
+	Render(config.TemplateConfig, func([]byte) error) ([]byte, error)
+}
+
+// UpstreamRenderer renders only the upstreams of the configuration
+type UpstreamRenderer interface {
+	// RenderUpstreams replaces the upstreams of a configuration file
+	// produced by Render. The content is validated with testFn.
+	RenderUpstreams([]byte, config.TemplateConfig, func([]byte) error) ([]byte, error)
+}
+
 // Template ...
 type Template struct {
 	tmpl      *text_template.Template
@@ -106,17 +144,98 @@ func (t *Template) Write(conf config.TemplateConfig) ([]byte, error) {
 		return nil, err
 	}
 
-	// squeezes multiple adjacent empty lines to be single
-	// spaced this is to avoid the use of regular expressions
+	return cleanConf(t.tmplBuf, t.outCmdBuf), nil
+}
+
+// cleanConf squeezes multiple adjacent empty lines to be single
+// spaced this is to avoid the use of regular expressions
+func cleanConf(in, out *bytes.Buffer) []byte {
 	cmd := exec.Command("/ingress-controller/clean-nginx-conf.sh")
-	cmd.Stdin = t.tmplBuf
-	cmd.Stdout = t.outCmdBuf
+	cmd.Stdin = in
+	cmd.Stdout = out
 	if err := cmd.Run(); err != nil {
 		glog.Warningf("unexpected error cleaning template: %v", err)
-		return t.tmplBuf.Bytes(), nil
+		return in.Bytes()
+	}
+
+	return out.Bytes()
+}
+
+// RenderUpstreams replaces the upstreams in content, a configuration file
+// rendered by the same template, with the upstreams of the backends in conf.
+// Used when only the endpoints of the backends changed to avoid the
+// rendering of the complete template. The result is validated with testFn
+func (t *Template) RenderUpstreams(content []byte, conf config.TemplateConfig, testFn func([]byte) error) ([]byte, error) {
+	var buf, out bytes.Buffer
+	err := t.tmpl.ExecuteTemplate(&buf, upstreamsTemplate, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := replaceSection(content, cleanConf(&buf, &out), upstreamsBegin, upstreamsEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	err = testFn(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// replaceSection replaces the lines between the begin and end markers
+// (included) in content with the same lines of section
+func replaceSection(content, section []byte, begin, end string) ([]byte, error) {
+	cb, ce, err := findSection(content, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	sb, se, err := findSection(section, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid section: %v", err)
+	}
+
+	res := make([]byte, 0, len(content)-(ce-cb)+(se-sb))
+	res = append(res, content[:cb]...)
+	res = append(res, section[sb:se]...)
+	res = append(res, content[ce:]...)
+
+	return res, nil
+}
+
+// findSection returns the position of the begin marker and the
+// position after the end marker. Each marker must appear once
+func findSection(content []byte, begin, end string) (int, int, error) {
+	if bytes.Count(content, []byte(begin)) != 1 || bytes.Count(content, []byte(end)) != 1 {
+		return 0, 0, fmt.Errorf("expected one %q and one %q", begin, end)
+	}
+
+	b := bytes.Index(content, []byte(begin))
+	e := bytes.Index(content, []byte(end)) + len(end)
+	if e < b {
+		return 0, 0, fmt.Errorf("%q found before %q", end, begin)
 	}
 
-	return t.outCmdBuf.Bytes(), nil
+	return b, e, nil
+}
+
+// Render populates the template with the NGINX configuration and
+// validates the result using testFn
+func (t *Template) Render(conf config.TemplateConfig, testFn func([]byte) error) ([]byte, error) {
+	content, err := t.Write(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = testFn(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
 }
 
 var (
@@ -128,25 +247,47 @@ var (
 			}
 			return true
 		},
-		"buildLocation":            buildLocation,
-		"buildAuthLocation":        buildAuthLocation,
-		"buildAuthResponseHeaders": buildAuthResponseHeaders,
-		"buildProxyPass":           buildProxyPass,
-		"buildRateLimitZones":      buildRateLimitZones,
-		"buildRateLimit":           buildRateLimit,
-		"buildResolvers":           buildResolvers,
-		"buildUpstreamName":        buildUpstreamName,
-		"isLocationAllowed":        isLocationAllowed,
-		"buildLogFormatUpstream":   buildLogFormatUpstream,
-		"buildDenyVariable":        buildDenyVariable,
-		"getenv":                   os.Getenv,
-		"contains":                 strings.Contains,
-		"hasPrefix":                strings.HasPrefix,
-		"hasSuffix":                strings.HasSuffix,
-		"toUpper":                  strings.ToUpper,
-		"toLower":                  strings.ToLower,
-		"formatIP":                 formatIP,
-		"buildNextUpstream":        buildNextUpstream,
+		"buildLocation":             buildLocation,
+		"buildAuthLocation":         buildAuthLocation,
+		"buildMirrorLocation":       buildMirrorLocation,
+		"buildCustomErrors":         buildCustomErrors,
+		"hasProxyCacheZone":         hasProxyCacheZone,
+		"buildRedirectFromToWWW":    wwwredirect.Alias,
+		"buildAuthResponseHeaders":  buildAuthResponseHeaders,
+		"buildAuthSignURL":          buildAuthSignURL,
+		"buildProxyPass":            buildProxyPass,
+		"buildProxySSL":             buildProxySSL,
+		"buildBackendProtocol":      buildBackendProtocol,
+		"buildProxyRedirect":        buildProxyRedirect,
+		"buildRateLimitZones":       buildRateLimitZones,
+		"buildRateLimit":            buildRateLimit,
+		"buildRateLimitKey":         buildRateLimitKey,
+		"buildGlobalRateLimitZones": buildGlobalRateLimitZones,
+		"buildGlobalRateLimit":      buildGlobalRateLimit,
+		"buildResolvers":            buildResolvers,
+		"buildUpstreamName":         buildUpstreamName,
+		"isLocationAllowed":         isLocationAllowed,
+		"buildLogFormatUpstream":    buildLogFormatUpstream,
+		"buildDenyVariable":         buildDenyVariable,
+		"getenv":                    os.Getenv,
+		"contains":                  strings.Contains,
+		"hasPrefix":                 strings.HasPrefix,
+		"hasSuffix":                 strings.HasSuffix,
+		"toUpper":                   strings.ToUpper,
+		"toLower":                   strings.ToLower,
+		"formatIP":                  formatIP,
+		"buildNextUpstream":         buildNextUpstream,
+		"buildRedirectMaps":         buildRedirectMaps,
+		"buildStreamTimeout":        buildStreamTimeout,
+		"buildSSLRedirect":          buildSSLRedirect,
+		"buildHSTS":                 buildHSTS,
+		"buildAppRoot":              buildAppRoot,
+		"buildHealthCheckModule":    buildHealthCheckModule,
+		"buildAccessList":           buildAccessList,
+		"buildLocationAccessList":   buildLocationAccessList,
+		"isWhitelistChecked":        isWhitelistChecked,
+		"isDynamicUpstream":         IsDynamicUpstream,
+		"upstreamAlgorithm":         UpstreamAlgorithm,
 	}
 )
 
@@ -195,6 +336,10 @@ func buildLocation(input interface{}) string {
 
 	path := location.Path
 	if len(location.Redirect.Target) > 0 && location.Redirect.Target != path {
+		if rewrite.UsesCaptureGroups(location.Redirect.Target) {
+			// the path is a regular expression with the capture groups
+			return fmt.Sprintf(`~* "^%s"`, path)
+		}
 		if path == slash {
 			return fmt.Sprintf("~* %s", path)
 		}
@@ -226,6 +371,88 @@ func buildAuthLocation(input interface{}) string {
 	return fmt.Sprintf("/_external-auth-%v", str)
 }
 
+// hasProxyCacheZone checks the cache zone used in a location is
+// defined in the configuration. The cache is disabled in the
+// locations using an unknown zone
+func hasProxyCacheZone(input interface{}, zone string) bool {
+	zones, ok := input.([]config.CacheZone)
+	if !ok {
+		glog.Errorf("expected a '[]config.CacheZone' type but %T was returned", input)
+		return false
+	}
+
+	if zone == "" {
+		return false
+	}
+
+	for _, z := range zones {
+		if z.Name == zone {
+			return true
+		}
+	}
+
+	glog.Warningf("cache zone %v is not defined in proxy-cache-zones, the cache is disabled", zone)
+	return false
+}
+
+// buildMirrorLocation returns the path of the internal location
+// that sends a copy of the requests of the location to the mirror
+func buildMirrorLocation(input interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		return ""
+	}
+
+	if location.Mirror.Target == "" {
+		return ""
+	}
+
+	str := base64.URLEncoding.EncodeToString([]byte(location.Path))
+	// avoid locations containing the = char
+	str = strings.Replace(str, "=", "", -1)
+	return fmt.Sprintf("/_mirror-%v", str)
+}
+
+// customErrors contains the status codes with custom error pages
+// and the upstream that returns the pages
+type customErrors struct {
+	Codes    []int
+	Upstream string
+}
+
+// buildCustomErrors returns the custom error pages of a server (nil for
+// the default server), obtained from the default backend of the server
+// (annotation default-backend) or the default backend of the controller.
+// The codes of the locations (annotation custom-http-errors) are added to
+// the global codes
+func buildCustomErrors(s interface{}, codes []int) customErrors {
+	res := customErrors{
+		Codes:    append([]int{}, codes...),
+		Upstream: defaultBackendUpstream,
+	}
+
+	server, ok := s.(*ingress.Server)
+	if !ok || server == nil {
+		return res
+	}
+
+	if server.DefaultBackend != "" {
+		res.Upstream = server.DefaultBackend
+	}
+
+	seen := sets.NewInt(codes...)
+	for _, location := range server.Locations {
+		for _, code := range location.CustomHTTPErrors {
+			if !seen.Has(code) {
+				seen.Insert(code)
+				res.Codes = append(res.Codes, code)
+			}
+		}
+	}
+
+	return res
+}
+
 func buildAuthResponseHeaders(input interface{}) []string {
 	location, ok := input.(*ingress.Location)
 	res := []string{}
@@ -246,6 +473,28 @@ func buildAuthResponseHeaders(input interface{}) []string {
 	return res
 }
 
+// buildAuthSignURL returns the URL where the requests rejected by the
+// external authentication are redirected, with the original URL of the
+// request in the parameter rd unless the annotation already defines it
+func buildAuthSignURL(input interface{}) string {
+	signin, ok := input.(string)
+	if !ok {
+		glog.Errorf("expected a string type but %T was returned", input)
+		return ""
+	}
+
+	u, err := url.Parse(signin)
+	if err != nil || u.Query().Get("rd") != "" {
+		return signin
+	}
+
+	sep := "?"
+	if u.RawQuery != "" {
+		sep = "&"
+	}
+	return fmt.Sprintf("%v%vrd=$pass_access_scheme://$http_host$escaped_request_uri", signin, sep)
+}
+
 func buildLogFormatUpstream(input interface{}) string {
 	cfg, ok := input.(config.Configuration)
 	if !ok {
@@ -267,13 +516,13 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 	}
 
 	path := location.Path
-	proto := "http"
+	secure := false
 
 	upstreamName := location.Backend
 	for _, backend := range backends {
 		if backend.Name == location.Backend {
 			if backend.Secure || backend.SSLPassthrough {
-				proto = "https"
+				secure = true
 			}
 
 			if isSticky(host, location, backend.SessionAffinity.CookieSessionAffinity.Locations) {
@@ -284,13 +533,28 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 		}
 	}
 
+	backendName := location.Backend
+	if location.Canary.Backend != "" {
+		// the upstream is selected in each request (canary.lua)
+		upstreamName = "$proxy_upstream_name"
+		backendName = upstreamName
+	}
+
 	// defProxyPass returns the default proxy_pass, just the name of the upstream
-	defProxyPass := fmt.Sprintf("proxy_pass %s://%s;", proto, upstreamName)
+	defProxyPass := buildPass(location.BackendProtocol, secure, upstreamName)
 	// if the path in the ingress rule is equals to the target: no special rewrite
 	if path == location.Redirect.Target {
 		return defProxyPass
 	}
 
+	if rewrite.UsesCaptureGroups(location.Redirect.Target) {
+		// the target references the capture groups of the path
+		return fmt.Sprintf(`
+	rewrite "(?i)^%s" %s break;
+	%s
+	`, path, location.Redirect.Target, defProxyPass)
+	}
+
 	if path != slash && !strings.HasSuffix(path, slash) {
 		path = fmt.Sprintf("%s/", path)
 	}
@@ -311,20 +575,159 @@ func buildProxyPass(host string, b interface{}, loc interface{}) string {
 			return fmt.Sprintf(`
 	rewrite %s(.*) /$1 break;
 	rewrite %s / break;
-	proxy_pass %s://%s;
-	%v`, path, location.Path, proto, location.Backend, abu)
+	%s
+	%v`, path, location.Path, buildPass(location.BackendProtocol, secure, backendName), abu)
 		}
 
 		return fmt.Sprintf(`
 	rewrite %s(.*) %s/$1 break;
-	proxy_pass %s://%s;
-	%v`, path, location.Redirect.Target, proto, location.Backend, abu)
+	%s
+	%v`, path, location.Redirect.Target, buildPass(location.BackendProtocol, secure, backendName), abu)
 	}
 
 	// default proxy_pass
 	return defProxyPass
 }
 
+// buildPass returns the directive used to send the requests to the
+// upstream with the protocol defined in the backend-protocol annotation.
+// The secure backends use the TLS version of the protocol
+func buildPass(protocol string, secure bool, upstream string) string {
+	switch protocol {
+	case backendprotocol.GRPC, backendprotocol.GRPCS:
+		if secure || protocol == backendprotocol.GRPCS {
+			return fmt.Sprintf("grpc_pass grpcs://%s;", upstream)
+		}
+		return fmt.Sprintf("grpc_pass grpc://%s;", upstream)
+	case backendprotocol.FCGI:
+		return fmt.Sprintf("fastcgi_pass %s;", upstream)
+	case backendprotocol.UWSGI:
+		return fmt.Sprintf("uwsgi_pass %s;", upstream)
+	case backendprotocol.AJP:
+		return fmt.Sprintf("ajp_pass %s;", upstream)
+	}
+
+	if secure {
+		return fmt.Sprintf("proxy_pass https://%s;", upstream)
+	}
+	return fmt.Sprintf("proxy_pass http://%s;", upstream)
+}
+
+// buildProxyRedirect returns the proxy_redirect directive of the location.
+// NGINX only accepts it after proxy_pass, so it is empty in the backends
+// that do not use proxy_pass. The value default is also empty, as it is
+// the default behaviour of NGINX and invalid after a proxy_pass with
+// variables (canary)
+func buildProxyRedirect(loc interface{}) string {
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", loc)
+		return ""
+	}
+
+	switch location.BackendProtocol {
+	case "", backendprotocol.HTTP, backendprotocol.HTTPS:
+	default:
+		return ""
+	}
+
+	value := location.Proxy.ProxyRedirect
+	if value == "" || value == "default" {
+		return ""
+	}
+	return fmt.Sprintf("proxy_redirect %v;", value)
+}
+
+// buildBackendProtocol returns the directives required by the backends
+// that do not use HTTP or HTTPS, as the proxy_* directives of the
+// location only apply to proxy_pass: the timeouts of the location, the
+// headers with the address of the client (GRPC) and the default
+// parameters of the protocol (FCGI and UWSGI)
+func buildBackendProtocol(loc interface{}) []string {
+	directives := []string{}
+
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", loc)
+		return directives
+	}
+
+	prefix := ""
+	switch location.BackendProtocol {
+	case backendprotocol.GRPC, backendprotocol.GRPCS:
+		prefix = "grpc"
+		directives = append(directives,
+			"grpc_set_header X-Real-IP $the_real_ip;",
+			"grpc_set_header X-Forwarded-For $the_real_ip;",
+			"grpc_set_header X-Forwarded-Host $best_http_host;",
+			"grpc_set_header X-Forwarded-Proto $pass_access_scheme;")
+	case backendprotocol.FCGI:
+		prefix = "fastcgi"
+		directives = append(directives, "include /etc/nginx/fastcgi_params;")
+	case backendprotocol.UWSGI:
+		prefix = "uwsgi"
+		directives = append(directives, "include /etc/nginx/uwsgi_params;")
+	case backendprotocol.AJP:
+		prefix = "ajp"
+	default:
+		return directives
+	}
+
+	return append(directives,
+		fmt.Sprintf("%v_connect_timeout %vs;", prefix, location.Proxy.ConnectTimeout),
+		fmt.Sprintf("%v_send_timeout %vs;", prefix, location.Proxy.SendTimeout),
+		fmt.Sprintf("%v_read_timeout %vs;", prefix, location.Proxy.ReadTimeout))
+}
+
+// buildProxySSL returns the directives to verify the certificate of a
+// secure backend with the CA of the secure-verify-ca-secret annotation
+// and to send the client certificate of the secure-client-cert-secret
+// annotation. The certificate of the backend must be valid for the name
+// of the service (<name>.<namespace>.svc). The sha of the secrets forces
+// a reload when the content of the files changes
+func buildProxySSL(b interface{}, loc interface{}) []string {
+	backends, ok := b.([]*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an '[]*ingress.Backend' type but %T was returned", b)
+		return []string{}
+	}
+	location, ok := loc.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", loc)
+		return []string{}
+	}
+
+	directives := []string{}
+	for _, backend := range backends {
+		if backend.Name != location.Backend || !backend.Secure {
+			continue
+		}
+
+		if backend.SecureCACert.CAFileName != "" {
+			directives = append(directives,
+				fmt.Sprintf("# PEM sha: %v", backend.SecureCACert.PemSHA),
+				fmt.Sprintf("proxy_ssl_trusted_certificate           %v;", backend.SecureCACert.CAFileName),
+				"proxy_ssl_verify                        on;")
+			if backend.Service != nil {
+				directives = append(directives,
+					fmt.Sprintf("proxy_ssl_name                          %v.%v.svc;", backend.Service.Name, backend.Service.Namespace),
+					"proxy_ssl_server_name                   on;")
+			}
+		}
+
+		if backend.SecureClientCert.PemFileName != "" {
+			directives = append(directives,
+				fmt.Sprintf("# PEM sha: %v", backend.SecureClientCert.PemSHA),
+				fmt.Sprintf("proxy_ssl_certificate                   %v;", backend.SecureClientCert.PemFileName),
+				fmt.Sprintf("proxy_ssl_certificate_key               %v;", backend.SecureClientCert.PemFileName))
+		}
+
+		break
+	}
+
+	return directives
+}
+
 // buildRateLimitZones produces an array of limit_conn_zone in order to allow
 // rate limiting of request. Each Ingress rule could have up to two zones, one
 // for connection limit by IP address and other for limiting request per second
@@ -359,6 +762,17 @@ func buildRateLimitZones(variable string, input interface{}) []string {
 					zones.Insert(zone)
 				}
 			}
+
+			if loc.RateLimit.RPM.Limit > 0 {
+				zone := fmt.Sprintf("limit_req_zone %v zone=%v:%vm rate=%vr/m;",
+					variable,
+					loc.RateLimit.RPM.Name,
+					loc.RateLimit.RPM.SharedSize,
+					loc.RateLimit.RPM.Limit)
+				if !zones.Has(zone) {
+					zones.Insert(zone)
+				}
+			}
 		}
 	}
 
@@ -366,7 +780,7 @@ func buildRateLimitZones(variable string, input interface{}) []string {
 }
 
 // buildRateLimit produces an array of limit_req to be used inside the Path of
-// Ingress rules. The order: connections by IP first and RPS next.
+// Ingress rules. The order: connections by IP first, RPS and RPM next.
 func buildRateLimit(input interface{}) []string {
 	limits := []string{}
 
@@ -387,9 +801,250 @@ func buildRateLimit(input interface{}) []string {
 		limits = append(limits, limit)
 	}
 
+	if loc.RateLimit.RPM.Limit > 0 {
+		limit := fmt.Sprintf("limit_req zone=%v burst=%v nodelay;",
+			loc.RateLimit.RPM.Name, loc.RateLimit.RPM.Burst)
+		limits = append(limits, limit)
+	}
+
+	return limits
+}
+
+// buildRateLimitKey returns the key of the zones of the rate limits
+// defined with the annotations. The key of the addresses in the
+// limit-whitelist is empty and the requests are not accounted
+func buildRateLimitKey(input interface{}) string {
+	cfg, ok := input.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a config.Configuration type but %T was returned", input)
+		return ""
+	}
+
+	if len(cfg.LimitWhitelist) == 0 {
+		return cfg.LimitConnZoneVariable
+	}
+	return "$limit_key"
+}
+
+// buildGlobalRateLimitZones produces the limit_conn_zone and limit_req_zone
+// used by the global limits defined in the configuration. Like the zones of
+// the annotations, the addresses in the limit-whitelist are not accounted
+func buildGlobalRateLimitZones(input interface{}) []string {
+	zones := []string{}
+
+	cfg, ok := input.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a config.Configuration type but %T was returned", input)
+		return zones
+	}
+
+	key := buildRateLimitKey(cfg)
+
+	if cfg.GlobalLimitConnections > 0 {
+		zones = append(zones, fmt.Sprintf("limit_conn_zone %v zone=%v:%v;",
+			key, globalLimitConnZone, cfg.GlobalLimitZoneSize))
+	}
+
+	if cfg.GlobalLimitRPS > 0 {
+		zones = append(zones, fmt.Sprintf("limit_req_zone %v zone=%v:%v rate=%vr/s;",
+			key, globalLimitRPSZone, cfg.GlobalLimitZoneSize, cfg.GlobalLimitRPS))
+	}
+
+	return zones
+}
+
+// buildGlobalRateLimit produces the limit_conn and limit_req of the global
+// limits defined in the configuration. NGINX only inherits these directives
+// when the location does not define its own limits, so the global limits
+// must be rendered in each location before the limits of the Ingress rule.
+func buildGlobalRateLimit(input interface{}) []string {
+	limits := []string{}
+
+	cfg, ok := input.(config.Configuration)
+	if !ok {
+		glog.Errorf("expected a config.Configuration type but %T was returned", input)
+		return limits
+	}
+
+	if cfg.GlobalLimitConnections > 0 {
+		limits = append(limits, fmt.Sprintf("limit_conn %v %v;",
+			globalLimitConnZone, cfg.GlobalLimitConnections))
+	}
+
+	if cfg.GlobalLimitRPS > 0 {
+		limits = append(limits, fmt.Sprintf("limit_req zone=%v burst=%v nodelay;",
+			globalLimitRPSZone, cfg.GlobalLimitBurst))
+	}
+
 	return limits
 }
 
+// buildStreamTimeout returns the timeout defined in a TCP or UDP service
+// or the default value when the service does not define a valid timeout
+func buildStreamTimeout(value, def string) string {
+	if value == "" {
+		return def
+	}
+
+	if !isValidTime(value) {
+		glog.Warningf("%v is not a valid timeout for a stream service, using the default (%v)", value, def)
+		return def
+	}
+
+	return value
+}
+
+// buildSSLRedirect produces the return directive used to redirect the
+// requests of a location to the HTTPS port
+func buildSSLRedirect(input interface{}) string {
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an ingress.Location type but %T was returned", input)
+		return ""
+	}
+
+	code := loc.Redirect.SSLRedirectCode
+	if code == 0 {
+		code = http.StatusMovedPermanently
+	}
+
+	host := "$best_http_host"
+	if loc.Redirect.SSLRedirectHost != "" {
+		host = loc.Redirect.SSLRedirectHost
+	} else if loc.Redirect.SSLRedirectPort != 0 {
+		// $best_http_host can contain the port of the request
+		host = "$host"
+	}
+
+	if loc.Redirect.SSLRedirectPort != 0 && loc.Redirect.SSLRedirectPort != 443 {
+		host = fmt.Sprintf("%v:%v", host, loc.Redirect.SSLRedirectPort)
+	}
+
+	return fmt.Sprintf("return %v https://%v$request_uri;", code, host)
+}
+
+// buildHSTS produces the directive that adds the Strict-Transport-Security
+// header to the responses of a server running SSL
+func buildHSTS(input interface{}) string {
+	server, ok := input.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an ingress.Server type but %T was returned", input)
+		return ""
+	}
+
+	if server.SSLCertificate == "" || server.HSTS == nil || !server.HSTS.Enabled {
+		return ""
+	}
+
+	header := fmt.Sprintf("max-age=%v", server.HSTS.MaxAge)
+	if server.HSTS.IncludeSubdomains {
+		header += "; includeSubDomains"
+	}
+	if server.HSTS.Preload {
+		header += "; preload"
+	}
+
+	return fmt.Sprintf("more_set_headers                        \"Strict-Transport-Security: %v\";", header)
+}
+
+// buildAppRoot returns the application root of the server, where the
+// requests for / are redirected. The redirect is defined in the server
+// block, so only the application root of the first location is used
+func buildAppRoot(input interface{}) string {
+	server, ok := input.(*ingress.Server)
+	if !ok {
+		glog.Errorf("expected an ingress.Server type but %T was returned", input)
+		return ""
+	}
+
+	for _, location := range server.Locations {
+		if location.Redirect.AppRoot != "" {
+			return location.Redirect.AppRoot
+		}
+	}
+
+	return ""
+}
+
+var njsInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// buildHealthCheckModule returns the name of the njs module (and the
+// variable) that contains the health check script of a backend.
+// The name of the backend can contain characters not allowed in
+// njs identifiers or NGINX variables
+func buildHealthCheckModule(input interface{}) string {
+	backend, ok := input.(*ingress.Backend)
+	if !ok {
+		glog.Errorf("expected an ingress.Backend type but %T was returned", input)
+		return ""
+	}
+
+	return fmt.Sprintf("hc_%v", njsInvalidChars.ReplaceAllString(backend.Name, "_"))
+}
+
+// buildAccessList returns the allow and deny directives of an access list.
+// The rules are checked in order until the first match: with allowed
+// networks the denied networks are checked first and the rest of the
+// clients are denied. With only denied networks the rest are allowed.
+// The directives use the client address after the realip module
+func buildAccessList(input interface{}) []string {
+	al, ok := input.(ipaccess.AccessList)
+	if !ok {
+		glog.Errorf("expected an ipaccess.AccessList type but %T was returned", input)
+		return []string{}
+	}
+
+	rules := []string{}
+	for _, cidr := range al.DenyCIDRs {
+		rules = append(rules, fmt.Sprintf("deny %v;", cidr))
+	}
+	for _, cidr := range al.AllowCIDRs {
+		rules = append(rules, fmt.Sprintf("allow %v;", cidr))
+	}
+
+	if len(al.AllowCIDRs) > 0 {
+		rules = append(rules, "deny all;")
+	} else if len(al.DenyCIDRs) > 0 {
+		rules = append(rules, "allow all;")
+	}
+
+	return rules
+}
+
+// buildLocationAccessList returns the allow and deny directives of the
+// access list of a location. With auth-satisfy any the whitelist-source-range
+// is also checked by the access module, as the check of the geo variable
+// (isWhitelistChecked) is done before the authentication
+func buildLocationAccessList(input interface{}) []string {
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return []string{}
+	}
+
+	if loc.Satisfy != satisfy.Any || len(loc.Whitelist.CIDR) == 0 {
+		return buildAccessList(loc.AccessList)
+	}
+
+	al := ipaccess.AccessList{
+		AllowCIDRs: append(append([]string{}, loc.AccessList.AllowCIDRs...), loc.Whitelist.CIDR...),
+		DenyCIDRs:  loc.AccessList.DenyCIDRs,
+	}
+	return buildAccessList(al)
+}
+
+// isWhitelistChecked returns true if the whitelist-source-range of
+// the location is checked with a geo variable before the authentication
+func isWhitelistChecked(input interface{}) bool {
+	loc, ok := input.(*ingress.Location)
+	if !ok {
+		glog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return false
+	}
+
+	return len(loc.Whitelist.CIDR) > 0 && loc.Satisfy != satisfy.Any
+}
+
 func isLocationAllowed(input interface{}) bool {
 	loc, ok := input.(*ingress.Location)
 	if !ok {
@@ -471,3 +1126,78 @@ func buildNextUpstream(input interface{}) string {
 
 	return strings.Join(nextUpstreamCodes, " ")
 }
+
+// buildRedirectMaps returns the maps used to obtain the target and code
+// of the redirects defined in the configuration using the request URI.
+// An empty list of redirects returns an empty string.
+func buildRedirectMaps(input interface{}) string {
+	redirects, ok := input.([]config.Redirect)
+	if !ok {
+		glog.Errorf("expected a '[]config.Redirect' type but %T was returned", input)
+		return ""
+	}
+
+	if len(redirects) == 0 {
+		return ""
+	}
+
+	quote := strings.NewReplacer(`"`, `\"`)
+
+	targets := []string{"map $request_uri $redirect_target {", `    default "";`}
+	codes := []string{"map $request_uri $redirect_code {", "    default 0;"}
+	for _, r := range redirects {
+		from := quote.Replace(r.From)
+		targets = append(targets, fmt.Sprintf(`    "%v" "%v";`, from, quote.Replace(r.To)))
+		codes = append(codes, fmt.Sprintf(`    "%v" %v;`, from, r.Code))
+	}
+	targets = append(targets, "}")
+	codes = append(codes, "}")
+
+	return fmt.Sprintf("%v\n%v", strings.Join(targets, "\n"), strings.Join(codes, "\n"))
+}
+
+// UpstreamAlgorithm returns the load balance directive of the backend:
+// a consistent hash (upstream-hash-by), the algorithm defined in the
+// Ingress rule (load-balance) or the algorithm of the configuration
+func UpstreamAlgorithm(backend *ingress.Backend, algorithm string) string {
+	if backend != nil {
+		if backend.UpstreamHashBy != "" {
+			return fmt.Sprintf("hash %v consistent", backend.UpstreamHashBy)
+		}
+		if backend.LoadBalance != "" {
+			return backend.LoadBalance
+		}
+	}
+
+	if algorithm == "" {
+		return "round_robin"
+	}
+	return algorithm
+}
+
+// IsDynamicUpstream returns true if the endpoints of the backend can be
+// updated without a reload of NGINX (balancer.lua). The balancer only
+// implements round robin, so the backends with session affinity, backup
+// endpoints or a different load balance algorithm (see UpstreamAlgorithm)
+// use the endpoints rendered in the configuration file
+func IsDynamicUpstream(backend *ingress.Backend, algorithm string) bool {
+	if backend == nil || backend.Name == defaultBackendUpstream {
+		return false
+	}
+
+	if UpstreamAlgorithm(backend, algorithm) != "round_robin" {
+		return false
+	}
+
+	if backend.SessionAffinity.AffinityType != "" {
+		return false
+	}
+
+	for _, endpoint := range backend.Endpoints {
+		if endpoint.Backup {
+			return false
+		}
+	}
+
+	return true
+}
@@ -18,7 +18,9 @@ package template
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"os/exec"
 	"path"
 	"reflect"
 	"strings"
@@ -26,10 +28,28 @@ import (
 
 	"io/ioutil"
 
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api_v1 "k8s.io/client-go/pkg/api/v1"
+
 	"k8s.io/ingress/controllers/nginx/pkg/config"
 	"k8s.io/ingress/core/pkg/ingress"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authreq"
+	"k8s.io/ingress/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/canary"
+	"k8s.io/ingress/core/pkg/ingress/annotations/cors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/globalratelimit"
+	"k8s.io/ingress/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress/core/pkg/ingress/annotations/mirror"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxycache"
 	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/ingress/annotations/satisfy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/urlredirect"
+	"k8s.io/ingress/core/pkg/ingress/resolver"
 )
 
 var (
@@ -79,6 +99,14 @@ var (
 	subs_filter '<head(.*)>' '<head$1><base href="$scheme://$http_host/end-with-slash/$baseuri">' r;
 	subs_filter '<HEAD(.*)>' '<HEAD$1><base href="$scheme://$http_host/end-with-slash/$baseuri">' r;
 	`, true},
+		"redirect /something to / with capture groups": {"/something(/|$)(.*)", "/$2", `~* "^/something(/|$)(.*)"`, `
+	rewrite "(?i)^/something(/|$)(.*)" /$2 break;
+	proxy_pass http://upstream-name;
+	`, false},
+		"redirect /api/v1 to /v1/api with capture groups": {"/api/(v[0-9]+)/(.*)", "/$1/api/$2", `~* "^/api/(v[0-9]+)/(.*)"`, `
+	rewrite "(?i)^/api/(v[0-9]+)/(.*)" /$1/api/$2 break;
+	proxy_pass http://upstream-name;
+	`, false},
 		"redirect /something-complex to /not-root and rewrite": {"/something-complex", "/not-root", `~* ^/something-complex\/?(?<baseuri>.*)`, `
 	rewrite /something-complex/(.*) /not-root/$1 break;
 	proxy_pass http://upstream-name;
@@ -107,6 +135,79 @@ func TestFormatIP(t *testing.T) {
 	}
 }
 
+// testNginxConfig checks the blocks of the rendered configuration are
+// balanced and, when the NGINX binary (NGINX_BINARY or /usr/sbin/nginx)
+// is available, that the configuration passes nginx -t
+func testNginxConfig(t *testing.T, cfg []byte) {
+	if err := checkBlocks(cfg); err != nil {
+		t.Errorf("invalid NGINX configuration: %v", err)
+		return
+	}
+
+	binary := os.Getenv("NGINX_BINARY")
+	if binary == "" {
+		binary = "/usr/sbin/nginx"
+	}
+	if _, err := os.Stat(binary); err != nil {
+		t.Logf("skipping nginx -t, the NGINX binary %v is not available", binary)
+		return
+	}
+
+	tmpfile, err := ioutil.TempFile("", "nginx-cfg")
+	if err != nil {
+		t.Fatalf("unexpected error creating the configuration file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+	if err := ioutil.WriteFile(tmpfile.Name(), cfg, 0644); err != nil {
+		t.Fatalf("unexpected error writing the configuration file: %v", err)
+	}
+
+	out, err := exec.Command(binary, "-t", "-c", tmpfile.Name()).CombinedOutput()
+	if err != nil {
+		t.Errorf("invalid NGINX configuration: %v\n%s", err, out)
+	}
+}
+
+// checkBlocks returns an error when the braces of the blocks of the
+// configuration are not balanced, ignoring quoted strings and comments
+func checkBlocks(cfg []byte) error {
+	depth, line := 0, 1
+	var quote byte
+	comment := false
+	for i, c := range cfg {
+		switch {
+		case c == '\n':
+			line++
+			comment = false
+		case comment:
+		case quote != 0:
+			if c == quote && cfg[i-1] != '\\' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || cfg[i-1] == ' ' || cfg[i-1] == '\n' || cfg[i-1] == '\t'):
+			comment = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unexpected '}' in line %v", line)
+			}
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("unterminated string")
+	}
+	if depth != 0 {
+		return fmt.Errorf("unexpected end of file, expecting '}'")
+	}
+	return nil
+}
+
 func TestBuildLocation(t *testing.T) {
 	for k, tc := range tmplFuncTestcases {
 		loc := &ingress.Location{
@@ -136,6 +237,221 @@ func TestBuildProxyPass(t *testing.T) {
 	}
 }
 
+func TestBuildProxyPassCanary(t *testing.T) {
+	loc := &ingress.Location{
+		Path:    "/",
+		Backend: "default-stable-80",
+		Canary:  canary.Config{Enabled: true, Weight: 20, Backend: "default-canary-80"},
+	}
+
+	expected := "proxy_pass http://$proxy_upstream_name;"
+	pp := buildProxyPass("", []*ingress.Backend{}, loc)
+	if pp != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, pp)
+	}
+
+	loc.Path = "/something"
+	loc.Redirect = rewrite.Redirect{Target: "/"}
+	pp = buildProxyPass("", []*ingress.Backend{}, loc)
+	if !strings.Contains(pp, expected) {
+		t.Errorf("expected '%v' in '%v'", expected, pp)
+	}
+}
+
+func TestBuildProxyPassBackendProtocol(t *testing.T) {
+	for protocol, expected := range map[string]string{
+		"":                    "proxy_pass http://upstream-name;",
+		backendprotocol.HTTP:  "proxy_pass http://upstream-name;",
+		backendprotocol.HTTPS: "proxy_pass https://upstream-name;",
+		backendprotocol.GRPC:  "grpc_pass grpcs://upstream-name;",
+		backendprotocol.GRPCS: "grpc_pass grpcs://upstream-name;",
+		backendprotocol.FCGI:  "fastcgi_pass upstream-name;",
+		backendprotocol.UWSGI: "uwsgi_pass upstream-name;",
+		backendprotocol.AJP:   "ajp_pass upstream-name;",
+	} {
+		loc := &ingress.Location{Path: "/", Backend: "upstream-name", BackendProtocol: protocol}
+		backend := &ingress.Backend{Name: "upstream-name", Secure: protocol == backendprotocol.HTTPS || protocol == backendprotocol.GRPC}
+		pp := buildProxyPass("", []*ingress.Backend{backend}, loc)
+		if pp != expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", protocol, expected, pp)
+		}
+	}
+
+	loc := &ingress.Location{Path: "/something", Backend: "upstream-name", BackendProtocol: backendprotocol.GRPC}
+	loc.Redirect = rewrite.Redirect{Target: "/"}
+	pp := buildProxyPass("", []*ingress.Backend{}, loc)
+	if !strings.Contains(pp, "grpc_pass grpc://upstream-name;") {
+		t.Errorf("expected grpc_pass in '%v'", pp)
+	}
+}
+
+func TestBuildBackendProtocol(t *testing.T) {
+	for protocol, expected := range map[string][]string{
+		backendprotocol.HTTP: {},
+		backendprotocol.GRPC: {
+			"grpc_set_header X-Real-IP $the_real_ip;",
+			"grpc_set_header X-Forwarded-For $the_real_ip;",
+			"grpc_set_header X-Forwarded-Host $best_http_host;",
+			"grpc_set_header X-Forwarded-Proto $pass_access_scheme;",
+			"grpc_connect_timeout 5s;",
+			"grpc_send_timeout 60s;",
+			"grpc_read_timeout 120s;",
+		},
+		backendprotocol.FCGI: {
+			"include /etc/nginx/fastcgi_params;",
+			"fastcgi_connect_timeout 5s;",
+			"fastcgi_send_timeout 60s;",
+			"fastcgi_read_timeout 120s;",
+		},
+		backendprotocol.AJP: {
+			"ajp_connect_timeout 5s;",
+			"ajp_send_timeout 60s;",
+			"ajp_read_timeout 120s;",
+		},
+	} {
+		loc := &ingress.Location{BackendProtocol: protocol}
+		loc.Proxy.ConnectTimeout = 5
+		loc.Proxy.SendTimeout = 60
+		loc.Proxy.ReadTimeout = 120
+		if directives := buildBackendProtocol(loc); !reflect.DeepEqual(directives, expected) {
+			t.Errorf("%v: expected '%v' but returned '%v'", protocol, expected, directives)
+		}
+	}
+}
+
+func TestTemplateCanary(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Servers[1].Locations[0].Canary = canary.Config{Enabled: true, Weight: 20, Header: "X-Canary", Backend: "default-canary-80"}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	out := string(b)
+	for _, expected := range []string{
+		`return require("canary").route(ngx.var.proxy_upstream_name, "default-canary-80", 20, "X-Canary", "")`,
+		"proxy_pass http://$proxy_upstream_name;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %v in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateMirror(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	loc := dat.Servers[1].Locations[0]
+	loc.Mirror = mirror.Config{Target: "http://test.default.svc.cluster.local$request_uri"}
+	mirrorPath := buildMirrorLocation(loc)
+	if !strings.HasPrefix(mirrorPath, "/_mirror-") {
+		t.Errorf("unexpected mirror location %v", mirrorPath)
+	}
+	if buildMirrorLocation(dat.Servers[1].Locations[1]) != "" {
+		t.Errorf("unexpected mirror location without a target")
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	out := string(b)
+	for _, expected := range []string{
+		fmt.Sprintf("location = %v {", mirrorPath),
+		"proxy_pass                  http://test.default.svc.cluster.local$request_uri;",
+		fmt.Sprintf("mirror                                  %v;", mirrorPath),
+		"mirror_request_body                     off;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %v in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateCustomErrors(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Cfg.CustomHTTPErrors = []int{404, 503}
+	dat.Servers[1].DefaultBackend = "default-errors-80"
+	dat.Servers[1].Locations[0].CustomHTTPErrors = []int{502, 404}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	out := string(b)
+	for _, expected := range []string{
+		`openURL(ngx.req.get_headers(0), 503, "default-errors-80")`,
+		`openURL(ngx.req.get_headers(0), 502, "default-errors-80")`,
+		`openURL(ngx.req.get_headers(0), 404, "upstream-default-backend")`,
+		"error_page 502 = @custom_502;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %v in the configuration", expected)
+		}
+	}
+	if strings.Contains(out, `openURL(ngx.req.get_headers(0), 502, "upstream-default-backend")`) {
+		t.Errorf("unexpected custom error page of a location in other servers")
+	}
+	if len(dat.Cfg.CustomHTTPErrors) != 2 {
+		t.Errorf("unexpected change in the global codes: %v", dat.Cfg.CustomHTTPErrors)
+	}
+}
+
+func TestBuildProxySSL(t *testing.T) {
+	svc := &api_v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "demo", Namespace: "default"}}
+	caCert := resolver.AuthSSLCert{
+		Secret:     "default/backend-ca",
+		CAFileName: "/ingress-controller/ssl/ca-default-backend-ca.pem",
+		PemSHA:     "abc",
+	}
+	clientCert := resolver.AuthSSLCert{
+		Secret:      "default/client",
+		PemFileName: "/ingress-controller/ssl/default-client.pem",
+		PemSHA:      "def",
+	}
+
+	for name, tc := range map[string]struct {
+		backend  *ingress.Backend
+		expected []string
+	}{
+		"insecure backend": {
+			&ingress.Backend{Name: "default-demo-443", Service: svc, SecureCACert: caCert},
+			[]string{},
+		},
+		"secure backend": {
+			&ingress.Backend{Name: "default-demo-443", Service: svc, Secure: true},
+			[]string{},
+		},
+		"secure backend with CA and client certificate": {
+			&ingress.Backend{Name: "default-demo-443", Service: svc, Secure: true, SecureCACert: caCert, SecureClientCert: clientCert},
+			[]string{
+				"# PEM sha: abc",
+				"proxy_ssl_trusted_certificate           /ingress-controller/ssl/ca-default-backend-ca.pem;",
+				"proxy_ssl_verify                        on;",
+				"proxy_ssl_name                          demo.default.svc;",
+				"proxy_ssl_server_name                   on;",
+				"# PEM sha: def",
+				"proxy_ssl_certificate                   /ingress-controller/ssl/default-client.pem;",
+				"proxy_ssl_certificate_key               /ingress-controller/ssl/default-client.pem;",
+			},
+		},
+	} {
+		loc := &ingress.Location{Path: "/", Backend: "default-demo-443"}
+		directives := buildProxySSL([]*ingress.Backend{tc.backend}, loc)
+		if !reflect.DeepEqual(tc.expected, directives) {
+			t.Errorf("%v: expected \n'%v'\nbut returned \n'%v'", name, tc.expected, directives)
+		}
+	}
+}
+
 func TestBuildAuthResponseHeaders(t *testing.T) {
 	loc := &ingress.Location{
 		ExternalAuth: authreq.External{ResponseHeaders: []string{"h1", "H-With-Caps-And-Dashes"}},
@@ -153,6 +469,19 @@ func TestBuildAuthResponseHeaders(t *testing.T) {
 	}
 }
 
+func TestBuildAuthSignURL(t *testing.T) {
+	for signin, expected := range map[string]string{
+		"https://auth.example.com/oauth2/start":          "https://auth.example.com/oauth2/start?rd=$pass_access_scheme://$http_host$escaped_request_uri",
+		"https://auth.example.com/start?provider=github": "https://auth.example.com/start?provider=github&rd=$pass_access_scheme://$http_host$escaped_request_uri",
+		"/oauth2/start": "/oauth2/start?rd=$pass_access_scheme://$http_host$escaped_request_uri",
+		"https://auth.example.com/start?rd=/home": "https://auth.example.com/start?rd=/home",
+	} {
+		if u := buildAuthSignURL(signin); u != expected {
+			t.Errorf("expected %v but %v returned for %v", expected, u, signin)
+		}
+	}
+}
+
 func TestTemplateWithData(t *testing.T) {
 	pwd, _ := os.Getwd()
 	f, err := os.Open(path.Join(pwd, "../../test/data/config.json"))
@@ -225,3 +554,1581 @@ func TestBuildDenyVariable(t *testing.T) {
 		t.Errorf("Expected '%v' but returned '%v'", a, b)
 	}
 }
+
+func TestBuildRedirectMaps(t *testing.T) {
+	maps := buildRedirectMaps([]config.Redirect{})
+	if maps != "" {
+		t.Errorf("expected an empty string but returned '%v'", maps)
+	}
+
+	maps = buildRedirectMaps([]config.Redirect{
+		{From: "/old", To: "https://www.bar.com/new", Code: 301},
+		{From: "/foo?a=b", To: "/bar", Code: 302},
+	})
+	expected := `map $request_uri $redirect_target {
+    default "";
+    "/old" "https://www.bar.com/new";
+    "/foo?a=b" "/bar";
+}
+map $request_uri $redirect_code {
+    default 0;
+    "/old" 301;
+    "/foo?a=b" 302;
+}`
+	if maps != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, maps)
+	}
+}
+
+func TestBuildGlobalRateLimit(t *testing.T) {
+	cfg := config.NewDefault()
+	if zones := buildGlobalRateLimitZones(cfg); len(zones) != 0 {
+		t.Errorf("expected no zones but returned %v", zones)
+	}
+	if limits := buildGlobalRateLimit(cfg); len(limits) != 0 {
+		t.Errorf("expected no limits but returned %v", limits)
+	}
+
+	cfg.GlobalLimitConnections = 100
+	cfg.GlobalLimitRPS = 50
+	cfg.GlobalLimitBurst = 200
+
+	zones := buildGlobalRateLimitZones(cfg)
+	expectedZones := []string{
+		"limit_conn_zone $binary_remote_addr zone=global-limit-conn:10m;",
+		"limit_req_zone $binary_remote_addr zone=global-limit-rps:10m rate=50r/s;",
+	}
+	if !reflect.DeepEqual(expectedZones, zones) {
+		t.Errorf("expected '%v' but returned '%v'", expectedZones, zones)
+	}
+
+	limits := buildGlobalRateLimit(cfg)
+	expectedLimits := []string{
+		"limit_conn global-limit-conn 100;",
+		"limit_req zone=global-limit-rps burst=200 nodelay;",
+	}
+	if !reflect.DeepEqual(expectedLimits, limits) {
+		t.Errorf("expected '%v' but returned '%v'", expectedLimits, limits)
+	}
+
+	cfg.LimitWhitelist = []string{"10.0.0.0/8"}
+	zones = buildGlobalRateLimitZones(cfg)
+	expectedZones = []string{
+		"limit_conn_zone $limit_key zone=global-limit-conn:10m;",
+		"limit_req_zone $limit_key zone=global-limit-rps:10m rate=50r/s;",
+	}
+	if !reflect.DeepEqual(expectedZones, zones) {
+		t.Errorf("expected '%v' but returned '%v'", expectedZones, zones)
+	}
+}
+
+func TestBuildRateLimit(t *testing.T) {
+	loc := &ingress.Location{}
+	loc.RateLimit.RPS.Name = "default_foo_rps"
+	loc.RateLimit.RPS.Limit = 10
+	loc.RateLimit.RPS.Burst = 50
+	loc.RateLimit.RPS.SharedSize = 5
+	loc.RateLimit.RPM.Name = "default_foo_rpm"
+	loc.RateLimit.RPM.Limit = 300
+	loc.RateLimit.RPM.Burst = 900
+	loc.RateLimit.RPM.SharedSize = 5
+
+	zones := buildRateLimitZones("$limit_key", []*ingress.Server{{Locations: []*ingress.Location{loc}}})
+	expectedZones := []string{
+		"limit_req_zone $limit_key zone=default_foo_rpm:5m rate=300r/m;",
+		"limit_req_zone $limit_key zone=default_foo_rps:5m rate=10r/s;",
+	}
+	if !reflect.DeepEqual(expectedZones, zones) {
+		t.Errorf("expected '%v' but returned '%v'", expectedZones, zones)
+	}
+
+	limits := buildRateLimit(loc)
+	expectedLimits := []string{
+		"limit_req zone=default_foo_rps burst=50 nodelay;",
+		"limit_req zone=default_foo_rpm burst=900 nodelay;",
+	}
+	if !reflect.DeepEqual(expectedLimits, limits) {
+		t.Errorf("expected '%v' but returned '%v'", expectedLimits, limits)
+	}
+}
+
+func TestBuildRateLimitKey(t *testing.T) {
+	cfg := config.NewDefault()
+	if key := buildRateLimitKey(cfg); key != "$binary_remote_addr" {
+		t.Errorf("expected $binary_remote_addr but returned %v", key)
+	}
+
+	cfg.LimitWhitelist = []string{"10.0.0.0/8"}
+	if key := buildRateLimitKey(cfg); key != "$limit_key" {
+		t.Errorf("expected $limit_key but returned %v", key)
+	}
+}
+
+// loadTemplateConfig returns the configuration in test/data/config.json
+// and the NGINX template. The caller must close the template.
+func loadTemplateConfig(t *testing.T) (config.TemplateConfig, *Template) {
+	pwd, _ := os.Getwd()
+	data, err := ioutil.ReadFile(path.Join(pwd, "../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := json.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+
+	ngxTpl, err := NewTemplate(path.Join(pwd, "../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	return dat, ngxTpl
+}
+
+func TestTemplateWithGlobalRateLimit(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Cfg.LimitConnZoneVariable = "$binary_remote_addr"
+	dat.Cfg.GlobalLimitConnections = 100
+	dat.Cfg.GlobalLimitRPS = 50
+	dat.Cfg.GlobalLimitZoneSize = "10m"
+	dat.Cfg.GlobalLimitStatusCode = 503
+
+	loc := dat.Servers[0].Locations[0]
+	loc.RateLimit.Connections.Name = "default_foo_conn"
+	loc.RateLimit.Connections.Limit = 5
+	loc.RateLimit.Connections.SharedSize = 5
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, directive := range []string{
+		"limit_conn_status 503;",
+		"limit_req_status 503;",
+		"limit_conn_zone $binary_remote_addr zone=global-limit-conn:10m;",
+		"limit_req_zone $binary_remote_addr zone=global-limit-rps:10m rate=50r/s;",
+		"limit_conn_zone $binary_remote_addr zone=default_foo_conn:5m;",
+	} {
+		if !strings.Contains(out, directive) {
+			t.Errorf("expected the directive '%v' in the configuration", directive)
+		}
+	}
+
+	global := strings.Index(out, "limit_conn global-limit-conn 100;")
+	local := strings.Index(out, "limit_conn default_foo_conn 5;")
+	if global == -1 || local == -1 {
+		t.Fatalf("expected global and location limits in the configuration")
+	}
+	if global > local {
+		t.Errorf("expected the global limits before the limits of the location")
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateDefaultServerAction(t *testing.T) {
+	for action, expected := range map[string]string{
+		"default-backend": "",
+		"404":             "return 404;",
+		"444":             "return 444;",
+	} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.IsIPV6Enabled = true
+		dat.Cfg.DefaultServerAction = action
+		dat.Servers[0].SSLCertificate = "/ingress-controller/ssl/default-fake-certificate.pem"
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		out := string(b)
+
+		for _, code := range []string{"return 404;", "return 444;"} {
+			count := strings.Count(out, code)
+			if code == expected && count != 1 {
+				t.Errorf("expected one '%v' with action %v but returned %v", code, action, count)
+			}
+			if code != expected && count != 0 {
+				t.Errorf("unexpected '%v' with action %v", code, action)
+			}
+		}
+
+		for _, addr := range []string{"80", "[::]:80", "442", "[::]:442"} {
+			count := 0
+			for _, line := range strings.Split(out, "\n") {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "listen "+addr+" ") && strings.Contains(line, "default_server") {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Errorf("expected one default_server listening in %v but returned %v", addr, count)
+			}
+		}
+	}
+}
+
+func TestTemplateProxyRedirect(t *testing.T) {
+	for _, value := range []string{"off", "default", "http://internal.svc.cluster.local:8080/ /"} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		for _, server := range dat.Servers {
+			for _, location := range server.Locations {
+				location.Proxy.ProxyRedirect = value
+			}
+		}
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		if value == "default" {
+			if strings.Contains(string(b), "proxy_redirect ") {
+				t.Errorf("unexpected proxy_redirect in the configuration with the value default")
+			}
+		} else {
+			expected := "proxy_redirect " + value + ";"
+			if strings.Count(string(b), "proxy_redirect ") != strings.Count(string(b), expected) {
+				t.Errorf("expected only '%v' as proxy_redirect in the configuration", expected)
+			}
+			// NGINX only accepts proxy_redirect after proxy_pass
+			location := string(b)[strings.Index(string(b), "proxy_pass http://"):]
+			if !strings.Contains(location[:strings.Index(location, "}")], expected) {
+				t.Errorf("expected '%v' after proxy_pass in the configuration", expected)
+			}
+		}
+
+		testNginxConfig(t, b)
+	}
+}
+
+func TestBuildProxyRedirect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		protocol string
+		value    string
+		expected string
+	}{
+		"empty":   {"", "", ""},
+		"default": {"", "default", ""},
+		"off":     {backendprotocol.HTTP, "off", "proxy_redirect off;"},
+		"rule":    {backendprotocol.HTTPS, "http://internal/ /", "proxy_redirect http://internal/ /;"},
+		"grpc":    {backendprotocol.GRPC, "off", ""},
+		"fcgi":    {backendprotocol.FCGI, "http://internal/ /", ""},
+	} {
+		loc := &ingress.Location{BackendProtocol: tc.protocol}
+		loc.Proxy.ProxyRedirect = tc.value
+		if directive := buildProxyRedirect(loc); directive != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", name, tc.expected, directive)
+		}
+	}
+}
+
+func TestTemplateProxyBuffering(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Proxy.ProxyBuffering = "on"
+			location.Proxy.RequestBuffering = "off"
+		}
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"proxy_buffering                         on;",
+		"proxy_request_buffering                 off;",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateProxyCache(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Cfg.ProxyCachePath = "/tmp/nginx-cache"
+	dat.Cfg.ProxyCacheZones = []config.CacheZone{{Name: "static", Size: "10m", MaxSize: "1g"}}
+	dat.Servers[0].Locations[0].ProxyCache = proxycache.Config{
+		Zone:   "static",
+		Key:    "$host$request_uri",
+		Valid:  []string{"200 302 10m", "404 1m"},
+		Bypass: []string{"$http_pragma", "$cookie_nocache"},
+	}
+	dat.Servers[1].Locations[0].ProxyCache = proxycache.Config{Zone: "unknown", Key: "$host"}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"proxy_cache_path                /tmp/nginx-cache/static levels=1:2 keys_zone=static:10m max_size=1g;",
+		"proxy_cache                             static;",
+		"proxy_cache_key                         \"$host$request_uri\";",
+		"proxy_cache_valid                       200 302 10m;",
+		"proxy_cache_valid                       404 1m;",
+		"proxy_cache_bypass                      $http_pragma $cookie_nocache;",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+	if strings.Contains(string(b), "unknown") {
+		t.Errorf("unexpected cache with an unknown zone in the configuration")
+	}
+}
+
+func TestTemplateProxyNoCache(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Cfg.ProxyCachePath = "/tmp/nginx-cache"
+	dat.Cfg.ProxyCacheZones = []config.CacheZone{{Name: "static", Size: "10m"}}
+	// authenticated requests are neither served from nor saved in the cache
+	dat.Servers[0].Locations[0].ProxyCache = proxycache.Config{
+		Zone:    "static",
+		Key:     "$host$request_uri",
+		Bypass:  []string{"$cookie_session"},
+		NoCache: []string{"$cookie_session", "$http_authorization"},
+	}
+	// the conditions are ignored in locations without cache
+	dat.Servers[1].Locations[0].ProxyCache = proxycache.Config{
+		Bypass:  []string{"$cookie_nocache"},
+		NoCache: []string{"$cookie_nocache"},
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"proxy_cache                             static;",
+		"proxy_cache_bypass                      $cookie_session;",
+		"proxy_no_cache                          $cookie_session $http_authorization;",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+	if strings.Count(string(b), "proxy_no_cache ") != 1 || strings.Contains(string(b), "$cookie_nocache") {
+		t.Errorf("unexpected no-cache conditions in a location without cache")
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateLocationAnnotations(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Servers[0].Locations[0].UpstreamVhost = "internal.example.com"
+	dat.Servers[0].Locations[0].Redirect.XForwardedPrefix = "/api"
+	dat.Servers[1].Locations[0].URLRedirect = urlredirect.Config{URL: "https://www.example.com$request_uri", Code: 308}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"proxy_set_header Host                   \"internal.example.com\";",
+		"proxy_set_header Host                   $best_http_host;",
+		"proxy_set_header X-Forwarded-Prefix     \"/api\";",
+		"return 308 \"https://www.example.com$request_uri\";",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateRedirectFromToWWW(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Servers[1].Hostname = "example.com"
+	dat.Servers[1].RedirectFromToWWW = true
+	dat.Servers[1].SSLCertificate = "/ingress-controller/ssl/example.pem"
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	alias := string(b)[strings.Index(string(b), "## start server www.example.com"):strings.Index(string(b), "## end server www.example.com")]
+	for _, expected := range []string{
+		"server_name www.example.com;",
+		"ssl_certificate                         /ingress-controller/ssl/example.pem;",
+		"return 308 $pass_access_scheme://example.com$request_uri;",
+	} {
+		if !strings.Contains(alias, expected) {
+			t.Errorf("expected '%v' in the server www.example.com", expected)
+		}
+	}
+}
+
+func TestTemplatePortInRedirects(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Cfg.UsePortInRedirects = true
+	for _, location := range dat.Servers[1].Locations {
+		location.UsePortInRedirects = false
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, expected := range []string{
+		"port_in_redirect        on;",
+		"port_in_redirect off;",
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateServerAliases(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Servers[1].Hostname = "example.com"
+	dat.Servers[1].Aliases = []string{"example.net", "*.example.org"}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	expected := "server_name example.com example.net *.example.org;"
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected '%v' in the configuration", expected)
+	}
+}
+
+func TestTemplateWithAdditionalCertificates(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Servers[0].SSLCertificate = "/ingress-controller/ssl/default-rsa.pem"
+	dat.Servers[0].SSLAdditionalCertificates = []ingress.SSLCertificateFile{
+		{PemFileName: "/ingress-controller/ssl/default-ecdsa.pem", PemSHA: "abc", KeyType: "ECDSA"},
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, pem := range []string{"/ingress-controller/ssl/default-rsa.pem", "/ingress-controller/ssl/default-ecdsa.pem"} {
+		for _, directive := range []string{"ssl_certificate ", "ssl_certificate_key "} {
+			if strings.Count(out, directive+strings.Repeat(" ", 40-len(directive))+pem+";") != 1 {
+				t.Errorf("expected one '%v' directive with the certificate %v", strings.TrimSpace(directive), pem)
+			}
+		}
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateOCSPStapling(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Servers[0].SSLCertificate = "/ingress-controller/ssl/default-foo.pem"
+	dat.Servers[0].SSLTrustedCertificate = "/ingress-controller/ssl/default-foo.pem"
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(b), "ssl_stapling") {
+		t.Errorf("unexpected ssl_stapling with OCSP stapling disabled")
+	}
+
+	dat.Cfg.EnableOCSPStapling = true
+	dat.Cfg.OCSPStaplingVerify = true
+	dat.Cfg.OCSPResolver = "10.0.0.10 [fd00::10]:53"
+	b, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, expected := range []string{
+		"ssl_stapling                            on;",
+		"ssl_stapling_verify                     on;",
+		"ssl_trusted_certificate                 /ingress-controller/ssl/default-foo.pem;",
+		"resolver                                10.0.0.10 [fd00::10]:53;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateUpstreamChecks(t *testing.T) {
+	pwd, _ := os.Getwd()
+	ngxTpl, err := NewTemplate(path.Join(pwd, "../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(config.TemplateConfig{
+		Backends: []*ingress.Backend{
+			{
+				Name: "default-foo-80",
+				Endpoints: []ingress.Endpoint{
+					{Address: "10.0.0.1", Port: "8080"},
+					{Address: "10.0.0.2", Port: "8080", MaxFails: 3, FailTimeout: 30},
+					{Address: "10.0.0.3", Port: "8080", Backup: true},
+				},
+			},
+		},
+		Cfg: config.NewDefault(),
+	})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, server := range []string{
+		"server 10.0.0.1:8080 max_fails=0 fail_timeout=0;",
+		"server 10.0.0.2:8080 max_fails=3 fail_timeout=30;",
+		"server 10.0.0.3:8080 max_fails=0 fail_timeout=0 backup;",
+	} {
+		if !strings.Contains(string(b), server) {
+			t.Errorf("expected '%v' in the upstream", server)
+		}
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateAdminServer(t *testing.T) {
+	pwd, _ := os.Getwd()
+	ngxTpl, err := NewTemplate(path.Join(pwd, "../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	defer ngxTpl.Close()
+
+	// the admin server must exist without Ingress rules
+	b, err := ngxTpl.Write(config.TemplateConfig{
+		HealthzURI:     "/healthz",
+		ControllerPort: 10254,
+		IsIPV6Enabled:  true,
+		Cfg:            config.NewDefault(),
+	})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	start := strings.Index(out, "listen 127.0.0.1:10246;")
+	if start == -1 {
+		t.Fatalf("expected an admin server listening in 127.0.0.1:10246")
+	}
+	end := strings.Index(out[start:], "# default server for services without endpoints")
+	if end == -1 {
+		t.Fatalf("unexpected end of the admin server")
+	}
+	admin := out[start : start+end]
+
+	for _, expected := range []string{
+		"listen [::1]:10246;",
+		"allow 127.0.0.1;",
+		"allow ::1;",
+		"deny all;",
+		"location /healthz {",
+		"location = /healthz/config {",
+		"location /nginx_status {",
+		"stub_status on;",
+		"location /debug/ {",
+		"proxy_pass http://127.0.0.1:10254;",
+	} {
+		if !strings.Contains(admin, expected) {
+			t.Errorf("expected '%v' in the admin server", expected)
+		}
+	}
+
+	// the endpoints used by the controller in the default server
+	// (port 18080) must be restricted to localhost
+	start = strings.Index(out, "listen 18080 default_server")
+	if start == -1 {
+		t.Fatalf("expected a default server listening in port 18080")
+	}
+	end = strings.Index(out[start:], "listen 127.0.0.1:10246;")
+	if end == -1 {
+		t.Fatalf("unexpected end of the default server")
+	}
+	status := out[start : start+end]
+
+	for _, location := range []string{"location /healthz {", "location /nginx_status {"} {
+		i := strings.Index(status, location)
+		if i == -1 {
+			t.Errorf("expected '%v' in the default server", location)
+			continue
+		}
+		block := status[i : i+strings.Index(status[i:], "}")]
+		for _, expected := range []string{"allow 127.0.0.1;", "allow ::1;", "deny all;"} {
+			if !strings.Contains(block, expected) {
+				t.Errorf("expected '%v' in '%v' of the default server", expected, location)
+			}
+		}
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateStreamServices(t *testing.T) {
+	pwd, _ := os.Getwd()
+	ngxTpl, err := NewTemplate(path.Join(pwd, "../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(config.TemplateConfig{
+		TCPBackends: []ingress.L4Service{
+			// long lived connections
+			{
+				Port: 5432,
+				Backend: ingress.L4Backend{
+					Name: "postgres", Namespace: "default", Protocol: "TCP",
+					ProxyTimeout: "1h", ProxyConnectTimeout: "5s",
+				},
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "5432"}},
+			},
+			// invalid timeout
+			{
+				Port: 6379,
+				Backend: ingress.L4Backend{
+					Name: "redis", Namespace: "default", Protocol: "TCP",
+					ProxyTimeout: "1 hour",
+				},
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.2", Port: "6379"}},
+			},
+		},
+		UDPBackends: []ingress.L4Service{
+			{
+				Port: 53,
+				Backend: ingress.L4Backend{
+					Name: "dns", Namespace: "kube-system", Protocol: "UDP",
+					ProxyResponses: 3, ProxyTimeout: "10s",
+				},
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.3", Port: "53"}},
+			},
+			{
+				Port: 514,
+				Backend: ingress.L4Backend{
+					Name: "syslog", Namespace: "default", Protocol: "UDP",
+				},
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.4", Port: "514"}},
+			},
+		},
+		Cfg: config.NewDefault(),
+	})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for port, directives := range map[string][]string{
+		"5432": {"proxy_timeout           1h;", "proxy_connect_timeout   5s;"},
+		"6379": {"proxy_timeout           600s;", "proxy_connect_timeout   60s;"},
+		"53":   {"proxy_responses         3;", "proxy_timeout           10s;"},
+		"514":  {"proxy_responses         1;", "proxy_timeout           600s;"},
+	} {
+		start := strings.Index(out, "listen                  "+port)
+		if start == -1 {
+			t.Fatalf("expected a server listening in port %v", port)
+		}
+		end := strings.Index(out[start:], "}")
+		server := out[start : start+end]
+		for _, directive := range directives {
+			if !strings.Contains(server, directive) {
+				t.Errorf("expected '%v' in the server listening in port %v", directive, port)
+			}
+		}
+	}
+}
+
+func TestTemplateTLSServices(t *testing.T) {
+	pwd, _ := os.Getwd()
+	ngxTpl, err := NewTemplate(path.Join(pwd, "../../rootfs/etc/nginx/template/nginx.tmpl"), func() {})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(config.TemplateConfig{Cfg: config.NewDefault()})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(b), "ssl_preread") {
+		t.Errorf("unexpected ssl_preread server without TLS services")
+	}
+
+	b, err = ngxTpl.Write(config.TemplateConfig{
+		TLSBackends: []ingress.L4Service{
+			{
+				Port:     443,
+				Hostname: "db.foo.bar",
+				Backend: ingress.L4Backend{
+					Name: "postgres", Namespace: "default", Protocol: "TCP", Port: intstr.FromString("5432"),
+				},
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "5432"}, {Address: "10.0.0.2", Port: "5432"}},
+			},
+		},
+		Cfg: config.NewDefault(),
+	})
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, expected := range []string{
+		"upstream tls-db.foo.bar-default-postgres-5432 {",
+		"server                  10.0.0.2:5432;",
+		"db.foo.bar tls-db.foo.bar-default-postgres-5432;",
+		"listen                  127.0.0.1:444 proxy_protocol;",
+		"ssl_preread             on;",
+		"proxy_pass              $tls_service_upstream;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+}
+
+func TestTemplateHTTP3(t *testing.T) {
+	for _, useHTTP3 := range []bool{false, true} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.IsIPV6Enabled = true
+		dat.Cfg.UseHTTP3 = useHTTP3
+		// only servers with TLS must listen for QUIC connections
+		for _, server := range dat.Servers {
+			server.SSLCertificate = ""
+		}
+		dat.Servers[0].SSLCertificate = "/ingress-controller/ssl/default-fake-certificate.pem"
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		out := string(b)
+
+		expected := 0
+		if useHTTP3 {
+			expected = 1
+		}
+		for _, directive := range []string{
+			"listen 443 quic reuseport;",
+			"listen [::]:443 quic reuseport;",
+			`more_set_headers                        'Alt-Svc: h3=":443"; ma=86400';`,
+		} {
+			if count := strings.Count(out, directive); count != expected {
+				t.Errorf("expected %v '%v' with use-http3 %v but returned %v", expected, directive, useHTTP3, count)
+			}
+		}
+		if strings.Count(out, " quic") != 2*expected {
+			t.Errorf("expected QUIC listeners only in servers with TLS")
+		}
+	}
+}
+
+func TestTemplateSSLEarlyData(t *testing.T) {
+	for _, earlyData := range []bool{false, true} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.SSLEarlyData = earlyData
+		locations := 0
+		for _, server := range dat.Servers {
+			for _, location := range server.Locations {
+				location.SSLEarlyData = true
+				locations++
+			}
+		}
+		// opt-out of a replay-sensitive location
+		dat.Servers[0].Locations[0].SSLEarlyData = false
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		out := string(b)
+
+		expected := map[string]int{
+			"ssl_early_data on;": 0,
+			"proxy_set_header Early-Data             $ssl_early_data;": 0,
+			"return 425;": 0,
+		}
+		if earlyData {
+			expected = map[string]int{
+				"ssl_early_data on;": 1,
+				"proxy_set_header Early-Data             $ssl_early_data;": locations,
+				"return 425;": 1,
+			}
+		}
+		for directive, count := range expected {
+			if c := strings.Count(out, directive); c != count {
+				t.Errorf("expected %v '%v' with ssl-early-data %v but returned %v", count, directive, earlyData, c)
+			}
+		}
+	}
+}
+
+func TestTemplateGlobalRateLimit(t *testing.T) {
+	directives := []string{
+		`host = "memcached.kube-system.svc.cluster.local",`,
+		"port = 11211,",
+		"status_code = 429,",
+		"set $global_rate_limit_key $the_real_ip;",
+		`require("global_ratelimit").throttle("default_foo", 100, 60, ngx.var.global_rate_limit_key)`,
+	}
+
+	for _, host := range []string{"", "memcached.kube-system.svc.cluster.local"} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.GlobalRateLimitMemcachedHost = host
+		dat.Cfg.GlobalRateLimitMemcachedPort = 11211
+		dat.Cfg.GlobalRateLimitStatusCode = 429
+		dat.Servers[0].Locations[0].GlobalRateLimit = globalratelimit.Config{
+			Namespace:  "default_foo",
+			Limit:      100,
+			WindowSize: 60,
+			Key:        "$the_real_ip",
+		}
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		for _, directive := range directives {
+			if strings.Contains(string(b), directive) != (host != "") {
+				t.Errorf("expected %v in the configuration to be %v with the memcached host %q", directive, host != "", host)
+			}
+		}
+		if strings.Contains(string(b), "ngx.var.limit_whitelisted") {
+			t.Errorf("unexpected check of the limit-whitelist without addresses")
+		}
+
+		dat.Cfg.LimitWhitelist = []string{"10.0.0.0/8"}
+		b, err = ngxTpl.Write(dat)
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if strings.Contains(string(b), `if ngx.var.limit_whitelisted == "1" then`) != (host != "") {
+			t.Errorf("expected the check of the limit-whitelist to be %v with the memcached host %q", host != "", host)
+		}
+	}
+}
+
+func TestTemplateCORS(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	loc := dat.Servers[0].Locations[0]
+	loc.EnableCORS = true
+	loc.CorsConfig = cors.Config{
+		AllowOrigin:  "https://app.example.com",
+		AllowMethods: "GET, POST",
+		AllowHeaders: "Content-Type",
+		MaxAge:       600,
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, directive := range []string{
+		"add_header 'Access-Control-Allow-Origin' 'https://app.example.com';",
+		"add_header 'Access-Control-Allow-Methods' 'GET, POST';",
+		"add_header 'Access-Control-Allow-Headers' 'Content-Type';",
+		"add_header 'Access-Control-Max-Age' 600;",
+		"add_header 'Access-Control-Allow-Origin' 'https://app.example.com' always;",
+	} {
+		if !strings.Contains(out, directive) {
+			t.Errorf("expected %v in the configuration", directive)
+		}
+	}
+	if strings.Contains(out, "Access-Control-Allow-Credentials") {
+		t.Errorf("unexpected Access-Control-Allow-Credentials header in the configuration")
+	}
+}
+
+func TestTemplateStickySession(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Backends[0].SessionAffinity = ingress.SessionAffinityConfig{
+		AffinityType: "cookie",
+		CookieSessionAffinity: ingress.CookieSessionAffinity{
+			Name:    "route",
+			Hash:    "sha1",
+			Expires: "48h",
+			Path:    "/app",
+		},
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	expected := "sticky hash=sha1 name=route expires=48h path=/app httponly;"
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected %v in the configuration", expected)
+	}
+}
+
+func TestTemplateUpstreamHashBy(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	dat.Cfg.LoadBalanceAlgorithm = "least_conn"
+	dat.Backends[0].UpstreamHashBy = "$request_uri"
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	out := string(b)
+	expected := "hash $request_uri consistent;"
+	if !strings.Contains(out, expected) {
+		t.Errorf("expected %v in the configuration", expected)
+	}
+	if strings.Count(out, "least_conn;") != len(dat.Backends)-1 {
+		t.Errorf("expected the load balance algorithm only in the upstreams without a hash")
+	}
+}
+
+func TestTemplateConfigurationSnippet(t *testing.T) {
+	snippet := `more_set_headers "Request-Id: $req_id";`
+	serverSnippet := `error_page 404 /404.html;`
+	for _, allow := range []bool{true, false} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.AllowSnippetAnnotations = allow
+		dat.Servers[0].Locations[0].ConfigurationSnippet = snippet
+		dat.Servers[0].ServerSnippet = serverSnippet
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		if strings.Contains(string(b), snippet) != allow {
+			t.Errorf("expected snippet rendered to be %v with allow-snippet-annotations %v", allow, allow)
+		}
+		if strings.Contains(string(b), serverSnippet) != allow {
+			t.Errorf("expected server snippet rendered to be %v with allow-snippet-annotations %v", allow, allow)
+		}
+	}
+}
+
+func TestTemplateClientCertificates(t *testing.T) {
+	for verify, errorPage := range map[string]string{
+		"on":       "https://example.com/certificate-error",
+		"optional": "",
+	} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Servers[0].SSLCertificate = "/ingress-controller/ssl/default-fake-certificate.pem"
+		dat.Servers[0].Locations[0].CertificateAuth = authtls.AuthSSLConfig{
+			AuthSSLCert: resolver.AuthSSLCert{
+				Secret:     "default/ca",
+				CAFileName: "/ingress-controller/ssl/ca-default-ca.pem",
+				PemSHA:     "abc",
+			},
+			ValidationDepth: 2,
+			VerifyClient:    verify,
+			ErrorPage:       errorPage,
+		}
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		out := string(b)
+
+		for _, directive := range []string{
+			"ssl_client_certificate                  /ingress-controller/ssl/ca-default-ca.pem;",
+			"ssl_verify_client                       " + verify + ";",
+			"ssl_verify_depth                        2;",
+			"proxy_set_header ssl-client-cert        $ssl_client_cert;",
+			"proxy_set_header ssl-client-subject-dn  $ssl_client_s_dn;",
+			"proxy_set_header ssl-client-verify      $ssl_client_verify;",
+		} {
+			if strings.Count(out, directive) != 1 {
+				t.Errorf("expected one '%v' with verify-client %v", directive, verify)
+			}
+		}
+
+		testNginxConfig(t, b)
+
+		c := strings.Count(out, "error_page 495 496 https://example.com/certificate-error;")
+		if (c == 1) != (errorPage != "") {
+			t.Errorf("expected the error page '%v' but returned %v error_page directives", errorPage, c)
+		}
+	}
+}
+
+func TestBuildSSLRedirect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		redirect rewrite.Redirect
+		expected string
+	}{
+		"default":            {rewrite.Redirect{}, "return 301 https://$best_http_host$request_uri;"},
+		"temporary redirect": {rewrite.Redirect{SSLRedirectCode: 307}, "return 307 https://$best_http_host$request_uri;"},
+		"load balancer in a custom port": {rewrite.Redirect{SSLRedirectCode: 308, SSLRedirectPort: 8443},
+			"return 308 https://$host:8443$request_uri;"},
+		"explicit host": {rewrite.Redirect{SSLRedirectCode: 301, SSLRedirectHost: "foo.bar.com"},
+			"return 301 https://foo.bar.com$request_uri;"},
+		"explicit host and port": {rewrite.Redirect{SSLRedirectCode: 302, SSLRedirectHost: "foo.bar.com", SSLRedirectPort: 8443},
+			"return 302 https://foo.bar.com:8443$request_uri;"},
+		"standard port": {rewrite.Redirect{SSLRedirectCode: 301, SSLRedirectPort: 443},
+			"return 301 https://$host$request_uri;"},
+	} {
+		redirect := buildSSLRedirect(&ingress.Location{Redirect: tc.redirect})
+		if redirect != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", name, tc.expected, redirect)
+		}
+	}
+}
+
+func TestBuildHSTS(t *testing.T) {
+	for name, tc := range map[string]struct {
+		server   ingress.Server
+		expected string
+	}{
+		"without ssl":           {ingress.Server{HSTS: &hsts.Config{Enabled: true, MaxAge: "100"}}, ""},
+		"without configuration": {ingress.Server{SSLCertificate: "/cert.pem"}, ""},
+		"disabled":              {ingress.Server{SSLCertificate: "/cert.pem", HSTS: &hsts.Config{MaxAge: "100"}}, ""},
+		"max-age": {ingress.Server{SSLCertificate: "/cert.pem", HSTS: &hsts.Config{Enabled: true, MaxAge: "100"}},
+			`more_set_headers                        "Strict-Transport-Security: max-age=100";`},
+		"subdomains and preload": {ingress.Server{SSLCertificate: "/cert.pem",
+			HSTS: &hsts.Config{Enabled: true, MaxAge: "15724800", IncludeSubdomains: true, Preload: true}},
+			`more_set_headers                        "Strict-Transport-Security: max-age=15724800; includeSubDomains; preload";`},
+	} {
+		directive := buildHSTS(&tc.server)
+		if directive != tc.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", name, tc.expected, directive)
+		}
+	}
+}
+
+func TestBuildAppRoot(t *testing.T) {
+	server := &ingress.Server{
+		Locations: []*ingress.Location{
+			{Path: "/"},
+			{Path: "/console", Redirect: rewrite.Redirect{AppRoot: "/console"}},
+			{Path: "/api", Redirect: rewrite.Redirect{AppRoot: "/api"}},
+		},
+	}
+	if appRoot := buildAppRoot(server); appRoot != "/console" {
+		t.Errorf("expected /console but returned %v", appRoot)
+	}
+
+	if appRoot := buildAppRoot(&ingress.Server{Locations: []*ingress.Location{{Path: "/"}}}); appRoot != "" {
+		t.Errorf("expected no application root but returned %v", appRoot)
+	}
+}
+
+func TestTemplateSSLRedirect(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Redirect.ForceSSLRedirect = true
+			location.Redirect.SSLRedirectCode = 308
+			location.Redirect.SSLRedirectPort = 8443
+		}
+	}
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(b), "return 308 https://$host:8443$request_uri;") {
+		t.Errorf("expected the redirect to the port of the load balancer in the configuration")
+	}
+	if strings.Contains(string(b), "https://$best_http_host$request_uri") {
+		t.Errorf("unexpected redirect with the host of the request")
+	}
+}
+
+func TestTemplateHealthCheckScript(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(b), "js_import") || strings.Contains(string(b), "js_set") {
+		t.Errorf("unexpected njs directives without health check scripts")
+	}
+
+	dat.Backends = append(dat.Backends, &ingress.Backend{
+		Name:              "default-foo.bar-80",
+		HealthCheckScript: healthcheck.Script{Path: "/etc/nginx/njs/health.js", Function: "check"},
+	})
+
+	b, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, directive := range []string{
+		"js_import hc_default_foo_bar_80 from /etc/nginx/njs/health.js;",
+		"js_set $hc_default_foo_bar_80 hc_default_foo_bar_80.check;",
+		"location = /upstream-health/default-foo.bar-80 {",
+		"return 200 $hc_default_foo_bar_80;",
+	} {
+		if strings.Count(out, directive) != 1 {
+			t.Errorf("expected one '%v' in the configuration", directive)
+		}
+	}
+}
+
+func TestBuildHealthCheckModule(t *testing.T) {
+	module := buildHealthCheckModule(&ingress.Backend{Name: "kube-system-foo.bar-http"})
+	if module != "hc_kube_system_foo_bar_http" {
+		t.Errorf("expected hc_kube_system_foo_bar_http but returned %v", module)
+	}
+
+	if module := buildHealthCheckModule("invalid"); module != "" {
+		t.Errorf("expected an empty module name with an invalid input but returned %v", module)
+	}
+}
+
+func TestTemplateClientBody(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+	dat.Cfg.ClientBodyBufferSize = "16k"
+	dat.Cfg.ClientBodyInFileOnly = "clean"
+	dat.Cfg.ClientBodyTempPath = "/tmp/nginx/client-body"
+	dat.Servers[0].Locations[0].Proxy.ClientBodyBufferSize = "1m"
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, directive := range []string{
+		"client_body_buffer_size         16k;",
+		"client_body_in_file_only        clean;",
+		"client_body_temp_path           /tmp/nginx/client-body;",
+		"client_body_buffer_size                 1m;",
+	} {
+		if strings.Count(out, directive) != 1 {
+			t.Errorf("expected one '%v' in the configuration", directive)
+		}
+	}
+
+	dat.Cfg.ClientBodyTempPath = ""
+	b, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(b), "client_body_temp_path") {
+		t.Errorf("unexpected client_body_temp_path without a configured path")
+	}
+}
+
+func TestBuildAccessList(t *testing.T) {
+	tests := map[string]struct {
+		list     interface{}
+		expected []string
+	}{
+		"empty": {ipaccess.AccessList{}, []string{}},
+		"allowlist": {
+			ipaccess.AccessList{AllowCIDRs: []string{"10.0.0.0/24", "2001:db8::/32"}},
+			[]string{"allow 10.0.0.0/24;", "allow 2001:db8::/32;", "deny all;"},
+		},
+		"denylist": {
+			ipaccess.AccessList{DenyCIDRs: []string{"192.168.0.0/16"}},
+			[]string{"deny 192.168.0.0/16;", "allow all;"},
+		},
+		"both": {
+			ipaccess.AccessList{AllowCIDRs: []string{"10.0.0.0/8"}, DenyCIDRs: []string{"10.0.1.0/24"}},
+			[]string{"deny 10.0.1.0/24;", "allow 10.0.0.0/8;", "deny all;"},
+		},
+		"invalid": {"10.0.0.0/8", []string{}},
+	}
+
+	for name, test := range tests {
+		rules := buildAccessList(test.list)
+		if !reflect.DeepEqual(rules, test.expected) {
+			t.Errorf("%v: expected %v but returned %v", name, test.expected, rules)
+		}
+	}
+}
+
+func TestBuildLocationAccessList(t *testing.T) {
+	loc := &ingress.Location{
+		AccessList: ipaccess.AccessList{DenyCIDRs: []string{"10.0.1.0/24"}},
+		Whitelist:  ipwhitelist.SourceRange{CIDR: []string{"10.0.0.0/8"}},
+	}
+
+	expected := []string{"deny 10.0.1.0/24;", "allow all;"}
+	if rules := buildLocationAccessList(loc); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %v but returned %v", expected, rules)
+	}
+	if !isWhitelistChecked(loc) {
+		t.Errorf("expected the check of the whitelist without auth-satisfy any")
+	}
+
+	loc.Satisfy = satisfy.Any
+	expected = []string{"deny 10.0.1.0/24;", "allow 10.0.0.0/8;", "deny all;"}
+	if rules := buildLocationAccessList(loc); !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %v but returned %v", expected, rules)
+	}
+	if isWhitelistChecked(loc) {
+		t.Errorf("unexpected check of the whitelist with auth-satisfy any")
+	}
+}
+
+func TestTemplateSatisfy(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	loc := dat.Servers[1].Locations[0]
+	loc.Satisfy = satisfy.Any
+	loc.Whitelist = ipwhitelist.SourceRange{CIDR: []string{"10.8.0.0/16"}}
+	loc.BasicDigestAuth.Secured = true
+	loc.BasicDigestAuth.Type = "basic"
+	loc.BasicDigestAuth.Realm = "admin"
+	loc.BasicDigestAuth.File = "/etc/ingress-controller/auth/default-admin.passwd"
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, expected := range []string{
+		"satisfy any;",
+		"allow 10.8.0.0/16;\n            deny all;",
+		`auth_basic "admin";`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected '%v' in the configuration", expected)
+		}
+	}
+	if strings.Contains(out, "geo $the_real_ip") {
+		t.Errorf("unexpected geo variable of the whitelist with auth-satisfy any")
+	}
+}
+
+func TestTemplateAccessList(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(b), "allow all;") || strings.Contains(string(b), "allow 10.") {
+		t.Errorf("unexpected access rules without access lists")
+	}
+
+	// allowlist in the server and denylist in a location
+	dat.Servers[0].AccessList = ipaccess.AccessList{AllowCIDRs: []string{"10.0.0.0/24"}}
+	dat.Servers[0].Locations[0].AccessList = ipaccess.AccessList{DenyCIDRs: []string{"192.168.0.0/16"}}
+
+	b, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	// the rules of the server must be before the first location
+	server := out[strings.Index(out, "server_name "+dat.Servers[0].Hostname+";"):]
+	server = server[:strings.Index(server, "location ")]
+	if !strings.Contains(server, "allow 10.0.0.0/24;\n        deny all;") {
+		t.Errorf("expected the allowlist followed by deny all in the server")
+	}
+	if !strings.Contains(out, "deny 192.168.0.0/16;\n            allow all;") {
+		t.Errorf("expected the denylist followed by allow all in the location")
+	}
+
+	testNginxConfig(t, b)
+}
+
+func TestTemplateWorkerShutdownTimeout(t *testing.T) {
+	for timeout, expected := range map[string]int{"": 0, "30s": 1} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.WorkerShutdownTimeout = timeout
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if c := strings.Count(string(b), "worker_shutdown_timeout "+timeout+";"); c != expected {
+			t.Errorf("expected %v worker_shutdown_timeout with '%v' but returned %v", expected, timeout, c)
+		}
+	}
+}
+
+func TestTemplateSyslog(t *testing.T) {
+	for enabled, expected := range map[bool]int{false: 0, true: 4} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.DisableAccessLog = false
+		dat.Cfg.EnableSyslog = enabled
+		dat.Cfg.SyslogHost = "10.0.0.1"
+		dat.Cfg.SyslogPort = 514
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if c := strings.Count(string(b), "syslog:server=10.0.0.1:514"); c != expected {
+			t.Errorf("expected %v logs using syslog (enabled: %v) but returned %v", expected, enabled, c)
+		}
+		if enabled && strings.Contains(string(b), "/var/log/nginx/") {
+			t.Errorf("expected no log files with syslog enabled")
+		}
+	}
+}
+
+func TestTemplateOpentracing(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Cfg.EnableOpentracing = enabled
+		dat.Cfg.OpentracingTracer = config.JaegerTracer
+		dat.Servers = []*ingress.Server{
+			{
+				Hostname: "example.com",
+				Locations: []*ingress.Location{
+					{
+						Path:             "/",
+						Backend:          "default-demo-80",
+						Service:          &api_v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "demo"}},
+						IngressNamespace: "default",
+						IngressName:      "demo",
+					},
+				},
+			},
+		}
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+
+		for _, directive := range []string{
+			"load_module /etc/nginx/modules/ngx_http_opentracing_module.so;",
+			"opentracing_load_tracer /usr/local/lib/libjaegertracing_plugin.so /etc/nginx/opentracing.json;",
+			"opentracing_propagate_context;",
+			`opentracing_tag ingress.namespace "default";`,
+			`opentracing_tag ingress.name "demo";`,
+			`opentracing_tag service.name "demo";`,
+		} {
+			if strings.Contains(string(b), directive) != enabled {
+				t.Errorf("expected '%v' in the configuration: %v", directive, enabled)
+			}
+		}
+	}
+}
+
+func TestTemplateRequestID(t *testing.T) {
+	for _, generate := range []bool{false, true} {
+		dat, ngxTpl := loadTemplateConfig(t)
+		dat.Servers = []*ingress.Server{
+			{
+				Hostname: "example.com",
+				Locations: []*ingress.Location{
+					{Path: "/", Backend: "default-demo-80", GenerateRequestID: generate},
+				},
+			},
+		}
+
+		b, err := ngxTpl.Write(dat)
+		ngxTpl.Close()
+		if err != nil {
+			t.Fatalf("invalid NGINX template: %v", err)
+		}
+		if !strings.Contains(string(b), "map $http_x_request_id $req_id {") {
+			t.Errorf("expected the map of the variable $req_id")
+		}
+		if c := strings.Count(string(b), "proxy_set_header X-Request-ID           $req_id;"); (c == 1) != generate {
+			t.Errorf("expected the X-Request-ID header: %v but returned %v headers", generate, c)
+		}
+	}
+}
+
+func TestIsDynamicUpstream(t *testing.T) {
+	sticky := &ingress.Backend{Name: "default-sticky-80"}
+	sticky.SessionAffinity.AffinityType = "cookie"
+	hashBy := &ingress.Backend{Name: "default-hash-80", UpstreamHashBy: "$request_uri"}
+	leastConn := &ingress.Backend{Name: "default-lc-80", LoadBalance: "least_conn"}
+	roundRobin := &ingress.Backend{Name: "default-rr-80", LoadBalance: "round_robin"}
+
+	fooTests := []struct {
+		backend   *ingress.Backend
+		algorithm string
+		dynamic   bool
+	}{
+		{&ingress.Backend{Name: "default-foo-80"}, "", true},
+		{&ingress.Backend{Name: "default-foo-80"}, "round_robin", true},
+		{&ingress.Backend{Name: "default-foo-80"}, "least_conn", false},
+		{&ingress.Backend{Name: "upstream-default-backend"}, "round_robin", false},
+		{sticky, "round_robin", false},
+		{hashBy, "round_robin", false},
+		{leastConn, "round_robin", false},
+		{roundRobin, "least_conn", true},
+		{&ingress.Backend{Name: "default-foo-80", Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "80"}, {Address: "10.0.0.2", Port: "80", Backup: true}}}, "round_robin", false},
+		{nil, "round_robin", false},
+	}
+
+	for _, ft := range fooTests {
+		dynamic := IsDynamicUpstream(ft.backend, ft.algorithm)
+		if dynamic != ft.dynamic {
+			t.Errorf("expected %v for %+v with algorithm %v but returned %v", ft.dynamic, ft.backend, ft.algorithm, dynamic)
+		}
+	}
+}
+
+func TestUpstreamAlgorithm(t *testing.T) {
+	fooTests := []struct {
+		backend   *ingress.Backend
+		algorithm string
+		expected  string
+	}{
+		{&ingress.Backend{}, "least_conn", "least_conn"},
+		{&ingress.Backend{}, "", "round_robin"},
+		{&ingress.Backend{LoadBalance: "ip_hash"}, "least_conn", "ip_hash"},
+		{&ingress.Backend{LoadBalance: "round_robin"}, "least_conn", "round_robin"},
+		{&ingress.Backend{UpstreamHashBy: "$request_uri", LoadBalance: "ip_hash"}, "least_conn", "hash $request_uri consistent"},
+		{nil, "least_conn", "least_conn"},
+	}
+
+	for _, ft := range fooTests {
+		algorithm := UpstreamAlgorithm(ft.backend, ft.algorithm)
+		if algorithm != ft.expected {
+			t.Errorf("expected %v for %+v with algorithm %v but returned %v", ft.expected, ft.backend, ft.algorithm, algorithm)
+		}
+	}
+}
+
+func TestTemplateDynamicConfiguration(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	for _, directive := range []string{"balancer_by_lua_block", "lua_shared_dict configuration_data", "location = /configuration/backends"} {
+		if strings.Contains(string(b), directive) {
+			t.Errorf("unexpected '%v' without dynamic configuration", directive)
+		}
+	}
+
+	dat.DynamicConfigurationEnabled = true
+	b, err = ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	out := string(b)
+
+	for _, directive := range []string{
+		"balancer = require(\"balancer\")",
+		"lua_shared_dict configuration_data 5m;",
+		"location = /configuration/backends {",
+		"balancer.balance(\"default-echoheaders-80\")",
+	} {
+		if strings.Count(out, directive) != 1 {
+			t.Errorf("expected one '%v' in the configuration", directive)
+		}
+	}
+
+	// the default backend is used by the custom error pages
+	if strings.Contains(out, "balancer.balance(\"upstream-default-backend\")") {
+		t.Errorf("unexpected dynamic upstream for the default backend")
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	content := []byte("http {\n    # begin\n    upstream a {}\n    # end\n    server {}\n}\n")
+	section := []byte("\n    # begin\n    upstream b {}\n    # end\n")
+
+	res, err := replaceSection(content, section, "# begin", "# end")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "http {\n    # begin\n    upstream b {}\n    # end\n    server {}\n}\n"
+	if string(res) != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, string(res))
+	}
+
+	for _, invalid := range []string{
+		"http {}",
+		"# begin\n# end\n# begin\n# end",
+		"# end\n# begin",
+	} {
+		if _, err := replaceSection([]byte(invalid), section, "# begin", "# end"); err == nil {
+			t.Errorf("expected an error replacing the section of '%v'", invalid)
+		}
+	}
+}
+
+func TestRenderUpstreams(t *testing.T) {
+	dat, ngxTpl := loadTemplateConfig(t)
+	defer ngxTpl.Close()
+
+	b, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	content := make([]byte, len(b))
+	copy(content, b)
+
+	dat.Backends[0].Endpoints = append(dat.Backends[0].Endpoints, ingress.Endpoint{Address: "10.2.0.10", Port: "8080", MaxFails: 1, FailTimeout: 5})
+	expected, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	tested := false
+	res, err := ngxTpl.RenderUpstreams(content, dat, func([]byte) error {
+		tested = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error rendering the upstreams: %v", err)
+	}
+	if !tested {
+		t.Errorf("expected the test of the configuration")
+	}
+	if string(res) != string(expected) {
+		t.Errorf("expected the same configuration rendering only the upstreams")
+	}
+	if !strings.Contains(string(res), "server 10.2.0.10:8080 max_fails=1 fail_timeout=5;") {
+		t.Errorf("expected the new endpoint in the configuration")
+	}
+}
@@ -0,0 +1,118 @@
+-- Balancer of the upstreams configured dynamically by the ingress controller.
+-- The controller sends the endpoints of the upstreams (POST to
+-- /configuration/backends in the internal server) and the list is stored in
+-- a shared dictionary, so changes in the endpoints do not require a reload.
+
+local cjson = require "cjson"
+local ngx_balancer = require "ngx.balancer"
+
+local _M = {}
+
+local configuration_data = ngx.shared.configuration_data
+
+-- endpoints of each upstream in this worker, refreshed when
+-- the version of the configuration in the shared dictionary changes
+local backends = {}
+local backends_version = nil
+
+-- index of the last endpoint used in each upstream (round robin)
+local last_index = {}
+
+local function sync_backends()
+    local version = configuration_data:get("version")
+    if version == nil or version == backends_version then
+        return
+    end
+
+    local data = configuration_data:get("backends")
+    if not data then
+        return
+    end
+
+    local ok, decoded = pcall(cjson.decode, data)
+    if not ok then
+        ngx.log(ngx.ERR, "invalid configuration of the backends: ", decoded)
+        return
+    end
+
+    local new_backends = {}
+    for _, backend in ipairs(decoded) do
+        new_backends[backend.name] = backend.endpoints or {}
+    end
+
+    backends = new_backends
+    backends_version = version
+end
+
+-- configure stores the endpoints sent by the controller
+function _M.configure()
+    if ngx.var.request_method ~= "POST" then
+        ngx.status = ngx.HTTP_NOT_ALLOWED
+        ngx.print("only POST is allowed")
+        return
+    end
+
+    ngx.req.read_body()
+    local body = ngx.req.get_body_data()
+    if not body then
+        ngx.status = ngx.HTTP_BAD_REQUEST
+        ngx.print("empty configuration")
+        return
+    end
+
+    local ok, err = pcall(cjson.decode, body)
+    if not ok then
+        ngx.status = ngx.HTTP_BAD_REQUEST
+        ngx.print("invalid configuration: ", err)
+        return
+    end
+
+    local success, err = configuration_data:set("backends", body)
+    if not success then
+        ngx.log(ngx.ERR, "error saving the configuration of the backends: ", err)
+        ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+        return
+    end
+
+    configuration_data:incr("version", 1, 0)
+    ngx.status = ngx.HTTP_CREATED
+end
+
+-- endpoints returns the endpoints of the upstream, nil if the
+-- upstream is not configured dynamically
+function _M.endpoints(name)
+    sync_backends()
+    return backends[name]
+end
+
+-- balance selects the endpoint of the upstream using round robin
+function _M.balance(name)
+    sync_backends()
+
+    local endpoints = backends[name]
+    if not endpoints or #endpoints == 0 then
+        ngx.log(ngx.WARN, "there are no endpoints for the upstream ", name)
+        return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+    end
+
+    -- the upstream only contains a placeholder server. The retries
+    -- (proxy_next_upstream) can use the rest of the endpoints
+    if not ngx.ctx.balancer_tries then
+        ngx.ctx.balancer_tries = true
+        if #endpoints > 1 then
+            ngx_balancer.set_more_tries(#endpoints - 1)
+        end
+    end
+
+    local index = (last_index[name] or 0) % #endpoints + 1
+    last_index[name] = index
+
+    local endpoint = endpoints[index]
+    local ok, err = ngx_balancer.set_current_peer(endpoint.address, tonumber(endpoint.port))
+    if not ok then
+        ngx.log(ngx.ERR, "error setting the endpoint ", endpoint.address, ":", endpoint.port, " of the upstream ", name, ": ", err)
+        return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+    end
+end
+
+return _M
@@ -0,0 +1,48 @@
+-- Selection of the upstream in the locations with a canary Ingress rule.
+-- The header (canary-by-header) or the cookie (canary-by-cookie) of the
+-- request with the value "always" selects the canary and "never" the main
+-- upstream. Other requests are sent to the canary with the percentage
+-- defined by the annotation canary-weight.
+
+local _M = {}
+
+-- the module is loaded by each worker in the first request with a canary
+math.randomseed(ngx.now() * 1000 + ngx.worker.pid())
+
+-- opt_in returns the upstream selected by the value of the header or
+-- cookie, or nil when the value does not select an upstream
+local function opt_in(value, main, canary)
+    if value == "always" then
+        return canary
+    end
+    if value == "never" then
+        return main
+    end
+    return nil
+end
+
+-- route returns the upstream used by the request
+function _M.route(main, canary, weight, header, cookie)
+    if header ~= "" then
+        local name = string.gsub(string.lower(header), "-", "_")
+        local upstream = opt_in(ngx.var["http_" .. name], main, canary)
+        if upstream then
+            return upstream
+        end
+    end
+
+    if cookie ~= "" then
+        local upstream = opt_in(ngx.var["cookie_" .. cookie], main, canary)
+        if upstream then
+            return upstream
+        end
+    end
+
+    if weight > 0 and math.random(100) <= weight then
+        return canary
+    end
+
+    return main
+end
+
+return _M
@@ -8,13 +8,15 @@ local get_upstreams = upstream.get_upstreams
 local random = math.random
 local us = get_upstreams()
 
-function openURL(original_headers, status)
+-- openURL returns the custom error page of the status obtained from the
+-- upstream, by default the default backend of the controller
+function openURL(original_headers, status, upstream_name)
     local httpc = http.new()
 
     original_headers["X-Code"] = status or "404"
     original_headers["X-Format"] = original_headers["Accept"] or "text/html"
 
-    local random_backend = get_destination()
+    local random_backend = get_destination(upstream_name or def_backend)
     local res, err = httpc:request_uri(random_backend, {
         path = "/",
         method = "GET",
@@ -34,9 +36,19 @@ function openURL(original_headers, status)
     ngx.say(res.body)
 end
 
-function get_destination()
+function get_destination(upstream_name)
+    -- the upstreams configured dynamically (balancer.lua) only
+    -- contain a placeholder server
+    if balancer then
+        local endpoints = balancer.endpoints(upstream_name)
+        if endpoints and #endpoints > 0 then
+            local endpoint = endpoints[random(1, #endpoints)]
+            return "http://"..endpoint.address..":"..endpoint.port
+        end
+    end
+
     for _, u in ipairs(us) do
-        if u == def_backend then
+        if u == upstream_name then
             local srvs, err = get_servers(u)
             local us_table = {}
             if not srvs then
@@ -0,0 +1,110 @@
+-- Rate limits shared by all the replicas of the ingress controller.
+-- The requests are counted in memcached using fixed windows, so the limits
+-- defined with the annotation global-rate-limit apply to the cluster
+-- instead of each NGINX. When memcached is not available the requests
+-- are allowed (the limits of limit_req still apply).
+
+local floor = math.floor
+local ceil = math.ceil
+
+local _M = {}
+
+-- connection settings of memcached, defined in the configmap
+local config = {}
+
+-- init stores the connection settings of memcached
+function _M.init(cfg)
+    config = cfg
+end
+
+local function command(sock, cmd)
+    local bytes, err = sock:send(cmd)
+    if not bytes then
+        return nil, err
+    end
+
+    return sock:receive("*l")
+end
+
+local function incr(sock, key)
+    local line, err = command(sock, "incr " .. key .. " 1\r\n")
+    if not line then
+        return nil, err
+    end
+    if line == "NOT_FOUND" then
+        return nil
+    end
+
+    local count = tonumber(line)
+    if not count then
+        return nil, line
+    end
+    return count
+end
+
+-- count increments the counter of the key, created with the expiration
+-- of the window when it does not exist
+local function count(sock, key, ttl)
+    local n, err = incr(sock, key)
+    if n or err then
+        return n, err
+    end
+
+    local line
+    line, err = command(sock, "add " .. key .. " 0 " .. ttl .. " 1\r\n1\r\n")
+    if not line then
+        return nil, err
+    end
+    if line == "STORED" then
+        return 1
+    end
+    if line ~= "NOT_STORED" then
+        return nil, line
+    end
+
+    -- created by a concurrent request
+    n, err = incr(sock, key)
+    if not n then
+        return nil, err or "counter not found"
+    end
+    return n
+end
+
+-- throttle rejects the request when the number of requests with the same
+-- key in the current window of the namespace (Ingress rule) is over the limit
+function _M.throttle(namespace, limit, window, key)
+    if not config.host then
+        return
+    end
+
+    local now = ngx.now()
+    local index = floor(now / window)
+    -- memcached keys cannot contain spaces and are limited to 250 bytes
+    local counter = ngx.md5(namespace .. ":" .. key) .. ":" .. index
+
+    local sock = ngx.socket.tcp()
+    sock:settimeout(config.connect_timeout)
+
+    local ok, err = sock:connect(config.host, config.port)
+    if not ok then
+        ngx.log(ngx.ERR, "error connecting to memcached: ", err)
+        return
+    end
+
+    local n
+    n, err = count(sock, counter, window * 2)
+    if not n then
+        ngx.log(ngx.ERR, "error counting the request in memcached: ", err)
+        sock:close()
+        return
+    end
+
+    sock:setkeepalive(config.max_idle_timeout, config.pool_size)
+
+    if n > limit then
+        ngx.header["Retry-After"] = ceil((index + 1) * window - now)
+        return ngx.exit(config.status_code)
+    end
+end
+
+return _M
@@ -0,0 +1,77 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alias
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/server-alias"
+)
+
+// ParseAliases parses a list of hostnames separated by commas or
+// spaces. The hostnames can start with a wildcard (*.example.com)
+func ParseAliases(val string) ([]string, error) {
+	aliases := []string{}
+	for _, alias := range strings.FieldsFunc(val, func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		alias = strings.ToLower(alias)
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(alias, "*.")); len(errs) != 0 {
+			return nil, fmt.Errorf("%v is not a valid hostname: %v", alias, strings.Join(errs, ", "))
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("the list of hostnames is empty")
+	}
+
+	return aliases, nil
+}
+
+type alias struct {
+}
+
+// NewParser creates a new server-alias annotation parser
+func NewParser() parser.IngressAnnotation {
+	return alias{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// add hostnames to the servers defined in the Ingress rule. An invalid
+// list is ignored
+func (a alias) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil {
+		return []string{}, nil
+	}
+
+	aliases, err := ParseAliases(val)
+	if err != nil {
+		return []string{}, nil
+	}
+
+	return aliases, nil
+}
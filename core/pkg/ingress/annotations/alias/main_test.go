@@ -0,0 +1,57 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alias
+
+import (
+	"reflect"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    []string
+	}{
+		{map[string]string{annotation: "example.net"}, []string{"example.net"}},
+		{map[string]string{annotation: "example.net, www.example.net"}, []string{"example.net", "www.example.net"}},
+		{map[string]string{annotation: "Example.org *.example.org"}, []string{"example.org", "*.example.org"}},
+		{map[string]string{annotation: "example.net, example_org"}, []string{}},
+		{map[string]string{annotation: "example.net;"}, []string{}},
+		{map[string]string{annotation: " , "}, []string{}},
+		{nil, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 	api "k8s.io/client-go/pkg/api/v1"
@@ -39,7 +40,9 @@ const (
 )
 
 var (
-	authTypeRegex = regexp.MustCompile(`basic|digest`)
+	authTypeRegex = regexp.MustCompile(`^(basic|digest)$`)
+	// hash of the htdigest files (MD5 of user:realm:password)
+	digestHashRegex = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
 	// AuthDirectory default directory used to store files
 	// to authenticate request
 	AuthDirectory = "/etc/ingress-controller/auth"
@@ -107,7 +110,7 @@ func (a auth) Parse(ing *extensions.Ingress) (interface{}, error) {
 		return nil, err
 	}
 
-	if !authTypeRegex.MatchString(at) {
+	if !IsValidType(at) {
 		return nil, ing_errors.NewLocationDenied("invalid authentication type")
 	}
 
@@ -127,6 +130,14 @@ func (a auth) Parse(ing *extensions.Ingress) (interface{}, error) {
 	}
 
 	realm, _ := parser.GetStringAnnotation(authRealm, ing)
+	err = validateSecret(at, realm, secret)
+	if err != nil {
+		return nil, ing_errors.LocationDenied{
+			Reason: errors.Wrapf(err, "invalid content in secret %v", name),
+		}
+	}
+	// the realm is rendered as a quoted string
+	realm = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(realm)
 
 	passFile := fmt.Sprintf("%v/%v-%v.passwd", a.authDirectory, ing.GetNamespace(), ing.GetName())
 	err = dumpSecret(passFile, secret)
@@ -142,6 +153,53 @@ func (a auth) Parse(ing *extensions.Ingress) (interface{}, error) {
 	}, nil
 }
 
+// IsValidType checks the authentication type is basic or digest
+func IsValidType(at string) bool {
+	return authTypeRegex.MatchString(at)
+}
+
+// validateSecret checks the key auth of the secret contains the users
+// in the format of the authentication type: user:hash (htpasswd) for basic
+// and user:realm:hash (htdigest) for digest. The users of a digest file
+// must belong to the realm, otherwise the authentication always fails
+func validateSecret(at, realm string, secret *api.Secret) error {
+	val, ok := secret.Data["auth"]
+	if !ok {
+		// reported when the secret is dumped
+		return nil
+	}
+
+	users := 0
+	for i, line := range strings.Split(string(val), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if at == "digest" {
+			if len(fields) != 3 || fields[0] == "" || !digestHashRegex.MatchString(fields[2]) {
+				return errors.Errorf("line %v is not in the format user:realm:hash", i+1)
+			}
+			if fields[1] != realm {
+				continue
+			}
+		} else if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return errors.Errorf("line %v is not in the format user:hash", i+1)
+		}
+		users++
+	}
+
+	if users == 0 {
+		if at == "digest" {
+			return errors.Errorf("there are no users with the realm %q", realm)
+		}
+		return errors.New("there are no users")
+	}
+
+	return nil
+}
+
 // dumpSecret dumps the content of a secret into a file
 // in the expected format for the specified authorization
 func dumpSecret(filename string, secret *api.Secret) error {
@@ -152,8 +210,8 @@ func dumpSecret(filename string, secret *api.Secret) error {
 		}
 	}
 
-	// TODO: check permissions required
-	err := ioutil.WriteFile(filename, val, 0777)
+	// the file is read by the NGINX workers
+	err := ioutil.WriteFile(filename, val, 0644)
 	if err != nil {
 		return ing_errors.LocationDenied{
 			Reason: errors.Wrap(err, "unexpected error creating password file"),
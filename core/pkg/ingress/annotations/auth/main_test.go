@@ -124,6 +124,93 @@ func TestIngressAuth(t *testing.T) {
 	}
 }
 
+func TestIngressAuthType(t *testing.T) {
+	ing := buildIngress()
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	for at, valid := range map[string]bool{
+		"basic":      true,
+		"digest":     true,
+		"notbasic":   false,
+		"basic-auth": false,
+		"Basic":      false,
+	} {
+		data := map[string]string{}
+		data[authType] = at
+		data[authSecret] = "demo-secret"
+		ing.SetAnnotations(data)
+
+		if IsValidType(at) != valid {
+			t.Errorf("expected %v validating auth type %v", valid, at)
+		}
+
+		_, err := NewParser(dir, mockSecret{}).Parse(ing)
+		if at == "basic" && err != nil {
+			t.Errorf("unexpected error with auth type %v: %v", at, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("expected an error with auth type %v", at)
+		}
+	}
+}
+
+func TestIngressAuthRealm(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[authType] = "basic"
+	data[authSecret] = "demo-secret"
+	data[authRealm] = `staging "area" \`
+	ing.SetAnnotations(data)
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	i, err := NewParser(dir, mockSecret{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error with ingress: %v", err)
+	}
+	expected := `staging \"area\" \\`
+	if realm := i.(*BasicDigest).Realm; realm != expected {
+		t.Errorf("expected %v as realm but returned %v", expected, realm)
+	}
+}
+
+func TestValidateSecret(t *testing.T) {
+	testCases := []struct {
+		authType string
+		realm    string
+		content  string
+		isError  bool
+	}{
+		{"basic", "", "foo:$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0", false},
+		{"basic", "", "# users\nfoo:$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0\n\nbar:{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=\n", false},
+		{"basic", "", "foo", true},
+		{"basic", "", ":$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0", true},
+		{"basic", "", "", true},
+		{"digest", "staging", "foo:staging:6a6a4ae8f38fc1d9f42f37a3c7e70e4f", false},
+		{"digest", "staging", "foo:staging:6a6a4ae8f38fc1d9f42f37a3c7e70e4f\nbar:other:6a6a4ae8f38fc1d9f42f37a3c7e70e4f", false},
+		{"digest", "staging", "foo:other:6a6a4ae8f38fc1d9f42f37a3c7e70e4f", true},
+		{"digest", "staging", "foo:$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0", true},
+		{"digest", "staging", "foo:staging:plain", true},
+	}
+
+	for _, tc := range testCases {
+		s := &api.Secret{
+			Data: map[string][]byte{"auth": []byte(tc.content)},
+		}
+		err := validateSecret(tc.authType, tc.realm, s)
+		if tc.isError && err == nil {
+			t.Errorf("expected an error validating %v secret %q", tc.authType, tc.content)
+		}
+		if !tc.isError && err != nil {
+			t.Errorf("unexpected error validating %v secret %q: %v", tc.authType, tc.content, err)
+		}
+	}
+}
+
 func TestIngressAuthWithoutSecret(t *testing.T) {
 	ing := buildIngress()
 
@@ -174,4 +261,12 @@ func TestDumpSecret(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unexpected error creating htpasswd file %v: %v", tmpfile, err)
 	}
+
+	content, err := ioutil.ReadFile(tmpfile)
+	if err != nil {
+		t.Fatalf("unexpected error reading htpasswd file %v: %v", tmpfile, err)
+	}
+	if string(content) != string(sd["auth"]) {
+		t.Errorf("expected %v as content of the htpasswd file but %v returned", string(sd["auth"]), string(content))
+	}
 }
@@ -69,10 +69,10 @@ func (e1 *External) Equal(e2 *External) bool {
 	if e1.SendBody != e2.SendBody {
 		return false
 	}
-	if e1.Method != e2.Method {
+
+	if len(e1.ResponseHeaders) != len(e2.ResponseHeaders) {
 		return false
 	}
-
 	for _, ep1 := range e1.ResponseHeaders {
 		found := false
 		for _, ep2 := range e2.ResponseHeaders {
@@ -111,6 +111,19 @@ func validHeader(header string) bool {
 	return headerRegexp.Match([]byte(header))
 }
 
+// validSigninURL checks the signin URL is an http or https URL
+// or an absolute path, where the unauthorized requests are redirected
+func validSigninURL(signin string) bool {
+	ur, err := url.Parse(signin)
+	if err != nil {
+		return false
+	}
+	if ur.Scheme == "" && ur.Host == "" {
+		return strings.HasPrefix(ur.Path, "/")
+	}
+	return (ur.Scheme == "http" || ur.Scheme == "https") && ur.Host != ""
+}
+
 type authReq struct {
 }
 
@@ -140,6 +153,9 @@ func (a authReq) Parse(ing *extensions.Ingress) (interface{}, error) {
 	if ur.Scheme == "" {
 		return nil, ing_errors.NewLocationDenied("url scheme is empty")
 	}
+	if ur.Scheme != "http" && ur.Scheme != "https" {
+		return nil, ing_errors.NewLocationDenied("url scheme must be http or https")
+	}
 	if ur.Host == "" {
 		return nil, ing_errors.NewLocationDenied("url host is empty")
 	}
@@ -148,6 +164,10 @@ func (a authReq) Parse(ing *extensions.Ingress) (interface{}, error) {
 		return nil, ing_errors.NewLocationDenied("invalid url host")
 	}
 
+	if signin != "" && !validSigninURL(signin) {
+		return nil, ing_errors.NewLocationDenied("invalid signin url")
+	}
+
 	m, _ := parser.GetStringAnnotation(authMethod, ing)
 	if len(m) != 0 && !validMethod(m) {
 		return nil, ing_errors.NewLocationDenied("invalid HTTP method")
@@ -81,6 +81,10 @@ func TestAnnotations(t *testing.T) {
 		{"no scheme", "bar", "bar", "", false, true},
 		{"invalid host", "http://", "http://", "", false, true},
 		{"invalid host (multiple dots)", "http://foo..bar.com", "http://foo..bar.com", "", false, true},
+		{"invalid scheme", "ftp://foo.com/external-auth", "", "", false, true},
+		{"invalid signin URL", "http://foo.com/external-auth", "ftp://foo.com/signin", "", false, true},
+		{"invalid signin path", "http://foo.com/external-auth", "signin", "", false, true},
+		{"valid URL - signin path", "https://foo.com/external-auth", "/oauth2/start", "", false, false},
 		{"valid URL", "http://bar.foo.com/external-auth", "http://bar.foo.com/external-auth", "", false, false},
 		{"valid URL - send body", "http://foo.com/external-auth", "http://foo.com/external-auth", "POST", true, false},
 		{"valid URL - send body", "http://foo.com/external-auth", "http://foo.com/external-auth", "GET", true, false},
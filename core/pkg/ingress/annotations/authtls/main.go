@@ -17,6 +17,11 @@ limitations under the License.
 package authtls
 
 import (
+	"crypto/x509"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
 	"github.com/pkg/errors"
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
@@ -30,14 +35,45 @@ const (
 	// name of the secret
 	annotationAuthTLSSecret = "ingress.kubernetes.io/auth-tls-secret"
 	annotationAuthTLSDepth  = "ingress.kubernetes.io/auth-tls-verify-depth"
+	annotationAuthTLSVerify = "ingress.kubernetes.io/auth-tls-verify-client"
+	annotationAuthTLSError  = "ingress.kubernetes.io/auth-tls-error-page"
 	defaultAuthTLSDepth     = 1
+	defaultAuthTLSVerify    = "on"
+)
+
+var (
+	// valid values of the auth-tls-verify-client annotation
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_verify_client
+	verifyClientValues = map[string]bool{
+		"on":       true,
+		"optional": true,
+		"off":      true,
+	}
+
+	// ErrMissingCACertificate is returned when the verification of client
+	// certificates is enabled and the secret does not contain a CA certificate
+	ErrMissingCACertificate = errors.New("the secret does not contain a CA certificate (ca.crt)")
 )
 
+// IsMissingCACertificate checks if the error was returned because
+// the secret used to verify client certificates does not contain a CA
+func IsMissingCACertificate(err error) bool {
+	if e, ok := err.(ing_errors.LocationDenied); ok {
+		err = e.Reason
+	}
+	return errors.Cause(err) == ErrMissingCACertificate
+}
+
 // AuthSSLConfig contains the AuthSSLCert used for muthual autentication
 // and the configured ValidationDepth
 type AuthSSLConfig struct {
 	AuthSSLCert     resolver.AuthSSLCert `json:"authSSLCert"`
 	ValidationDepth int                  `json:"validationDepth"`
+	// VerifyClient defines the verification of client certificates (on, optional or off)
+	VerifyClient string `json:"verifyClient"`
+	// ErrorPage is the URL where the clients are redirected when
+	// the certificate is not valid or was not sent
+	ErrorPage string `json:"errorPage"`
 }
 
 func (assl1 *AuthSSLConfig) Equal(assl2 *AuthSSLConfig) bool {
@@ -53,6 +89,12 @@ func (assl1 *AuthSSLConfig) Equal(assl2 *AuthSSLConfig) bool {
 	if assl1.ValidationDepth != assl2.ValidationDepth {
 		return false
 	}
+	if assl1.VerifyClient != assl2.VerifyClient {
+		return false
+	}
+	if assl1.ErrorPage != assl2.ErrorPage {
+		return false
+	}
 
 	return true
 }
@@ -89,6 +131,23 @@ func (a authTLS) Parse(ing *extensions.Ingress) (interface{}, error) {
 		tlsdepth = defaultAuthTLSDepth
 	}
 
+	verify, err := parser.GetStringAnnotation(annotationAuthTLSVerify, ing)
+	if err != nil || !verifyClientValues[verify] {
+		verify = defaultAuthTLSVerify
+	}
+
+	errorPage, err := parser.GetStringAnnotation(annotationAuthTLSError, ing)
+	if err != nil || !IsValidErrorPage(errorPage) {
+		errorPage = ""
+	}
+
+	if verify == "off" {
+		return &AuthSSLConfig{
+			ValidationDepth: tlsdepth,
+			VerifyClient:    verify,
+		}, nil
+	}
+
 	authCert, err := a.certResolver.GetAuthCertificate(tlsauthsecret)
 	if err != nil {
 		return &AuthSSLConfig{}, ing_errors.LocationDenied{
@@ -96,8 +155,55 @@ func (a authTLS) Parse(ing *extensions.Ingress) (interface{}, error) {
 		}
 	}
 
+	if authCert.CAFileName == "" {
+		return &AuthSSLConfig{}, ing_errors.LocationDenied{
+			Reason: errors.Wrapf(ErrMissingCACertificate, "secret %v", tlsauthsecret),
+		}
+	}
+
+	err = loadCACertificate(authCert.CAFileName)
+	if err != nil {
+		return &AuthSSLConfig{}, ing_errors.LocationDenied{
+			Reason: errors.Wrap(err, "invalid CA certificate"),
+		}
+	}
+
 	return &AuthSSLConfig{
 		AuthSSLCert:     *authCert,
 		ValidationDepth: tlsdepth,
+		VerifyClient:    verify,
+		ErrorPage:       errorPage,
 	}, nil
 }
+
+// IsValidErrorPage checks the value is an absolute path or a http(s)
+// URL that can be used in the error_page directive of the configuration
+func IsValidErrorPage(val string) bool {
+	if val == "" || strings.ContainsAny(val, " \t\n;{}'\"") {
+		return false
+	}
+	if strings.HasPrefix(val, "/") {
+		return true
+	}
+
+	u, err := url.Parse(val)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// loadCACertificate checks the file contains at least one
+// valid PEM encoded certificate
+func loadCACertificate(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	if !x509.NewCertPool().AppendCertsFromPEM(data) {
+		return errors.Errorf("file %v does not contain a valid PEM certificate", fileName)
+	}
+
+	return nil
+}
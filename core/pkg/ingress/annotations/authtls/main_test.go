@@ -17,12 +17,24 @@ limitations under the License.
 package authtls
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
 	"testing"
+	"time"
 
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	api "k8s.io/client-go/pkg/api/v1"
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/resolver"
 )
 
 func buildIngress() *extensions.Ingress {
@@ -106,3 +118,154 @@ func TestAnnotations(t *testing.T) {
 				}
 		}*/
 }
+
+// newCACertificate returns a PEM encoded self-signed CA certificate
+func newCACertificate(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("unexpected error generating the private key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating the certificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+type mockSecret struct {
+	caFileName string
+}
+
+func (m mockSecret) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	if name != "default/ca" {
+		return nil, fmt.Errorf("secret %v does not exist", name)
+	}
+
+	return &resolver.AuthSSLCert{
+		Secret:     name,
+		CAFileName: m.caFileName,
+		PemSHA:     "abc",
+	}, nil
+}
+
+func TestVerifyClient(t *testing.T) {
+	ca, err := ioutil.TempFile("", "ca.crt")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	defer os.Remove(ca.Name())
+	ca.Write(newCACertificate(t))
+	ca.Close()
+
+	ing := buildIngress()
+	for verify, expected := range map[string]string{
+		"":         "on",
+		"on":       "on",
+		"optional": "optional",
+		"off":      "off",
+		"invalid":  "on",
+	} {
+		data := map[string]string{
+			annotationAuthTLSSecret: "default/ca",
+			annotationAuthTLSVerify: verify,
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockSecret{ca.Name()}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error with verify-client '%v': %v", verify, err)
+			continue
+		}
+		config := i.(*AuthSSLConfig)
+		if config.VerifyClient != expected {
+			t.Errorf("expected %v with verify-client '%v' but returned %v", expected, verify, config.VerifyClient)
+		}
+		if expected != "off" && config.AuthSSLCert.CAFileName != ca.Name() {
+			t.Errorf("expected the CA file %v but returned '%v'", ca.Name(), config.AuthSSLCert.CAFileName)
+		}
+	}
+}
+
+func TestErrorPage(t *testing.T) {
+	ca, err := ioutil.TempFile("", "ca.crt")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	defer os.Remove(ca.Name())
+	ca.Write(newCACertificate(t))
+	ca.Close()
+
+	ing := buildIngress()
+	for errorPage, expected := range map[string]string{
+		"":                                      "",
+		"/certificate-error":                    "/certificate-error",
+		"https://example.com/certificate-error": "https://example.com/certificate-error",
+		"ftp://example.com/certificate-error":   "",
+		"example.com":                           "",
+		"/error; return 200":                    "",
+	} {
+		data := map[string]string{
+			annotationAuthTLSSecret: "default/ca",
+			annotationAuthTLSError:  errorPage,
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockSecret{ca.Name()}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error with error page '%v': %v", errorPage, err)
+			continue
+		}
+		if config := i.(*AuthSSLConfig); config.ErrorPage != expected {
+			t.Errorf("expected '%v' with error page '%v' but returned '%v'", expected, errorPage, config.ErrorPage)
+		}
+	}
+}
+
+func TestMissingCACertificate(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		annotationAuthTLSSecret: "default/ca",
+	})
+
+	_, err := NewParser(mockSecret{}).Parse(ing)
+	if !IsMissingCACertificate(err) {
+		t.Errorf("expected a missing CA certificate error but returned %v", err)
+	}
+
+	invalid, err := ioutil.TempFile("", "ca.crt")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	defer os.Remove(invalid.Name())
+	invalid.WriteString("invalid certificate")
+	invalid.Close()
+
+	_, err = NewParser(mockSecret{invalid.Name()}).Parse(ing)
+	if err == nil {
+		t.Errorf("expected an error with an invalid CA certificate")
+	}
+	if IsMissingCACertificate(err) {
+		t.Errorf("unexpected missing CA certificate error with an invalid CA certificate")
+	}
+
+	// the CA is not required without verification
+	ing.SetAnnotations(map[string]string{
+		annotationAuthTLSSecret: "default/ca",
+		annotationAuthTLSVerify: "off",
+	})
+	_, err = NewParser(mockSecret{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error without verification: %v", err)
+	}
+}
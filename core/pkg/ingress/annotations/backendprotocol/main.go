@@ -0,0 +1,69 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backendprotocol
+
+import (
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/backend-protocol"
+)
+
+// protocols used to communicate with the backend
+const (
+	HTTP  = "HTTP"
+	HTTPS = "HTTPS"
+	GRPC  = "GRPC"
+	GRPCS = "GRPCS"
+	FCGI  = "FCGI"
+	UWSGI = "UWSGI"
+	AJP   = "AJP"
+)
+
+// IsValidProtocol checks the value of the backend-protocol annotation
+// is one of the supported protocols (case insensitive)
+func IsValidProtocol(protocol string) bool {
+	switch strings.ToUpper(protocol) {
+	case HTTP, HTTPS, GRPC, GRPCS, FCGI, UWSGI, AJP:
+		return true
+	}
+	return false
+}
+
+type backendProtocol struct {
+}
+
+// NewParser creates a new backend-protocol annotation parser
+func NewParser() parser.IngressAnnotation {
+	return backendProtocol{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the protocol used to communicate with the backend.
+// Invalid values are ignored, so the backend uses HTTP
+func (a backendProtocol) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidProtocol(val) {
+		return HTTP, nil
+	}
+	return strings.ToUpper(val), nil
+}
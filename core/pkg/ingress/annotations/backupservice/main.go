@@ -0,0 +1,48 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupservice
+
+import (
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/defaultbackend"
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/upstream-backup-service"
+)
+
+type backup struct {
+}
+
+// NewParser creates a new backup service annotation parser
+func NewParser() parser.IngressAnnotation {
+	return backup{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the service, in the namespace of the Ingress rule,
+// with the endpoints used as backup servers of the upstreams of the
+// rule. Invalid values are ignored
+func (b backup) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !defaultbackend.IsValidService(val) {
+		return "", nil
+	}
+	return val, nil
+}
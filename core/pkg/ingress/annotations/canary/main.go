@@ -0,0 +1,141 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canary
+
+import (
+	"regexp"
+	"strconv"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	enabled = "ingress.kubernetes.io/canary"
+	weight  = "ingress.kubernetes.io/canary-weight"
+	header  = "ingress.kubernetes.io/canary-by-header"
+	cookie  = "ingress.kubernetes.io/canary-by-cookie"
+)
+
+var (
+	headerRegex = regexp.MustCompile(`^[a-zA-Z0-9\-]+$`)
+	cookieRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+)
+
+// Config returns the configuration of a canary Ingress rule, which receives
+// part of the requests of the locations with the same host and path defined
+// in other Ingress rules
+type Config struct {
+	// Enabled indicates the Ingress rule is a canary
+	Enabled bool `json:"enabled"`
+	// Weight percentage of the requests sent to the canary
+	Weight int `json:"weight"`
+	// Header name of the request header used to select the canary
+	// (value always) or the main backend (value never)
+	Header string `json:"header,omitempty"`
+	// Cookie name of the cookie used to select the canary
+	// (value always) or the main backend (value never)
+	Cookie string `json:"cookie,omitempty"`
+	// Backend name of the upstream of the canary, defined by the
+	// controller in the locations that send requests to the canary
+	Backend string `json:"backend,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.Weight != c2.Weight {
+		return false
+	}
+	if c1.Header != c2.Header {
+		return false
+	}
+	if c1.Cookie != c2.Cookie {
+		return false
+	}
+	if c1.Backend != c2.Backend {
+		return false
+	}
+
+	return true
+}
+
+// IsValidWeight checks the weight is a percentage between 0 and 100
+func IsValidWeight(val string) bool {
+	w, err := strconv.Atoi(val)
+	return err == nil && w >= 0 && w <= 100
+}
+
+// IsValidHeader checks the value is the name of a header
+func IsValidHeader(val string) bool {
+	return headerRegex.MatchString(val)
+}
+
+// IsValidCookie checks the value is the name of a cookie
+func IsValidCookie(val string) bool {
+	return cookieRegex.MatchString(val)
+}
+
+type canary struct {
+}
+
+// NewParser creates a new canary annotation parser
+func NewParser() parser.IngressAnnotation {
+	return canary{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send part of the requests to a new version of a service.
+// An invalid weight is ignored, so no requests are sent to the canary
+// without the header or the cookie. Invalid names are also ignored
+func (c canary) Parse(ing *extensions.Ingress) (interface{}, error) {
+	e, err := parser.GetBoolAnnotation(enabled, ing)
+	if err != nil || !e {
+		return &Config{}, nil
+	}
+
+	w, _ := parser.GetIntAnnotation(weight, ing)
+	if w < 0 || w > 100 {
+		w = 0
+	}
+
+	h, _ := parser.GetStringAnnotation(header, ing)
+	if !IsValidHeader(h) {
+		h = ""
+	}
+
+	ck, _ := parser.GetStringAnnotation(cookie, ing)
+	if !IsValidCookie(ck) {
+		ck = ""
+	}
+
+	return &Config{
+		Enabled: true,
+		Weight:  w,
+		Header:  h,
+		Cookie:  ck,
+	}, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package canary
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{enabled: "true", weight: "20"}, &Config{Enabled: true, Weight: 20}},
+		{map[string]string{enabled: "true", weight: "100"}, &Config{Enabled: true, Weight: 100}},
+		{map[string]string{enabled: "true"}, &Config{Enabled: true}},
+		{map[string]string{enabled: "true", weight: "101"}, &Config{Enabled: true}},
+		{map[string]string{enabled: "true", weight: "-1"}, &Config{Enabled: true}},
+		{map[string]string{enabled: "true", header: "X-Canary"}, &Config{Enabled: true, Header: "X-Canary"}},
+		{map[string]string{enabled: "true", cookie: "canary_user", weight: "5"}, &Config{Enabled: true, Weight: 5, Cookie: "canary_user"}},
+		{map[string]string{enabled: "true", header: "X Canary", cookie: "canary;user"}, &Config{Enabled: true}},
+		{map[string]string{header: "X-Canary"}, &Config{}},
+		{map[string]string{enabled: "false", weight: "20"}, &Config{}},
+		{map[string]string{weight: "20"}, &Config{}},
+		{nil, &Config{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !testCase.expected.Equal(result.(*Config)) {
+			t.Errorf("expected %+v but returned %+v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestIsValidWeight(t *testing.T) {
+	for _, w := range []string{"0", "1", "50", "100"} {
+		if !IsValidWeight(w) {
+			t.Errorf("expected %v to be a valid weight", w)
+		}
+	}
+	for _, w := range []string{"-1", "101", "10%", ""} {
+		if IsValidWeight(w) {
+			t.Errorf("expected %v to be an invalid weight", w)
+		}
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package cors
 
 import (
+	"regexp"
+
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
@@ -24,8 +26,82 @@ import (
 
 const (
 	annotation = "ingress.kubernetes.io/enable-cors"
+
+	allowOrigin      = "ingress.kubernetes.io/cors-allow-origin"
+	allowMethods     = "ingress.kubernetes.io/cors-allow-methods"
+	allowHeaders     = "ingress.kubernetes.io/cors-allow-headers"
+	allowCredentials = "ingress.kubernetes.io/cors-allow-credentials"
+	maxAge           = "ingress.kubernetes.io/cors-max-age"
+
+	defAllowOrigin  = "*"
+	defAllowMethods = "GET, PUT, POST, DELETE, PATCH, OPTIONS"
+	defAllowHeaders = "DNT,X-CustomHeader,Keep-Alive,User-Agent,X-Requested-With,If-Modified-Since,Cache-Control,Content-Type,Authorization"
+	// the preflight responses are valid for 20 days
+	defMaxAge = 1728000
 )
 
+var (
+	// * or a single origin (scheme, host and optional port)
+	originRegex = regexp.MustCompile(`^(\*|https?://[A-Za-z0-9\-.]+(:[0-9]+)?)$`)
+	// list of methods separated by commas
+	methodsRegex = regexp.MustCompile(`^[A-Z]+(\s*,\s*[A-Z]+)*$`)
+	// list of headers separated by commas
+	headersRegex = regexp.MustCompile(`^[A-Za-z0-9\-_]+(\s*,\s*[A-Za-z0-9\-_]+)*$`)
+)
+
+// Config contains the CORS headers sent by the locations
+// of an Ingress rule with CORS enabled
+type Config struct {
+	AllowOrigin      string `json:"allowOrigin"`
+	AllowMethods     string `json:"allowMethods"`
+	AllowHeaders     string `json:"allowHeaders"`
+	AllowCredentials bool   `json:"allowCredentials"`
+	MaxAge           int    `json:"maxAge"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.AllowOrigin != c2.AllowOrigin {
+		return false
+	}
+	if c1.AllowMethods != c2.AllowMethods {
+		return false
+	}
+	if c1.AllowHeaders != c2.AllowHeaders {
+		return false
+	}
+	if c1.AllowCredentials != c2.AllowCredentials {
+		return false
+	}
+	if c1.MaxAge != c2.MaxAge {
+		return false
+	}
+
+	return true
+}
+
+// IsValidOrigin checks the origin is * or a single origin
+// e.g. https://example.com or http://localhost:8080
+func IsValidOrigin(origin string) bool {
+	return originRegex.MatchString(origin)
+}
+
+// IsValidMethods checks the value is a list of HTTP methods separated by commas
+func IsValidMethods(methods string) bool {
+	return methodsRegex.MatchString(methods)
+}
+
+// IsValidHeaders checks the value is a list of headers separated by commas
+func IsValidHeaders(headers string) bool {
+	return headersRegex.MatchString(headers)
+}
+
 type cors struct {
 }
 
@@ -39,3 +115,49 @@ func NewParser() parser.IngressAnnotation {
 func (a cors) Parse(ing *extensions.Ingress) (interface{}, error) {
 	return parser.GetBoolAnnotation(annotation, ing)
 }
+
+type corsConfig struct {
+}
+
+// NewConfigParser creates a new parser of the CORS headers
+// sent by the locations with CORS enabled
+func NewConfigParser() parser.IngressAnnotation {
+	return corsConfig{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// configure the CORS headers. Invalid values are replaced by the defaults
+func (a corsConfig) Parse(ing *extensions.Ingress) (interface{}, error) {
+	origin, _ := parser.GetStringAnnotation(allowOrigin, ing)
+	if !IsValidOrigin(origin) {
+		origin = defAllowOrigin
+	}
+
+	methods, _ := parser.GetStringAnnotation(allowMethods, ing)
+	if !IsValidMethods(methods) {
+		methods = defAllowMethods
+	}
+
+	headers, _ := parser.GetStringAnnotation(allowHeaders, ing)
+	if !IsValidHeaders(headers) {
+		headers = defAllowHeaders
+	}
+
+	credentials, err := parser.GetBoolAnnotation(allowCredentials, ing)
+	if err != nil {
+		credentials = true
+	}
+
+	age, err := parser.GetIntAnnotation(maxAge, ing)
+	if err != nil || age < 0 {
+		age = defMaxAge
+	}
+
+	return &Config{
+		AllowOrigin:      origin,
+		AllowMethods:     methods,
+		AllowHeaders:     headers,
+		AllowCredentials: credentials,
+		MaxAge:           age,
+	}, nil
+}
@@ -61,3 +61,62 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseConfig(t *testing.T) {
+	ap := NewConfigParser()
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	defaults := Config{
+		AllowOrigin:      defAllowOrigin,
+		AllowMethods:     defAllowMethods,
+		AllowHeaders:     defAllowHeaders,
+		AllowCredentials: true,
+		MaxAge:           defMaxAge,
+	}
+
+	testCases := []struct {
+		title       string
+		annotations map[string]string
+		expected    Config
+	}{
+		{"without annotations", nil, defaults},
+		{"custom headers", map[string]string{
+			allowOrigin:      "https://app.example.com",
+			allowMethods:     "GET, POST",
+			allowHeaders:     "Content-Type,X-Api-Key",
+			allowCredentials: "false",
+			maxAge:           "600",
+		}, Config{
+			AllowOrigin:      "https://app.example.com",
+			AllowMethods:     "GET, POST",
+			AllowHeaders:     "Content-Type,X-Api-Key",
+			AllowCredentials: false,
+			MaxAge:           600,
+		}},
+		{"invalid values", map[string]string{
+			allowOrigin:  "https://a.example.com https://b.example.com",
+			allowMethods: "get; return 200",
+			allowHeaders: "X-Api-Key'",
+			maxAge:       "-1",
+		}, defaults},
+	}
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := ap.Parse(ing)
+		config := result.(*Config)
+		if !config.Equal(&testCase.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", testCase.title, testCase.expected, config)
+		}
+	}
+}
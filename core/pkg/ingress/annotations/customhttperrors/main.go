@@ -0,0 +1,79 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customhttperrors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/custom-http-errors"
+)
+
+// ParseCodes parses a list of HTTP status codes separated by commas,
+// returning the valid codes without duplicates and an error if any of the
+// codes is not a number between 300 and 599
+func ParseCodes(val string) ([]int, error) {
+	codes := []int{}
+	seen := map[int]bool{}
+
+	var err error
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		code, e := strconv.Atoi(v)
+		if e != nil || code < 300 || code > 599 {
+			if err == nil {
+				err = fmt.Errorf("%v is not a valid HTTP status code for custom error pages", v)
+			}
+			continue
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	return codes, err
+}
+
+type customHTTPErrors struct {
+}
+
+// NewParser creates a new custom HTTP errors annotation parser
+func NewParser() parser.IngressAnnotation {
+	return customHTTPErrors{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the status codes of the responses of the backends
+// replaced by the custom error pages. Invalid codes are ignored
+func (c customHTTPErrors) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil {
+		return []int{}, nil
+	}
+
+	codes, _ := ParseCodes(val)
+	return codes, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customhttperrors
+
+import (
+	"reflect"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    []int
+	}{
+		{map[string]string{annotation: "404,503"}, []int{404, 503}},
+		{map[string]string{annotation: " 503 , 404 ,503"}, []int{503, 404}},
+		{map[string]string{annotation: "404,abc,200,700"}, []int{404}},
+		{map[string]string{annotation: ""}, []int{}},
+		{nil, []int{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestParseCodes(t *testing.T) {
+	if _, err := ParseCodes("404,503"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, val := range []string{"404,", "200", "404;503", "600"} {
+		if _, err := ParseCodes(val); err == nil {
+			t.Errorf("expected an error parsing %v", val)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultbackend
+
+import (
+	"regexp"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/default-backend"
+)
+
+var (
+	// names of services (DNS-1035 labels)
+	serviceRegex = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// IsValidService checks the value is a valid name of a service
+func IsValidService(val string) bool {
+	return len(val) <= 63 && serviceRegex.MatchString(val)
+}
+
+type backend struct {
+}
+
+// NewParser creates a new default backend annotation parser
+func NewParser() parser.IngressAnnotation {
+	return backend{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the service, in the namespace of the Ingress rule,
+// used as default backend of the hosts of the rule. Invalid values
+// are ignored, so the default backend of the controller is used
+func (b backend) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidService(val) {
+		return "", nil
+	}
+	return val, nil
+}
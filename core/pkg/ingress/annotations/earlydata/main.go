@@ -0,0 +1,49 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package earlydata
+
+import (
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingress/core/pkg/ingress/resolver"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/ssl-early-data"
+)
+
+type earlyData struct {
+	backendResolver resolver.DefaultBackend
+}
+
+// NewParser creates a new early data annotation parser
+func NewParser(db resolver.DefaultBackend) parser.IngressAnnotation {
+	return earlyData{db}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to indicate if the locations accept requests sent in
+// the TLSv1.3 early data (0-RTT), that can be replayed
+func (a earlyData) Parse(ing *extensions.Ingress) (interface{}, error) {
+	ed, err := parser.GetBoolAnnotation(annotation, ing)
+	if err != nil {
+		return a.backendResolver.GetDefaultBackend().SSLEarlyData, nil
+	}
+
+	return ed, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package earlydata
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/defaults"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+type mockBackend struct {
+	sslEarlyData bool
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{SSLEarlyData: m.sslEarlyData}
+}
+
+func TestEarlyData(t *testing.T) {
+	tests := []struct {
+		title      string
+		annotation string
+		def        bool
+		exp        bool
+	}{
+		{"no annotation - default false", "", false, false},
+		{"no annotation - default true", "", true, true},
+		{"opt-out - default true", "false", true, false},
+		{"true - default true", "true", true, true},
+		{"invalid value - default true", "no", true, true},
+	}
+
+	for _, test := range tests {
+		ing := buildIngress()
+
+		data := map[string]string{}
+		if test.annotation != "" {
+			data[annotation] = test.annotation
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{test.def}).Parse(ing)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+		}
+		ed, ok := i.(bool)
+		if !ok {
+			t.Errorf("%v: expected a bool type", test.title)
+		}
+		if ed != test.exp {
+			t.Errorf("%v: expected %v but %v was returned", test.title, test.exp, ed)
+		}
+	}
+}
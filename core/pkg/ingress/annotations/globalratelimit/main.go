@@ -0,0 +1,131 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globalratelimit
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	limit  = "ingress.kubernetes.io/global-rate-limit"
+	window = "ingress.kubernetes.io/global-rate-limit-window"
+	key    = "ingress.kubernetes.io/global-rate-limit-key"
+
+	// requests are counted in windows of one minute
+	defWindow = time.Minute
+	// requests are counted by client address
+	defKey = "$the_real_ip"
+
+	// the counters of the windows are stored in memcached, which
+	// only accepts relative expiration times up to 30 days
+	maxWindow = 24 * time.Hour
+)
+
+var (
+	// variables and text allowed in the key of the counters
+	keyRegex = regexp.MustCompile(`^[a-zA-Z0-9_$.:\-]+$`)
+)
+
+// Config returns the configuration of the rate limit shared by all the
+// replicas of the controller for an Ingress rule
+type Config struct {
+	// Namespace prefix of the counters of the Ingress rule
+	Namespace string `json:"namespace"`
+	// Limit number of requests allowed in each window
+	Limit int `json:"limit"`
+	// WindowSize duration of the windows in seconds
+	WindowSize int `json:"windowSize"`
+	// Key NGINX variables used to count the requests
+	Key string `json:"key"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Namespace != c2.Namespace {
+		return false
+	}
+	if c1.Limit != c2.Limit {
+		return false
+	}
+	if c1.WindowSize != c2.WindowSize {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+
+	return true
+}
+
+// IsValidWindow checks the window is a duration between one second and one day
+func IsValidWindow(w string) bool {
+	d, err := time.ParseDuration(w)
+	return err == nil && d >= time.Second && d <= maxWindow
+}
+
+// IsValidKey checks the key only contains NGINX variables and text
+// without spaces or quotes, e.g. `$the_real_ip` or `$host:$http_x_api_key`
+func IsValidKey(k string) bool {
+	return keyRegex.MatchString(k)
+}
+
+type globalRateLimit struct {
+}
+
+// NewParser creates a new global rate limit annotation parser
+func NewParser() parser.IngressAnnotation {
+	return globalRateLimit{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the requests allowed in all the replicas
+func (a globalRateLimit) Parse(ing *extensions.Ingress) (interface{}, error) {
+	l, err := parser.GetIntAnnotation(limit, ing)
+	if err != nil || l <= 0 {
+		return &Config{}, nil
+	}
+
+	w := defWindow
+	val, _ := parser.GetStringAnnotation(window, ing)
+	if IsValidWindow(val) {
+		w, _ = time.ParseDuration(val)
+	}
+
+	k, _ := parser.GetStringAnnotation(key, ing)
+	if !IsValidKey(k) {
+		k = defKey
+	}
+
+	return &Config{
+		Namespace:  fmt.Sprintf("%v_%v", ing.GetNamespace(), ing.GetName()),
+		Limit:      l,
+		WindowSize: int(w.Seconds()),
+		Key:        k,
+	}, nil
+}
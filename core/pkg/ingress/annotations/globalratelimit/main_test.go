@@ -0,0 +1,81 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package globalratelimit
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		expected    Config
+	}{
+		{"without annotations", nil, Config{}},
+		{"invalid limit", map[string]string{limit: "many"}, Config{}},
+		{"negative limit", map[string]string{limit: "-1"}, Config{}},
+		{"limit with defaults", map[string]string{limit: "100"},
+			Config{Namespace: "default_foo", Limit: 100, WindowSize: 60, Key: "$the_real_ip"}},
+		{"custom window and key", map[string]string{limit: "10", window: "1h", key: "$host:$http_x_api_key"},
+			Config{Namespace: "default_foo", Limit: 10, WindowSize: 3600, Key: "$host:$http_x_api_key"}},
+		{"invalid window and key", map[string]string{limit: "10", window: "500ms", key: `$host "`},
+			Config{Namespace: "default_foo", Limit: 10, WindowSize: 60, Key: "$the_real_ip"}},
+	}
+
+	for _, test := range tests {
+		ing.SetAnnotations(test.annotations)
+		i, err := NewParser().Parse(ing)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+		c := i.(*Config)
+		if !c.Equal(&test.expected) {
+			t.Errorf("%v: expected %+v but %+v was returned", test.title, test.expected, c)
+		}
+	}
+}
+
+func TestIsValidWindow(t *testing.T) {
+	for w, expected := range map[string]bool{
+		"1s":    true,
+		"90s":   true,
+		"1h":    true,
+		"24h":   true,
+		"25h":   false,
+		"500ms": false,
+		"1":     false,
+		"":      false,
+	} {
+		if IsValidWindow(w) != expected {
+			t.Errorf("expected %v validating the window %q", expected, w)
+		}
+	}
+}
@@ -17,6 +17,12 @@ limitations under the License.
 package healthcheck
 
 import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
@@ -26,13 +32,50 @@ import (
 const (
 	upsMaxFails    = "ingress.kubernetes.io/upstream-max-fails"
 	upsFailTimeout = "ingress.kubernetes.io/upstream-fail-timeout"
+
+	upsCheckScript   = "ingress.kubernetes.io/upstream-health-check-script"
+	upsCheckFunction = "ingress.kubernetes.io/upstream-health-check-function"
+
+	defaultCheckFunction = "check"
+)
+
+var (
+	// njs exported functions must be valid JavaScript identifiers
+	checkFunctionRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 )
 
 // Upstream returns the URL and method to use check the status of
 // the upstream server/s
 type Upstream struct {
-	MaxFails    int `json:"maxFails"`
-	FailTimeout int `json:"failTimeout"`
+	MaxFails    int    `json:"maxFails"`
+	FailTimeout int    `json:"failTimeout"`
+	Script      Script `json:"script"`
+}
+
+// Script defines a njs script used to check the status of the upstream.
+// Path is the location of the script and Function the name of the
+// function exported by the script that returns the status
+type Script struct {
+	Path     string `json:"path"`
+	Function string `json:"function"`
+}
+
+// Equal tests for equality between two Script types
+func (s1 *Script) Equal(s2 *Script) bool {
+	if s1 == s2 {
+		return true
+	}
+	if s1 == nil || s2 == nil {
+		return false
+	}
+	if s1.Path != s2.Path {
+		return false
+	}
+	if s1.Function != s2.Function {
+		return false
+	}
+
+	return true
 }
 
 type healthCheck struct {
@@ -49,18 +92,48 @@ func NewParser(br resolver.DefaultBackend) parser.IngressAnnotation {
 func (a healthCheck) Parse(ing *extensions.Ingress) (interface{}, error) {
 	defBackend := a.backendResolver.GetDefaultBackend()
 	if ing.GetAnnotations() == nil {
-		return &Upstream{defBackend.UpstreamMaxFails, defBackend.UpstreamFailTimeout}, nil
+		return &Upstream{defBackend.UpstreamMaxFails, defBackend.UpstreamFailTimeout, Script{}}, nil
 	}
 
 	mf, err := parser.GetIntAnnotation(upsMaxFails, ing)
 	if err != nil {
 		mf = defBackend.UpstreamMaxFails
+	} else if mf < 0 {
+		glog.Warningf("invalid value in annotation %v (%v). The value must be zero or positive", upsMaxFails, mf)
+		mf = defBackend.UpstreamMaxFails
 	}
 
 	ft, err := parser.GetIntAnnotation(upsFailTimeout, ing)
 	if err != nil {
 		ft = defBackend.UpstreamFailTimeout
+	} else if ft < 0 {
+		glog.Warningf("invalid value in annotation %v (%v). The value must be zero or positive", upsFailTimeout, ft)
+		ft = defBackend.UpstreamFailTimeout
+	}
+
+	return &Upstream{mf, ft, parseScript(ing)}, nil
+}
+
+// parseScript returns the njs script used to check the status of the
+// upstream. The script must be an absolute path to a JavaScript file
+func parseScript(ing *extensions.Ingress) Script {
+	path, err := parser.GetStringAnnotation(upsCheckScript, ing)
+	if err != nil || path == "" {
+		return Script{}
+	}
+
+	if !filepath.IsAbs(path) || !strings.HasSuffix(path, ".js") || strings.ContainsAny(path, " ;{}") {
+		glog.Warningf("invalid value in annotation %v (%v). The value must be the absolute path of a .js file", upsCheckScript, path)
+		return Script{}
+	}
+
+	fn, err := parser.GetStringAnnotation(upsCheckFunction, ing)
+	if err != nil || fn == "" {
+		fn = defaultCheckFunction
+	} else if !checkFunctionRegex.MatchString(fn) {
+		glog.Warningf("invalid value in annotation %v (%v). Using the default %v", upsCheckFunction, fn, defaultCheckFunction)
+		fn = defaultCheckFunction
 	}
 
-	return &Upstream{mf, ft}, nil
+	return Script{Path: path, Function: fn}
 }
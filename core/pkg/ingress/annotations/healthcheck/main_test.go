@@ -90,3 +90,64 @@ func TestIngressHealthCheck(t *testing.T) {
 		t.Errorf("expected 0 as fail-timeout but returned %v", nginxHz.FailTimeout)
 	}
 }
+
+func TestIngressHealthCheckInvalidValues(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[upsMaxFails] = "-1"
+	data[upsFailTimeout] = "-5"
+	ing.SetAnnotations(data)
+
+	hzi, _ := NewParser(mockBackend{}).Parse(ing)
+	nginxHz, ok := hzi.(*Upstream)
+	if !ok {
+		t.Fatalf("expected a Upstream type")
+	}
+
+	if nginxHz.MaxFails != 0 {
+		t.Errorf("expected the default max-fails (0) but returned %v", nginxHz.MaxFails)
+	}
+
+	if nginxHz.FailTimeout != 1 {
+		t.Errorf("expected the default fail-timeout (1) but returned %v", nginxHz.FailTimeout)
+	}
+}
+
+func TestIngressHealthCheckScript(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[upsCheckScript] = "/etc/nginx/njs/health.js"
+	ing.SetAnnotations(data)
+
+	hzi, _ := NewParser(mockBackend{}).Parse(ing)
+	hz := hzi.(*Upstream)
+	expected := Script{Path: "/etc/nginx/njs/health.js", Function: "check"}
+	if !(&hz.Script).Equal(&expected) {
+		t.Errorf("expected %v but returned %v", expected, hz.Script)
+	}
+
+	data[upsCheckFunction] = "probe_http"
+	hzi, _ = NewParser(mockBackend{}).Parse(ing)
+	hz = hzi.(*Upstream)
+	if hz.Script.Function != "probe_http" {
+		t.Errorf("expected probe_http as function but returned %v", hz.Script.Function)
+	}
+
+	data[upsCheckFunction] = "probe; return"
+	hzi, _ = NewParser(mockBackend{}).Parse(ing)
+	hz = hzi.(*Upstream)
+	if hz.Script.Function != "check" {
+		t.Errorf("expected the default function with an invalid value but returned %v", hz.Script.Function)
+	}
+
+	for _, path := range []string{"health.js", "/etc/nginx/njs/health.sh", "/tmp/a.js; include /etc/passwd;.js"} {
+		data[upsCheckScript] = path
+		hzi, _ = NewParser(mockBackend{}).Parse(ing)
+		hz = hzi.(*Upstream)
+		if hz.Script.Path != "" {
+			t.Errorf("expected no script with the invalid path %v but returned %v", path, hz.Script)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"strconv"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingress/core/pkg/ingress/resolver"
+)
+
+const (
+	hsts                  = "ingress.kubernetes.io/hsts"
+	hstsMaxAge            = "ingress.kubernetes.io/hsts-max-age"
+	hstsIncludeSubdomains = "ingress.kubernetes.io/hsts-include-subdomains"
+	hstsPreload           = "ingress.kubernetes.io/hsts-preload"
+)
+
+// Config describes the Strict-Transport-Security header of a server
+type Config struct {
+	// Enabled indicates if the header is sent in the servers running SSL
+	Enabled bool `json:"enabled"`
+	// MaxAge is the time, in seconds, that the browser should remember
+	// that the server is only to be accessed using HTTPS
+	MaxAge string `json:"maxAge"`
+	// IncludeSubdomains indicates if the header applies to the subdomains
+	IncludeSubdomains bool `json:"includeSubdomains"`
+	// Preload indicates if the preload attribute is added to the header
+	Preload bool `json:"preload"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled {
+		return false
+	}
+	if c1.MaxAge != c2.MaxAge {
+		return false
+	}
+	if c1.IncludeSubdomains != c2.IncludeSubdomains {
+		return false
+	}
+	if c1.Preload != c2.Preload {
+		return false
+	}
+
+	return true
+}
+
+// IsValidMaxAge checks the value is a non negative number of seconds
+func IsValidMaxAge(val string) bool {
+	_, err := strconv.ParseUint(val, 10, 32)
+	return err == nil
+}
+
+type hstsConfig struct {
+	backendResolver resolver.DefaultBackend
+}
+
+// NewParser creates a new HSTS annotation parser
+func NewParser(br resolver.DefaultBackend) parser.IngressAnnotation {
+	return hstsConfig{br}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// configure the Strict-Transport-Security header of the servers. The
+// annotations not present or with an invalid value use the values of
+// the configuration
+func (a hstsConfig) Parse(ing *extensions.Ingress) (interface{}, error) {
+	def := a.backendResolver.GetDefaultBackend()
+
+	enabled, err := parser.GetBoolAnnotation(hsts, ing)
+	if err != nil {
+		enabled = def.HSTS
+	}
+	maxAge, err := parser.GetStringAnnotation(hstsMaxAge, ing)
+	if err != nil || !IsValidMaxAge(maxAge) {
+		maxAge = def.HSTSMaxAge
+	}
+	subdomains, err := parser.GetBoolAnnotation(hstsIncludeSubdomains, ing)
+	if err != nil {
+		subdomains = def.HSTSIncludeSubdomains
+	}
+	preload, err := parser.GetBoolAnnotation(hstsPreload, ing)
+	if err != nil {
+		preload = def.HSTSPreload
+	}
+
+	return &Config{
+		Enabled:           enabled,
+		MaxAge:            maxAge,
+		IncludeSubdomains: subdomains,
+		Preload:           preload,
+	}, nil
+}
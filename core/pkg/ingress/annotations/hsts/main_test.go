@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/defaults"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+type mockBackend struct{}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{
+		HSTS:                  true,
+		HSTSMaxAge:            "15724800",
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           false,
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]string
+		expected    Config
+	}{
+		"without annotations": {map[string]string{}, Config{true, "15724800", true, false}},
+		"disabled":            {map[string]string{hsts: "false"}, Config{false, "15724800", true, false}},
+		"override": {map[string]string{
+			hstsMaxAge:            "31536000",
+			hstsIncludeSubdomains: "false",
+			hstsPreload:           "true",
+		}, Config{true, "31536000", false, true}},
+		"invalid values": {map[string]string{
+			hsts:        "yes",
+			hstsMaxAge:  "-1",
+			hstsPreload: "1y",
+		}, Config{true, "15724800", true, false}},
+	} {
+		ing := buildIngress()
+		ing.SetAnnotations(tc.annotations)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", name, err)
+			continue
+		}
+		cfg, ok := i.(*Config)
+		if !ok {
+			t.Errorf("%v: expected a Config type", name)
+			continue
+		}
+		if !cfg.Equal(&tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", name, tc.expected, *cfg)
+		}
+	}
+}
+
+func TestIsValidMaxAge(t *testing.T) {
+	for val, expected := range map[string]bool{
+		"0":        true,
+		"31536000": true,
+		"":         false,
+		"-1":       false,
+		"1y":       false,
+		"100;":     false,
+	} {
+		if IsValidMaxAge(val) != expected {
+			t.Errorf("expected %v for '%v'", expected, val)
+		}
+	}
+}
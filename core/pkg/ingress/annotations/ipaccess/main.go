@@ -0,0 +1,164 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipaccess
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/util/net/sets"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+const (
+	allow = "ingress.kubernetes.io/allow-source-range"
+	deny  = "ingress.kubernetes.io/deny-source-range"
+
+	serverAllow = "ingress.kubernetes.io/server-allow-source-range"
+	serverDeny  = "ingress.kubernetes.io/server-deny-source-range"
+)
+
+// AccessList contains the client addresses or networks allowed
+// or denied. If AllowCIDRs is not empty only the connections from
+// the allowed networks are accepted (allowlist mode). If only
+// DenyCIDRs is not empty all the connections except from the
+// denied networks are accepted (denylist mode)
+type AccessList struct {
+	AllowCIDRs []string `json:"allowCIDRs,omitempty"`
+	DenyCIDRs  []string `json:"denyCIDRs,omitempty"`
+}
+
+// Equal tests for equality between two AccessList types
+func (al1 *AccessList) Equal(al2 *AccessList) bool {
+	if al1 == al2 {
+		return true
+	}
+	if al1 == nil || al2 == nil {
+		return false
+	}
+	if !equalCIDRs(al1.AllowCIDRs, al2.AllowCIDRs) {
+		return false
+	}
+	if !equalCIDRs(al1.DenyCIDRs, al2.DenyCIDRs) {
+		return false
+	}
+
+	return true
+}
+
+func equalCIDRs(c1, c2 []string) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+
+	for i := range c1 {
+		if c1[i] != c2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsEmpty returns true if the list does not restrict the access
+func (al *AccessList) IsEmpty() bool {
+	return len(al.AllowCIDRs) == 0 && len(al.DenyCIDRs) == 0
+}
+
+type ipaccess struct {
+	allowAnnotation string
+	denyAnnotation  string
+}
+
+// NewParser creates a new parser of the access list of a location
+func NewParser() parser.IngressAnnotation {
+	return ipaccess{allow, deny}
+}
+
+// NewServerParser creates a new parser of the access list of the
+// servers (hosts) defined in the Ingress rule
+func NewServerParser() parser.IngressAnnotation {
+	return ipaccess{serverAllow, serverDeny}
+}
+
+// Parse parses the annotations contained in the ingress rule used
+// to allow or deny the access to certain client addresses or networks.
+// Multiple ranges can specified using commas as separator
+// e.g. `18.0.0.0/8,56.0.0.0/8`
+func (a ipaccess) Parse(ing *extensions.Ingress) (interface{}, error) {
+	allowCIDRs, err := parseCIDRs(a.allowAnnotation, ing)
+	if err != nil {
+		return &AccessList{}, err
+	}
+
+	denyCIDRs, err := parseCIDRs(a.denyAnnotation, ing)
+	if err != nil {
+		return &AccessList{}, err
+	}
+
+	if len(allowCIDRs) == 0 && len(denyCIDRs) == 0 {
+		return &AccessList{}, ing_errors.ErrMissingAnnotations
+	}
+
+	return &AccessList{AllowCIDRs: allowCIDRs, DenyCIDRs: denyCIDRs}, nil
+}
+
+// parseCIDRs returns the sorted list of networks in the annotation.
+// An invalid value denies the access to the location
+func parseCIDRs(name string, ing *extensions.Ingress) ([]string, error) {
+	val, err := parser.GetStringAnnotation(name, ing)
+	if err != nil || strings.TrimSpace(val) == "" {
+		return nil, nil
+	}
+
+	values := []string{}
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		// a single address is a network with one address
+		if !strings.Contains(v, "/") {
+			if strings.Contains(v, ":") {
+				v = v + "/128"
+			} else {
+				v = v + "/32"
+			}
+		}
+		values = append(values, v)
+	}
+
+	ipnets, err := sets.ParseIPNets(values...)
+	if err != nil {
+		return nil, ing_errors.LocationDenied{
+			Reason: errors.Wrapf(err, "the annotation %v does not contain a valid IP address or network", name),
+		}
+	}
+
+	cidrs := []string{}
+	for k := range ipnets {
+		cidrs = append(cidrs, k)
+	}
+	sort.Strings(cidrs)
+
+	return cidrs, nil
+}
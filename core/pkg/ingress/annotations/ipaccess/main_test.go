@@ -0,0 +1,133 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipaccess
+
+import (
+	"reflect"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+func buildIngress() *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	tests := map[string]struct {
+		allow    string
+		deny     string
+		expected *AccessList
+		denied   bool
+	}{
+		"allowlist": {
+			allow:    "10.0.0.0/24, 192.168.1.1",
+			expected: &AccessList{AllowCIDRs: []string{"10.0.0.0/24", "192.168.1.1/32"}},
+		},
+		"denylist": {
+			deny:     "2001:db8::/32,172.16.0.0/12",
+			expected: &AccessList{DenyCIDRs: []string{"172.16.0.0/12", "2001:db8::/32"}},
+		},
+		"both": {
+			allow:    "10.0.0.0/8",
+			deny:     "10.0.1.0/24",
+			expected: &AccessList{AllowCIDRs: []string{"10.0.0.0/8"}, DenyCIDRs: []string{"10.0.1.0/24"}},
+		},
+		"invalid allowlist": {
+			allow:  "10.0.0.0/33",
+			denied: true,
+		},
+		"invalid denylist": {
+			deny:   "10.0.0.0/8,all",
+			denied: true,
+		},
+	}
+
+	ing := buildIngress()
+	for name, test := range tests {
+		for _, p := range []struct {
+			parser      ipaccess
+			allow, deny string
+		}{
+			{NewParser().(ipaccess), allow, deny},
+			{NewServerParser().(ipaccess), serverAllow, serverDeny},
+		} {
+			data := map[string]string{}
+			if test.allow != "" {
+				data[p.allow] = test.allow
+			}
+			if test.deny != "" {
+				data[p.deny] = test.deny
+			}
+			ing.SetAnnotations(data)
+
+			i, err := p.parser.Parse(ing)
+			if test.denied {
+				if _, ok := err.(errors.LocationDenied); !ok {
+					t.Errorf("%v: expected a LocationDenied error but returned %v", name, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", name, err)
+				continue
+			}
+
+			al := i.(*AccessList)
+			if !reflect.DeepEqual(al, test.expected) {
+				t.Errorf("%v: expected %v but returned %v", name, test.expected, al)
+			}
+		}
+	}
+}
+
+func TestParseWithoutAnnotations(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{serverAllow: "10.0.0.0/8"})
+
+	// the annotations of the server do not apply to the locations
+	_, err := NewParser().Parse(ing)
+	if !errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotations error but returned %v", err)
+	}
+}
+
+func TestAccessListEqual(t *testing.T) {
+	al1 := &AccessList{AllowCIDRs: []string{"10.0.0.0/8"}}
+	al2 := &AccessList{AllowCIDRs: []string{"10.0.0.0/8"}}
+	if !al1.Equal(al2) {
+		t.Errorf("expected equal access lists")
+	}
+
+	al2.DenyCIDRs = []string{"10.0.0.0/8"}
+	if al1.Equal(al2) {
+		t.Errorf("expected different access lists")
+	}
+
+	if !(&AccessList{}).IsEmpty() || al1.IsEmpty() {
+		t.Errorf("unexpected result of IsEmpty")
+	}
+}
@@ -90,14 +90,42 @@ func (a ipwhitelist) Parse(ing *extensions.Ingress) (interface{}, error) {
 		return &SourceRange{CIDR: defBackend.WhitelistSourceRange}, nil
 	}
 
-	values := strings.Split(val, ",")
-	ipnets, err := sets.ParseIPNets(values...)
+	cidrs, err := ParseCIDRs(val)
 	if err != nil {
 		return &SourceRange{CIDR: defBackend.WhitelistSourceRange}, ing_errors.LocationDenied{
 			Reason: errors.Wrap(err, "the annotation does not contain a valid IP address or network"),
 		}
 	}
 
+	return &SourceRange{cidrs}, nil
+}
+
+// ParseCIDRs parses a list of networks separated by commas and returns
+// the sorted list of CIDRs. A single address is a network with one
+// address and the spaces around the values are ignored
+// e.g. `10.0.0.0/24, 172.10.0.1`
+func ParseCIDRs(val string) ([]string, error) {
+	values := []string{}
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			if strings.Contains(v, ":") {
+				v = v + "/128"
+			} else {
+				v = v + "/32"
+			}
+		}
+		values = append(values, v)
+	}
+
+	ipnets, err := sets.ParseIPNets(values...)
+	if err != nil {
+		return nil, err
+	}
+
 	cidrs := []string{}
 	for k := range ipnets {
 		cidrs = append(cidrs, k)
@@ -105,5 +133,5 @@ func (a ipwhitelist) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 	sort.Strings(cidrs)
 
-	return &SourceRange{cidrs}, nil
+	return cidrs, nil
 }
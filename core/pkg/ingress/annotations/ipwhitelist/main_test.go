@@ -227,6 +227,39 @@ func TestParseAnnotationsWithDefaultConfig(t *testing.T) {
 	}
 }
 
+func TestParseCIDRs(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []string
+		isError  bool
+	}{
+		{"10.0.0.0/24", []string{"10.0.0.0/24"}, false},
+		{"10.0.0.0/24,172.10.0.1", []string{"10.0.0.0/24", "172.10.0.1/32"}, false},
+		{" 2.2.2.2/32 , 1.1.1.1/32,", []string{"1.1.1.1/32", "2.2.2.2/32"}, false},
+		{"2001:db8::1", []string{"2001:db8::1/128"}, false},
+		{"", []string{}, false},
+		{"www", nil, true},
+		{"10.0.0.0/24,192.168.0.300/16", nil, true},
+	}
+
+	for _, tc := range testCases {
+		cidrs, err := ParseCIDRs(tc.value)
+		if tc.isError {
+			if err == nil {
+				t.Errorf("expected an error parsing %q", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tc.value, err)
+			continue
+		}
+		if !strsEquals(cidrs, tc.expected) {
+			t.Errorf("expected %v but returned %v parsing %q", tc.expected, cidrs, tc.value)
+		}
+	}
+}
+
 func strsEquals(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,60 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalance
+
+import (
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/load-balance"
+)
+
+var (
+	// load balance algorithms that can be configured in an Ingress rule
+	algorithms = map[string]bool{
+		"round_robin": true,
+		"least_conn":  true,
+		"ip_hash":     true,
+	}
+)
+
+// IsValidAlgorithm checks the value is round_robin, least_conn or ip_hash
+func IsValidAlgorithm(val string) bool {
+	return algorithms[val]
+}
+
+type loadBalance struct {
+}
+
+// NewParser creates a new load balance annotation parser
+func NewParser() parser.IngressAnnotation {
+	return loadBalance{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to select the load balance algorithm of the upstreams.
+// Invalid values are ignored, so the global algorithm is used
+func (a loadBalance) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidAlgorithm(val) {
+		return "", nil
+	}
+	return val, nil
+}
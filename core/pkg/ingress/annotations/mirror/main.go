@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"regexp"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	target      = "ingress.kubernetes.io/mirror-target"
+	requestBody = "ingress.kubernetes.io/mirror-request-body"
+)
+
+var (
+	// URL of the service, e.g. http://test.default.svc.cluster.local$request_uri
+	targetRegex = regexp.MustCompile(`^https?://[^\s;{}'"]+$`)
+)
+
+// Config returns the configuration of the service that receives
+// a copy of the requests of a location
+type Config struct {
+	// Target URL of the requests sent to the service
+	Target string `json:"target"`
+	// RequestBody indicates if the body of the request is mirrored
+	RequestBody bool `json:"requestBody"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Target != c2.Target {
+		return false
+	}
+	if c1.RequestBody != c2.RequestBody {
+		return false
+	}
+
+	return true
+}
+
+// IsValidTarget checks the target is an HTTP or HTTPS URL
+// without spaces, quotes, braces or semicolons
+func IsValidTarget(val string) bool {
+	return targetRegex.MatchString(val)
+}
+
+type mirror struct {
+}
+
+// NewParser creates a new mirror annotation parser
+func NewParser() parser.IngressAnnotation {
+	return mirror{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send a copy of the requests to another service.
+// An invalid target is ignored, so the requests are not mirrored
+func (m mirror) Parse(ing *extensions.Ingress) (interface{}, error) {
+	t, err := parser.GetStringAnnotation(target, ing)
+	if err != nil || !IsValidTarget(t) {
+		return &Config{}, nil
+	}
+
+	rb, err := parser.GetBoolAnnotation(requestBody, ing)
+	if err != nil {
+		rb = true
+	}
+
+	return &Config{
+		Target:      t,
+		RequestBody: rb,
+	}, nil
+}
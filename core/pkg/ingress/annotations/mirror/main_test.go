@@ -0,0 +1,55 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{target: "http://test.default.svc.cluster.local$request_uri"}, &Config{Target: "http://test.default.svc.cluster.local$request_uri", RequestBody: true}},
+		{map[string]string{target: "https://test.example.com/shadow$request_uri", requestBody: "false"}, &Config{Target: "https://test.example.com/shadow$request_uri"}},
+		{map[string]string{target: "test.example.com$request_uri"}, &Config{}},
+		{map[string]string{target: "http://test.example.com; return 200"}, &Config{}},
+		{map[string]string{requestBody: "false"}, &Config{}},
+		{nil, &Config{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !testCase.expected.Equal(result.(*Config)) {
+			t.Errorf("expected %+v but returned %+v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -17,6 +17,11 @@ limitations under the License.
 package proxy
 
 import (
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
@@ -32,6 +37,19 @@ const (
 	cookiePath   = "ingress.kubernetes.io/proxy-cookie-path"
 	cookieDomain = "ingress.kubernetes.io/proxy-cookie-domain"
 	nextUpstream = "ingress.kubernetes.io/proxy-next-upstream"
+	redirect     = "ingress.kubernetes.io/proxy-redirect"
+	buffering    = "ingress.kubernetes.io/proxy-buffering"
+	reqBuffering = "ingress.kubernetes.io/proxy-request-buffering"
+
+	clientBodyBufferSize = "ingress.kubernetes.io/client-body-buffer-size"
+)
+
+var (
+	// size with the NGINX syntax, like 512, 16k or 1m
+	// http://nginx.org/en/docs/syntax.html
+	sizeRegex = regexp.MustCompile(`^[0-9]+[kKmM]?$`)
+	// offset with the NGINX syntax, a size that also accepts the g suffix
+	offsetRegex = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
 )
 
 // Configuration returns the proxy timeout to use in the upstream server/s
@@ -44,6 +62,12 @@ type Configuration struct {
 	CookieDomain   string `json:"cookieDomain"`
 	CookiePath     string `json:"cookiePath"`
 	NextUpstream   string `json:"nextUpstream"`
+	ProxyRedirect  string `json:"proxyRedirect"`
+
+	ProxyBuffering   string `json:"proxyBuffering"`
+	RequestBuffering string `json:"requestBuffering"`
+
+	ClientBodyBufferSize string `json:"clientBodyBufferSize"`
 }
 
 func (l1 *Configuration) Equal(l2 *Configuration) bool {
@@ -74,6 +98,18 @@ func (l1 *Configuration) Equal(l2 *Configuration) bool {
 	if l1.CookiePath != l2.CookiePath {
 		return false
 	}
+	if l1.ProxyRedirect != l2.ProxyRedirect {
+		return false
+	}
+	if l1.ProxyBuffering != l2.ProxyBuffering {
+		return false
+	}
+	if l1.RequestBuffering != l2.RequestBuffering {
+		return false
+	}
+	if l1.ClientBodyBufferSize != l2.ClientBodyBufferSize {
+		return false
+	}
 
 	return true
 }
@@ -91,20 +127,9 @@ func NewParser(br resolver.DefaultBackend) parser.IngressAnnotation {
 // rule used to configure upstream check parameters
 func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 	defBackend := a.backendResolver.GetDefaultBackend()
-	ct, err := parser.GetIntAnnotation(connect, ing)
-	if err != nil {
-		ct = defBackend.ProxyConnectTimeout
-	}
-
-	st, err := parser.GetIntAnnotation(send, ing)
-	if err != nil {
-		st = defBackend.ProxySendTimeout
-	}
-
-	rt, err := parser.GetIntAnnotation(read, ing)
-	if err != nil {
-		rt = defBackend.ProxyReadTimeout
-	}
+	ct := a.timeout(connect, ing, defBackend.ProxyConnectTimeout)
+	st := a.timeout(send, ing, defBackend.ProxySendTimeout)
+	rt := a.timeout(read, ing, defBackend.ProxyReadTimeout)
 
 	bufs, err := parser.GetStringAnnotation(bufferSize, ing)
 	if err != nil || bufs == "" {
@@ -124,6 +149,9 @@ func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 	bs, err := parser.GetStringAnnotation(bodySize, ing)
 	if err != nil || bs == "" {
 		bs = defBackend.ProxyBodySize
+	} else if !IsValidBodySize(bs) {
+		glog.Warningf("invalid size in annotation %v: '%v'", bodySize, bs)
+		bs = defBackend.ProxyBodySize
 	}
 
 	nu, err := parser.GetStringAnnotation(nextUpstream, ing)
@@ -131,5 +159,89 @@ func (a proxy) Parse(ing *extensions.Ingress) (interface{}, error) {
 		nu = defBackend.ProxyNextUpstream
 	}
 
-	return &Configuration{bs, ct, st, rt, bufs, cd, cp, nu}, nil
+	pr, err := parser.GetStringAnnotation(redirect, ing)
+	if err != nil || pr == "" {
+		pr = defBackend.ProxyRedirect
+	} else if !IsValidProxyRedirect(pr) {
+		glog.Warningf("invalid value (off, default or <redirect> <replacement>) in annotation %v: '%v'", redirect, pr)
+		pr = defBackend.ProxyRedirect
+	}
+
+	pb := a.buffering(buffering, ing, defBackend.ProxyBuffering)
+	rb := a.buffering(reqBuffering, ing, defBackend.ProxyRequestBuffering)
+
+	cbbs, err := parser.GetStringAnnotation(clientBodyBufferSize, ing)
+	if err != nil || cbbs == "" {
+		cbbs = defBackend.ClientBodyBufferSize
+	} else if !IsValidSize(cbbs) {
+		glog.Warningf("invalid size in annotation %v: '%v'", clientBodyBufferSize, cbbs)
+		cbbs = defBackend.ClientBodyBufferSize
+	}
+
+	return &Configuration{bs, ct, st, rt, bufs, cd, cp, nu, pr, pb, rb, cbbs}, nil
+}
+
+// timeout returns the timeout in seconds defined in the annotation or
+// the default value when the annotation is missing or not valid
+func (a proxy) timeout(name string, ing *extensions.Ingress, def int) int {
+	val, err := parser.GetIntAnnotation(name, ing)
+	if err != nil {
+		return def
+	}
+	if !IsValidTimeout(val) {
+		glog.Warningf("invalid timeout (seconds greater than 0) in annotation %v: '%v'", name, val)
+		return def
+	}
+	return val
+}
+
+// buffering returns the buffering (on or off) defined in the annotation
+// or the default value when the annotation is missing or not valid
+func (a proxy) buffering(name string, ing *extensions.Ingress, def string) string {
+	val, err := parser.GetStringAnnotation(name, ing)
+	if err != nil || val == "" {
+		return def
+	}
+	if !IsValidBuffering(val) {
+		glog.Warningf("invalid value (on or off) in annotation %v: '%v'", name, val)
+		return def
+	}
+	return val
+}
+
+// IsValidBuffering checks the value of proxy_buffering or
+// proxy_request_buffering is on or off
+func IsValidBuffering(value string) bool {
+	return value == "on" || value == "off"
+}
+
+// IsValidTimeout checks the timeout in seconds is greater than 0
+func IsValidTimeout(value int) bool {
+	return value > 0
+}
+
+// IsValidSize checks the value is a size with the NGINX syntax
+// (a number with an optional k or m suffix)
+func IsValidSize(value string) bool {
+	return sizeRegex.MatchString(value)
+}
+
+// IsValidBodySize checks the value is a size with the NGINX syntax
+// (a number with an optional k, m or g suffix). 0 disables the limit
+func IsValidBodySize(value string) bool {
+	return offsetRegex.MatchString(value)
+}
+
+// IsValidProxyRedirect checks the value of proxy_redirect is off, default
+// or a rule with the format <redirect> <replacement>
+func IsValidProxyRedirect(value string) bool {
+	if value == "off" || value == "default" {
+		return true
+	}
+
+	if strings.ContainsAny(value, ";{}\"'") {
+		return false
+	}
+
+	return len(strings.Fields(value)) == 2
 }
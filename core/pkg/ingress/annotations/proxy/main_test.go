@@ -74,6 +74,12 @@ func (m mockBackend) GetDefaultBackend() defaults.Backend {
 		ProxyBufferSize:     "10k",
 		ProxyBodySize:       "3k",
 		ProxyNextUpstream:   "error",
+		ProxyRedirect:       "default",
+
+		ProxyBuffering:        "off",
+		ProxyRequestBuffering: "on",
+
+		ClientBodyBufferSize: "8k",
 	}
 }
 
@@ -87,6 +93,7 @@ func TestProxy(t *testing.T) {
 	data[bufferSize] = "1k"
 	data[bodySize] = "2k"
 	data[nextUpstream] = "off"
+	data[redirect] = "off"
 	ing.SetAnnotations(data)
 
 	i, err := NewParser(mockBackend{}).Parse(ing)
@@ -115,6 +122,9 @@ func TestProxy(t *testing.T) {
 	if p.NextUpstream != "off" {
 		t.Errorf("expected off as next-upstream but returned %v", p.NextUpstream)
 	}
+	if p.ProxyRedirect != "off" {
+		t.Errorf("expected off as proxy-redirect but returned %v", p.ProxyRedirect)
+	}
 }
 
 func TestProxyWithNoAnnotation(t *testing.T) {
@@ -149,4 +159,148 @@ func TestProxyWithNoAnnotation(t *testing.T) {
 	if p.NextUpstream != "error" {
 		t.Errorf("expected error as next-upstream but returned %v", p.NextUpstream)
 	}
+	if p.ProxyRedirect != "default" {
+		t.Errorf("expected default as proxy-redirect but returned %v", p.ProxyRedirect)
+	}
+}
+
+func TestProxyRedirect(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]string{
+		"off":     "off",
+		"default": "default",
+		"http://internal.svc.cluster.local:8080/ /": "http://internal.svc.cluster.local:8080/ /",
+		"~^http://([^:]+):\\d+(/.+)$ http://$1$2":   "~^http://([^:]+):\\d+(/.+)$ http://$1$2",
+		"http://internal/":                          "default",
+		"http://internal/ / extra":                  "default",
+		"http://internal/ /; return 200":            "default",
+	}
+
+	for value, expected := range tests {
+		ing.SetAnnotations(map[string]string{redirect: value})
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p, ok := i.(*Configuration)
+		if !ok {
+			t.Fatalf("expected a Configuration type")
+		}
+		if p.ProxyRedirect != expected {
+			t.Errorf("expected '%v' as proxy-redirect for '%v' but returned '%v'", expected, value, p.ProxyRedirect)
+		}
+	}
+}
+
+func TestClientBodyBufferSize(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]string{
+		"":          "8k",
+		"16k":       "16k",
+		"1M":        "1M",
+		"1024":      "1024",
+		"1g":        "8k",
+		"16k; more": "8k",
+		"-1k":       "8k",
+	}
+
+	for value, expected := range tests {
+		ing.SetAnnotations(map[string]string{clientBodyBufferSize: value})
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p := i.(*Configuration)
+		if p.ClientBodyBufferSize != expected {
+			t.Errorf("expected %v as client-body-buffer-size with %v but returned %v", expected, value, p.ClientBodyBufferSize)
+		}
+	}
+}
+
+func TestProxyTimeouts(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]int{
+		"":     20,
+		"3600": 3600,
+		"1":    1,
+		"0":    20,
+		"-5":   20,
+		"5s":   20,
+	}
+
+	for value, expected := range tests {
+		ing.SetAnnotations(map[string]string{read: value})
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p := i.(*Configuration)
+		if p.ReadTimeout != expected {
+			t.Errorf("expected %v as read-timeout with '%v' but returned %v", expected, value, p.ReadTimeout)
+		}
+	}
+}
+
+func TestProxyBodySize(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]string{
+		"":        "3k",
+		"0":       "0",
+		"8m":      "8m",
+		"1G":      "1G",
+		"1024":    "1024",
+		"1t":      "3k",
+		"8m; foo": "3k",
+		"-1m":     "3k",
+	}
+
+	for value, expected := range tests {
+		ing.SetAnnotations(map[string]string{bodySize: value})
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p := i.(*Configuration)
+		if p.BodySize != expected {
+			t.Errorf("expected %v as proxy-body-size with '%v' but returned %v", expected, value, p.BodySize)
+		}
+	}
+}
+
+func TestProxyBuffering(t *testing.T) {
+	ing := buildIngress()
+
+	tests := []struct {
+		annotations  map[string]string
+		buffering    string
+		reqBuffering string
+	}{
+		{map[string]string{}, "off", "on"},
+		{map[string]string{buffering: "on", reqBuffering: "off"}, "on", "off"},
+		{map[string]string{buffering: "true", reqBuffering: "off; foo"}, "off", "on"},
+	}
+
+	for _, test := range tests {
+		ing.SetAnnotations(test.annotations)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p := i.(*Configuration)
+		if p.ProxyBuffering != test.buffering {
+			t.Errorf("expected %v as proxy-buffering with %v but returned %v", test.buffering, test.annotations, p.ProxyBuffering)
+		}
+		if p.RequestBuffering != test.reqBuffering {
+			t.Errorf("expected %v as proxy-request-buffering with %v but returned %v", test.reqBuffering, test.annotations, p.RequestBuffering)
+		}
+	}
 }
@@ -0,0 +1,283 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	zone    = "ingress.kubernetes.io/proxy-cache"
+	key     = "ingress.kubernetes.io/proxy-cache-key"
+	valid   = "ingress.kubernetes.io/proxy-cache-valid"
+	bypass  = "ingress.kubernetes.io/proxy-cache-bypass"
+	noCache = "ingress.kubernetes.io/proxy-no-cache"
+
+	// key used by NGINX when proxy_cache_key is not defined
+	defKey = "$scheme$proxy_host$request_uri"
+)
+
+var (
+	// name of a cache zone defined in the configuration
+	zoneRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	// NGINX variable, like $http_pragma or $cookie_nocache
+	variableRegex = regexp.MustCompile(`^\$[a-zA-Z0-9_]+$`)
+	// time with the NGINX syntax, like 30s or 1h30m
+	// http://nginx.org/en/docs/syntax.html
+	timeRegex = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|M|y)?)+$`)
+
+	// variables of the request or the response of the backend used in
+	// the bypass and no-cache conditions
+	// http://nginx.org/en/docs/varindex.html
+	conditionVariables = toSet(
+		// ngx_http_core_module
+		"args", "binary_remote_addr", "content_length", "content_type", "host",
+		"https", "is_args", "query_string", "remote_addr", "remote_user", "request_method",
+		"request_uri", "scheme", "server_name", "server_port", "uri",
+		// ngx_http_upstream_module
+		"upstream_cache_status", "upstream_status",
+		// ngx_http_ssl_module
+		"ssl_client_s_dn", "ssl_client_verify",
+		// variables defined in the template
+		"the_real_ip", "best_http_host", "pass_access_scheme",
+	)
+
+	// prefixes of the variables with the name of a header, cookie or argument
+	conditionVariablePrefixes = []string{
+		"arg_", "cookie_", "http_", "upstream_cookie_", "upstream_http_",
+	}
+)
+
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// isKnownVariable checks the name (without $) is a variable of the
+// list or a header, cookie or argument
+func isKnownVariable(name string) bool {
+	if conditionVariables[name] {
+		return true
+	}
+
+	for _, prefix := range conditionVariablePrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config returns the cache configuration of a location
+type Config struct {
+	// Zone is the name of the cache zone. An empty zone disables the cache
+	Zone string `json:"zone"`
+	// Key of the cached responses
+	Key string `json:"key"`
+	// Valid contains the caching time of the responses, with optional
+	// status codes (e.g. 200 302 10m)
+	Valid []string `json:"valid"`
+	// Bypass contains the variables that, when not empty or "0",
+	// return the response from the backend instead of the cache
+	Bypass []string `json:"bypass"`
+	// NoCache contains the variables that, when not empty or "0",
+	// do not save the response in the cache
+	NoCache []string `json:"noCache"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Zone != c2.Zone {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+	if len(c1.Valid) != len(c2.Valid) {
+		return false
+	}
+	for i, v := range c1.Valid {
+		if v != c2.Valid[i] {
+			return false
+		}
+	}
+	if len(c1.Bypass) != len(c2.Bypass) {
+		return false
+	}
+	for i, v := range c1.Bypass {
+		if v != c2.Bypass[i] {
+			return false
+		}
+	}
+	if len(c1.NoCache) != len(c2.NoCache) {
+		return false
+	}
+	for i, v := range c1.NoCache {
+		if v != c2.NoCache[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidZone checks the name of the cache zone contains only
+// letters, numbers and underscores
+func IsValidZone(val string) bool {
+	return zoneRegex.MatchString(val)
+}
+
+// IsValidKey checks the cache key is not empty and does not contain
+// spaces, quotes, braces or semicolons
+func IsValidKey(val string) bool {
+	return val != "" && !strings.ContainsAny(val, " \t\n;{}\"'")
+}
+
+// ParseValid parses a list of caching times separated by commas.
+// Each entry is a time with an optional list of status codes or
+// any before it, e.g. 200 302 10m, 404 1m
+func ParseValid(val string) ([]string, error) {
+	entries := []string{}
+	for _, entry := range strings.Split(val, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !timeRegex.MatchString(fields[len(fields)-1]) {
+			return nil, fmt.Errorf("%v is not a valid time in '%v'", fields[len(fields)-1], entry)
+		}
+		for _, code := range fields[:len(fields)-1] {
+			if code == "any" {
+				continue
+			}
+			c, err := strconv.Atoi(code)
+			if err != nil || c < 100 || c > 599 {
+				return nil, fmt.Errorf("%v is not a valid status code in '%v'", code, entry)
+			}
+		}
+
+		entries = append(entries, strings.Join(fields, " "))
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the list of caching times is empty")
+	}
+
+	return entries, nil
+}
+
+// ParseBypass parses a list of NGINX variables separated by spaces.
+// The variables must be known variables or headers, cookies or arguments
+func ParseBypass(val string) ([]string, error) {
+	vars := strings.Fields(val)
+	if len(vars) == 0 {
+		return nil, fmt.Errorf("the list of variables is empty")
+	}
+
+	for _, v := range vars {
+		if !variableRegex.MatchString(v) {
+			return nil, fmt.Errorf("%v is not a valid variable", v)
+		}
+		if !isKnownVariable(v[1:]) {
+			return nil, fmt.Errorf("unknown variable %v", v)
+		}
+	}
+
+	return vars, nil
+}
+
+type proxyCache struct {
+}
+
+// NewParser creates a new proxy cache annotation parser
+func NewParser() parser.IngressAnnotation {
+	return proxyCache{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// cache the responses of the backends. The cache is disabled when the
+// zone is not valid. Invalid keys, caching times, bypass and no-cache
+// rules are ignored
+func (p proxyCache) Parse(ing *extensions.Ingress) (interface{}, error) {
+	z, err := parser.GetStringAnnotation(zone, ing)
+	if err != nil || !IsValidZone(z) {
+		return &Config{}, nil
+	}
+
+	k, err := parser.GetStringAnnotation(key, ing)
+	if err != nil {
+		k = defKey
+	} else if !IsValidKey(k) {
+		glog.Warningf("invalid cache key in annotation %v: '%v'", key, k)
+		k = defKey
+	}
+
+	vl := []string{}
+	if val, err := parser.GetStringAnnotation(valid, ing); err == nil {
+		vl, err = ParseValid(val)
+		if err != nil {
+			glog.Warningf("invalid caching times in annotation %v: %v", valid, err)
+			vl = []string{}
+		}
+	}
+
+	bp := []string{}
+	if val, err := parser.GetStringAnnotation(bypass, ing); err == nil {
+		bp, err = ParseBypass(val)
+		if err != nil {
+			glog.Warningf("invalid bypass rules in annotation %v: %v", bypass, err)
+			bp = []string{}
+		}
+	}
+
+	nc := []string{}
+	if val, err := parser.GetStringAnnotation(noCache, ing); err == nil {
+		nc, err = ParseBypass(val)
+		if err != nil {
+			glog.Warningf("invalid no-cache rules in annotation %v: %v", noCache, err)
+			nc = []string{}
+		}
+	}
+
+	return &Config{
+		Zone:    z,
+		Key:     k,
+		Valid:   vl,
+		Bypass:  bp,
+		NoCache: nc,
+	}, nil
+}
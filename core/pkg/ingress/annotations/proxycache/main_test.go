@@ -0,0 +1,109 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"reflect"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{zone: "static"}, &Config{Zone: "static", Key: defKey, Valid: []string{}, Bypass: []string{}}},
+		{map[string]string{
+			zone:    "static",
+			key:     "$host$request_uri",
+			valid:   "200 302  10m, 404 1m,any 5s",
+			bypass:  "$http_pragma $cookie_nocache",
+			noCache: " $cookie_session  $http_authorization",
+		}, &Config{
+			Zone:    "static",
+			Key:     "$host$request_uri",
+			Valid:   []string{"200 302 10m", "404 1m", "any 5s"},
+			Bypass:  []string{"$http_pragma", "$cookie_nocache"},
+			NoCache: []string{"$cookie_session", "$http_authorization"},
+		}},
+		{map[string]string{
+			zone:    "static",
+			key:     "$host; return 200",
+			valid:   "200 ten",
+			bypass:  "http_pragma",
+			noCache: "$cookie_session; return 200",
+		}, &Config{Zone: "static", Key: defKey, Valid: []string{}, Bypass: []string{}}},
+		{map[string]string{
+			zone:    "static",
+			bypass:  "$http_pragma $nocache",
+			noCache: "$cookie_ $upstream_http_set_cookie",
+		}, &Config{Zone: "static", Key: defKey, Valid: []string{}, Bypass: []string{}}},
+		{map[string]string{zone: "static", valid: "600 10m"}, &Config{Zone: "static", Key: defKey, Valid: []string{}, Bypass: []string{}}},
+		{map[string]string{zone: "static zone", valid: "10m"}, &Config{}},
+		{map[string]string{valid: "10m"}, &Config{}},
+		{nil, &Config{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !testCase.expected.Equal(result.(*Config)) {
+			t.Errorf("expected %+v but returned %+v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestParseBypass(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []string
+	}{
+		{"$http_pragma $cookie_nocache $arg_nocache", []string{"$http_pragma", "$cookie_nocache", "$arg_nocache"}},
+		{"$upstream_http_set_cookie", []string{"$upstream_http_set_cookie"}},
+		{"$remote_user $request_method", []string{"$remote_user", "$request_method"}},
+		{"$http_", nil},
+		{"$nocache", nil},
+		{"$http_pragma $unknown", nil},
+		{"http_pragma", nil},
+		{"", nil},
+	}
+
+	for _, testCase := range testCases {
+		vars, err := ParseBypass(testCase.value)
+		if testCase.expected == nil {
+			if err == nil {
+				t.Errorf("expected an error parsing '%v' but returned %v", testCase.value, vars)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(vars, testCase.expected) {
+			t.Errorf("expected %v parsing '%v' but returned %v (%v)", testCase.expected, testCase.value, vars, err)
+		}
+	}
+}
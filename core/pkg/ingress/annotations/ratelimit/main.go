@@ -22,14 +22,17 @@ import (
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingress/core/pkg/ingress/resolver"
 )
 
 const (
-	limitIP  = "ingress.kubernetes.io/limit-connections"
-	limitRPS = "ingress.kubernetes.io/limit-rps"
+	limitIP              = "ingress.kubernetes.io/limit-connections"
+	limitRPS             = "ingress.kubernetes.io/limit-rps"
+	limitRPM             = "ingress.kubernetes.io/limit-rpm"
+	limitBurstMultiplier = "ingress.kubernetes.io/limit-burst-multiplier"
 
 	// allow 5 times the specified limit as burst
-	defBurst = 5
+	defBurstMultiplier = 5
 
 	// 1MB -> 16 thousand 64-byte states or about 8 thousand 128-byte states
 	// default is 5MB
@@ -37,7 +40,7 @@ const (
 )
 
 // RateLimit returns rate limit configuration for an Ingress rule limiting the
-// number of connections per IP address and/or connections per second.
+// number of connections per IP address and/or connections per second or minute.
 // If you both annotations are specified in a single Ingress rule, RPS limits
 // takes precedence
 type RateLimit struct {
@@ -45,6 +48,8 @@ type RateLimit struct {
 	Connections Zone `json:"connections"`
 	// RPS indicates a limit with the number of connections per second
 	RPS Zone `json:"rps"`
+	// RPM indicates a limit with the number of connections per minute
+	RPM Zone `json:"rpm"`
 }
 
 func (rt1 *RateLimit) Equal(rt2 *RateLimit) bool {
@@ -60,6 +65,9 @@ func (rt1 *RateLimit) Equal(rt2 *RateLimit) bool {
 	if !(&rt1.RPS).Equal(&rt2.RPS) {
 		return false
 	}
+	if !(&rt1.RPM).Equal(&rt2.RPM) {
+		return false
+	}
 
 	return true
 }
@@ -98,11 +106,12 @@ func (z1 *Zone) Equal(z2 *Zone) bool {
 }
 
 type ratelimit struct {
+	backendResolver resolver.DefaultBackend
 }
 
 // NewParser creates a new ratelimit annotation parser
-func NewParser() parser.IngressAnnotation {
-	return ratelimit{}
+func NewParser(br resolver.DefaultBackend) parser.IngressAnnotation {
+	return ratelimit{br}
 }
 
 // ParseAnnotations parses the annotations contained in the ingress
@@ -110,28 +119,45 @@ func NewParser() parser.IngressAnnotation {
 func (a ratelimit) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 	rps, _ := parser.GetIntAnnotation(limitRPS, ing)
+	rpm, _ := parser.GetIntAnnotation(limitRPM, ing)
 	conn, _ := parser.GetIntAnnotation(limitIP, ing)
 
-	if rps == 0 && conn == 0 {
+	if rps == 0 && rpm == 0 && conn == 0 {
 		return &RateLimit{
 			Connections: Zone{},
 			RPS:         Zone{},
+			RPM:         Zone{},
 		}, nil
 	}
 
+	defBurst := a.backendResolver.GetDefaultBackend().LimitBurstMultiplier
+	if defBurst <= 0 {
+		defBurst = defBurstMultiplier
+	}
+	burst, err := parser.GetIntAnnotation(limitBurstMultiplier, ing)
+	if err != nil || burst <= 0 {
+		burst = defBurst
+	}
+
 	zoneName := fmt.Sprintf("%v_%v", ing.GetNamespace(), ing.GetName())
 
 	return &RateLimit{
 		Connections: Zone{
 			Name:       fmt.Sprintf("%v_conn", zoneName),
 			Limit:      conn,
-			Burst:      conn * defBurst,
+			Burst:      conn * burst,
 			SharedSize: defSharedSize,
 		},
 		RPS: Zone{
 			Name:       fmt.Sprintf("%v_rps", zoneName),
 			Limit:      rps,
-			Burst:      rps * defBurst,
+			Burst:      rps * burst,
+			SharedSize: defSharedSize,
+		},
+		RPM: Zone{
+			Name:       fmt.Sprintf("%v_rpm", zoneName),
+			Limit:      rpm,
+			Burst:      rpm * burst,
 			SharedSize: defSharedSize,
 		},
 	}, nil
@@ -24,8 +24,18 @@ import (
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress/core/pkg/ingress/defaults"
 )
 
+type mockBackend struct {
+	limitBurstMultiplier int
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{LimitBurstMultiplier: m.limitBurstMultiplier}
+}
+
 func buildIngress() *extensions.Ingress {
 	defaultBackend := extensions.IngressBackend{
 		ServiceName: "default-backend",
@@ -63,7 +73,7 @@ func buildIngress() *extensions.Ingress {
 
 func TestWithoutAnnotations(t *testing.T) {
 	ing := buildIngress()
-	_, err := NewParser().Parse(ing)
+	_, err := NewParser(mockBackend{}).Parse(ing)
 	if err != nil {
 		t.Error("unexpected error with ingress without annotations")
 	}
@@ -77,7 +87,7 @@ func TestBadRateLimiting(t *testing.T) {
 	data[limitRPS] = "0"
 	ing.SetAnnotations(data)
 
-	_, err := NewParser().Parse(ing)
+	_, err := NewParser(mockBackend{}).Parse(ing)
 	if err != nil {
 		t.Errorf("unexpected error with invalid limits (0)")
 	}
@@ -87,7 +97,7 @@ func TestBadRateLimiting(t *testing.T) {
 	data[limitRPS] = "100"
 	ing.SetAnnotations(data)
 
-	i, err := NewParser().Parse(ing)
+	i, err := NewParser(mockBackend{}).Parse(ing)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -102,3 +112,59 @@ func TestBadRateLimiting(t *testing.T) {
 		t.Errorf("expected 100 in limit by rps but %v was returend", rateLimit.RPS)
 	}
 }
+
+func TestRateLimitRPM(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[limitRPM] = "60"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	rateLimit, ok := i.(*RateLimit)
+	if !ok {
+		t.Fatalf("expected a RateLimit type")
+	}
+	if rateLimit.RPM.Limit != 60 {
+		t.Errorf("expected 60 in limit by rpm but %v was returned", rateLimit.RPM)
+	}
+	if rateLimit.RPM.Name != "default_foo_rpm" {
+		t.Errorf("expected default_foo_rpm as zone name but %v was returned", rateLimit.RPM.Name)
+	}
+	if rateLimit.RPS.Limit != 0 {
+		t.Errorf("expected no limit by rps but %v was returned", rateLimit.RPS)
+	}
+}
+
+func TestRateLimitBurstMultiplier(t *testing.T) {
+	tests := []struct {
+		title      string
+		def        int
+		annotation string
+		expected   int
+	}{
+		{"default multiplier", 0, "", 50},
+		{"multiplier from the configuration", 2, "", 20},
+		{"multiplier from the annotation", 2, "3", 30},
+		{"invalid annotation", 2, "-1", 20},
+	}
+
+	ing := buildIngress()
+	for _, test := range tests {
+		data := map[string]string{}
+		data[limitRPS] = "10"
+		if test.annotation != "" {
+			data[limitBurstMultiplier] = test.annotation
+		}
+		ing.SetAnnotations(data)
+
+		i, _ := NewParser(mockBackend{test.def}).Parse(ing)
+		rateLimit := i.(*RateLimit)
+		if rateLimit.RPS.Burst != test.expected {
+			t.Errorf("%v: expected %v as burst but %v was returned", test.title, test.expected, rateLimit.RPS.Burst)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestid
+
+import (
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingress/core/pkg/ingress/resolver"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/generate-request-id"
+)
+
+type requestID struct {
+	backendResolver resolver.DefaultBackend
+}
+
+// NewParser creates a new request id annotation parser
+func NewParser(db resolver.DefaultBackend) parser.IngressAnnotation {
+	return requestID{db}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to indicate if the X-Request-ID header must be generated
+// (when absent) and sent to the upstream
+func (a requestID) Parse(ing *extensions.Ingress) (interface{}, error) {
+	gen, err := parser.GetBoolAnnotation(annotation, ing)
+	if err != nil {
+		return a.backendResolver.GetDefaultBackend().GenerateRequestID, nil
+	}
+
+	return gen, nil
+}
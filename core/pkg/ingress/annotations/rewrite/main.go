@@ -17,6 +17,9 @@ limitations under the License.
 package rewrite
 
 import (
+	"regexp"
+	"strings"
+
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
@@ -28,9 +31,16 @@ const (
 	addBaseURL       = "ingress.kubernetes.io/add-base-url"
 	sslRedirect      = "ingress.kubernetes.io/ssl-redirect"
 	forceSSLRedirect = "ingress.kubernetes.io/force-ssl-redirect"
+	sslRedirectCode  = "ingress.kubernetes.io/ssl-redirect-code"
+	sslRedirectHost  = "ingress.kubernetes.io/ssl-redirect-host"
+	sslRedirectPort  = "ingress.kubernetes.io/ssl-redirect-port"
 	appRoot          = "ingress.kubernetes.io/app-root"
+	xForwardedPrefix = "ingress.kubernetes.io/x-forwarded-prefix"
 )
 
+// captureGroupRegex matches a reference to a numbered capture group
+var captureGroupRegex = regexp.MustCompile(`\$[1-9]`)
+
 // Redirect describes the per location redirect config
 type Redirect struct {
 	// Target URI where the traffic must be redirected
@@ -42,8 +52,48 @@ type Redirect struct {
 	SSLRedirect bool `json:"sslRedirect"`
 	// ForceSSLRedirect indicates if the location section is accessible SSL only
 	ForceSSLRedirect bool `json:"forceSSLRedirect"`
+	// SSLRedirectCode status code of the redirect to the HTTPS port
+	SSLRedirectCode int `json:"sslRedirectCode"`
+	// SSLRedirectHost host used in the redirect to the HTTPS port instead of the host of the request
+	SSLRedirectHost string `json:"sslRedirectHost"`
+	// SSLRedirectPort port used in the redirect to the HTTPS port (0 omits the port)
+	SSLRedirectPort int `json:"sslRedirectPort"`
 	// AppRoot defines the Application Root that the Controller must redirect if it's not in '/' context
 	AppRoot string `json:"appRoot"`
+	// XForwardedPrefix is the value of the header X-Forwarded-Prefix sent
+	// to the backend, usually the prefix removed by the rewrite target
+	XForwardedPrefix string `json:"xForwardedPrefix"`
+}
+
+// UsesCaptureGroups checks if the target references numbered capture
+// groups ($1 to $9) of the path, used as a regular expression
+func UsesCaptureGroups(target string) bool {
+	return captureGroupRegex.MatchString(target)
+}
+
+// IsValidPath checks the value is an absolute path that can be used
+// in the directives of the configuration (rewrite and return)
+func IsValidPath(val string) bool {
+	return strings.HasPrefix(val, "/") && !strings.ContainsAny(val, " \t\n;{}'\"")
+}
+
+// IsValidRedirectCode checks the code is a valid status code for a redirect
+func IsValidRedirectCode(code int) bool {
+	switch code {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
+}
+
+// IsValidRedirectHost checks the value is a host without scheme, port or path
+func IsValidRedirectHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/:; ")
+}
+
+// IsValidRedirectPort checks the value is a valid port
+func IsValidRedirectPort(port int) bool {
+	return port > 0 && port < 65536
 }
 
 func (r1 *Redirect) Equal(r2 *Redirect) bool {
@@ -65,9 +115,21 @@ func (r1 *Redirect) Equal(r2 *Redirect) bool {
 	if r1.ForceSSLRedirect != r2.ForceSSLRedirect {
 		return false
 	}
+	if r1.SSLRedirectCode != r2.SSLRedirectCode {
+		return false
+	}
+	if r1.SSLRedirectHost != r2.SSLRedirectHost {
+		return false
+	}
+	if r1.SSLRedirectPort != r2.SSLRedirectPort {
+		return false
+	}
 	if r1.AppRoot != r2.AppRoot {
 		return false
 	}
+	if r1.XForwardedPrefix != r2.XForwardedPrefix {
+		return false
+	}
 
 	return true
 }
@@ -93,13 +155,36 @@ func (a rewrite) Parse(ing *extensions.Ingress) (interface{}, error) {
 	if err != nil {
 		fSslRe = a.backendResolver.GetDefaultBackend().ForceSSLRedirect
 	}
+	sslReCode, err := parser.GetIntAnnotation(sslRedirectCode, ing)
+	if err != nil || !IsValidRedirectCode(sslReCode) {
+		sslReCode = a.backendResolver.GetDefaultBackend().SSLRedirectCode
+	}
+	sslReHost, err := parser.GetStringAnnotation(sslRedirectHost, ing)
+	if err != nil || !IsValidRedirectHost(sslReHost) {
+		sslReHost = a.backendResolver.GetDefaultBackend().SSLRedirectHost
+	}
+	sslRePort, err := parser.GetIntAnnotation(sslRedirectPort, ing)
+	if err != nil || !IsValidRedirectPort(sslRePort) {
+		sslRePort = a.backendResolver.GetDefaultBackend().SSLRedirectPort
+	}
 	abu, _ := parser.GetBoolAnnotation(addBaseURL, ing)
 	ar, _ := parser.GetStringAnnotation(appRoot, ing)
+	if !IsValidPath(ar) {
+		ar = ""
+	}
+	xfp, _ := parser.GetStringAnnotation(xForwardedPrefix, ing)
+	if !IsValidPath(xfp) {
+		xfp = ""
+	}
 	return &Redirect{
 		Target:           rt,
 		AddBaseURL:       abu,
 		SSLRedirect:      sslRe,
 		ForceSSLRedirect: fSslRe,
+		SSLRedirectCode:  sslReCode,
+		SSLRedirectHost:  sslReHost,
+		SSLRedirectPort:  sslRePort,
 		AppRoot:          ar,
+		XForwardedPrefix: xfp,
 	}, nil
 }
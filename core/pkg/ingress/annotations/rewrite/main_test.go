@@ -175,4 +175,84 @@ func TestAppRoot(t *testing.T) {
 		t.Errorf("Unexpected value got in AppRoot")
 	}
 
+	for _, invalid := range []string{"app1", "/app1; return 200", "/app 1"} {
+		data[appRoot] = invalid
+		ing.SetAnnotations(data)
+
+		i, _ = NewParser(mockBackend{true}).Parse(ing)
+		if redirect := i.(*Redirect); redirect.AppRoot != "" {
+			t.Errorf("expected the invalid app root %v to be ignored but returned %v", invalid, redirect.AppRoot)
+		}
+	}
+}
+
+func TestXForwardedPrefix(t *testing.T) {
+	ing := buildIngress()
+
+	tests := map[string]string{
+		"/api":           "/api",
+		"/api/v1/":       "/api/v1/",
+		"api":            "",
+		"/api; deny all": "",
+		"":               "",
+	}
+
+	for value, expected := range tests {
+		ing.SetAnnotations(map[string]string{xForwardedPrefix: value})
+
+		i, _ := NewParser(mockBackend{true}).Parse(ing)
+		if redirect := i.(*Redirect); redirect.XForwardedPrefix != expected {
+			t.Errorf("expected '%v' as x-forwarded-prefix with '%v' but returned '%v'", expected, value, redirect.XForwardedPrefix)
+		}
+	}
+}
+
+func TestSSLRedirectTarget(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[sslRedirectCode] = "308"
+	data[sslRedirectHost] = "foo.bar.com"
+	data[sslRedirectPort] = "8443"
+	ing.SetAnnotations(data)
+
+	i, _ := NewParser(mockBackend{true}).Parse(ing)
+	redirect, ok := i.(*Redirect)
+	if !ok {
+		t.Errorf("expected a Redirect type")
+	}
+	if redirect.SSLRedirectCode != 308 || redirect.SSLRedirectHost != "foo.bar.com" || redirect.SSLRedirectPort != 8443 {
+		t.Errorf("expected 308, foo.bar.com and 8443 but returned %v, %v and %v",
+			redirect.SSLRedirectCode, redirect.SSLRedirectHost, redirect.SSLRedirectPort)
+	}
+
+	data[sslRedirectCode] = "200"
+	data[sslRedirectHost] = "https://foo.bar.com/"
+	data[sslRedirectPort] = "70000"
+	ing.SetAnnotations(data)
+
+	i, _ = NewParser(mockBackend{true}).Parse(ing)
+	redirect, ok = i.(*Redirect)
+	if !ok {
+		t.Errorf("expected a Redirect type")
+	}
+	if redirect.SSLRedirectCode != 0 || redirect.SSLRedirectHost != "" || redirect.SSLRedirectPort != 0 {
+		t.Errorf("expected the default values but returned %v, %v and %v",
+			redirect.SSLRedirectCode, redirect.SSLRedirectHost, redirect.SSLRedirectPort)
+	}
+}
+
+func TestUsesCaptureGroups(t *testing.T) {
+	for target, expected := range map[string]bool{
+		"/":            false,
+		"/foo":         false,
+		"/$2":          true,
+		"/$1/api/$2":   true,
+		"/$request_id": false,
+		"/$0":          false,
+	} {
+		if UsesCaptureGroups(target) != expected {
+			t.Errorf("expected %v for %v", expected, target)
+		}
+	}
 }
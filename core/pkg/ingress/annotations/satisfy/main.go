@@ -0,0 +1,62 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package satisfy
+
+import (
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/auth-satisfy"
+
+	// All requires the client address and the authentication to be valid
+	All = "all"
+	// Any requires the client address or the authentication to be valid
+	Any = "any"
+)
+
+// IsValidSatisfy checks the value is all or any (case insensitive)
+func IsValidSatisfy(val string) bool {
+	switch strings.ToLower(val) {
+	case All, Any:
+		return true
+	}
+	return false
+}
+
+type satisfy struct {
+}
+
+// NewParser creates a new auth-satisfy annotation parser
+func NewParser() parser.IngressAnnotation {
+	return satisfy{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// combine the access lists and the authentication of the locations.
+// Invalid values are ignored, so both must be valid (all)
+func (a satisfy) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidSatisfy(val) {
+		return "", nil
+	}
+	return strings.ToLower(val), nil
+}
@@ -18,6 +18,7 @@ package secureupstream
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
@@ -27,14 +28,20 @@ import (
 )
 
 const (
-	secureUpstream       = "ingress.kubernetes.io/secure-backends"
-	secureVerifyCASecret = "ingress.kubernetes.io/secure-verify-ca-secret"
+	secureUpstream         = "ingress.kubernetes.io/secure-backends"
+	secureVerifyCASecret   = "ingress.kubernetes.io/secure-verify-ca-secret"
+	secureClientCertSecret = "ingress.kubernetes.io/secure-client-cert-secret"
+	backendProtocol        = "ingress.kubernetes.io/backend-protocol"
+	backendProtocolHTTPS   = "HTTPS"
 )
 
 // Secure describes SSL backend configuration
 type Secure struct {
 	Secure bool                 `json:"secure"`
 	CACert resolver.AuthSSLCert `json:"caCert"`
+	// ClientCert is the certificate (and key) sent to the
+	// backend when the backend requests a client certificate
+	ClientCert resolver.AuthSSLCert `json:"clientCert"`
 }
 
 type su struct {
@@ -49,10 +56,17 @@ func NewParser(resolver resolver.AuthCertificate) parser.IngressAnnotation {
 }
 
 // Parse parses the annotations contained in the ingress
-// rule used to indicate if the upstream servers should use SSL
+// rule used to indicate if the upstream servers should use SSL.
+// The backend-protocol annotation HTTPS is equivalent to secure-backends
 func (a su) Parse(ing *extensions.Ingress) (interface{}, error) {
 	s, _ := parser.GetBoolAnnotation(secureUpstream, ing)
+	protocol, err := parser.GetStringAnnotation(backendProtocol, ing)
+	if err == nil && strings.ToUpper(protocol) == backendProtocolHTTPS {
+		s = true
+	}
+
 	ca, _ := parser.GetStringAnnotation(secureVerifyCASecret, ing)
+	clientCert, _ := parser.GetStringAnnotation(secureClientCertSecret, ing)
 	secure := &Secure{
 		Secure: s,
 		CACert: resolver.AuthSSLCert{},
@@ -61,18 +75,32 @@ func (a su) Parse(ing *extensions.Ingress) (interface{}, error) {
 		return secure,
 			errors.Errorf("trying to use CA from secret %v/%v on a non secure backend", ing.Namespace, ca)
 	}
-	if ca == "" {
-		return secure, nil
+	if !s && clientCert != "" {
+		return secure,
+			errors.Errorf("trying to use client certificate from secret %v/%v on a non secure backend", ing.Namespace, clientCert)
 	}
-	caCert, err := a.certResolver.GetAuthCertificate(fmt.Sprintf("%v/%v", ing.Namespace, ca))
-	if err != nil {
-		return secure, errors.Wrap(err, "error obtaining certificate")
+
+	if ca != "" {
+		caCert, err := a.certResolver.GetAuthCertificate(fmt.Sprintf("%v/%v", ing.Namespace, ca))
+		if err != nil {
+			return secure, errors.Wrap(err, "error obtaining certificate")
+		}
+		if caCert != nil {
+			secure.CACert = *caCert
+		}
 	}
-	if caCert == nil {
-		return secure, nil
+
+	if clientCert != "" {
+		cert, err := a.certResolver.GetAuthCertificate(fmt.Sprintf("%v/%v", ing.Namespace, clientCert))
+		if err != nil {
+			return secure, errors.Wrap(err, "error obtaining client certificate")
+		}
+		if cert == nil || cert.PemFileName == "" {
+			return secure,
+				errors.Errorf("secret %v/%v does not contain a certificate and key (tls.crt and tls.key)", ing.Namespace, clientCert)
+		}
+		secure.ClientCert = *cert
 	}
-	return &Secure{
-		Secure: s,
-		CACert: *caCert,
-	}, nil
+
+	return secure, nil
 }
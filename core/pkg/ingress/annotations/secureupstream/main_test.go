@@ -118,3 +118,58 @@ func TestSecretOnNonSecure(t *testing.T) {
 		t.Error("Expected CA secret on non secure backend error on ingress")
 	}
 }
+
+func TestBackendProtocol(t *testing.T) {
+	ing := buildIngress()
+	for protocol, expected := range map[string]bool{
+		"":      false,
+		"HTTP":  false,
+		"HTTPS": true,
+		"https": true,
+		"GRPC":  false,
+	} {
+		ing.SetAnnotations(map[string]string{backendProtocol: protocol})
+		i, err := NewParser(mockCfg{}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error with backend protocol '%v': %v", protocol, err)
+			continue
+		}
+		if secure := i.(*Secure).Secure; secure != expected {
+			t.Errorf("expected secure %v with backend protocol '%v' but returned %v", expected, protocol, secure)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		backendProtocol:        "HTTPS",
+		secureClientCertSecret: "client",
+	})
+	i, err := NewParser(mockCfg{
+		certs: map[string]resolver.AuthSSLCert{
+			"default/client": {Secret: "default/client", PemFileName: "/ssl/default-client.pem"},
+		},
+	}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error on ingress: %v", err)
+	}
+	if pem := i.(*Secure).ClientCert.PemFileName; pem != "/ssl/default-client.pem" {
+		t.Errorf("expected the client certificate /ssl/default-client.pem but returned '%v'", pem)
+	}
+
+	// a secret with only a CA cannot be used as client certificate
+	_, err = NewParser(mockCfg{
+		certs: map[string]resolver.AuthSSLCert{
+			"default/client": {Secret: "default/client", CAFileName: "/ssl/ca-default-client.pem"},
+		},
+	}).Parse(ing)
+	if err == nil {
+		t.Error("expected an error with a secret without certificate and key")
+	}
+
+	ing.SetAnnotations(map[string]string{secureClientCertSecret: "client"})
+	if _, err := NewParser(mockCfg{}).Parse(ing); err == nil {
+		t.Error("expected client certificate on non secure backend error on ingress")
+	}
+}
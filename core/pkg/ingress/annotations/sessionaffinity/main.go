@@ -36,12 +36,31 @@ const (
 	// one isn't supplied and affinity is set to "cookie".
 	annotationAffinityCookieHash = "ingress.kubernetes.io/session-cookie-hash"
 	defaultAffinityCookieHash    = "md5"
+	// Time after which the cookie expires. Without a value the cookie
+	// is only valid for the session of the browser
+	annotationAffinityCookieExpires = "ingress.kubernetes.io/session-cookie-expires"
+	// Path of the cookie. Without a value the path is /
+	annotationAffinityCookiePath = "ingress.kubernetes.io/session-cookie-path"
 )
 
 var (
 	affinityCookieHashRegex = regexp.MustCompile(`^(index|md5|sha1)$`)
+	// time with the NGINX syntax, like 30m or 48h
+	affinityCookieExpiresRegex = regexp.MustCompile(`^([0-9]+(s|m|h|d|w|M|y)?)+$`)
+	affinityCookiePathRegex    = regexp.MustCompile(`^/[A-Za-z0-9\-._~/]*$`)
 )
 
+// IsValidCookieExpires checks the expiration of the cookie uses
+// the NGINX time format, e.g. 30m or 48h
+func IsValidCookieExpires(expires string) bool {
+	return affinityCookieExpiresRegex.MatchString(expires)
+}
+
+// IsValidCookiePath checks the path of the cookie is an absolute path
+func IsValidCookiePath(path string) bool {
+	return affinityCookiePathRegex.MatchString(path)
+}
+
 // AffinityConfig describes the per ingress session affinity config
 type AffinityConfig struct {
 	// The type of affinity that will be used
@@ -55,6 +74,10 @@ type CookieConfig struct {
 	Name string `json:"name"`
 	// The hash that will be used to encode the cookie in case of cookie affinity type
 	Hash string `json:"hash"`
+	// The time after which the cookie expires
+	Expires string `json:"expires,omitempty"`
+	// The path of the cookie
+	Path string `json:"path,omitempty"`
 }
 
 // CookieAffinityParse gets the annotation values related to Cookie Affinity
@@ -75,9 +98,25 @@ func CookieAffinityParse(ing *extensions.Ingress) *CookieConfig {
 		sh = defaultAffinityCookieHash
 	}
 
+	se, err := parser.GetStringAnnotation(annotationAffinityCookieExpires, ing)
+
+	if err == nil && !IsValidCookieExpires(se) {
+		glog.V(3).Infof("Invalid annotation value found in Ingress %v: %v. Ignoring it", ing.Name, annotationAffinityCookieExpires)
+		se = ""
+	}
+
+	sp, err := parser.GetStringAnnotation(annotationAffinityCookiePath, ing)
+
+	if err == nil && !IsValidCookiePath(sp) {
+		glog.V(3).Infof("Invalid annotation value found in Ingress %v: %v. Ignoring it", ing.Name, annotationAffinityCookiePath)
+		sp = ""
+	}
+
 	return &CookieConfig{
-		Name: sn,
-		Hash: sh,
+		Name:    sn,
+		Hash:    sh,
+		Expires: se,
+		Path:    sp,
 	}
 }
 
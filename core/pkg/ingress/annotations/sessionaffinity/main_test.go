@@ -87,3 +87,35 @@ func TestIngressAffinityCookieConfig(t *testing.T) {
 		t.Errorf("expected route as sticky-name but returned %v", nginxAffinity.CookieConfig.Name)
 	}
 }
+
+func TestIngressAffinityCookieExpiresAndPath(t *testing.T) {
+	testCases := []struct {
+		expires         string
+		path            string
+		expectedExpires string
+		expectedPath    string
+	}{
+		{"48h", "/app", "48h", "/app"},
+		{"1d12h", "/", "1d12h", "/"},
+		{"", "", "", ""},
+		{"tomorrow", "app; secure", "", ""},
+	}
+
+	ing := buildIngress()
+	for _, tc := range testCases {
+		data := map[string]string{}
+		data[annotationAffinityType] = "cookie"
+		data[annotationAffinityCookieExpires] = tc.expires
+		data[annotationAffinityCookiePath] = tc.path
+		ing.SetAnnotations(data)
+
+		affin, _ := NewParser().Parse(ing)
+		cookie := affin.(*AffinityConfig).CookieConfig
+		if cookie.Expires != tc.expectedExpires {
+			t.Errorf("expected %q as cookie expiration but returned %q", tc.expectedExpires, cookie.Expires)
+		}
+		if cookie.Path != tc.expectedPath {
+			t.Errorf("expected %q as cookie path but returned %q", tc.expectedPath, cookie.Path)
+		}
+	}
+}
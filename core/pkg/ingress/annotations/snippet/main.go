@@ -23,20 +23,28 @@ import (
 )
 
 const (
-	annotation = "ingress.kubernetes.io/configuration-snippet"
+	annotation       = "ingress.kubernetes.io/configuration-snippet"
+	serverAnnotation = "ingress.kubernetes.io/server-snippet"
 )
 
 type snippet struct {
+	annotation string
 }
 
 // NewParser creates a new CORS annotation parser
 func NewParser() parser.IngressAnnotation {
-	return snippet{}
+	return snippet{annotation}
+}
+
+// NewServerParser creates a new parser of the fragment of configuration
+// included in the servers (hosts) defined in the Ingress rule
+func NewServerParser() parser.IngressAnnotation {
+	return snippet{serverAnnotation}
 }
 
 // Parse parses the annotations contained in the ingress rule
-// used to indicate if the location/s contains a fragment of
-// configuration to be included inside the paths of the rules
+// used to indicate if the location/s or server/s contains a fragment
+// of configuration to be included inside the paths or hosts of the rules
 func (a snippet) Parse(ing *extensions.Ingress) (interface{}, error) {
-	return parser.GetStringAnnotation(annotation, ing)
+	return parser.GetStringAnnotation(a.annotation, ing)
 }
@@ -24,7 +24,8 @@ import (
 )
 
 const (
-	passthrough = "ingress.kubernetes.io/ssl-passthrough"
+	passthrough   = "ingress.kubernetes.io/ssl-passthrough"
+	proxyProtocol = "ingress.kubernetes.io/ssl-passthrough-proxy-protocol"
 )
 
 type sslpt struct {
@@ -44,3 +45,23 @@ func (a sslpt) Parse(ing *extensions.Ingress) (interface{}, error) {
 
 	return parser.GetBoolAnnotation(passthrough, ing)
 }
+
+type sslptProxyProtocol struct {
+}
+
+// NewProxyProtocolParser creates a new parser of the annotation used
+// to send the PROXY protocol header to the SSL passthrough backends
+func NewProxyProtocolParser() parser.IngressAnnotation {
+	return sslptProxyProtocol{}
+}
+
+// Parse parses the annotation contained in the ingress rule used to
+// indicate if the connections to the SSL passthrough backend start
+// with the PROXY protocol header, with the address of the client
+func (a sslptProxyProtocol) Parse(ing *extensions.Ingress) (interface{}, error) {
+	if ing.GetAnnotations() == nil {
+		return false, ing_errors.ErrMissingAnnotations
+	}
+
+	return parser.GetBoolAnnotation(proxyProtocol, ing)
+}
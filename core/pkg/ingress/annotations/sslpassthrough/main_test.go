@@ -76,3 +76,29 @@ func TestParseAnnotations(t *testing.T) {
 		t.Errorf("expected true but false returned")
 	}
 }
+
+func TestParseProxyProtocolAnnotation(t *testing.T) {
+	ing := buildIngress()
+
+	_, err := NewProxyProtocolParser().Parse(ing)
+	if err == nil {
+		t.Errorf("expected error parsing ingress without annotations")
+	}
+
+	for value, expected := range map[string]bool{
+		"true":  true,
+		"false": false,
+	} {
+		ing.SetAnnotations(map[string]string{
+			passthrough:   "true",
+			proxyProtocol: value,
+		})
+		i, err := NewProxyProtocolParser().Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error parsing %v: %v", value, err)
+		}
+		if val, ok := i.(bool); !ok || val != expected {
+			t.Errorf("expected %v but %v returned", expected, i)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstreamhashby
+
+import (
+	"regexp"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/upstream-hash-by"
+)
+
+var (
+	// text and at least one NGINX variable, e.g. `$request_uri` or `$host$uri`
+	hashByRegex = regexp.MustCompile(`^[a-zA-Z0-9_.:/\-]*(\$[a-zA-Z0-9_]+[a-zA-Z0-9_.:/\-]*)+$`)
+)
+
+// IsValidHashBy checks the value contains NGINX variables and text
+// without spaces or quotes
+func IsValidHashBy(val string) bool {
+	return hashByRegex.MatchString(val)
+}
+
+type upstreamHashBy struct {
+}
+
+// NewParser creates a new upstream-hash-by annotation parser
+func NewParser() parser.IngressAnnotation {
+	return upstreamHashBy{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to select the endpoint of the upstream with a consistent hash.
+// Invalid values are ignored, so the load balance algorithm is used
+func (a upstreamHashBy) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidHashBy(val) {
+		return "", nil
+	}
+	return val, nil
+}
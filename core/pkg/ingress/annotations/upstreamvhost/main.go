@@ -0,0 +1,58 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstreamvhost
+
+import (
+	"regexp"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/upstream-vhost"
+)
+
+var (
+	// hostname with an optional port, e.g. internal.example.com or backend:8080
+	vhostRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-.]*[a-zA-Z0-9])?(:[0-9]{1,5})?$`)
+)
+
+// IsValidVhost checks the value is a hostname with an optional port
+func IsValidVhost(val string) bool {
+	return vhostRegex.MatchString(val)
+}
+
+type upstreamVhost struct {
+}
+
+// NewParser creates a new upstream-vhost annotation parser
+func NewParser() parser.IngressAnnotation {
+	return upstreamVhost{}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the Host header sent to the upstream.
+// Invalid values are ignored, so the host of the request is used
+func (a upstreamVhost) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(annotation, ing)
+	if err != nil || !IsValidVhost(val) {
+		return "", nil
+	}
+	return val, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package urlredirect
+
+import (
+	"regexp"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+)
+
+const (
+	permanent     = "ingress.kubernetes.io/permanent-redirect"
+	permanentCode = "ingress.kubernetes.io/permanent-redirect-code"
+	temporal      = "ingress.kubernetes.io/temporal-redirect"
+
+	defPermanentCode = 301
+	temporalCode     = 302
+)
+
+var (
+	// absolute URL, e.g. https://www.example.com/new or https://www.example.com$request_uri
+	urlRegex = regexp.MustCompile(`^https?://[^\s;{}'"]+$`)
+)
+
+// Config returns the redirect of all the requests of a location to a URL
+type Config struct {
+	// URL of the redirect. An empty URL disables the redirect
+	URL string `json:"url"`
+	// Code is the status code of the redirect
+	Code int `json:"code"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.URL != c2.URL {
+		return false
+	}
+	if c1.Code != c2.Code {
+		return false
+	}
+
+	return true
+}
+
+// IsValidURL checks the value is an absolute HTTP or HTTPS URL
+// without spaces, quotes, braces or semicolons
+func IsValidURL(val string) bool {
+	return urlRegex.MatchString(val)
+}
+
+type urlRedirect struct {
+}
+
+// NewParser creates a new URL redirect annotation parser
+func NewParser() parser.IngressAnnotation {
+	return urlRedirect{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// redirect the requests to a URL. The permanent redirect takes precedence
+// over the temporal one. Invalid URLs are ignored and invalid codes of the
+// permanent redirect are replaced with 301
+func (r urlRedirect) Parse(ing *extensions.Ingress) (interface{}, error) {
+	url, err := parser.GetStringAnnotation(permanent, ing)
+	if err == nil && IsValidURL(url) {
+		code, err := parser.GetIntAnnotation(permanentCode, ing)
+		if err != nil || !rewrite.IsValidRedirectCode(code) {
+			code = defPermanentCode
+		}
+
+		return &Config{
+			URL:  url,
+			Code: code,
+		}, nil
+	}
+
+	url, err = parser.GetStringAnnotation(temporal, ing)
+	if err == nil && IsValidURL(url) {
+		return &Config{
+			URL:  url,
+			Code: temporalCode,
+		}, nil
+	}
+
+	return &Config{}, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package urlredirect
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func TestParse(t *testing.T) {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: extensions.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+	}{
+		{map[string]string{permanent: "https://www.example.com/new"}, &Config{URL: "https://www.example.com/new", Code: 301}},
+		{map[string]string{permanent: "https://www.example.com$request_uri", permanentCode: "308"}, &Config{URL: "https://www.example.com$request_uri", Code: 308}},
+		{map[string]string{permanent: "https://www.example.com", permanentCode: "200"}, &Config{URL: "https://www.example.com", Code: 301}},
+		{map[string]string{temporal: "http://www.example.com/tmp"}, &Config{URL: "http://www.example.com/tmp", Code: 302}},
+		{map[string]string{permanent: "https://www.example.com", temporal: "http://www.example.com/tmp"}, &Config{URL: "https://www.example.com", Code: 301}},
+		{map[string]string{permanent: "/new", temporal: "http://www.example.com/tmp"}, &Config{URL: "http://www.example.com/tmp", Code: 302}},
+		{map[string]string{permanent: "https://www.example.com; return 200"}, &Config{}},
+		{map[string]string{temporal: "ftp://www.example.com"}, &Config{}},
+		{map[string]string{permanentCode: "308"}, &Config{}},
+		{nil, &Config{}},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, _ := NewParser().Parse(ing)
+		if !testCase.expected.Equal(result.(*Config)) {
+			t.Errorf("expected %+v but returned %+v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/alias"
+	"k8s.io/ingress/core/pkg/ingress/annotations/auth"
+	"k8s.io/ingress/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/canary"
+	"k8s.io/ingress/core/pkg/ingress/annotations/cors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/customhttperrors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/defaultbackend"
+	"k8s.io/ingress/core/pkg/ingress/annotations/globalratelimit"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress/core/pkg/ingress/annotations/loadbalance"
+	"k8s.io/ingress/core/pkg/ingress/annotations/mirror"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxycache"
+	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/ingress/annotations/satisfy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/sessionaffinity"
+	"k8s.io/ingress/core/pkg/ingress/annotations/upstreamhashby"
+	"k8s.io/ingress/core/pkg/ingress/annotations/upstreamvhost"
+	"k8s.io/ingress/core/pkg/ingress/annotations/urlredirect"
+	"k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+const (
+	// prefix of the annotations processed by the controller
+	annotationPrefix = "ingress.kubernetes.io/"
+)
+
+// validator checks the value of an annotation
+type validator func(string) bool
+
+var (
+	// annotations processed by the controller and the validation of the value.
+	// The parsers use the default value when the value is not valid
+	annotations = map[string]validator{
+		"add-base-url":                   isBool,
+		"affinity":                       isAny,
+		"allow-source-range":             isAddressList,
+		"app-root":                       rewrite.IsValidPath,
+		"auth-method":                    isAny,
+		"auth-realm":                     isAny,
+		"auth-response-headers":          isAny,
+		"auth-satisfy":                   satisfy.IsValidSatisfy,
+		"auth-secret":                    isAny,
+		"auth-send-body":                 isBool,
+		"auth-signin":                    isAny,
+		"auth-tls-error-page":            authtls.IsValidErrorPage,
+		"auth-tls-secret":                isAny,
+		"auth-tls-verify-client":         isAny,
+		"auth-tls-verify-depth":          isInt,
+		"auth-type":                      auth.IsValidType,
+		"backend-protocol":               backendprotocol.IsValidProtocol,
+		"auth-url":                       isAny,
+		"canary":                         isBool,
+		"canary-by-cookie":               canary.IsValidCookie,
+		"canary-by-header":               canary.IsValidHeader,
+		"canary-weight":                  canary.IsValidWeight,
+		"client-body-buffer-size":        isAny,
+		"configuration-snippet":          isAny,
+		"cors-allow-credentials":         isBool,
+		"cors-allow-headers":             cors.IsValidHeaders,
+		"cors-allow-methods":             cors.IsValidMethods,
+		"cors-allow-origin":              cors.IsValidOrigin,
+		"cors-max-age":                   isInt,
+		"custom-http-errors":             isHTTPErrorList,
+		"default-backend":                defaultbackend.IsValidService,
+		"deny-source-range":              isAddressList,
+		"enable-cors":                    isBool,
+		"force-ssl-redirect":             isBool,
+		"from-to-www-redirect":           isBool,
+		"generate-request-id":            isBool,
+		"global-rate-limit":              isInt,
+		"global-rate-limit-key":          globalratelimit.IsValidKey,
+		"global-rate-limit-window":       globalratelimit.IsValidWindow,
+		"hsts":                           isBool,
+		"hsts-include-subdomains":        isBool,
+		"hsts-max-age":                   hsts.IsValidMaxAge,
+		"hsts-preload":                   isBool,
+		"limit-connections":              isInt,
+		"limit-rps":                      isInt,
+		"limit-rpm":                      isInt,
+		"limit-burst-multiplier":         isInt,
+		"load-balance":                   loadbalance.IsValidAlgorithm,
+		"mirror-request-body":            isBool,
+		"mirror-target":                  mirror.IsValidTarget,
+		"permanent-redirect":             urlredirect.IsValidURL,
+		"permanent-redirect-code":        isRedirectCode,
+		"proxy-body-size":                proxy.IsValidBodySize,
+		"proxy-buffer-size":              isAny,
+		"proxy-buffering":                proxy.IsValidBuffering,
+		"proxy-cache":                    proxycache.IsValidZone,
+		"proxy-cache-bypass":             isCacheBypass,
+		"proxy-cache-key":                proxycache.IsValidKey,
+		"proxy-cache-valid":              isCacheValid,
+		"proxy-connect-timeout":          isTimeout,
+		"proxy-cookie-domain":            isAny,
+		"proxy-cookie-path":              isAny,
+		"proxy-next-upstream":            isAny,
+		"proxy-no-cache":                 isCacheBypass,
+		"proxy-read-timeout":             isTimeout,
+		"proxy-redirect":                 isAny,
+		"proxy-request-buffering":        proxy.IsValidBuffering,
+		"proxy-send-timeout":             isTimeout,
+		"rewrite-target":                 rewrite.IsValidPath,
+		"secure-backends":                isBool,
+		"secure-verify-ca-secret":        isAny,
+		"secure-client-cert-secret":      isAny,
+		"server-alias":                   isAliasList,
+		"server-allow-source-range":      isAddressList,
+		"server-deny-source-range":       isAddressList,
+		"server-snippet":                 isAny,
+		"service-upstream":               isBool,
+		"session-cookie-expires":         sessionaffinity.IsValidCookieExpires,
+		"session-cookie-hash":            isAny,
+		"session-cookie-name":            isAny,
+		"session-cookie-path":            sessionaffinity.IsValidCookiePath,
+		"ssl-passthrough":                isBool,
+		"ssl-passthrough-proxy-protocol": isBool,
+		"ssl-early-data":                 isBool,
+		"ssl-redirect":                   isBool,
+		"ssl-redirect-code":              isRedirectCode,
+		"ssl-redirect-host":              rewrite.IsValidRedirectHost,
+		"ssl-redirect-port":              isRedirectPort,
+		"temporal-redirect":              urlredirect.IsValidURL,
+		"upstream-backup-service":        defaultbackend.IsValidService,
+		"upstream-fail-timeout":          isInt,
+		"upstream-hash-by":               upstreamhashby.IsValidHashBy,
+		"upstream-health-check-function": isAny,
+		"upstream-health-check-script":   isAny,
+		"upstream-max-fails":             isInt,
+		"upstream-vhost":                 upstreamvhost.IsValidVhost,
+		"use-port-in-redirects":          isBool,
+		"whitelist-source-range":         isCIDRList,
+		"x-forwarded-prefix":             rewrite.IsValidPath,
+	}
+)
+
+// Validate checks the annotations of the Ingress rule with the prefix
+// ingress.kubernetes.io/, returning an error for each unknown annotation
+// or invalid value. The parsers of the annotations ignore these values
+func Validate(ing *extensions.Ingress) []error {
+	names := []string{}
+	for name := range ing.GetAnnotations() {
+		if strings.HasPrefix(name, annotationPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, name := range names {
+		val := ing.GetAnnotations()[name]
+		isValid, ok := annotations[strings.TrimPrefix(name, annotationPrefix)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown annotation %v", name))
+			continue
+		}
+		if !isValid(val) {
+			errs = append(errs, errors.NewInvalidAnnotationContent(name, val))
+		}
+	}
+
+	return errs
+}
+
+func isAny(string) bool {
+	return true
+}
+
+func isBool(val string) bool {
+	_, err := strconv.ParseBool(val)
+	return err == nil
+}
+
+func isInt(val string) bool {
+	_, err := strconv.Atoi(val)
+	return err == nil
+}
+
+func isTimeout(val string) bool {
+	t, err := strconv.Atoi(val)
+	return err == nil && proxy.IsValidTimeout(t)
+}
+
+func isRedirectCode(val string) bool {
+	code, err := strconv.Atoi(val)
+	return err == nil && rewrite.IsValidRedirectCode(code)
+}
+
+func isRedirectPort(val string) bool {
+	port, err := strconv.Atoi(val)
+	return err == nil && rewrite.IsValidRedirectPort(port)
+}
+
+// isCIDRList checks the value is a list of addresses or networks
+// separated by commas
+func isCIDRList(val string) bool {
+	_, err := ipwhitelist.ParseCIDRs(val)
+	return err == nil
+}
+
+// isHTTPErrorList checks the value is a list of status codes
+// separated by commas
+func isHTTPErrorList(val string) bool {
+	_, err := customhttperrors.ParseCodes(val)
+	return err == nil
+}
+
+// isAliasList checks the value is a list of hostnames
+// separated by commas or spaces
+func isAliasList(val string) bool {
+	_, err := alias.ParseAliases(val)
+	return err == nil
+}
+
+// isCacheValid checks the value is a list of caching times
+// with optional status codes separated by commas
+func isCacheValid(val string) bool {
+	_, err := proxycache.ParseValid(val)
+	return err == nil
+}
+
+// isCacheBypass checks the value is a list of variables
+// separated by spaces
+func isCacheBypass(val string) bool {
+	_, err := proxycache.ParseBypass(val)
+	return err == nil
+}
+
+// isAddressList checks the value is a list of addresses or networks
+// separated by commas. Empty values are ignored
+func isAddressList(val string) bool {
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			if _, _, err := net.ParseCIDR(v); err != nil {
+				return false
+			}
+		} else if net.ParseIP(v) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+func buildIngress(annotations map[string]string) *extensions.Ingress {
+	return &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    []string
+	}{
+		{nil, []string{}},
+		{map[string]string{"kubernetes.io/ingress.class": "nginx", "foo": "bar"}, []string{}},
+		{map[string]string{
+			"ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8,192.168.0.0/16",
+			"ingress.kubernetes.io/allow-source-range":     "10.0.0.1, 2001:db8::/32",
+			"ingress.kubernetes.io/rewrite-target":         "/foo",
+			"ingress.kubernetes.io/ssl-redirect":           "false",
+			"ingress.kubernetes.io/limit-rps":              "10",
+			"ingress.kubernetes.io/ssl-redirect-code":      "308",
+			"ingress.kubernetes.io/proxy-read-timeout":     "3600",
+		}, []string{}},
+		{map[string]string{"ingress.kubernetes.io/rewrite-targets": "/"}, []string{
+			"unknown annotation ingress.kubernetes.io/rewrite-targets",
+		}},
+		{map[string]string{
+			"ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8,192.168.0.300/16",
+			"ingress.kubernetes.io/deny-source-range":      "10.0.0",
+			"ingress.kubernetes.io/rewrite-target":         "foo; return 200",
+			"ingress.kubernetes.io/ssl-redirect":           "yes",
+			"ingress.kubernetes.io/limit-rps":              "ten",
+			"ingress.kubernetes.io/ssl-redirect-code":      "200",
+			"ingress.kubernetes.io/proxy-read-timeout":     "0",
+		}, []string{
+			"the annotation ingress.kubernetes.io/deny-source-range does not contain a valid value (10.0.0)",
+			"the annotation ingress.kubernetes.io/limit-rps does not contain a valid value (ten)",
+			"the annotation ingress.kubernetes.io/proxy-read-timeout does not contain a valid value (0)",
+			"the annotation ingress.kubernetes.io/rewrite-target does not contain a valid value (foo; return 200)",
+			"the annotation ingress.kubernetes.io/ssl-redirect does not contain a valid value (yes)",
+			"the annotation ingress.kubernetes.io/ssl-redirect-code does not contain a valid value (200)",
+			"the annotation ingress.kubernetes.io/whitelist-source-range does not contain a valid value (10.0.0.0/8,192.168.0.300/16)",
+		}},
+	}
+
+	for _, test := range tests {
+		errs := Validate(buildIngress(test.annotations))
+		if len(errs) != len(test.expected) {
+			t.Errorf("%v: expected %v errors but returned %v", test.annotations, len(test.expected), errs)
+			continue
+		}
+		for i, err := range errs {
+			if err.Error() != test.expected[i] {
+				t.Errorf("expected '%v' but returned '%v'", test.expected[i], err)
+			}
+		}
+	}
+}
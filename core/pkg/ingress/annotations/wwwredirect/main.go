@@ -0,0 +1,69 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wwwredirect
+
+import (
+	"strings"
+
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
+)
+
+const (
+	annotation = "ingress.kubernetes.io/from-to-www-redirect"
+
+	wwwPrefix = "www."
+)
+
+// Alias returns the hostname redirected to the host, www.<host> or
+// the host without the www. prefix. Hostnames without a domain (like
+// the default server _) and wildcard hostnames do not have an alias
+func Alias(host string) string {
+	if !strings.Contains(host, ".") || strings.HasPrefix(host, "*") {
+		return ""
+	}
+
+	if strings.HasPrefix(host, wwwPrefix) {
+		alias := strings.TrimPrefix(host, wwwPrefix)
+		if !strings.Contains(alias, ".") {
+			return ""
+		}
+		return alias
+	}
+
+	return wwwPrefix + host
+}
+
+type wwwRedirect struct {
+}
+
+// NewParser creates a new from-to-www-redirect annotation parser
+func NewParser() parser.IngressAnnotation {
+	return wwwRedirect{}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// redirect the requests of www.<host> to <host> (or the opposite when
+// the host starts with www.) in the servers defined in the Ingress rule
+func (a wwwRedirect) Parse(ing *extensions.Ingress) (interface{}, error) {
+	val, err := parser.GetBoolAnnotation(annotation, ing)
+	if err != nil {
+		return false, nil
+	}
+	return val, nil
+}
@@ -18,23 +18,44 @@ package controller
 
 import (
 	"github.com/golang/glog"
+	api "k8s.io/client-go/pkg/api/v1"
 	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/ingress/core/pkg/ingress/annotations/alias"
 	"k8s.io/ingress/core/pkg/ingress/annotations/auth"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authreq"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backendprotocol"
+	"k8s.io/ingress/core/pkg/ingress/annotations/backupservice"
+	"k8s.io/ingress/core/pkg/ingress/annotations/canary"
 	"k8s.io/ingress/core/pkg/ingress/annotations/cors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/customhttperrors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/defaultbackend"
+	"k8s.io/ingress/core/pkg/ingress/annotations/earlydata"
+	"k8s.io/ingress/core/pkg/ingress/annotations/globalratelimit"
 	"k8s.io/ingress/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
 	"k8s.io/ingress/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress/core/pkg/ingress/annotations/loadbalance"
+	"k8s.io/ingress/core/pkg/ingress/annotations/mirror"
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
 	"k8s.io/ingress/core/pkg/ingress/annotations/portinredirect"
 	"k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxycache"
 	"k8s.io/ingress/core/pkg/ingress/annotations/ratelimit"
+	"k8s.io/ingress/core/pkg/ingress/annotations/requestid"
 	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/ingress/annotations/satisfy"
 	"k8s.io/ingress/core/pkg/ingress/annotations/secureupstream"
 	"k8s.io/ingress/core/pkg/ingress/annotations/serviceupstream"
 	"k8s.io/ingress/core/pkg/ingress/annotations/sessionaffinity"
 	"k8s.io/ingress/core/pkg/ingress/annotations/snippet"
 	"k8s.io/ingress/core/pkg/ingress/annotations/sslpassthrough"
+	"k8s.io/ingress/core/pkg/ingress/annotations/upstreamhashby"
+	"k8s.io/ingress/core/pkg/ingress/annotations/upstreamvhost"
+	"k8s.io/ingress/core/pkg/ingress/annotations/urlredirect"
+	"k8s.io/ingress/core/pkg/ingress/annotations/validation"
+	"k8s.io/ingress/core/pkg/ingress/annotations/wwwredirect"
 	"k8s.io/ingress/core/pkg/ingress/errors"
 	"k8s.io/ingress/core/pkg/ingress/resolver"
 )
@@ -54,21 +75,44 @@ func newAnnotationExtractor(cfg extractorConfig) annotationExtractor {
 	return annotationExtractor{
 		cfg,
 		map[string]parser.IngressAnnotation{
-			"BasicDigestAuth":      auth.NewParser(auth.AuthDirectory, cfg),
-			"ExternalAuth":         authreq.NewParser(),
-			"CertificateAuth":      authtls.NewParser(cfg),
-			"EnableCORS":           cors.NewParser(),
-			"HealthCheck":          healthcheck.NewParser(cfg),
-			"Whitelist":            ipwhitelist.NewParser(cfg),
-			"UsePortInRedirects":   portinredirect.NewParser(cfg),
-			"Proxy":                proxy.NewParser(cfg),
-			"RateLimit":            ratelimit.NewParser(),
-			"Redirect":             rewrite.NewParser(cfg),
-			"SecureUpstream":       secureupstream.NewParser(cfg),
-			"ServiceUpstream":      serviceupstream.NewParser(),
-			"SessionAffinity":      sessionaffinity.NewParser(),
-			"SSLPassthrough":       sslpassthrough.NewParser(),
-			"ConfigurationSnippet": snippet.NewParser(),
+			"BasicDigestAuth":             auth.NewParser(auth.AuthDirectory, cfg),
+			"ExternalAuth":                authreq.NewParser(),
+			"CertificateAuth":             authtls.NewParser(cfg),
+			"EnableCORS":                  cors.NewParser(),
+			"CorsConfig":                  cors.NewConfigParser(),
+			"HealthCheck":                 healthcheck.NewParser(cfg),
+			"HSTS":                        hsts.NewParser(cfg),
+			"Whitelist":                   ipwhitelist.NewParser(cfg),
+			"AccessList":                  ipaccess.NewParser(),
+			"ServerAccessList":            ipaccess.NewServerParser(),
+			"UsePortInRedirects":          portinredirect.NewParser(cfg),
+			"GenerateRequestID":           requestid.NewParser(cfg),
+			"SSLEarlyData":                earlydata.NewParser(cfg),
+			"Proxy":                       proxy.NewParser(cfg),
+			"RateLimit":                   ratelimit.NewParser(cfg),
+			"GlobalRateLimit":             globalratelimit.NewParser(),
+			"Redirect":                    rewrite.NewParser(cfg),
+			"SecureUpstream":              secureupstream.NewParser(cfg),
+			"ServiceUpstream":             serviceupstream.NewParser(),
+			"SessionAffinity":             sessionaffinity.NewParser(),
+			"SSLPassthrough":              sslpassthrough.NewParser(),
+			"SSLPassthroughProxyProtocol": sslpassthrough.NewProxyProtocolParser(),
+			"ConfigurationSnippet":        snippet.NewParser(),
+			"ServerSnippet":               snippet.NewServerParser(),
+			"RedirectFromToWWW":           wwwredirect.NewParser(),
+			"Aliases":                     alias.NewParser(),
+			"UpstreamHashBy":              upstreamhashby.NewParser(),
+			"LoadBalance":                 loadbalance.NewParser(),
+			"Canary":                      canary.NewParser(),
+			"Mirror":                      mirror.NewParser(),
+			"DefaultBackend":              defaultbackend.NewParser(),
+			"BackupService":               backupservice.NewParser(),
+			"CustomHTTPErrors":            customhttperrors.NewParser(),
+			"ProxyCache":                  proxycache.NewParser(),
+			"UpstreamVhost":               upstreamvhost.NewParser(),
+			"URLRedirect":                 urlredirect.NewParser(),
+			"BackendProtocol":             backendprotocol.NewParser(),
+			"Satisfy":                     satisfy.NewParser(),
 		},
 	}
 }
@@ -101,19 +145,121 @@ func (e *annotationExtractor) Extract(ing *extensions.Ingress) map[string]interf
 	return anns
 }
 
+// validateAnnotations records an event in the Ingress rule for each unknown
+// annotation or annotation with an invalid value, ignored by the parsers
+func (ic *GenericController) validateAnnotations(ing *extensions.Ingress) {
+	errs := validation.Validate(ing)
+	for _, err := range errs {
+		glog.Warningf("Ingress %v/%v: %v", ing.Namespace, ing.Name, err)
+		ic.recorder.Event(ing, api.EventTypeWarning, "INVALID_ANNOTATION", err.Error())
+	}
+	setInvalidAnnotations(ing.Namespace, ing.Name, len(errs))
+}
+
 const (
-	secureUpstream  = "SecureUpstream"
-	healthCheck     = "HealthCheck"
-	sslPassthrough  = "SSLPassthrough"
-	sessionAffinity = "SessionAffinity"
-	serviceUpstream = "ServiceUpstream"
+	secureUpstream              = "SecureUpstream"
+	healthCheck                 = "HealthCheck"
+	sslPassthrough              = "SSLPassthrough"
+	sslPassthroughProxyProtocol = "SSLPassthroughProxyProtocol"
+	sessionAffinity             = "SessionAffinity"
+	serviceUpstream             = "ServiceUpstream"
+	upstreamHashBy              = "UpstreamHashBy"
+	loadBalance                 = "LoadBalance"
+	canaryConfig                = "Canary"
+	defaultBackend              = "DefaultBackend"
+	backupService               = "BackupService"
+	redirect                    = "Redirect"
+
+	serverAccessList = "ServerAccessList"
+	serverHSTS       = "HSTS"
+	serverSnippet    = "ServerSnippet"
+	serverWWW        = "RedirectFromToWWW"
+	serverAliases    = "Aliases"
 )
 
+// ServerAccessList returns the access list of the servers defined in
+// the Ingress rule. An invalid value denies the access to the locations
+// of the rule, so the error is ignored here
+func (e *annotationExtractor) ServerAccessList(ing *extensions.Ingress) ipaccess.AccessList {
+	val, err := e.annotations[serverAccessList].Parse(ing)
+	if err != nil {
+		return ipaccess.AccessList{}
+	}
+	return *val.(*ipaccess.AccessList)
+}
+
+// HSTS returns the configuration of the Strict-Transport-Security
+// header of the servers defined in the Ingress rule
+func (e *annotationExtractor) HSTS(ing *extensions.Ingress) *hsts.Config {
+	val, _ := e.annotations[serverHSTS].Parse(ing)
+	return val.(*hsts.Config)
+}
+
+// ServerSnippet returns the fragment of configuration included
+// in the servers defined in the Ingress rule
+func (e *annotationExtractor) ServerSnippet(ing *extensions.Ingress) string {
+	val, _ := e.annotations[serverSnippet].Parse(ing)
+	return val.(string)
+}
+
+// RedirectFromToWWW indicates if the requests of the www. alias of
+// the servers defined in the Ingress rule are redirected to the server
+func (e *annotationExtractor) RedirectFromToWWW(ing *extensions.Ingress) bool {
+	val, _ := e.annotations[serverWWW].Parse(ing)
+	return val.(bool)
+}
+
+// ServerAliases returns the additional hostnames of the
+// servers defined in the Ingress rule
+func (e *annotationExtractor) ServerAliases(ing *extensions.Ingress) []string {
+	val, _ := e.annotations[serverAliases].Parse(ing)
+	return val.([]string)
+}
+
+// SSLRedirect returns the configuration of the redirect to HTTPS defined
+// in the Ingress rule, without the rewrite of the paths
+func (e *annotationExtractor) SSLRedirect(ing *extensions.Ingress) rewrite.Redirect {
+	val, _ := e.annotations[redirect].Parse(ing)
+	r := val.(*rewrite.Redirect)
+	return rewrite.Redirect{
+		SSLRedirect:      r.SSLRedirect,
+		ForceSSLRedirect: r.ForceSSLRedirect,
+		SSLRedirectCode:  r.SSLRedirectCode,
+		SSLRedirectHost:  r.SSLRedirectHost,
+		SSLRedirectPort:  r.SSLRedirectPort,
+	}
+}
+
 func (e *annotationExtractor) ServiceUpstream(ing *extensions.Ingress) bool {
 	val, _ := e.annotations[serviceUpstream].Parse(ing)
 	return val.(bool)
 }
 
+func (e *annotationExtractor) UpstreamHashBy(ing *extensions.Ingress) string {
+	val, _ := e.annotations[upstreamHashBy].Parse(ing)
+	return val.(string)
+}
+
+func (e *annotationExtractor) LoadBalance(ing *extensions.Ingress) string {
+	val, _ := e.annotations[loadBalance].Parse(ing)
+	return val.(string)
+}
+
+func (e *annotationExtractor) Canary(ing *extensions.Ingress) *canary.Config {
+	val, _ := e.annotations[canaryConfig].Parse(ing)
+	return val.(*canary.Config)
+}
+
+func (e *annotationExtractor) DefaultBackend(ing *extensions.Ingress) string {
+	val, _ := e.annotations[defaultBackend].Parse(ing)
+	return val.(string)
+}
+
+func (e *annotationExtractor) BackupService(ing *extensions.Ingress) string {
+	val, _ := e.annotations[backupService].Parse(ing)
+	return val.(string)
+}
+
 func (e *annotationExtractor) SecureUpstream(ing *extensions.Ingress) *secureupstream.Secure {
 	val, err := e.annotations[secureUpstream].Parse(ing)
 	if err != nil {
@@ -133,6 +279,11 @@ func (e *annotationExtractor) SSLPassthrough(ing *extensions.Ingress) bool {
 	return val.(bool)
 }
 
+func (e *annotationExtractor) SSLPassthroughProxyProtocol(ing *extensions.Ingress) bool {
+	val, _ := e.annotations[sslPassthroughProxyProtocol].Parse(ing)
+	return val.(bool)
+}
+
 func (e *annotationExtractor) SessionAffinity(ing *extensions.Ingress) *sessionaffinity.AffinityConfig {
 	val, _ := e.annotations[sessionAffinity].Parse(ing)
 	return val.(*sessionaffinity.AffinityConfig)
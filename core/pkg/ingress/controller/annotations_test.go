@@ -34,6 +34,9 @@ const (
 	annotationUpsMaxFails        = "ingress.kubernetes.io/upstream-max-fails"
 	annotationUpsFailTimeout     = "ingress.kubernetes.io/upstream-fail-timeout"
 	annotationPassthrough        = "ingress.kubernetes.io/ssl-passthrough"
+	annotationSSLRedirect        = "ingress.kubernetes.io/ssl-redirect"
+	annotationSSLRedirectCode    = "ingress.kubernetes.io/ssl-redirect-code"
+	annotationRewriteTarget      = "ingress.kubernetes.io/rewrite-target"
 	annotationAffinityType       = "ingress.kubernetes.io/affinity"
 	annotationAffinityCookieName = "ingress.kubernetes.io/session-cookie-name"
 	annotationAffinityCookieHash = "ingress.kubernetes.io/session-cookie-hash"
@@ -228,6 +231,30 @@ func TestSSLPassthrough(t *testing.T) {
 	}
 }
 
+func TestSSLRedirect(t *testing.T) {
+	ec := newAnnotationExtractor(mockCfg{})
+	ing := buildIngress()
+
+	ing.SetAnnotations(map[string]string{
+		annotationSSLRedirect:     "true",
+		annotationSSLRedirectCode: "308",
+		annotationRewriteTarget:   "/foo",
+	})
+	r := ec.SSLRedirect(ing)
+	if !r.SSLRedirect || r.SSLRedirectCode != 308 {
+		t.Errorf("expected a redirect with the status code 308 but returned %+v", r)
+	}
+	if r.Target != "" {
+		t.Errorf("expected a redirect without rewrite but returned %v", r.Target)
+	}
+
+	ing.SetAnnotations(map[string]string{annotationSSLRedirect: "false"})
+	r = ec.SSLRedirect(ing)
+	if r.SSLRedirect {
+		t.Errorf("expected no redirect but returned %+v", r)
+	}
+}
+
 func TestAffinitySession(t *testing.T) {
 	ec := newAnnotationExtractor(mockCfg{})
 	ing := buildIngress()
@@ -34,8 +34,8 @@ import (
 
 // syncSecret keeps in sync Secrets used by Ingress rules with the files on
 // disk to allow copy of the content of the secret to disk to be used
-// by external processes.
-func (ic *GenericController) syncSecret(key string) {
+// by external processes. Returns true if the content of the files changed
+func (ic *GenericController) syncSecret(key string) bool {
 	glog.V(3).Infof("starting syncing of secret %v", key)
 
 	var cert *ingress.SSLCert
@@ -44,7 +44,7 @@ func (ic *GenericController) syncSecret(key string) {
 	cert, err = ic.getPemCertificate(key)
 	if err != nil {
 		glog.Warningf("error obtaining PEM from secret %v: %v", key, err)
-		return
+		return false
 	}
 
 	// create certificates and add or update the item in the store
@@ -53,17 +53,43 @@ func (ic *GenericController) syncSecret(key string) {
 		s := cur.(*ingress.SSLCert)
 		if reflect.DeepEqual(s, cert) {
 			// no need to update
-			return
+			return false
 		}
 		glog.Infof("updating secret %v in the local store", key)
 		ic.sslCertTracker.Update(key, cert)
 		ic.reloadRequired = true
-		return
+		return true
 	}
 
 	glog.Infof("adding secret %v to the local store", key)
 	ic.sslCertTracker.Add(key, cert)
 	ic.reloadRequired = true
+	return true
+}
+
+// secretChanged updates the files of a secret used by the controller
+// after a change. Returns true if the configuration must be synced,
+// so rotated certificates are applied without waiting for changes
+// in the Ingress rules. Secrets not used by the controller are ignored
+func (ic *GenericController) secretChanged(sec *api.Secret) bool {
+	key := fmt.Sprintf("%v/%v", sec.Namespace, sec.Name)
+	if key == ic.cfg.DefaultSSLCertificate || ic.isBackendSecret(key) {
+		// the default certificate and the secrets of
+		// the backend are read in each sync
+		ic.syncSecret(key)
+		return true
+	}
+
+	if _, tracked := ic.sslCertTracker.Get(key); !tracked && !ic.secrReferenced(sec.Name, sec.Namespace) {
+		return false
+	}
+
+	if !ic.syncSecret(key) {
+		return false
+	}
+
+	glog.Infof("secret changed event=certificate_update secret=%v", key)
+	return true
 }
 
 // getPemCertificate receives a secret, and creates a ingress.SSLCert as return.
@@ -122,6 +148,15 @@ func (ic *GenericController) secrReferenced(name, namespace string) bool {
 		if ing.Namespace != namespace {
 			continue
 		}
+		for _, annotation := range []string{
+			"ingress.kubernetes.io/secure-verify-ca-secret",
+			"ingress.kubernetes.io/secure-client-cert-secret",
+		} {
+			str, err := parser.GetStringAnnotation(annotation, ing)
+			if err == nil && str == name {
+				return true
+			}
+		}
 		for _, tls := range ing.Spec.TLS {
 			if tls.SecretName == name {
 				return true
@@ -131,6 +166,37 @@ func (ic *GenericController) secrReferenced(name, namespace string) bool {
 	return false
 }
 
+// isBackendSecret checks if the secret (namespace/name) is used
+// in the configuration of the backend
+func (ic *GenericController) isBackendSecret(key string) bool {
+	referencer, ok := ic.cfg.Backend.(ingress.SecretReferencer)
+	if !ok {
+		return false
+	}
+
+	for _, secret := range referencer.ReferencedSecrets() {
+		if secret == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setDefaultCertificate configures the certificate of the flag
+// --default-ssl-certificate (cert) in a server without a valid
+// certificate, if the certificate is valid for the host of the server
+func setDefaultCertificate(server *ingress.Server, cert *ingress.SSLCert) bool {
+	if !isHostValid(server.Hostname, cert) {
+		return false
+	}
+
+	server.SSLCertificate = cert.PemFileName
+	server.SSLPemChecksum = cert.PemSHA
+	server.SSLExpireTime = cert.ExpireTime
+	server.SSLTrustedCertificate = cert.CAFileName
+	return true
+}
+
 // sslCertTracker holds a store of referenced Secrets in Ingress rules
 type sslCertTracker struct {
 	cache.ThreadSafeStore
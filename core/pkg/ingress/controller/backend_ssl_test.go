@@ -24,8 +24,10 @@ import (
 	"fmt"
 
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	testclient "k8s.io/client-go/kubernetes/fake"
 	api_v1 "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 	cache_client "k8s.io/client-go/tools/cache"
 	"k8s.io/ingress/core/pkg/ingress"
 	"k8s.io/ingress/core/pkg/ingress/store"
@@ -181,6 +183,43 @@ func TestSyncSecret(t *testing.T) {
 	}
 }
 
+func TestSecretChanged(t *testing.T) {
+	dCrt, dKey, dCa, err := buildCrtKeyAndCA()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ic := buildGenericControllerForBackendSSL()
+	secret := buildSecretForBackendSSL()
+	secret.Data = map[string][]byte{api.TLSCertKey: dCrt, api.TLSPrivateKeyKey: dKey}
+	ic.secrLister.Add(secret)
+
+	key := "default/foo_secret"
+	if ic.secretChanged(secret) {
+		t.Errorf("expected no sync for a secret not used by the controller")
+	}
+	if _, exists := ic.sslCertTracker.Get(key); exists {
+		t.Errorf("expected the secret %v not tracked", key)
+	}
+
+	ic.syncSecret(key)
+	if ic.secretChanged(secret) {
+		t.Errorf("expected no sync without changes in the secret")
+	}
+
+	// rotation of the certificate
+	rotated := buildSecretForBackendSSL()
+	rotated.Data = map[string][]byte{api.TLSCertKey: dCrt, api.TLSPrivateKeyKey: dKey, tlscaName: dCa}
+	ic.secrLister.Update(rotated)
+	if !ic.secretChanged(rotated) {
+		t.Errorf("expected a sync after the change of the secret")
+	}
+	cert, _ := ic.sslCertTracker.Get(key)
+	if cert.(*ingress.SSLCert).CAFileName == "" {
+		t.Errorf("expected the secret %v updated in the local store", key)
+	}
+}
+
 func TestGetPemCertificate(t *testing.T) {
 	// prepare
 	dCrt, dKey, dCa, err := buildCrtKeyAndCA()
@@ -228,3 +267,156 @@ func TestGetPemCertificate(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAdditionalCertificates(t *testing.T) {
+	ic := buildGenericControllerForBackendSSL()
+
+	ic.sslCertTracker.Add("default/rsa", &ingress.SSLCert{PemFileName: "rsa.pem", PemSHA: "1", CN: []string{"foo.bar"}, KeyType: "RSA"})
+	ic.sslCertTracker.Add("default/rsa-2", &ingress.SSLCert{PemFileName: "rsa-2.pem", PemSHA: "2", CN: []string{"foo.bar"}, KeyType: "RSA"})
+	ic.sslCertTracker.Add("default/ecdsa", &ingress.SSLCert{PemFileName: "ecdsa.pem", PemSHA: "3", CN: []string{"foo.bar"}, KeyType: "ECDSA"})
+	ic.sslCertTracker.Add("default/ecdsa-2", &ingress.SSLCert{PemFileName: "ecdsa-2.pem", PemSHA: "4", CN: []string{"foo.bar"}, KeyType: "ECDSA"})
+	ic.sslCertTracker.Add("default/other-host", &ingress.SSLCert{PemFileName: "other.pem", PemSHA: "5", CN: []string{"other.bar"}, KeyType: "ECDSA"})
+
+	cert, _ := ic.sslCertTracker.Get("default/rsa")
+
+	foos := []struct {
+		tn          string
+		secretNames []string
+		expected    []string
+	}{
+		{"no_additional_certificates", []string{}, []string{}},
+		{"dual_certificate", []string{"ecdsa"}, []string{"ecdsa.pem"}},
+		{"duplicated_key_type", []string{"rsa-2"}, []string{}},
+		{"one_certificate_per_key_type", []string{"", "ecdsa", "ecdsa-2", "rsa-2"}, []string{"ecdsa.pem"}},
+		{"invalid_host", []string{"other-host", "ecdsa-2"}, []string{"ecdsa-2.pem"}},
+		{"secret_not_exist", []string{"not-exist"}, []string{}},
+	}
+
+	for _, foo := range foos {
+		t.Run(foo.tn, func(t *testing.T) {
+			certs := ic.getAdditionalCertificates("default", "foo.bar", cert.(*ingress.SSLCert), foo.secretNames)
+			if len(certs) != len(foo.expected) {
+				t.Fatalf("expected %v certificates but returned %v", len(foo.expected), len(certs))
+			}
+			for i, c := range certs {
+				if c.PemFileName != foo.expected[i] {
+					t.Errorf("expected certificate %v but returned %v", foo.expected[i], c.PemFileName)
+				}
+			}
+		})
+	}
+}
+
+func TestFindCertificateForHost(t *testing.T) {
+	ic := buildGenericControllerForBackendSSL()
+
+	ic.sslCertTracker.Add("default/foo", &ingress.SSLCert{PemFileName: "foo.pem", CN: []string{"foo.example.com"}})
+	ic.sslCertTracker.Add("default/wildcard", &ingress.SSLCert{PemFileName: "wildcard.pem", CN: []string{"example.com", "*.example.com"}})
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: extensions.IngressSpec{
+			TLS: []extensions.IngressTLS{
+				{Hosts: []string{"foo.example.com"}, SecretName: "foo"},
+				{Hosts: []string{"example.com"}, SecretName: ""},
+				{Hosts: []string{"example.com"}, SecretName: "not-exist"},
+				{Hosts: []string{"example.com"}, SecretName: "wildcard"},
+			},
+		},
+	}
+
+	for host, expected := range map[string]string{
+		"foo.example.com":     "default/foo",
+		"bar.example.com":     "default/wildcard",
+		"example.com":         "default/wildcard",
+		"foo.bar.example.com": "",
+		"example.org":         "",
+	} {
+		key, cert := ic.findCertificateForHost(ing, host)
+		if key != expected {
+			t.Errorf("expected the certificate '%v' for host %v but returned '%v'", expected, host, key)
+		}
+		if (cert == nil) != (expected == "") {
+			t.Errorf("unexpected certificate %v for host %v", cert, host)
+		}
+	}
+}
+
+func TestSetFallbackCertificates(t *testing.T) {
+	ic := buildGenericControllerForBackendSSL()
+
+	ic.sslCertTracker.Add("default/bar", &ingress.SSLCert{PemFileName: "bar.pem", CN: []string{"bar.example.org"}})
+	ic.sslCertTracker.Add("default/wildcard", &ingress.SSLCert{PemFileName: "wildcard.pem", CN: []string{"example.com", "*.example.com"}})
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: extensions.IngressSpec{
+			TLS: []extensions.IngressTLS{
+				{Hosts: []string{"foo.example.com"}, SecretName: "not-exist"},
+				{Hosts: []string{"bar.example.com"}, SecretName: "bar"},
+				{Hosts: []string{"example.com"}, SecretName: "wildcard"},
+			},
+			Rules: []extensions.IngressRule{
+				{Host: "foo.example.com"},
+				{Host: "bar.example.com"},
+				{Host: "unlisted.example.com"},
+			},
+		},
+	}
+
+	servers := map[string]*ingress.Server{
+		"foo.example.com":      {Hostname: "foo.example.com"},
+		"bar.example.com":      {Hostname: "bar.example.com"},
+		"unlisted.example.com": {Hostname: "unlisted.example.com"},
+	}
+	// unlisted.example.com is missing a certificate in the TLS section of another Ingress
+	missing := sets.NewString("foo.example.com", "bar.example.com", "unlisted.example.com")
+
+	ic.setFallbackCertificates([]interface{}{ing}, servers, missing)
+
+	for host, expected := range map[string]string{
+		"foo.example.com":      "wildcard.pem",
+		"bar.example.com":      "wildcard.pem",
+		"unlisted.example.com": "",
+	} {
+		if servers[host].SSLCertificate != expected {
+			t.Errorf("expected the certificate '%v' for host %v but returned '%v'", expected, host, servers[host].SSLCertificate)
+		}
+	}
+
+	// only the hosts with a missing certificate are changed
+	servers = map[string]*ingress.Server{
+		"foo.example.com": {Hostname: "foo.example.com"},
+	}
+	ic.setFallbackCertificates([]interface{}{ing}, servers, sets.NewString())
+	if servers["foo.example.com"].SSLCertificate != "" {
+		t.Errorf("unexpected certificate for a host without a missing certificate")
+	}
+}
+
+func TestSetDefaultCertificate(t *testing.T) {
+	cert := &ingress.SSLCert{
+		CN:          []string{"*.example.com"},
+		PemFileName: "/ingress-controller/ssl/default-wildcard.pem",
+		PemSHA:      "sha",
+	}
+
+	server := &ingress.Server{Hostname: "foo.example.com"}
+	if !setDefaultCertificate(server, cert) {
+		t.Fatalf("expected the default certificate to be valid for %v", server.Hostname)
+	}
+	if server.SSLCertificate != cert.PemFileName || server.SSLPemChecksum != cert.PemSHA {
+		t.Errorf("expected the default certificate in the server but returned %v (%v)", server.SSLCertificate, server.SSLPemChecksum)
+	}
+
+	server = &ingress.Server{Hostname: "foo.bar"}
+	if setDefaultCertificate(server, cert) || server.SSLCertificate != "" {
+		t.Errorf("expected the default certificate to be invalid for %v", server.Hostname)
+	}
+
+	// without --default-ssl-certificate
+	server = &ingress.Server{Hostname: "foo.example.com"}
+	if setDefaultCertificate(server, nil) || server.SSLCertificate != "" {
+		t.Errorf("expected no certificate without a default certificate")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+// configurationStatus is the result of the last attempts to
+// apply a new configuration in the backend
+type configurationStatus struct {
+	// Failing is true if the last attempt failed
+	Failing     bool       `json:"failing"`
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// LastError is the most recent failure, even if a new
+	// configuration was applied after the failure
+	LastError *configurationError `json:"lastError,omitempty"`
+}
+
+// configurationError is a failure applying a new configuration
+type configurationError struct {
+	Time time.Time `json:"time"`
+	// ExitCode is the exit code of the test of the configuration,
+	// if the backend rejected the configuration
+	ExitCode int    `json:"exitCode,omitempty"`
+	Message  string `json:"message"`
+}
+
+// configurationStatusTracker keeps the status of the configuration
+type configurationStatusTracker struct {
+	mu     sync.Mutex
+	status configurationStatus
+}
+
+// update records the result (err) of an attempt to apply a configuration
+func (t *configurationStatusTracker) update(err error, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.LastAttempt = &now
+	t.status.Failing = err != nil
+	if err == nil {
+		t.status.LastSuccess = &now
+		return
+	}
+
+	ce := &configurationError{
+		Time:    now,
+		Message: err.Error(),
+	}
+	if ic, ok := err.(errors.InvalidConfiguration); ok {
+		ce.ExitCode = ic.ExitCode
+	}
+	t.status.LastError = ce
+}
+
+// get returns a copy of the status of the configuration
+func (t *configurationStatusTracker) get() configurationStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status
+}
+
+// configurationStatus returns the status of the configuration in JSON
+func (ic *GenericController) configurationStatus(w http.ResponseWriter, r *http.Request) {
+	b, err := json.MarshalIndent(ic.configStatus.get(), "", "  ")
+	if err != nil {
+		glog.Errorf("unexpected error encoding the status of the configuration: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+func TestConfigurationStatusTracker(t *testing.T) {
+	tracker := &configurationStatusTracker{}
+	if s := tracker.get(); s.Failing || s.LastAttempt != nil || s.LastError != nil {
+		t.Errorf("unexpected status before the first attempt: %+v", s)
+	}
+
+	first := time.Unix(1500000000, 0)
+	tracker.update(nil, first)
+	s := tracker.get()
+	if s.Failing || s.LastSuccess == nil || !s.LastSuccess.Equal(first) {
+		t.Errorf("unexpected status after a successful attempt: %+v", s)
+	}
+
+	second := first.Add(time.Minute)
+	tracker.update(errors.InvalidConfiguration{Reason: "invalid server_name", ExitCode: 1}, second)
+	s = tracker.get()
+	if !s.Failing {
+		t.Errorf("expected a failing status after an error")
+	}
+	if !s.LastSuccess.Equal(first) || !s.LastAttempt.Equal(second) {
+		t.Errorf("unexpected times of the last attempts: %+v", s)
+	}
+	if s.LastError == nil || s.LastError.ExitCode != 1 || s.LastError.Message != "invalid server_name" || !s.LastError.Time.Equal(second) {
+		t.Errorf("unexpected last error: %+v", s.LastError)
+	}
+
+	third := second.Add(time.Minute)
+	tracker.update(fmt.Errorf("reload failed"), third)
+	s = tracker.get()
+	if s.LastError.ExitCode != 0 || s.LastError.Message != "reload failed" {
+		t.Errorf("unexpected last error: %+v", s.LastError)
+	}
+
+	// the last error is kept after a successful attempt
+	fourth := third.Add(time.Minute)
+	tracker.update(nil, fourth)
+	s = tracker.get()
+	if s.Failing || !s.LastSuccess.Equal(fourth) || s.LastError == nil || !s.LastError.Time.Equal(third) {
+		t.Errorf("unexpected status after recovering from an error: %+v", s)
+	}
+}
+
+func TestConfigurationStatusHandler(t *testing.T) {
+	ic := &GenericController{configStatus: &configurationStatusTracker{}}
+	ic.configStatus.update(errors.InvalidConfiguration{Reason: "invalid", ExitCode: 1}, time.Now())
+
+	w := httptest.NewRecorder()
+	ic.configurationStatus(w, httptest.NewRequest("GET", "/configuration-status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+	}
+
+	s := configurationStatus{}
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatalf("unexpected error decoding the status: %v", err)
+	}
+	if !s.Failing || s.LastError == nil || s.LastError.ExitCode != 1 {
+		t.Errorf("unexpected status: %v", w.Body.String())
+	}
+}
@@ -18,13 +18,13 @@ package controller
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	unversionedcore "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -43,15 +44,20 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/annotations/authtls"
 	"k8s.io/ingress/core/pkg/ingress/annotations/class"
 	"k8s.io/ingress/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
 	"k8s.io/ingress/core/pkg/ingress/annotations/parser"
 	"k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/wwwredirect"
 	"k8s.io/ingress/core/pkg/ingress/defaults"
+	"k8s.io/ingress/core/pkg/ingress/errors"
 	"k8s.io/ingress/core/pkg/ingress/resolver"
 	"k8s.io/ingress/core/pkg/ingress/status"
 	"k8s.io/ingress/core/pkg/ingress/store"
 	"k8s.io/ingress/core/pkg/k8s"
+	"k8s.io/ingress/core/pkg/log"
 	"k8s.io/ingress/core/pkg/net/ssl"
 	local_strings "k8s.io/ingress/core/pkg/strings"
 	"k8s.io/ingress/core/pkg/task"
@@ -61,11 +67,14 @@ const (
 	defUpstreamName = "upstream-default-backend"
 	defServerName   = "_"
 	rootLocation    = "/"
+
+	// key of the sync queued after a failure in a deferred reload
+	deferredReloadKey = "deferred-reload"
 )
 
 var (
 	// list of ports that cannot be used by TCP or UDP services
-	reservedPorts = []string{"80", "443", "8181", "18080"}
+	reservedPorts = []string{"80", "443", "444", "8181", "18080"}
 )
 
 // GenericController holds the boilerplate code required to build an Ingress controlller.
@@ -110,8 +119,28 @@ type GenericController struct {
 	// runningConfig contains the running configuration in the Backend
 	runningConfig *ingress.Configuration
 
+	// appliedConfig contains the running configuration (*ingress.Configuration)
+	// read by the debug handlers from the HTTP server of the controller
+	appliedConfig atomic.Value
+
+	// pendingConfig contains the configuration (*ingress.Configuration)
+	// waiting for a deferred reload of the backend
+	pendingConfig atomic.Value
+
 	// reloadRequired indicates the configmap
 	reloadRequired bool
+
+	// endpointSeed is used to shuffle the endpoints when
+	// the backends are not sorted (see shuffleEndpoints)
+	endpointSeed int64
+
+	// podReference is the pod running the controller, used to
+	// record the events not related to an Ingress rule
+	podReference *api.ObjectReference
+
+	// configStatus contains the result of the last
+	// attempts to apply the configuration in the backend
+	configStatus *configurationStatusTracker
 }
 
 // Configuration contains all the settings required by an Ingress controller
@@ -129,7 +158,9 @@ type Configuration struct {
 	// optional
 	TCPConfigMapName string
 	// optional
-	UDPConfigMapName      string
+	UDPConfigMapName string
+	// optional
+	TLSConfigMapName      string
 	DefaultSSLCertificate string
 	DefaultHealthzURL     string
 	DefaultIngressClass   string
@@ -143,6 +174,10 @@ type Configuration struct {
 	ElectionID             string
 	UpdateStatusOnShutdown bool
 	SortBackends           bool
+
+	// DryRun renders the configuration from the state of the
+	// cluster and exits without applying it (flag --dry-run)
+	DryRun bool
 }
 
 // newIngressController creates an Ingress controller
@@ -150,9 +185,12 @@ func newIngressController(config *Configuration) *GenericController {
 
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
-	eventBroadcaster.StartRecordingToSink(&unversionedcore.EventSinkImpl{
-		Interface: config.Client.Core().Events(config.Namespace),
-	})
+	// the dry run does not modify the cluster
+	if !config.DryRun {
+		eventBroadcaster.StartRecordingToSink(&unversionedcore.EventSinkImpl{
+			Interface: config.Client.Core().Events(config.Namespace),
+		})
+	}
 
 	ic := GenericController{
 		cfg:             config,
@@ -163,10 +201,17 @@ func newIngressController(config *Configuration) *GenericController {
 			Component: "ingress-controller",
 		}),
 		sslCertTracker: newSSLCertTracker(),
+		endpointSeed:   hostnameSeed(),
+		podReference:   podReference(),
+		configStatus:   &configurationStatusTracker{},
 	}
 
 	ic.syncQueue = task.NewTaskQueue(ic.syncIngress)
 
+	if reloader, ok := config.Backend.(ingress.DeferredReloader); ok {
+		reloader.SetReloadHandler(ic.deferredReloadDone)
+	}
+
 	// from here to the end of the method all the code is just boilerplate
 	// required to watch Ingress, Secrets, ConfigMaps and Endoints.
 	// This is used to detect new content, updates or removals and act accordingly
@@ -179,8 +224,10 @@ func newIngressController(config *Configuration) *GenericController {
 				return
 			}
 			ic.recorder.Eventf(addIng, api.EventTypeNormal, "CREATE", fmt.Sprintf("Ingress %s/%s", addIng.Namespace, addIng.Name))
+			glog.V(2).Infof("ingress changed event=create ingress=%v/%v", addIng.Namespace, addIng.Name)
 			ic.syncQueue.Enqueue(obj)
 			ic.extractSecretNames(addIng)
+			ic.validateAnnotations(addIng)
 		},
 		DeleteFunc: func(obj interface{}) {
 			delIng := obj.(*extensions.Ingress)
@@ -189,27 +236,39 @@ func newIngressController(config *Configuration) *GenericController {
 				return
 			}
 			ic.recorder.Eventf(delIng, api.EventTypeNormal, "DELETE", fmt.Sprintf("Ingress %s/%s", delIng.Namespace, delIng.Name))
+			glog.V(2).Infof("ingress changed event=delete ingress=%v/%v", delIng.Namespace, delIng.Name)
 			ic.syncQueue.Enqueue(obj)
+			setInvalidAnnotations(delIng.Namespace, delIng.Name, 0)
 		},
 		UpdateFunc: func(old, cur interface{}) {
 			oldIng := old.(*extensions.Ingress)
 			curIng := cur.(*extensions.Ingress)
 			validOld := class.IsValid(oldIng, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass)
 			validCur := class.IsValid(curIng, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass)
+			var event string
 			if !validOld && validCur {
 				glog.Infof("creating ingress %v based on annotation %v", curIng.Name, class.IngressKey)
 				ic.recorder.Eventf(curIng, api.EventTypeNormal, "CREATE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
+				event = "create"
 			} else if validOld && !validCur {
 				glog.Infof("removing ingress %v based on annotation %v", curIng.Name, class.IngressKey)
 				ic.recorder.Eventf(curIng, api.EventTypeNormal, "DELETE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
+				event = "delete"
 			} else if validCur && !reflect.DeepEqual(old, cur) {
 				ic.recorder.Eventf(curIng, api.EventTypeNormal, "UPDATE", fmt.Sprintf("Ingress %s/%s", curIng.Namespace, curIng.Name))
+				event = "update"
 			} else {
 				// old and cur are invalid or old and cur doesn't have changes, so ignore
 				return
 			}
+			glog.V(2).Infof("ingress changed event=%v ingress=%v/%v", event, curIng.Namespace, curIng.Name)
 			ic.syncQueue.Enqueue(cur)
 			ic.extractSecretNames(curIng)
+			if validCur {
+				ic.validateAnnotations(curIng)
+			} else {
+				setInvalidAnnotations(curIng.Namespace, curIng.Name, 0)
+			}
 		},
 	}
 
@@ -217,21 +276,30 @@ func newIngressController(config *Configuration) *GenericController {
 		AddFunc: func(obj interface{}) {
 			sec := obj.(*api.Secret)
 			key := fmt.Sprintf("%v/%v", sec.Namespace, sec.Name)
-			if ic.secrReferenced(sec.Namespace, sec.Name) {
+			if ic.secrReferenced(sec.Name, sec.Namespace) {
 				ic.syncSecret(key)
 			}
+			if key == ic.cfg.DefaultSSLCertificate || ic.isBackendSecret(key) {
+				// the default certificate and the secrets of
+				// the backend are read in each sync
+				ic.syncQueue.Enqueue(sec)
+			}
 		},
 		UpdateFunc: func(old, cur interface{}) {
 			if !reflect.DeepEqual(old, cur) {
 				sec := cur.(*api.Secret)
-				key := fmt.Sprintf("%v/%v", sec.Namespace, sec.Name)
-				ic.syncSecret(key)
+				if ic.secretChanged(sec) {
+					ic.syncQueue.Enqueue(sec)
+				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			sec := obj.(*api.Secret)
 			key := fmt.Sprintf("%v/%v", sec.Namespace, sec.Name)
 			ic.sslCertTracker.DeleteAll(key)
+			if ic.isBackendSecret(key) {
+				ic.syncQueue.Enqueue(sec)
+			}
 		},
 	}
 
@@ -269,7 +337,7 @@ func newIngressController(config *Configuration) *GenericController {
 					ic.reloadRequired = true
 				}
 				// updates to configuration configmaps can trigger an update
-				if mapKey == ic.cfg.ConfigMapName || mapKey == ic.cfg.TCPConfigMapName || mapKey == ic.cfg.UDPConfigMapName {
+				if mapKey == ic.cfg.ConfigMapName || mapKey == ic.cfg.TCPConfigMapName || mapKey == ic.cfg.UDPConfigMapName || mapKey == ic.cfg.TLSConfigMapName {
 					ic.recorder.Eventf(upCmap, api.EventTypeNormal, "UPDATE", fmt.Sprintf("ConfigMap %v", mapKey))
 					ic.syncQueue.Enqueue(cur)
 				}
@@ -382,6 +450,79 @@ func (ic *GenericController) syncIngress(key interface{}) error {
 		return nil
 	}
 
+	pcfg := ic.buildConfiguration()
+
+	if !ic.reloadRequired && (ic.runningConfig != nil && ic.runningConfig.Equal(&pcfg)) {
+		glog.V(3).Infof("skipping backend reload (no changes detected)")
+		setLastSyncTimestamp(time.Now())
+		return nil
+	}
+
+	glog.Infof("backend reload required event=reload key=%v", key)
+
+	start := time.Now()
+	err := ic.cfg.Backend.OnUpdate(pcfg)
+	duration := time.Since(start)
+	observeReloadDuration(duration)
+	if errors.IsReloadPending(err) {
+		glog.Infof("backend reload deferred event=reload_pending key=%v", key)
+		ic.reloadRequired = false
+		ic.runningConfig = &pcfg
+		ic.pendingConfig.Store(&pcfg)
+		return nil
+	}
+
+	ic.configStatus.update(err, time.Now())
+	if err != nil {
+		incReloadErrorCount()
+		glog.Errorf("unexpected failure restarting the backend event=reload_error key=%v duration=%v\n%v", key, duration, err)
+		ic.recordBackendError(err)
+		return err
+	}
+
+	ic.reloadRequired = false
+	glog.Infof("ingress backend successfully reloaded event=reload_success key=%v duration=%v", key, duration)
+	incReloadCount()
+	setSSLExpireTime(pcfg.Servers)
+	setLastSyncTimestamp(time.Now())
+	setConfiguredObjects(ic.ingressCount(), &pcfg)
+
+	ic.runningConfig = &pcfg
+	ic.appliedConfig.Store(&pcfg)
+
+	return nil
+}
+
+// deferredReloadDone records the result of a reload delayed by the backend
+// after OnUpdate returned ErrReloadPending. After a failure the configuration
+// is synced again
+func (ic *GenericController) deferredReloadDone(err error) {
+	ic.configStatus.update(err, time.Now())
+	if err != nil {
+		incReloadErrorCount()
+		glog.Errorf("unexpected failure reloading the backend event=reload_error key=%v\n%v", deferredReloadKey, err)
+		ic.recordBackendError(err)
+		ic.reloadRequired = true
+		ic.syncQueue.Enqueue(cache.ExplicitKey(deferredReloadKey))
+		return
+	}
+
+	glog.Infof("ingress backend successfully reloaded event=reload_success key=%v", deferredReloadKey)
+	incReloadCount()
+	setLastSyncTimestamp(time.Now())
+
+	pcfg, ok := ic.pendingConfig.Load().(*ingress.Configuration)
+	if !ok {
+		return
+	}
+	setSSLExpireTime(pcfg.Servers)
+	setConfiguredObjects(ic.ingressCount(), pcfg)
+	ic.appliedConfig.Store(pcfg)
+}
+
+// buildConfiguration returns the configuration of the backend
+// built from the Ingress rules and the services of the cluster
+func (ic *GenericController) buildConfiguration() ingress.Configuration {
 	upstreams, servers := ic.getBackendServers()
 	var passUpstreams []*ingress.SSLPassthroughBackend
 
@@ -396,45 +537,37 @@ func (ic *GenericController) syncIngress(key interface{}) error {
 				continue
 			}
 			passUpstreams = append(passUpstreams, &ingress.SSLPassthroughBackend{
-				Backend:  loc.Backend,
-				Hostname: server.Hostname,
-				Service:  loc.Service,
-				Port:     loc.Port,
+				Backend:       loc.Backend,
+				Hostname:      server.Hostname,
+				Service:       loc.Service,
+				Port:          loc.Port,
+				ProxyProtocol: server.SSLPassthroughProxyProtocol,
 			})
 			break
 		}
 	}
 
-	pcfg := ingress.Configuration{
+	return ingress.Configuration{
 		Backends:            upstreams,
 		Servers:             servers,
 		TCPEndpoints:        ic.getStreamServices(ic.cfg.TCPConfigMapName, api.ProtocolTCP),
+		TLSEndpoints:        ic.getTLSServices(ic.cfg.TLSConfigMapName),
 		UDPEndpoints:        ic.getStreamServices(ic.cfg.UDPConfigMapName, api.ProtocolUDP),
 		PassthroughBackends: passUpstreams,
 	}
+}
 
-	if !ic.reloadRequired && (ic.runningConfig != nil && ic.runningConfig.Equal(&pcfg)) {
-		glog.V(3).Infof("skipping backend reload (no changes detected)")
-		return nil
-	}
-
-	glog.Infof("backend reload required")
-
-	err := ic.cfg.Backend.OnUpdate(pcfg)
-	if err != nil {
-		incReloadErrorCount()
-		glog.Errorf("unexpected failure restarting the backend: \n%v", err)
-		return err
+// ingressCount returns the number of Ingress rules processed by the controller
+func (ic *GenericController) ingressCount() int {
+	count := 0
+	for _, ingIf := range ic.ingLister.Store.List() {
+		ing := ingIf.(*extensions.Ingress)
+		if class.IsValid(ing, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass) {
+			count++
+		}
 	}
 
-	ic.reloadRequired = false
-	glog.Infof("ingress backend successfully reloaded...")
-	incReloadCount()
-	setSSLExpireTime(servers)
-
-	ic.runningConfig = &pcfg
-
-	return nil
+	return count
 }
 
 func (ic *GenericController) getStreamServices(configmapName string, proto api.Protocol) []ingress.L4Service {
@@ -458,7 +591,7 @@ func (ic *GenericController) getStreamServices(configmapName string, proto api.P
 
 	var svcs []ingress.L4Service
 	// k -> port to expose
-	// v -> <namespace>/<service name>:<port from service to be used>
+	// v -> <namespace>/<service name>:<port from service to be used>[:PROXY][:<option>=<value>...]
 	for k, v := range configmap.Data {
 		externalPort, err := strconv.Atoi(k)
 		if err != nil {
@@ -480,78 +613,145 @@ func (ic *GenericController) getStreamServices(configmapName string, proto api.P
 
 		nsName := nsSvcPort[0]
 		svcPort := nsSvcPort[1]
-		useProxyProtocol := false
 
-		// Proxy protocol is possible if the service is TCP
-		if len(nsSvcPort) == 3 && proto == api.ProtocolTCP {
-			if strings.ToUpper(nsSvcPort[2]) == "PROXY" {
-				useProxyProtocol = true
-			}
-		}
+		l4Backend := parseStreamOptions(k, proto, nsSvcPort[2:])
 
-		svcNs, svcName, err := k8s.ParseNameNS(nsName)
+		svcNs, svcName, endps, err := ic.getStreamEndpoints(nsName, svcPort, proto)
 		if err != nil {
 			glog.Warningf("%v", err)
 			continue
 		}
 
-		svcObj, svcExists, err := ic.svcLister.Store.GetByKey(nsName)
-		if err != nil {
-			glog.Warningf("error getting service %v: %v", nsName, err)
+		l4Backend.Name = svcName
+		l4Backend.Namespace = svcNs
+		l4Backend.Port = intstr.FromString(svcPort)
+		l4Backend.Protocol = proto
+
+		svcs = append(svcs, ingress.L4Service{
+			Port:      externalPort,
+			Backend:   l4Backend,
+			Endpoints: endps,
+		})
+	}
+
+	// the services are obtained from a map
+	sort.Sort(ingress.L4ServiceByPort(svcs))
+
+	return svcs
+}
+
+// getTLSServices returns the TLS services of the configmap, routed by the
+// server name (SNI) of the connections received in the port 443
+func (ic *GenericController) getTLSServices(configmapName string) []ingress.L4Service {
+	glog.V(3).Infof("obtaining information about TLS services located in configmap %v", configmapName)
+	if configmapName == "" {
+		// no configmap configured
+		return []ingress.L4Service{}
+	}
+
+	ns, name, err := k8s.ParseNameNS(configmapName)
+	if err != nil {
+		glog.Errorf("unexpected error reading configmap %v: %v", name, err)
+		return []ingress.L4Service{}
+	}
+
+	configmap, err := ic.getConfigMap(ns, name)
+	if err != nil {
+		glog.Errorf("unexpected error reading configmap %v: %v", name, err)
+		return []ingress.L4Service{}
+	}
+
+	var svcs []ingress.L4Service
+	// k -> server name
+	// v -> <namespace>/<service name>:<port from service to be used>
+	for k, v := range configmap.Data {
+		hostname := strings.ToLower(k)
+		if errs := validation.IsDNS1123Subdomain(hostname); len(errs) != 0 {
+			glog.Warningf("%v is not valid as the server name of a TLS service: %v", k, strings.Join(errs, ", "))
 			continue
 		}
 
-		if !svcExists {
-			glog.Warningf("service %v was not found", nsName)
+		nsSvcPort := strings.Split(v, ":")
+		if len(nsSvcPort) != 2 {
+			glog.Warningf("invalid format (namespace/name:port) '%v' of the TLS service %v", v, k)
 			continue
 		}
 
-		svc := svcObj.(*api.Service)
-
-		var endps []ingress.Endpoint
-		targetPort, err := strconv.Atoi(svcPort)
+		svcNs, svcName, endps, err := ic.getStreamEndpoints(nsSvcPort[0], nsSvcPort[1], api.ProtocolTCP)
 		if err != nil {
-			glog.V(3).Infof("searching service %v/%v endpoints using the name '%v'", svcNs, svcName, svcPort)
-			for _, sp := range svc.Spec.Ports {
-				if sp.Name == svcPort {
-					endps = ic.getEndpoints(svc, &sp, proto, &healthcheck.Upstream{})
-					break
-				}
-			}
-		} else {
-			// we need to use the TargetPort (where the endpoints are running)
-			glog.V(3).Infof("searching service %v/%v endpoints using the target port '%v'", svcNs, svcName, targetPort)
-			for _, sp := range svc.Spec.Ports {
-				if sp.Port == int32(targetPort) {
-					endps = ic.getEndpoints(svc, &sp, proto, &healthcheck.Upstream{})
-					break
-				}
-			}
-		}
-
-		// stream services cannot contain empty upstreams and there is no
-		// default backend equivalent
-		if len(endps) == 0 {
-			glog.Warningf("service %v/%v does not have any active endpoints for port %v and protocol %v", svcNs, svcName, svcPort, proto)
+			glog.Warningf("%v", err)
 			continue
 		}
 
 		svcs = append(svcs, ingress.L4Service{
-			Port: externalPort,
+			Port:     443,
+			Hostname: hostname,
 			Backend: ingress.L4Backend{
-				Name:             svcName,
-				Namespace:        svcNs,
-				Port:             intstr.FromString(svcPort),
-				Protocol:         proto,
-				UseProxyProtocol: useProxyProtocol,
+				Name:      svcName,
+				Namespace: svcNs,
+				Port:      intstr.FromString(nsSvcPort[1]),
+				Protocol:  api.ProtocolTCP,
 			},
 			Endpoints: endps,
 		})
 	}
 
+	// the services are obtained from a map
+	sort.Sort(ingress.L4ServiceByHostname(svcs))
+
 	return svcs
 }
 
+// getStreamEndpoints returns the namespace, name and active endpoints of
+// the port (number or name) of the service nsName used by a stream service
+func (ic *GenericController) getStreamEndpoints(nsName, svcPort string, proto api.Protocol) (string, string, []ingress.Endpoint, error) {
+	svcNs, svcName, err := k8s.ParseNameNS(nsName)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	svcObj, svcExists, err := ic.svcLister.Store.GetByKey(nsName)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error getting service %v: %v", nsName, err)
+	}
+
+	if !svcExists {
+		return "", "", nil, fmt.Errorf("service %v was not found", nsName)
+	}
+
+	svc := svcObj.(*api.Service)
+
+	var endps []ingress.Endpoint
+	targetPort, err := strconv.Atoi(svcPort)
+	if err != nil {
+		glog.V(3).Infof("searching service %v/%v endpoints using the name '%v'", svcNs, svcName, svcPort)
+		for _, sp := range svc.Spec.Ports {
+			if sp.Name == svcPort {
+				endps = ic.getEndpoints(svc, &sp, proto, ic.defaultUpstreamCheck())
+				break
+			}
+		}
+	} else {
+		// we need to use the TargetPort (where the endpoints are running)
+		glog.V(3).Infof("searching service %v/%v endpoints using the target port '%v'", svcNs, svcName, targetPort)
+		for _, sp := range svc.Spec.Ports {
+			if sp.Port == int32(targetPort) {
+				endps = ic.getEndpoints(svc, &sp, proto, ic.defaultUpstreamCheck())
+				break
+			}
+		}
+	}
+
+	// stream services cannot contain empty upstreams and there is no
+	// default backend equivalent
+	if len(endps) == 0 {
+		return "", "", nil, fmt.Errorf("service %v/%v does not have any active endpoints for port %v and protocol %v", svcNs, svcName, svcPort, proto)
+	}
+
+	sort.Sort(ingress.EndpointByAddrPort(endps))
+	return svcNs, svcName, endps, nil
+}
+
 // getDefaultUpstream returns an upstream associated with the
 // default backend service. In case of error retrieving information
 // configure the upstream to return http code 503.
@@ -574,7 +774,7 @@ func (ic *GenericController) getDefaultUpstream() *ingress.Backend {
 	}
 
 	svc := svcObj.(*api.Service)
-	endps := ic.getEndpoints(svc, &svc.Spec.Ports[0], api.ProtocolTCP, &healthcheck.Upstream{})
+	endps := ic.getEndpoints(svc, &svc.Spec.Ports[0], api.ProtocolTCP, ic.defaultUpstreamCheck())
 	if len(endps) == 0 {
 		glog.Warningf("service %v does not have any active endpoints", svcKey)
 		endps = []ingress.Endpoint{newDefaultServer()}
@@ -601,6 +801,10 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 	sort.Sort(ingressByRevision(ings))
 
 	upstreams := ic.createUpstreams(ings)
+
+	// the canary Ingress rules do not define servers or locations,
+	// part of the requests of other Ingress rules are sent to them
+	ings, canaries := ic.splitCanaries(ings)
 	servers := ic.createServers(ings, upstreams)
 
 	for _, ingIf := range ings {
@@ -614,6 +818,12 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 
 		anns := ic.annotations.Extract(ing)
 
+		// the error of the annotation denies the locations of the rule
+		if err, ok := anns[DeniedKeyName].(error); ok && authtls.IsMissingCACertificate(err) {
+			ic.recorder.Eventf(ing, api.EventTypeWarning, "MISSING_CA",
+				"Ingress %s/%s requires client certificates but the secret does not contain a CA certificate", ing.Namespace, ing.Name)
+		}
+
 		for _, rule := range ing.Spec.Rules {
 			host := rule.Host
 			if host == "" {
@@ -660,6 +870,7 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 						loc.Backend = ups.Name
 						loc.Port = ups.Port
 						loc.Service = ups.Service
+						setLocationIngress(loc, ing)
 						mergeLocationAnnotations(loc, anns)
 						break
 					}
@@ -674,6 +885,7 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 						Service:      ups.Service,
 						Port:         ups.Port,
 					}
+					setLocationIngress(loc, ing)
 					mergeLocationAnnotations(loc, anns)
 					server.Locations = append(server.Locations, loc)
 				}
@@ -685,6 +897,8 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 				if affinity.AffinityType == "cookie" {
 					ups.SessionAffinity.CookieSessionAffinity.Name = affinity.CookieConfig.Name
 					ups.SessionAffinity.CookieSessionAffinity.Hash = affinity.CookieConfig.Hash
+					ups.SessionAffinity.CookieSessionAffinity.Expires = affinity.CookieConfig.Expires
+					ups.SessionAffinity.CookieSessionAffinity.Path = affinity.CookieConfig.Path
 
 					locs := ups.SessionAffinity.CookieSessionAffinity.Locations
 					if _, ok := locs[host]; !ok {
@@ -697,6 +911,10 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 		}
 	}
 
+	for _, ing := range canaries {
+		ic.configureCanary(ing, servers)
+	}
+
 	// Configure Backends[].SSLPassthrough
 	for _, upstream := range upstreams {
 		isHTTPSfrom := []*ingress.Server{}
@@ -730,9 +948,7 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 		}
 		aUpstreams = append(aUpstreams, value)
 	}
-	if ic.cfg.SortBackends {
-		sort.Sort(ingress.BackendByNameServers(aUpstreams))
-	}
+	sort.Sort(ingress.BackendByNameServers(aUpstreams))
 
 	aServers := make([]*ingress.Server, 0, len(servers))
 	for _, value := range servers {
@@ -744,6 +960,108 @@ func (ic *GenericController) getBackendServers() ([]*ingress.Backend, []*ingress
 	return aUpstreams, aServers
 }
 
+// customDefaultBackend returns the name of the upstream and the service
+// of the annotation default-backend of the Ingress rule, using the first
+// port of the service. The service is nil if the annotation is not
+// defined or the service does not exist
+func (ic *GenericController) customDefaultBackend(ing *extensions.Ingress) (string, *api.Service) {
+	name := ic.annotations.DefaultBackend(ing)
+	if name == "" {
+		return "", nil
+	}
+
+	svcKey := fmt.Sprintf("%v/%v", ing.Namespace, name)
+	svcObj, exists, err := ic.svcLister.Store.GetByKey(svcKey)
+	if err != nil {
+		glog.Warningf("error obtaining the default backend %v of ingress rule %v/%v: %v", svcKey, ing.Namespace, ing.Name, err)
+		return "", nil
+	}
+	if !exists {
+		glog.Warningf("default backend %v of ingress rule %v/%v does not exist", svcKey, ing.Namespace, ing.Name)
+		return "", nil
+	}
+
+	svc := svcObj.(*api.Service)
+	if len(svc.Spec.Ports) == 0 {
+		glog.Warningf("default backend %v of ingress rule %v/%v does not define any port", svcKey, ing.Namespace, ing.Name)
+		return "", nil
+	}
+
+	return fmt.Sprintf("%v-%v-%v", ing.Namespace, name, svc.Spec.Ports[0].Port), svc
+}
+
+// splitCanaries returns the Ingress rules without the valid canary
+// Ingress rules, returned in the second list
+func (ic *GenericController) splitCanaries(data []interface{}) ([]interface{}, []*extensions.Ingress) {
+	ings := make([]interface{}, 0, len(data))
+	canaries := []*extensions.Ingress{}
+	for _, ingIf := range data {
+		ing := ingIf.(*extensions.Ingress)
+		if class.IsValid(ing, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass) &&
+			ic.annotations.Canary(ing).Enabled {
+			canaries = append(canaries, ing)
+			continue
+		}
+		ings = append(ings, ingIf)
+	}
+
+	return ings, canaries
+}
+
+// configureCanary sends part of the requests of the locations with the
+// same host and path of the paths of the canary Ingress rule to the
+// backends of the canary
+func (ic *GenericController) configureCanary(ing *extensions.Ingress, servers map[string]*ingress.Server) {
+	cfg := ic.annotations.Canary(ing)
+
+	for _, rule := range ing.Spec.Rules {
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+
+		server := servers[host]
+		if server == nil || rule.HTTP == nil {
+			glog.Warningf("ignoring canary ingress rule %v/%v: host %v is not defined in other ingress rules", ing.Namespace, ing.Name, host)
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			upsName := fmt.Sprintf("%v-%v-%v",
+				ing.GetNamespace(),
+				path.Backend.ServiceName,
+				path.Backend.ServicePort.String())
+
+			nginxPath := rootLocation
+			if path.Path != "" {
+				nginxPath = path.Path
+			}
+
+			var loc *ingress.Location
+			for _, l := range server.Locations {
+				if l.Path == nginxPath && !l.IsDefBackend {
+					loc = l
+					break
+				}
+			}
+
+			if loc == nil {
+				glog.Warningf("ignoring canary ingress rule %v/%v location %v: the location is not defined in other ingress rules of host %v", ing.Namespace, ing.Name, nginxPath, host)
+				continue
+			}
+
+			if loc.Canary.Backend != "" {
+				glog.Warningf("ignoring canary ingress rule %v/%v location %v: host %v already sends requests to the canary upstream %v", ing.Namespace, ing.Name, nginxPath, host, loc.Canary.Backend)
+				continue
+			}
+
+			glog.V(3).Infof("sending %v%% of the requests of location %v (host %v) to the canary upstream %v", cfg.Weight, nginxPath, host, upsName)
+			loc.Canary = *cfg
+			loc.Canary.Backend = upsName
+		}
+	}
+}
+
 // GetAuthCertificate ...
 func (ic GenericController) GetAuthCertificate(secretName string) (*resolver.AuthSSLCert, error) {
 	if _, exists := ic.sslCertTracker.Get(secretName); !exists {
@@ -761,9 +1079,10 @@ func (ic GenericController) GetAuthCertificate(secretName string) (*resolver.Aut
 	}
 	cert := bc.(*ingress.SSLCert)
 	return &resolver.AuthSSLCert{
-		Secret:     secretName,
-		CAFileName: cert.CAFileName,
-		PemSHA:     cert.PemSHA,
+		Secret:      secretName,
+		CAFileName:  cert.CAFileName,
+		PemSHA:      cert.PemSHA,
+		PemFileName: cert.PemFileName,
 	}, nil
 }
 
@@ -783,6 +1102,9 @@ func (ic *GenericController) createUpstreams(data []interface{}) map[string]*ing
 		secUpstream := ic.annotations.SecureUpstream(ing)
 		hz := ic.annotations.HealthCheck(ing)
 		serviceUpstream := ic.annotations.ServiceUpstream(ing)
+		hashBy := ic.annotations.UpstreamHashBy(ing)
+		lb := ic.annotations.LoadBalance(ing)
+		backupSvc := ic.annotations.BackupService(ing)
 
 		var defBackend string
 		if ing.Spec.Backend != nil {
@@ -793,6 +1115,12 @@ func (ic *GenericController) createUpstreams(data []interface{}) map[string]*ing
 
 			glog.V(3).Infof("creating upstream %v", defBackend)
 			upstreams[defBackend] = newUpstream(defBackend)
+			upstreams[defBackend].HealthCheckScript = hz.Script
+			upstreams[defBackend].Secure = secUpstream.Secure
+			upstreams[defBackend].SecureCACert = secUpstream.CACert
+			upstreams[defBackend].SecureClientCert = secUpstream.ClientCert
+			upstreams[defBackend].UpstreamHashBy = hashBy
+			upstreams[defBackend].LoadBalance = lb
 			svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), ing.Spec.Backend.ServiceName)
 
 			// Add the service cluster endpoint as the upstream instead of individual endpoints
@@ -814,6 +1142,26 @@ func (ic *GenericController) createUpstreams(data []interface{}) map[string]*ing
 				}
 			}
 
+			if backupSvc != "" {
+				ic.appendBackupEndpoints(upstreams[defBackend], ing.GetNamespace(), backupSvc, ing.Spec.Backend.ServicePort.String(), hz)
+			}
+
+		}
+
+		if name, svc := ic.customDefaultBackend(ing); svc != nil {
+			if _, ok := upstreams[name]; !ok {
+				glog.V(3).Infof("creating upstream %v", name)
+				upstreams[name] = newUpstream(name)
+				upstreams[name].Service = svc
+				upstreams[name].Port = intstr.FromInt(int(svc.Spec.Ports[0].Port))
+
+				svcKey := fmt.Sprintf("%v/%v", svc.Namespace, svc.Name)
+				endps, err := ic.serviceEndpoints(svcKey, upstreams[name].Port.String(), ic.defaultUpstreamCheck())
+				upstreams[name].Endpoints = endps
+				if err != nil {
+					glog.Warningf("error creating upstream %v: %v", name, err)
+				}
+			}
 		}
 
 		for _, rule := range ing.Spec.Rules {
@@ -841,6 +1189,22 @@ func (ic *GenericController) createUpstreams(data []interface{}) map[string]*ing
 					upstreams[name].SecureCACert = secUpstream.CACert
 				}
 
+				if upstreams[name].SecureClientCert.Secret == "" {
+					upstreams[name].SecureClientCert = secUpstream.ClientCert
+				}
+
+				if upstreams[name].HealthCheckScript.Path == "" {
+					upstreams[name].HealthCheckScript = hz.Script
+				}
+
+				if upstreams[name].UpstreamHashBy == "" {
+					upstreams[name].UpstreamHashBy = hashBy
+				}
+
+				if upstreams[name].LoadBalance == "" {
+					upstreams[name].LoadBalance = lb
+				}
+
 				svcKey := fmt.Sprintf("%v/%v", ing.GetNamespace(), path.Backend.ServiceName)
 
 				// Add the service cluster endpoint as the upstream instead of individual endpoints
@@ -863,6 +1227,10 @@ func (ic *GenericController) createUpstreams(data []interface{}) map[string]*ing
 					upstreams[name].Endpoints = endp
 				}
 
+				if backupSvc != "" {
+					ic.appendBackupEndpoints(upstreams[name], ing.GetNamespace(), backupSvc, path.Backend.ServicePort.String(), hz)
+				}
+
 				s, exists, err := ic.svcLister.Store.GetByKey(svcKey)
 				if err != nil {
 					glog.Warningf("error obtaining service: %v", err)
@@ -929,25 +1297,48 @@ func (ic *GenericController) serviceEndpoints(svcKey, backendPort string,
 				glog.Warningf("service %v does not have any active endpoints", svcKey)
 			}
 
-			if ic.cfg.SortBackends {
-				sort.Sort(ingress.EndpointByAddrPort(endps))
-			}
+			sort.Sort(ingress.EndpointByAddrPort(endps))
 			upstreams = append(upstreams, endps...)
 			break
 		}
 	}
 
 	if !ic.cfg.SortBackends {
-		rand.Seed(time.Now().UnixNano())
-		for i := range upstreams {
-			j := rand.Intn(i + 1)
-			upstreams[i], upstreams[j] = upstreams[j], upstreams[i]
-		}
+		upstreams = shuffleEndpoints(upstreams, svcKey, ic.endpointSeed)
 	}
 
 	return upstreams, nil
 }
 
+// appendBackupEndpoints adds the endpoints of the backup service, using the
+// same port of the backend, as backup servers of the upstream. The endpoints
+// already present in the upstream are not duplicated
+func (ic *GenericController) appendBackupEndpoints(upstream *ingress.Backend,
+	namespace, name, backendPort string, hz *healthcheck.Upstream) {
+	svcKey := fmt.Sprintf("%v/%v", namespace, name)
+	endps, err := ic.serviceEndpoints(svcKey, backendPort, hz)
+	if err != nil {
+		glog.Warningf("error obtaining the endpoints of the backup service %v of upstream %v: %v", svcKey, upstream.Name, err)
+		return
+	}
+
+	for _, endp := range endps {
+		exists := false
+		for _, current := range upstream.Endpoints {
+			if current.Address == endp.Address && current.Port == endp.Port {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		endp.Backup = true
+		upstream.Endpoints = append(upstream.Endpoints, endp)
+	}
+}
+
 // createServers initializes a map that contains information about the list of
 // FDQN referenced by ingress rules and the common name field in the referenced
 // SSL certificates. Each server is configured with location / using a default
@@ -966,14 +1357,27 @@ func (ic *GenericController) createServers(data []interface{},
 		CookieDomain:   bdef.ProxyCookieDomain,
 		CookiePath:     bdef.ProxyCookiePath,
 		NextUpstream:   bdef.ProxyNextUpstream,
+		ProxyRedirect:  bdef.ProxyRedirect,
+
+		ProxyBuffering:   bdef.ProxyBuffering,
+		RequestBuffering: bdef.ProxyRequestBuffering,
+
+		ClientBodyBufferSize: bdef.ClientBodyBufferSize,
 	}
 
 	// This adds the Default Certificate to Default Backend (or generates a new self signed one)
 	var defaultPemFileName, defaultPemSHA string
+	// certificate of the flag --default-ssl-certificate, nil if it
+	// is not defined or the self signed certificate is used
+	var customDefaultCertificate *ingress.SSLCert
 
 	// Tries to fetch the default Certificate. If it does not exists, generate a new self signed one.
 	defaultCertificate, err := ic.getPemCertificate(ic.cfg.DefaultSSLCertificate)
 	if err != nil {
+		if ic.cfg.DefaultSSLCertificate != "" {
+			glog.Warningf("unexpected error reading the default SSL certificate %v, using a self signed certificate: %v", ic.cfg.DefaultSSLCertificate, err)
+		}
+
 		// This means the Default Secret does not exists, so we will create a new one.
 		fakeCertificate := "default-fake-certificate"
 		fakeCertificatePath := fmt.Sprintf("%v/%v.pem", ingress.DefaultSSLDirectory, fakeCertificate)
@@ -985,7 +1389,7 @@ func (ic *GenericController) createServers(data []interface{},
 			defCert, defKey := ssl.GetFakeSSLCert()
 			defaultCertificate, err = ssl.AddOrUpdateCertAndKey(fakeCertificate, defCert, defKey, []byte{})
 			if err != nil {
-				glog.Fatalf("Error generating self signed certificate: %v", err)
+				log.Fatalf("Error generating self signed certificate: %v", err)
 			}
 			defaultPemFileName = defaultCertificate.PemFileName
 			defaultPemSHA = defaultCertificate.PemSHA
@@ -996,19 +1400,34 @@ func (ic *GenericController) createServers(data []interface{},
 	} else {
 		defaultPemFileName = defaultCertificate.PemFileName
 		defaultPemSHA = defaultCertificate.PemSHA
+		customDefaultCertificate = defaultCertificate
+	}
+
+	var defaultTrustedCertificate string
+	if customDefaultCertificate != nil {
+		defaultTrustedCertificate = customDefaultCertificate.CAFileName
 	}
 
 	// initialize the default server
 	servers[defServerName] = &ingress.Server{
-		Hostname:       defServerName,
-		SSLCertificate: defaultPemFileName,
-		SSLPemChecksum: defaultPemSHA,
+		Hostname:              defServerName,
+		SSLCertificate:        defaultPemFileName,
+		SSLPemChecksum:        defaultPemSHA,
+		SSLTrustedCertificate: defaultTrustedCertificate,
+		HSTS: &hsts.Config{
+			Enabled:           bdef.HSTS,
+			MaxAge:            bdef.HSTSMaxAge,
+			IncludeSubdomains: bdef.HSTSIncludeSubdomains,
+			Preload:           bdef.HSTSPreload,
+		},
 		Locations: []*ingress.Location{
 			{
-				Path:         rootLocation,
-				IsDefBackend: true,
-				Backend:      ic.getDefaultUpstream().Name,
-				Proxy:        ngxProxy,
+				Path:              rootLocation,
+				IsDefBackend:      true,
+				Backend:           ic.getDefaultUpstream().Name,
+				Proxy:             ngxProxy,
+				GenerateRequestID: bdef.GenerateRequestID,
+				SSLEarlyData:      bdef.SSLEarlyData,
 			},
 		}}
 
@@ -1021,12 +1440,29 @@ func (ic *GenericController) createServers(data []interface{},
 
 		// check if ssl passthrough is configured
 		sslpt := ic.annotations.SSLPassthrough(ing)
+		sslptpp := ic.annotations.SSLPassthroughProxyProtocol(ing)
+		accessList := ic.annotations.ServerAccessList(ing)
+		hstsConfig := ic.annotations.HSTS(ing)
+		sslRedirect := ic.annotations.SSLRedirect(ing)
+		serverSnippet := ic.annotations.ServerSnippet(ing)
+		redirectWWW := ic.annotations.RedirectFromToWWW(ing)
+		aliases := ic.annotations.ServerAliases(ing)
 		dun := ic.getDefaultUpstream().Name
+		var dunIngress *extensions.Ingress
+		// upstream of the annotation default-backend
+		customDun, _ := ic.customDefaultBackend(ing)
+		if _, ok := upstreams[customDun]; ok {
+			dun = customDun
+			dunIngress = ing
+		} else {
+			customDun = ""
+		}
 		if ing.Spec.Backend != nil {
 			// replace default backend
 			defUpstream := fmt.Sprintf("%v-%v-%v", ing.GetNamespace(), ing.Spec.Backend.ServiceName, ing.Spec.Backend.ServicePort.String())
 			if backendUpstream, ok := upstreams[defUpstream]; ok {
 				dun = backendUpstream.Name
+				dunIngress = ing
 			}
 		}
 
@@ -1037,6 +1473,18 @@ func (ic *GenericController) createServers(data []interface{},
 			}
 			if _, ok := servers[host]; ok {
 				// server already configured
+				if servers[host].AccessList.IsEmpty() {
+					servers[host].AccessList = accessList
+				}
+				if servers[host].ServerSnippet == "" {
+					servers[host].ServerSnippet = serverSnippet
+				}
+				if !servers[host].RedirectFromToWWW {
+					servers[host].RedirectFromToWWW = redirectWWW
+				}
+				if len(servers[host].Aliases) == 0 {
+					servers[host].Aliases = aliases
+				}
 				continue
 			}
 
@@ -1044,15 +1492,26 @@ func (ic *GenericController) createServers(data []interface{},
 				Hostname: host,
 				Locations: []*ingress.Location{
 					{
-						Path:         rootLocation,
-						IsDefBackend: true,
-						Backend:      dun,
-						Proxy:        ngxProxy,
+						Path:              rootLocation,
+						IsDefBackend:      true,
+						Backend:           dun,
+						Proxy:             ngxProxy,
+						GenerateRequestID: bdef.GenerateRequestID,
+						SSLEarlyData:      bdef.SSLEarlyData,
+						// the requests of the host without a location
+						// are also redirected to HTTPS
+						Redirect: sslRedirect,
 					},
-				}, SSLPassthrough: sslpt}
+				}, SSLPassthrough: sslpt, SSLPassthroughProxyProtocol: sslptpp, AccessList: accessList, HSTS: hstsConfig, ServerSnippet: serverSnippet, DefaultBackend: customDun,
+				RedirectFromToWWW: redirectWWW, Aliases: aliases}
+			setLocationIngress(servers[host].Locations[0], dunIngress)
 		}
 	}
 
+	// hosts with a secret in the TLS section that does not
+	// exist or does not contain a certificate for the host
+	missingCertificates := sets.NewString()
+
 	// configure default location and SSL
 	for _, ingIf := range data {
 		ing := ingIf.(*extensions.Ingress)
@@ -1071,22 +1530,21 @@ func (ic *GenericController) createServers(data []interface{},
 				continue
 			}
 
-			tlsSecretName := ""
-			found := false
+			tlsSecretNames := []string{}
 			for _, tls := range ing.Spec.TLS {
 				if sets.NewString(tls.Hosts...).Has(host) {
-					tlsSecretName = tls.SecretName
-					found = true
-					break
+					tlsSecretNames = append(tlsSecretNames, tls.SecretName)
 				}
 			}
 
 			// the current ing.Spec.Rules[].Host doesn't have an entry at
 			// ing.Spec.TLS[].Hosts[] skipping to the next Rule
-			if !found {
+			if len(tlsSecretNames) == 0 {
 				continue
 			}
 
+			tlsSecretName := tlsSecretNames[0]
+
 			if tlsSecretName == "" {
 				glog.Warningf("host %v is listed on tls section but secretName is empty. Using default cert", host)
 				servers[host].SSLCertificate = defaultPemFileName
@@ -1098,18 +1556,23 @@ func (ic *GenericController) createServers(data []interface{},
 			bc, exists := ic.sslCertTracker.Get(key)
 			if !exists {
 				glog.Infof("ssl certificate \"%v\" does not exist in local store", key)
+				missingCertificates.Insert(host)
 				continue
 			}
 
 			cert := bc.(*ingress.SSLCert)
 			if !isHostValid(host, cert) {
 				glog.Warningf("ssl certificate %v does not contain a common name for host %v", key, host)
+				missingCertificates.Insert(host)
 				continue
 			}
 
 			servers[host].SSLCertificate = cert.PemFileName
 			servers[host].SSLPemChecksum = cert.PemSHA
 			servers[host].SSLExpireTime = cert.ExpireTime
+			servers[host].SSLTrustedCertificate = cert.CAFileName
+			servers[host].SSLSecret = key
+			servers[host].SSLAdditionalCertificates = ic.getAdditionalCertificates(ing.Namespace, host, cert, tlsSecretNames[1:])
 
 			if cert.ExpireTime.Before(time.Now().Add(240 * time.Hour)) {
 				glog.Warningf("ssl certificate for host %v is about to expire in 10 days", host)
@@ -1117,9 +1580,212 @@ func (ic *GenericController) createServers(data []interface{},
 		}
 	}
 
+	ic.setFallbackCertificates(data, servers, missingCertificates)
+
+	for _, host := range missingCertificates.List() {
+		if servers[host].SSLCertificate != "" {
+			continue
+		}
+		if setDefaultCertificate(servers[host], customDefaultCertificate) {
+			glog.Infof("using the default ssl certificate %v for host %v", ic.cfg.DefaultSSLCertificate, host)
+		}
+	}
+
+	checkServerAliases(servers)
+	checkRedirectFromToWWW(servers)
+
 	return servers
 }
 
+// checkServerAliases removes the aliases of the servers that are also
+// the hostname or an alias of another server, and the aliases of the
+// servers with SSL passthrough. The servers are checked in order, so
+// an alias used by more than one server is kept in the first one
+func checkServerAliases(servers map[string]*ingress.Server) {
+	hosts := []string{}
+	names := sets.NewString()
+	for host := range servers {
+		hosts = append(hosts, host)
+		names.Insert(host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		server := servers[host]
+		if len(server.Aliases) == 0 {
+			continue
+		}
+
+		if server.SSLPassthrough {
+			glog.Warningf("ignoring the aliases %v of host %v as it uses ssl passthrough", server.Aliases, host)
+			server.Aliases = []string{}
+			continue
+		}
+
+		aliases := []string{}
+		for _, alias := range server.Aliases {
+			if names.Has(alias) {
+				glog.Warningf("ignoring the alias %v of host %v as it is already defined in another server", alias, host)
+				continue
+			}
+			names.Insert(alias)
+			aliases = append(aliases, alias)
+		}
+		server.Aliases = aliases
+	}
+}
+
+// checkRedirectFromToWWW disables the redirect from the www. alias of the
+// servers without a valid alias, with SSL passthrough or when the alias
+// is also a server or a server alias defined in an Ingress rule
+func checkRedirectFromToWWW(servers map[string]*ingress.Server) {
+	aliases := sets.NewString()
+	for _, server := range servers {
+		aliases.Insert(server.Aliases...)
+	}
+
+	for host, server := range servers {
+		if !server.RedirectFromToWWW {
+			continue
+		}
+
+		alias := wwwredirect.Alias(host)
+		switch {
+		case alias == "":
+			glog.Warningf("host %v does not have a www. alias, ignoring the redirect", host)
+		case server.SSLPassthrough:
+			glog.Warningf("ignoring the redirect from %v to %v as it uses ssl passthrough", alias, host)
+		case servers[alias] != nil, aliases.Has(alias):
+			glog.Warningf("ignoring the redirect from %v to %v as the host is defined in an Ingress rule", alias, host)
+		default:
+			continue
+		}
+
+		server.RedirectFromToWWW = false
+	}
+}
+
+// setFallbackCertificates configures the hosts listed in the TLS section
+// of an Ingress rule with a secret that does not exist or does not contain
+// a certificate for the host (missing) with another certificate of the
+// same TLS section valid for the host, like a wildcard certificate
+// (*.example.com) of a secret listed with other hosts
+func (ic *GenericController) setFallbackCertificates(data []interface{}, servers map[string]*ingress.Server, missing sets.String) {
+	for _, ingIf := range data {
+		ing := ingIf.(*extensions.Ingress)
+		if !class.IsValid(ing, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass) {
+			continue
+		}
+
+		tlsHosts := sets.NewString()
+		for _, tls := range ing.Spec.TLS {
+			tlsHosts.Insert(tls.Hosts...)
+		}
+
+		for _, rule := range ing.Spec.Rules {
+			if !missing.Has(rule.Host) || !tlsHosts.Has(rule.Host) {
+				continue
+			}
+
+			server, ok := servers[rule.Host]
+			if !ok || server.SSLCertificate != "" || server.SSLPassthrough {
+				continue
+			}
+
+			key, cert := ic.findCertificateForHost(ing, rule.Host)
+			if cert == nil {
+				continue
+			}
+
+			glog.Infof("using the ssl certificate %v for host %v", key, rule.Host)
+			server.SSLCertificate = cert.PemFileName
+			server.SSLPemChecksum = cert.PemSHA
+			server.SSLExpireTime = cert.ExpireTime
+			server.SSLTrustedCertificate = cert.CAFileName
+			server.SSLSecret = key
+		}
+	}
+}
+
+// findCertificateForHost returns the first secret of the TLS section of
+// the Ingress rule with a certificate valid for the host, or nil if there
+// is none. The certificates are checked in the order of the TLS section
+func (ic *GenericController) findCertificateForHost(ing *extensions.Ingress, host string) (string, *ingress.SSLCert) {
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
+		bc, exists := ic.sslCertTracker.Get(key)
+		if !exists {
+			continue
+		}
+
+		cert := bc.(*ingress.SSLCert)
+		if isHostValid(host, cert) {
+			return key, cert
+		}
+	}
+
+	return "", nil
+}
+
+// getAdditionalCertificates returns the certificates of the secrets, valid for
+// the host, that can be served with the certificate of the server.
+// Only one certificate of each key type is allowed because NGINX selects
+// the certificate using the key type supported by the client.
+func (ic *GenericController) getAdditionalCertificates(namespace, host string,
+	cert *ingress.SSLCert, secretNames []string) []ingress.SSLCertificateFile {
+	certs := []ingress.SSLCertificateFile{}
+	keyTypes := sets.NewString(cert.KeyType)
+
+	for _, secretName := range secretNames {
+		if secretName == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%v/%v", namespace, secretName)
+		bc, exists := ic.sslCertTracker.Get(key)
+		if !exists {
+			glog.Infof("ssl certificate \"%v\" does not exist in local store", key)
+			continue
+		}
+
+		c := bc.(*ingress.SSLCert)
+		if !isHostValid(host, c) {
+			glog.Warningf("ssl certificate %v does not contain a common name for host %v", key, host)
+			continue
+		}
+
+		if keyTypes.Has(c.KeyType) {
+			glog.Warningf("ignoring ssl certificate %v for host %v: a certificate with a %v key is already configured", key, host, c.KeyType)
+			continue
+		}
+		keyTypes.Insert(c.KeyType)
+
+		certs = append(certs, ingress.SSLCertificateFile{
+			PemFileName: c.PemFileName,
+			PemSHA:      c.PemSHA,
+			KeyType:     c.KeyType,
+			Secret:      key,
+			ExpireTime:  c.ExpireTime,
+		})
+	}
+
+	return certs
+}
+
+// defaultUpstreamCheck returns the max_fails and fail_timeout defaults of the
+// backend, used in the upstreams not configured with annotations
+func (ic *GenericController) defaultUpstreamCheck() *healthcheck.Upstream {
+	def := ic.GetDefaultBackend()
+	return &healthcheck.Upstream{
+		MaxFails:    def.UpstreamMaxFails,
+		FailTimeout: def.UpstreamFailTimeout,
+	}
+}
+
 // getEndpoints returns a list of <endpoint ip>:<port> for a given service/target port combination.
 func (ic *GenericController) getEndpoints(
 	s *api.Service,
@@ -1256,6 +1922,15 @@ func (ic GenericController) Start() {
 		runtime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 	}
 
+	if ic.cfg.DryRun {
+		if err := ic.dryRun(os.Stdout); err != nil {
+			glog.Errorf("invalid configuration: %v", err)
+			glog.Flush()
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	go ic.syncQueue.Run(10*time.Second, ic.stopCh)
 
 	if ic.syncStatus != nil {
@@ -0,0 +1,246 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/ingress/store"
+	"k8s.io/ingress/core/pkg/task"
+)
+
+func TestConfigureCanary(t *testing.T) {
+	ic := &GenericController{annotations: newAnnotationExtractor(mockCfg{})}
+
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		"ingress.kubernetes.io/canary":        "true",
+		"ingress.kubernetes.io/canary-weight": "20",
+	})
+	ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName = "canary"
+
+	foo := &ingress.Location{Path: "/foo", Backend: "default-stable-80"}
+	root := &ingress.Location{Path: "/", Backend: "upstream-default-backend", IsDefBackend: true}
+	servers := map[string]*ingress.Server{
+		"foo.bar.com": {Hostname: "foo.bar.com", Locations: []*ingress.Location{root, foo}},
+	}
+
+	ic.configureCanary(ing, servers)
+
+	if foo.Canary.Backend != "default-canary-80" {
+		t.Errorf("expected the canary upstream default-canary-80 but returned %v", foo.Canary.Backend)
+	}
+	if foo.Canary.Weight != 20 {
+		t.Errorf("expected a weight of 20 but returned %v", foo.Canary.Weight)
+	}
+	if foo.Backend != "default-stable-80" {
+		t.Errorf("unexpected change in the backend of the location: %v", foo.Backend)
+	}
+	if root.Canary.Backend != "" {
+		t.Errorf("unexpected canary in the location of the default backend")
+	}
+
+	// the location is not defined in other ingress rules
+	ing.Spec.Rules[0].HTTP.Paths[0].Path = "/bar"
+	foo.Canary.Backend = ""
+	ic.configureCanary(ing, servers)
+	if foo.Canary.Backend != "" {
+		t.Errorf("unexpected canary in location /foo")
+	}
+	if len(servers["foo.bar.com"].Locations) != 2 {
+		t.Errorf("unexpected location added by the canary ingress rule")
+	}
+}
+
+func TestCheckServerAliases(t *testing.T) {
+	servers := map[string]*ingress.Server{
+		"bar.com":             {Hostname: "bar.com", Aliases: []string{"bar.net", "foo.com", "foo.net"}},
+		"foo.com":             {Hostname: "foo.com", Aliases: []string{"foo.net", "foo.org"}},
+		"passthrough.foo.com": {Hostname: "passthrough.foo.com", Aliases: []string{"passthrough.foo.net"}, SSLPassthrough: true},
+		"example.com":         {Hostname: "example.com"},
+	}
+
+	checkServerAliases(servers)
+
+	expected := map[string][]string{
+		"bar.com":             {"bar.net", "foo.net"},
+		"foo.com":             {"foo.org"},
+		"passthrough.foo.com": {},
+		"example.com":         nil,
+	}
+	for host, aliases := range expected {
+		if !reflect.DeepEqual(servers[host].Aliases, aliases) {
+			t.Errorf("expected %v as aliases of %v but returned %v", aliases, host, servers[host].Aliases)
+		}
+	}
+}
+
+func TestCheckRedirectFromToWWW(t *testing.T) {
+	servers := map[string]*ingress.Server{
+		"_":                   {Hostname: "_", RedirectFromToWWW: true},
+		"example.com":         {Hostname: "example.com", RedirectFromToWWW: true},
+		"www.foo.com":         {Hostname: "www.foo.com", RedirectFromToWWW: true},
+		"bar.com":             {Hostname: "bar.com", RedirectFromToWWW: true},
+		"www.bar.com":         {Hostname: "www.bar.com"},
+		"passthrough.foo.com": {Hostname: "passthrough.foo.com", RedirectFromToWWW: true, SSLPassthrough: true},
+		"*.foo.com":           {Hostname: "*.foo.com", RedirectFromToWWW: true},
+		"baz.com":             {Hostname: "baz.com", RedirectFromToWWW: true},
+		"baz.net":             {Hostname: "baz.net", Aliases: []string{"www.baz.com"}},
+	}
+
+	checkRedirectFromToWWW(servers)
+
+	expected := map[string]bool{
+		"_":                   false,
+		"example.com":         true,
+		"www.foo.com":         true,
+		"bar.com":             false,
+		"www.bar.com":         false,
+		"passthrough.foo.com": false,
+		"*.foo.com":           false,
+		"baz.com":             false,
+		"baz.net":             false,
+	}
+	for host, redirect := range expected {
+		if servers[host].RedirectFromToWWW != redirect {
+			t.Errorf("expected %v as redirect from www. of %v but returned %v", redirect, host, servers[host].RedirectFromToWWW)
+		}
+	}
+}
+
+func TestDeferredReloadDone(t *testing.T) {
+	keys := make(chan interface{}, 1)
+	ic := &GenericController{
+		configStatus: &configurationStatusTracker{},
+		recorder:     record.NewFakeRecorder(10),
+		ingLister:    store.IngressLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+	}
+	ic.syncQueue = task.NewTaskQueue(func(key interface{}) error {
+		keys <- key
+		return nil
+	})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go ic.syncQueue.Run(time.Second, stopCh)
+
+	ic.deferredReloadDone(fmt.Errorf("reload failed"))
+	if !ic.configStatus.get().Failing {
+		t.Errorf("expected a failing configuration after an error in the reload")
+	}
+	if !ic.reloadRequired {
+		t.Errorf("expected a reload required after an error in the reload")
+	}
+	select {
+	case key := <-keys:
+		if key != deferredReloadKey {
+			t.Errorf("expected the sync of %v but returned %v", deferredReloadKey, key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a sync after an error in the reload")
+	}
+
+	pcfg := &ingress.Configuration{Servers: []*ingress.Server{{Hostname: "foo.bar"}}}
+	ic.pendingConfig.Store(pcfg)
+	ic.deferredReloadDone(nil)
+	if ic.configStatus.get().Failing {
+		t.Errorf("unexpected failing configuration after a successful reload")
+	}
+	if cfg, ok := ic.appliedConfig.Load().(*ingress.Configuration); !ok || cfg != pcfg {
+		t.Errorf("expected the pending configuration as applied configuration but returned %v", cfg)
+	}
+}
+
+func TestCreateUpstreamsWithBackupService(t *testing.T) {
+	ic := &GenericController{
+		cfg:         &Configuration{SortBackends: true},
+		annotations: newAnnotationExtractor(mockCfg{}),
+		svcLister:   store.ServiceLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		endpLister:  store.EndpointLister{Store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+	}
+
+	for name, ips := range map[string][]string{
+		"foo":        {"10.0.0.1", "10.0.0.2"},
+		"foo-backup": {"10.0.1.1", "10.0.0.2"},
+	} {
+		meta := meta_v1.ObjectMeta{Name: name, Namespace: api.NamespaceDefault}
+		ic.svcLister.Add(&api.Service{
+			ObjectMeta: meta,
+			Spec: api.ServiceSpec{
+				Ports: []api.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+			},
+		})
+		addresses := []api.EndpointAddress{}
+		for _, ip := range ips {
+			addresses = append(addresses, api.EndpointAddress{IP: ip})
+		}
+		ic.endpLister.Add(&api.Endpoints{
+			ObjectMeta: meta,
+			Subsets: []api.EndpointSubset{{
+				Addresses: addresses,
+				Ports:     []api.EndpointPort{{Port: 8080, Protocol: api.ProtocolTCP}},
+			}},
+		})
+	}
+
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   api.NamespaceDefault,
+			Annotations: map[string]string{"ingress.kubernetes.io/upstream-backup-service": "foo-backup"},
+		},
+		Spec: extensions.IngressSpec{
+			Rules: []extensions.IngressRule{{
+				Host: "foo.bar",
+				IngressRuleValue: extensions.IngressRuleValue{
+					HTTP: &extensions.HTTPIngressRuleValue{
+						Paths: []extensions.HTTPIngressPath{{
+							Path:    "/",
+							Backend: extensions.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromInt(80)},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	upstreams := ic.createUpstreams([]interface{}{ing})
+	upstream, ok := upstreams["default-foo-80"]
+	if !ok {
+		t.Fatalf("expected the upstream default-foo-80")
+	}
+
+	// the endpoint present in both services is only used as primary
+	expected := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080"},
+		{Address: "10.0.0.2", Port: "8080"},
+		{Address: "10.0.1.1", Port: "8080", Backup: true},
+	}
+	if !reflect.DeepEqual(upstream.Endpoints, expected) {
+		t.Errorf("expected the endpoints %v but returned %v", expected, upstream.Endpoints)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+// localhostOnly rejects the requests not coming from the loopback
+// interface. The debug handlers expose the complete configuration and
+// are only available using kubectl exec or kubectl port-forward
+func localhostOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// debugConfiguration returns the configuration applied
+// in the backend in the last successful reload in JSON
+func (ic *GenericController) debugConfiguration(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := ic.appliedConfig.Load().(*ingress.Configuration)
+	if !ok {
+		http.Error(w, "the configuration was not applied yet", http.StatusNotFound)
+		return
+	}
+
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		glog.Errorf("unexpected error encoding the configuration: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// debugBackend returns the configuration files of the backend,
+// /debug/backend/applied or /debug/backend/rejected
+func (ic *GenericController) debugBackend(w http.ResponseWriter, r *http.Request) {
+	dumper, ok := ic.cfg.Backend.(ingress.ConfigurationDumper)
+	if !ok {
+		http.Error(w, "the backend does not support the dump of the configuration", http.StatusNotImplemented)
+		return
+	}
+
+	applied, rejected := dumper.DumpConfiguration()
+
+	var content []byte
+	switch strings.TrimPrefix(r.URL.Path, "/debug/backend/") {
+	case "applied":
+		content = applied
+	case "rejected":
+		content = rejected
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if content == nil {
+		http.Error(w, "there is no configuration", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(content)
+}
+
+// debugDiffs returns the last changes in the configuration of the backend in JSON
+func (ic *GenericController) debugDiffs(w http.ResponseWriter, r *http.Request) {
+	history, ok := ic.cfg.Backend.(ingress.ConfigurationHistory)
+	if !ok {
+		http.Error(w, "the backend does not keep the changes in the configuration", http.StatusNotImplemented)
+		return
+	}
+
+	b, err := json.MarshalIndent(history.ConfigurationDiffs(), "", "  ")
+	if err != nil {
+		glog.Errorf("unexpected error encoding the changes in the configuration: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+func TestLocalhostOnly(t *testing.T) {
+	handler := localhostOnly(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := map[string]int{
+		"127.0.0.1:43210": http.StatusOK,
+		"[::1]:43210":     http.StatusOK,
+		"10.0.0.1:43210":  http.StatusForbidden,
+		"invalid":         http.StatusForbidden,
+	}
+
+	for addr, expected := range testCases {
+		r := httptest.NewRequest("GET", "/debug/configuration", nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != expected {
+			t.Errorf("%v: expected status %v but returned %v", addr, expected, w.Code)
+		}
+	}
+}
+
+func TestDebugConfiguration(t *testing.T) {
+	ic := &GenericController{}
+
+	w := httptest.NewRecorder()
+	ic.debugConfiguration(w, httptest.NewRequest("GET", "/debug/configuration", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %v before the first reload but returned %v", http.StatusNotFound, w.Code)
+	}
+
+	ic.appliedConfig.Store(&ingress.Configuration{
+		Servers: []*ingress.Server{{Hostname: "foo.bar"}},
+	})
+
+	w = httptest.NewRecorder()
+	ic.debugConfiguration(w, httptest.NewRequest("GET", "/debug/configuration", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+	}
+
+	cfg := &ingress.Configuration{}
+	if err := json.Unmarshal(w.Body.Bytes(), cfg); err != nil {
+		t.Fatalf("unexpected error decoding the configuration: %v", err)
+	}
+	if len(cfg.Servers) != 1 || cfg.Servers[0].Hostname != "foo.bar" {
+		t.Errorf("unexpected configuration: %v", w.Body.String())
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+// dryRun builds the configuration from the Ingress rules, renders
+// and tests it using the backend and writes the result in w. The
+// configuration is not applied
+func (ic *GenericController) dryRun(w io.Writer) error {
+	runner, ok := ic.cfg.Backend.(ingress.DryRunner)
+	if !ok {
+		return fmt.Errorf("the backend does not support the dry run")
+	}
+
+	content, err := runner.DryRun(ic.buildConfiguration())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"strings"
+
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/class"
+	"k8s.io/ingress/core/pkg/ingress/errors"
+)
+
+const (
+	// maximum length of the message of the events. The output
+	// of the backend can be longer than the size of the event
+	maxEventMessageLength = 1024
+)
+
+// podReference returns a reference to the pod running the controller
+// or nil if the environment variables POD_NAME and POD_NAMESPACE are
+// not defined
+func podReference() *api.ObjectReference {
+	name := os.Getenv("POD_NAME")
+	namespace := os.Getenv("POD_NAMESPACE")
+	if name == "" || namespace == "" {
+		return nil
+	}
+
+	return &api.ObjectReference{
+		Kind:      "Pod",
+		Namespace: namespace,
+		Name:      name,
+	}
+}
+
+// recordBackendError records an event with the error returned by the
+// backend applying a new configuration in the pod of the controller and,
+// if the backend identified the servers with the error, in the Ingress
+// rules that define the servers
+func (ic *GenericController) recordBackendError(err error) {
+	msg := truncateEventMessage(err.Error())
+
+	if ic.podReference != nil {
+		ic.recorder.Event(ic.podReference, api.EventTypeWarning, "RELOAD", msg)
+	}
+
+	ce, ok := err.(errors.InvalidConfiguration)
+	if !ok || len(ce.Hosts) == 0 {
+		return
+	}
+
+	for _, ing := range ingressesWithHosts(ic.ingLister.Store.List(), ce.Hosts, ic.cfg.IngressClass, ic.cfg.DefaultIngressClass) {
+		ic.recorder.Event(ing, api.EventTypeWarning, "INVALID_CONFIGURATION", msg)
+	}
+}
+
+// ingressesWithHosts returns the Ingress rules processed by the
+// controller that define a rule for any of the hosts
+func ingressesWithHosts(ings []interface{}, hosts []string, ingressClass, defIngressClass string) []*extensions.Ingress {
+	res := []*extensions.Ingress{}
+	for _, ingIf := range ings {
+		ing := ingIf.(*extensions.Ingress)
+		if !class.IsValid(ing, ingressClass, defIngressClass) {
+			continue
+		}
+
+	rules:
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = defServerName
+			}
+
+			for _, h := range hosts {
+				if h == host {
+					res = append(res, ing)
+					break rules
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// truncateEventMessage removes the separators around the message and
+// returns the end of the message (the last lines of the output of the
+// backend contain the error) if it is longer than the maximum size of
+// the events
+func truncateEventMessage(msg string) string {
+	msg = strings.Trim(msg, "\n-")
+	if len(msg) <= maxEventMessageLength {
+		return msg
+	}
+
+	return "..." + msg[len(msg)-maxEventMessageLength+3:]
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"k8s.io/ingress/core/pkg/ingress/annotations/class"
+)
+
+func buildIngressWithHosts(name, ingressClass string, hosts ...string) *extensions.Ingress {
+	ing := &extensions.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{},
+		},
+	}
+	if ingressClass != "" {
+		ing.Annotations[class.IngressKey] = ingressClass
+	}
+	for _, host := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, extensions.IngressRule{Host: host})
+	}
+	return ing
+}
+
+func TestIngressesWithHosts(t *testing.T) {
+	ings := []interface{}{
+		buildIngressWithHosts("foo", "", "foo.bar", "bar.foo"),
+		buildIngressWithHosts("bar", "", "bar.foo"),
+		buildIngressWithHosts("default", "", ""),
+		buildIngressWithHosts("gce", "gce", "foo.bar"),
+	}
+
+	testCases := map[string]struct {
+		hosts    []string
+		expected []string
+	}{
+		"no hosts":       {[]string{}, []string{}},
+		"unknown host":   {[]string{"foobar"}, []string{}},
+		"single ingress": {[]string{"foo.bar"}, []string{"foo"}},
+		"many ingresses": {[]string{"foo.bar", "bar.foo"}, []string{"foo", "bar"}},
+		"default server": {[]string{defServerName}, []string{"default"}},
+	}
+
+	for title, tc := range testCases {
+		names := []string{}
+		for _, ing := range ingressesWithHosts(ings, tc.hosts, "nginx", "nginx") {
+			names = append(names, ing.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%v: expected %v but returned %v", title, tc.expected, names)
+		}
+	}
+}
+
+func TestTruncateEventMessage(t *testing.T) {
+	msg := truncateEventMessage("\n----\nError: invalid configuration\n----\n")
+	if msg != "Error: invalid configuration" {
+		t.Errorf("unexpected message: %v", msg)
+	}
+
+	long := strings.Repeat("a", maxEventMessageLength) + "error"
+	msg = truncateEventMessage(long)
+	if len(msg) != maxEventMessageLength {
+		t.Errorf("expected a message of %v characters but returned %v", maxEventMessageLength, len(msg))
+	}
+	if !strings.HasPrefix(msg, "...") || !strings.HasSuffix(msg, "error") {
+		t.Errorf("unexpected message: %v", msg)
+	}
+}
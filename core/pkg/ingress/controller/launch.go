@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/pprof"
 	"os"
@@ -23,6 +24,7 @@ import (
 
 	"k8s.io/ingress/core/pkg/ingress"
 	"k8s.io/ingress/core/pkg/k8s"
+	"k8s.io/ingress/core/pkg/log"
 )
 
 // NewIngressController returns a configured Ingress controller
@@ -65,6 +67,12 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 		service with the format namespace/serviceName and the port of the service could be a 
 		number of the name of the port.`)
 
+		tlsConfigMapName = flags.String("tls-services-configmap", "",
+			`Name of the ConfigMap that contains the definition of the TLS services exposed in the
+		port 443 and routed by the server name (SNI) without TLS termination. The key in the map
+		is the server name and the value the name of the service with the format
+		namespace/serviceName and the port (number or name) of the service.`)
+
 		resyncPeriod = flags.Duration("sync-period", 60*time.Second,
 			`Relist and confirm cloud resources this often.`)
 
@@ -94,8 +102,18 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 		ingress controller should update the Ingress status IP/hostname when the controller 
 		is being stopped. Default is true`)
 
+		dryRun = flags.Bool("dry-run", false,
+			`Render the configuration from the state of the cluster, test it with the backend,
+		print it and exit without applying it. The exit code is 1 if the configuration is invalid`)
+
+		logFormat = flags.String("log-format", "text",
+			`Format of the logs of the controller: text (glog) or json. With json each entry
+		is a JSON object with the time, level, source and message and the fields of the
+		entry, like the event, the key of the Ingress rule or the duration of a reload`)
+
 		SortBackends = flags.Bool("sort-backends", false,
-			`Defines if backends and it's endpoints should be sorted`)
+			`Defines if the endpoints of the backends should be sorted. Otherwise the endpoints
+		are shuffled using a seed obtained from the hostname, producing the same order in each sync`)
 	)
 
 	flags.AddGoFlagSet(flag.CommandLine)
@@ -105,6 +123,16 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 
 	flag.Set("logtostderr", "true")
 
+	switch *logFormat {
+	case "text":
+	case "json":
+		if err := log.RedirectGlog(); err != nil {
+			log.Fatalf("%v", err)
+		}
+	default:
+		log.Fatalf("invalid log format %v (text or json)", *logFormat)
+	}
+
 	glog.Info(backend.Info())
 
 	if *ingressClass != "" {
@@ -112,7 +140,7 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 	}
 
 	if *defaultSvc == "" {
-		glog.Fatalf("Please specify --default-backend-service")
+		log.Fatalf("Please specify --default-backend-service")
 	}
 
 	kubeClient, err := createApiserverClient(*apiserverHost, *kubeConfigFile)
@@ -122,19 +150,19 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 
 	_, err = k8s.IsValidService(kubeClient, *defaultSvc)
 	if err != nil {
-		glog.Fatalf("no service with name %v found: %v", *defaultSvc, err)
+		log.Fatalf("no service with name %v found: %v", *defaultSvc, err)
 	}
 	glog.Infof("validated %v as the default backend", *defaultSvc)
 
 	if *publishSvc != "" {
 		svc, err := k8s.IsValidService(kubeClient, *publishSvc)
 		if err != nil {
-			glog.Fatalf("no service with name %v found: %v", *publishSvc, err)
+			log.Fatalf("no service with name %v found: %v", *publishSvc, err)
 		}
 
 		if len(svc.Status.LoadBalancer.Ingress) == 0 {
 			// We could poll here, but we instead just exit and rely on k8s to restart us
-			glog.Fatalf("service %s does not (yet) have ingress points", *publishSvc)
+			log.Fatalf("service %s does not (yet) have ingress points", *publishSvc)
 		}
 
 		glog.Infof("service %v validated as source of Ingress status", *publishSvc)
@@ -145,17 +173,25 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 		_, err = k8s.IsValidNamespace(kubeClient, *watchNamespace)
 
 		if err != nil {
-			glog.Fatalf("no watchNamespace with name %v found: %v", *watchNamespace, err)
+			log.Fatalf("no watchNamespace with name %v found: %v", *watchNamespace, err)
 		}
 	}
 
-	err = os.MkdirAll(ingress.DefaultSSLDirectory, 0655)
-	if err != nil {
-		glog.Errorf("Failed to mkdir SSL directory: %v", err)
+	if *dryRun {
+		// the certificates of the dry run are not used by the backend
+		ingress.DefaultSSLDirectory, err = ioutil.TempDir("", "ingress-ssl")
+		if err != nil {
+			log.Fatalf("unexpected error creating the SSL directory: %v", err)
+		}
+	} else {
+		err = os.MkdirAll(ingress.DefaultSSLDirectory, 0655)
+		if err != nil {
+			glog.Errorf("Failed to mkdir SSL directory: %v", err)
+		}
 	}
 
 	config := &Configuration{
-		UpdateStatus:            *updateStatus,
+		UpdateStatus:            *updateStatus && !*dryRun,
 		ElectionID:              *electionID,
 		Client:                  kubeClient,
 		ResyncPeriod:            *resyncPeriod,
@@ -165,6 +201,7 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 		Namespace:               *watchNamespace,
 		ConfigMapName:           *configMap,
 		TCPConfigMapName:        *tcpConfigMapName,
+		TLSConfigMapName:        *tlsConfigMapName,
 		UDPConfigMapName:        *udpConfigMapName,
 		DefaultSSLCertificate:   *defSSLCertificate,
 		DefaultHealthzURL:       *defHealthzURL,
@@ -173,10 +210,13 @@ func NewIngressController(backend ingress.Controller) *GenericController {
 		ForceNamespaceIsolation: *forceIsolation,
 		UpdateStatusOnShutdown:  *UpdateStatusOnShutdown,
 		SortBackends:            *SortBackends,
+		DryRun:                  *dryRun,
 	}
 
 	ic := newIngressController(config)
-	go registerHandlers(*profiling, *healthzPort, ic)
+	if !*dryRun {
+		go registerHandlers(*profiling, *healthzPort, ic)
+	}
 	return ic
 }
 
@@ -196,6 +236,8 @@ func registerHandlers(enableProfiling bool, port int, ic *GenericController) {
 		w.Write(b)
 	})
 
+	mux.HandleFunc("/configuration-status", ic.configurationStatus)
+
 	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
 		err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
 		if err != nil {
@@ -203,8 +245,13 @@ func registerHandlers(enableProfiling bool, port int, ic *GenericController) {
 		}
 	})
 
+	mux.HandleFunc("/debug/configuration", localhostOnly(ic.debugConfiguration))
+	mux.HandleFunc("/debug/backend/", localhostOnly(ic.debugBackend))
+	mux.HandleFunc("/debug/diffs", localhostOnly(ic.debugDiffs))
+
 	if enableProfiling {
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
@@ -214,7 +261,7 @@ func registerHandlers(enableProfiling bool, port int, ic *GenericController) {
 		Addr:    fmt.Sprintf(":%v", port),
 		Handler: mux,
 	}
-	glog.Fatal(server.ListenAndServe())
+	log.Fatalf("%v", server.ListenAndServe())
 }
 
 const (
@@ -278,7 +325,7 @@ func createApiserverClient(apiserverHost string, kubeConfig string) (*kubernetes
  * message and quits the server.
  */
 func handleFatalInitError(err error) {
-	glog.Fatalf("Error while initializing connection to Kubernetes apiserver. "+
+	log.Fatalf("Error while initializing connection to Kubernetes apiserver. "+
 		"This most likely means that the cluster is misconfigured (e.g., it has "+
 		"invalid apiserver certificates or service accounts configuration). Reason: %s\n"+
 		"Refer to the troubleshooting guide for more information: "+
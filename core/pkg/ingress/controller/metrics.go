@@ -17,6 +17,8 @@ limitations under the License.
 package controller
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/ingress/core/pkg/ingress"
 )
@@ -27,13 +29,20 @@ const (
 	reloadLabel    = "reloads"
 	sslLabelExpire = "ssl_expire_time_seconds"
 	sslLabelHost   = "host"
+	sslLabelSecret = "secret"
+	objectLabel    = "type"
+	namespaceLabel = "namespace"
+	ingressLabel   = "ingress"
 )
 
 func init() {
 	prometheus.MustRegister(reloadOperation)
 	prometheus.MustRegister(reloadOperationErrors)
 	prometheus.MustRegister(sslExpireTime)
-
+	prometheus.MustRegister(reloadDuration)
+	prometheus.MustRegister(lastSyncTimestamp)
+	prometheus.MustRegister(configuredObjects)
+	prometheus.MustRegister(invalidAnnotations)
 }
 
 var (
@@ -60,7 +69,39 @@ var (
 			Help: "Number of seconds since 1970 to the SSL Certificate expire. An example to check if this " +
 				"certificate will expire in 10 days is: \"ingress_controller_ssl_expire_time_seconds < (time() + (10 * 24 * 3600))\"",
 		},
-		[]string{sslLabelHost},
+		[]string{sslLabelHost, sslLabelSecret},
+	)
+	reloadDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "reload_duration_seconds",
+			Help:      "Time used by the backend to apply a new configuration (render, test and reload)",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+	)
+	lastSyncTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "last_sync_timestamp_seconds",
+			Help: "Number of seconds since 1970 to the last successful synchronization of the configuration. " +
+				"An example to check if the controller is stuck is: \"time() - ingress_controller_last_sync_timestamp_seconds > 600\"",
+		},
+	)
+	configuredObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "configured_objects",
+			Help:      "Number of ingresses, servers, hosts, locations, upstreams and endpoints in the running configuration",
+		},
+		[]string{objectLabel},
+	)
+	invalidAnnotations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "invalid_annotations",
+			Help:      "Number of unknown annotations or annotations with an invalid value in the Ingress rule",
+		},
+		[]string{namespaceLabel, ingressLabel},
 	)
 )
 
@@ -72,12 +113,61 @@ func incReloadErrorCount() {
 	reloadOperationErrors.WithLabelValues(reloadLabel).Inc()
 }
 
+// setSSLExpireTime replaces the expiration time of the certificates
+// with the certificates (secrets) of the servers. Servers without
+// a secret (default certificate) are ignored
 func setSSLExpireTime(servers []*ingress.Server) {
-
+	sslExpireTime.Reset()
 	for _, s := range servers {
+		if s.Hostname == defServerName || s.SSLSecret == "" {
+			continue
+		}
+
+		sslExpireTime.WithLabelValues(s.Hostname, s.SSLSecret).Set(float64(s.SSLExpireTime.Unix()))
+		for _, cert := range s.SSLAdditionalCertificates {
+			sslExpireTime.WithLabelValues(s.Hostname, cert.Secret).Set(float64(cert.ExpireTime.Unix()))
+		}
+	}
+}
+
+func observeReloadDuration(d time.Duration) {
+	reloadDuration.Observe(d.Seconds())
+}
+
+func setLastSyncTimestamp(t time.Time) {
+	lastSyncTimestamp.Set(float64(t.Unix()))
+}
+
+// setConfiguredObjects sets the number of objects of the running
+// configuration. The hosts are the servers without the default server
+func setConfiguredObjects(ingresses int, cfg *ingress.Configuration) {
+	hosts, locations := 0, 0
+	for _, s := range cfg.Servers {
 		if s.Hostname != defServerName {
-			sslExpireTime.WithLabelValues(s.Hostname).Set(float64(s.SSLExpireTime.Unix()))
+			hosts++
 		}
+		locations += len(s.Locations)
+	}
+
+	endpoints := 0
+	for _, b := range cfg.Backends {
+		endpoints += len(b.Endpoints)
 	}
 
+	configuredObjects.WithLabelValues("ingresses").Set(float64(ingresses))
+	configuredObjects.WithLabelValues("servers").Set(float64(len(cfg.Servers)))
+	configuredObjects.WithLabelValues("hosts").Set(float64(hosts))
+	configuredObjects.WithLabelValues("locations").Set(float64(locations))
+	configuredObjects.WithLabelValues("upstreams").Set(float64(len(cfg.Backends)))
+	configuredObjects.WithLabelValues("endpoints").Set(float64(endpoints))
+}
+
+// setInvalidAnnotations sets the number of invalid annotations of the
+// Ingress rule. The Ingress rules without errors are removed
+func setInvalidAnnotations(namespace, name string, count int) {
+	if count == 0 {
+		invalidAnnotations.DeleteLabelValues(namespace, name)
+		return
+	}
+	invalidAnnotations.WithLabelValues(namespace, name).Set(float64(count))
 }
@@ -0,0 +1,117 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"k8s.io/ingress/core/pkg/ingress"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	m := &dto.Metric{}
+	if err := g.Write(m); err != nil {
+		t.Fatalf("unexpected error reading metric: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestSetLastSyncTimestamp(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	setLastSyncTimestamp(now)
+	if v := gaugeValue(t, lastSyncTimestamp); v != 1500000000 {
+		t.Errorf("expected the timestamp 1500000000 but returned %v", v)
+	}
+}
+
+func TestSetConfiguredObjects(t *testing.T) {
+	cfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: defServerName, Locations: []*ingress.Location{{Path: "/"}}},
+			{Hostname: "foo.bar", Locations: []*ingress.Location{{Path: "/"}, {Path: "/foo"}}},
+		},
+		Backends: []*ingress.Backend{
+			{Name: "upstream-default-backend", Endpoints: []ingress.Endpoint{{Address: "10.0.0.1"}}},
+			{Name: "default-foo-80", Endpoints: []ingress.Endpoint{{Address: "10.0.0.2"}, {Address: "10.0.0.3"}}},
+			{Name: "default-bar-80"},
+		},
+	}
+
+	setConfiguredObjects(3, cfg)
+	expected := map[string]float64{
+		"ingresses": 3,
+		"servers":   2,
+		"hosts":     1,
+		"locations": 3,
+		"upstreams": 3,
+		"endpoints": 3,
+	}
+	for label, expected := range expected {
+		if v := gaugeValue(t, configuredObjects.WithLabelValues(label)); v != expected {
+			t.Errorf("expected %v %v but returned %v", expected, label, v)
+		}
+	}
+}
+
+func TestObserveReloadDuration(t *testing.T) {
+	observeReloadDuration(2 * time.Second)
+	m := &dto.Metric{}
+	if err := reloadDuration.Write(m); err != nil {
+		t.Fatalf("unexpected error reading metric: %v", err)
+	}
+	if m.GetHistogram().GetSampleCount() == 0 || m.GetHistogram().GetSampleSum() < 2 {
+		t.Errorf("expected the reload duration in the histogram but returned %v", m.GetHistogram())
+	}
+}
+
+func TestSetSSLExpireTime(t *testing.T) {
+	expire := time.Unix(1600000000, 0)
+	servers := []*ingress.Server{
+		{Hostname: defServerName, SSLCertificate: "/etc/ingress-controller/ssl/default-fake-certificate.pem"},
+		{Hostname: "no-tls.example.com"},
+		{
+			Hostname:      "example.com",
+			SSLSecret:     "default/example-rsa",
+			SSLExpireTime: expire,
+			SSLAdditionalCertificates: []ingress.SSLCertificateFile{
+				{Secret: "default/example-ecdsa", ExpireTime: expire.Add(time.Hour)},
+			},
+		},
+	}
+
+	setSSLExpireTime(servers)
+	expected := map[string]float64{
+		"default/example-rsa":   1600000000,
+		"default/example-ecdsa": 1600003600,
+	}
+	for secret, value := range expected {
+		if v := gaugeValue(t, sslExpireTime.WithLabelValues("example.com", secret)); v != value {
+			t.Errorf("expected %v for the secret %v but returned %v", value, secret, v)
+		}
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	sslExpireTime.Collect(ch)
+	close(ch)
+	if len(ch) != len(expected) {
+		t.Errorf("expected %v metrics but returned %v", len(expected), len(ch))
+	}
+}
@@ -17,12 +17,19 @@ limitations under the License.
 package controller
 
 import (
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/golang/glog"
 	"github.com/imdario/mergo"
 
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
 	"k8s.io/ingress/core/pkg/ingress"
 )
 
@@ -47,6 +54,50 @@ func newUpstream(name string) *ingress.Backend {
 	}
 }
 
+// setLocationIngress sets the Ingress rule that defines the location
+// and the namespace and name of the rule used in the configuration
+func setLocationIngress(loc *ingress.Location, ing *extensions.Ingress) {
+	loc.Ingress = ing
+	loc.IngressNamespace = ""
+	loc.IngressName = ""
+	if ing != nil {
+		loc.IngressNamespace = ing.Namespace
+		loc.IngressName = ing.Name
+	}
+}
+
+// shuffleEndpoints returns a copy of the endpoints in a pseudo-random order.
+// The order only depends on the endpoints, the key and the seed, so the same
+// endpoints always produce the same configuration in a controller (avoiding
+// reloads) while the replicas of the controller, each with a different seed,
+// do not send the requests to the endpoints in the same order
+func shuffleEndpoints(endps []ingress.Endpoint, key string, seed int64) []ingress.Endpoint {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	r := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+
+	res := make([]ingress.Endpoint, len(endps))
+	copy(res, endps)
+	for i := range res {
+		j := r.Intn(i + 1)
+		res[i], res[j] = res[j], res[i]
+	}
+
+	return res
+}
+
+// hostnameSeed returns a seed obtained from the hostname of the pod
+func hostnameSeed() int64 {
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Warningf("unexpected error reading the hostname: %v", err)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(hostname))
+	return int64(h.Sum64())
+}
+
 func isHostValid(host string, cert *ingress.SSLCert) bool {
 	if cert == nil {
 		return false
@@ -131,3 +182,44 @@ func mergeLocationAnnotations(loc *ingress.Location, anns map[string]interface{}
 		glog.Errorf("unexpected error merging extracted annotations in location type: %v", err)
 	}
 }
+
+// parseStreamOptions parses the options of a stream service defined after
+// the port of the service. Valid options are PROXY (only TCP), timeout=<time>,
+// connect-timeout=<time> (only TCP) and responses=<number> (only UDP).
+// Invalid options are ignored.
+func parseStreamOptions(port string, proto api.Protocol, options []string) ingress.L4Backend {
+	l4Backend := ingress.L4Backend{}
+	for _, option := range options {
+		// Proxy protocol is possible if the service is TCP
+		if strings.ToUpper(option) == "PROXY" {
+			if proto == api.ProtocolTCP {
+				l4Backend.UseProxyProtocol = true
+			}
+			continue
+		}
+
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			glog.Warningf("invalid option '%v' in %v service with port %v", option, proto, port)
+			continue
+		}
+
+		switch {
+		case kv[0] == "timeout":
+			l4Backend.ProxyTimeout = kv[1]
+		case kv[0] == "connect-timeout" && proto == api.ProtocolTCP:
+			l4Backend.ProxyConnectTimeout = kv[1]
+		case kv[0] == "responses" && proto == api.ProtocolUDP:
+			responses, err := strconv.Atoi(kv[1])
+			if err != nil || responses < 1 {
+				glog.Warningf("invalid number of responses '%v' in %v service with port %v", kv[1], proto, port)
+				continue
+			}
+			l4Backend.ProxyResponses = responses
+		default:
+			glog.Warningf("unsupported option '%v' in %v service with port %v", option, proto, port)
+		}
+	}
+
+	return l4Backend
+}
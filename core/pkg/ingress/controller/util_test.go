@@ -17,9 +17,16 @@ limitations under the License.
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
 	"k8s.io/ingress/core/pkg/ingress"
 	"k8s.io/ingress/core/pkg/ingress/annotations/auth"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authreq"
@@ -131,3 +138,84 @@ func TestMergeLocationAnnotations(t *testing.T) {
 		t.Errorf("%s should be removed after mergeLocationAnnotations", DeniedKeyName)
 	}
 }
+
+func TestParseStreamOptions(t *testing.T) {
+	testCases := map[string]struct {
+		proto    api.Protocol
+		options  []string
+		expected ingress.L4Backend
+	}{
+		"no options": {api.ProtocolTCP, []string{}, ingress.L4Backend{}},
+		"proxy protocol": {api.ProtocolTCP, []string{"PROXY"},
+			ingress.L4Backend{UseProxyProtocol: true}},
+		"proxy protocol in udp": {api.ProtocolUDP, []string{"PROXY"}, ingress.L4Backend{}},
+		"long lived tcp": {api.ProtocolTCP, []string{"PROXY", "timeout=1h", "connect-timeout=5s"},
+			ingress.L4Backend{UseProxyProtocol: true, ProxyTimeout: "1h", ProxyConnectTimeout: "5s"}},
+		"udp with multiple responses": {api.ProtocolUDP, []string{"responses=3", "timeout=30s"},
+			ingress.L4Backend{ProxyResponses: 3, ProxyTimeout: "30s"}},
+		"invalid responses": {api.ProtocolUDP, []string{"responses=0"}, ingress.L4Backend{}},
+		"responses in tcp":  {api.ProtocolTCP, []string{"responses=2"}, ingress.L4Backend{}},
+		"invalid options":   {api.ProtocolTCP, []string{"timeout", "foo=bar", "timeout="}, ingress.L4Backend{}},
+	}
+
+	for name, tc := range testCases {
+		l4Backend := parseStreamOptions("9000", tc.proto, tc.options)
+		if !reflect.DeepEqual(l4Backend, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", name, tc.expected, l4Backend)
+		}
+	}
+}
+
+func TestShuffleEndpoints(t *testing.T) {
+	endps := []ingress.Endpoint{}
+	for i := 1; i <= 10; i++ {
+		endps = append(endps, ingress.Endpoint{Address: fmt.Sprintf("10.0.0.%v", i), Port: "8080"})
+	}
+	orig := make([]ingress.Endpoint, len(endps))
+	copy(orig, endps)
+
+	s1 := shuffleEndpoints(endps, "default/foo", 42)
+	s2 := shuffleEndpoints(endps, "default/foo", 42)
+	if !reflect.DeepEqual(s1, s2) {
+		t.Errorf("expected the same order for the same endpoints, key and seed")
+	}
+	if !reflect.DeepEqual(endps, orig) {
+		t.Errorf("unexpected change in the original endpoints")
+	}
+
+	sorted := make([]ingress.Endpoint, len(s1))
+	copy(sorted, s1)
+	sort.Sort(ingress.EndpointByAddrPort(sorted))
+	sort.Sort(ingress.EndpointByAddrPort(orig))
+	if !reflect.DeepEqual(sorted, orig) {
+		t.Errorf("expected the same endpoints after the shuffle but returned %v", s1)
+	}
+
+	s3 := shuffleEndpoints(endps, "default/foo", 43)
+	if reflect.DeepEqual(s1, s3) {
+		t.Errorf("expected a different order with a different seed")
+	}
+}
+
+func TestSetLocationIngress(t *testing.T) {
+	foo := &ingress.Location{Path: "/"}
+	setLocationIngress(foo, &extensions.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}})
+	if foo.IngressNamespace != "default" || foo.IngressName != "foo" {
+		t.Errorf("expected the Ingress default/foo but returned %v/%v", foo.IngressNamespace, foo.IngressName)
+	}
+
+	bar := &ingress.Location{Path: "/"}
+	setLocationIngress(bar, &extensions.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "bar", Namespace: "default"}})
+
+	// the Ingress rule is not serialized, the checksum uses the name
+	b1, _ := json.Marshal(foo)
+	b2, _ := json.Marshal(bar)
+	if string(b1) == string(b2) {
+		t.Errorf("expected a different serialization for locations of different Ingress rules")
+	}
+
+	setLocationIngress(bar, nil)
+	if bar.Ingress != nil || bar.IngressNamespace != "" || bar.IngressName != "" {
+		t.Errorf("expected a location without Ingress rule but returned %v/%v", bar.IngressNamespace, bar.IngressName)
+	}
+}
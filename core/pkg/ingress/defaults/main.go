@@ -19,6 +19,28 @@ type Backend struct {
 	// Sets the maximum allowed size of the client request body
 	ProxyBodySize string `json:"proxy-body-size"`
 
+	// Enables or disables the header HSTS in servers running SSL
+	HSTS bool `json:"hsts,omitempty"`
+
+	// Enables or disables the use of HSTS in all the subdomains of the servername
+	// Default: true
+	HSTSIncludeSubdomains bool `json:"hsts-include-subdomains,omitempty"`
+
+	// HTTP Strict Transport Security (often abbreviated as HSTS) is a security feature (HTTP header)
+	// that tell browsers that it should only be communicated with using HTTPS, instead of using HTTP.
+	// https://developer.mozilla.org/en-US/docs/Web/Security/HTTP_strict_transport_security
+	// max-age is the time, in seconds, that the browser should remember that this site is only to be
+	// accessed using HTTPS.
+	HSTSMaxAge string `json:"hsts-max-age,omitempty"`
+
+	// Enables or disables the preload attribute in HSTS feature
+	HSTSPreload bool `json:"hsts-preload,omitempty"`
+
+	// Sets buffer size for reading client request body. Bodies larger
+	// than the buffer are written to a temporary file
+	// http://nginx.org/en/docs/http/ngx_http_core_module.html#client_body_buffer_size
+	ClientBodyBufferSize string `json:"client-body-buffer-size,omitempty"`
+
 	// Defines a timeout for establishing a connection with a proxied server.
 	// It should be noted that this timeout cannot usually exceed 75 seconds.
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_connect_timeout
@@ -53,6 +75,22 @@ type Backend struct {
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_next_upstream
 	ProxyNextUpstream string `json:"proxy-next-upstream"`
 
+	// Sets the text that should be changed in the “Location” and “Refresh” header fields of
+	// a proxied server response. Valid values are off, default or <redirect> <replacement>
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_redirect
+	ProxyRedirect string `json:"proxy-redirect"`
+
+	// Enables or disables the buffering of the responses of the proxied server
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_buffering
+	// Default: off
+	ProxyBuffering string `json:"proxy-buffering"`
+
+	// Enables or disables the buffering of the client request body. When it is
+	// disabled the body is sent to the proxied server as soon as it is received
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_request_buffering
+	// Default: on
+	ProxyRequestBuffering string `json:"proxy-request-buffering"`
+
 	// Name server/s used to resolve names of upstream servers into IP addresses.
 	// The file /etc/resolv.conf is used as DNS resolution configuration.
 	Resolver []net.IP
@@ -62,6 +100,11 @@ type Backend struct {
 	// By default this list is empty
 	SkipAccessLogURLs []string `json:"skip-access-log-urls,-"`
 
+	// Enables or disables the TLSv1.3 early data (0-RTT). The requests
+	// sent in the early data can be replayed by an attacker
+	// http://nginx.org/en/docs/http/ngx_http_ssl_module.html#ssl_early_data
+	// Default: false
+	SSLEarlyData bool `json:"ssl-early-data"`
 	// Enables or disables the redirect (301) to the HTTPS port
 	SSLRedirect bool `json:"ssl-redirect"`
 
@@ -69,22 +112,45 @@ type Backend struct {
 	// This is useful if doing SSL offloading outside of cluster eg AWS ELB
 	ForceSSLRedirect bool `json:"force-ssl-redirect"`
 
+	// Status code of the redirect to the HTTPS port (301, 302, 307 or 308)
+	// Default: 301
+	SSLRedirectCode int `json:"ssl-redirect-code"`
+
+	// Host used in the redirect to the HTTPS port instead of the host of the request
+	// By default this is empty
+	SSLRedirectHost string `json:"ssl-redirect-host"`
+
+	// Port used in the redirect to the HTTPS port. This is useful when the load
+	// balancer in front of the controller terminates TLS in a non-standard port
+	// By default this is 0 (the port is not included in the redirect)
+	SSLRedirectPort int `json:"ssl-redirect-port"`
+
 	// Enables or disables the specification of port in redirects
 	// Default: false
 	UsePortInRedirects bool `json:"use-port-in-redirects"`
 
+	// Generates a unique X-Request-ID header if the request does not
+	// contain one and sends it to the upstream
+	// Default: true
+	GenerateRequestID bool `json:"generate-request-id"`
+
 	// Number of unsuccessful attempts to communicate with the server that should happen in the
 	// duration set by the fail_timeout parameter to consider the server unavailable
 	// http://nginx.org/en/docs/http/ngx_http_upstream_module.html#upstream
-	// Default: 0, ie use platform liveness probe
+	// Default: 1
 	UpstreamMaxFails int `json:"upstream-max-fails"`
 
 	// Time during which the specified number of unsuccessful attempts to communicate with
 	// the server should happen to consider the server unavailable
 	// http://nginx.org/en/docs/http/ngx_http_upstream_module.html#upstream
-	// Default: 0, ie use platform liveness probe
+	// Default: 10 (seconds)
 	UpstreamFailTimeout int `json:"upstream-fail-timeout"`
 
+	// LimitBurstMultiplier sets the size of the burst of the rate limits
+	// defined with the annotations, as a multiple of the limit
+	// Default: 5
+	LimitBurstMultiplier int `json:"limit-burst-multiplier"`
+
 	// WhitelistSourceRange allows limiting access to certain client addresses
 	// http://nginx.org/en/docs/http/ngx_http_access_module.html
 	WhitelistSourceRange []string `json:"whitelist-source-range,-"`
@@ -30,6 +30,10 @@ var (
 	// ErrInvalidAnnotationName the ingress rule does contains an invalid
 	// annotation name
 	ErrInvalidAnnotationName = errors.New("invalid annotation name")
+
+	// ErrReloadPending the backend accepted the configuration
+	// but the reload is delayed
+	ErrReloadPending = errors.New("reload of the backend pending")
 )
 
 // NewInvalidAnnotationContent returns a new InvalidContent error
@@ -46,6 +50,28 @@ func NewLocationDenied(reason string) error {
 	}
 }
 
+// NewInvalidConfiguration returns a new InvalidConfiguration error
+func NewInvalidConfiguration(reason string, hosts ...string) error {
+	return InvalidConfiguration{
+		Reason: reason,
+		Hosts:  hosts,
+	}
+}
+
+// InvalidConfiguration error returned by the backend when the
+// configuration is rejected. Hosts contains the servers with the
+// invalid configuration, if the backend can identify them, and
+// ExitCode the exit code of the command used to test the configuration
+type InvalidConfiguration struct {
+	Reason   string
+	Hosts    []string
+	ExitCode int
+}
+
+func (e InvalidConfiguration) Error() string {
+	return e.Reason
+}
+
 // InvalidContent error
 type InvalidContent struct {
 	Name string
@@ -77,6 +103,19 @@ func IsMissingAnnotations(e error) bool {
 	return e == ErrMissingAnnotations
 }
 
+// IsReloadPending checks if the err is an error which
+// indicates the reload of the backend is delayed
+func IsReloadPending(e error) bool {
+	return e == ErrReloadPending
+}
+
+// IsInvalidConfiguration checks if the err is an error which
+// indicates the backend rejected the configuration
+func IsInvalidConfiguration(e error) bool {
+	_, ok := e.(InvalidConfiguration)
+	return ok
+}
+
 // IsInvalidContent checks if the err is an error which
 // indicates an annotations value is not valid
 func IsInvalidContent(e error) bool {
@@ -50,3 +50,19 @@ func TestInvalidContent(t *testing.T) {
 		t.Error("expected false")
 	}
 }
+
+func TestInvalidConfiguration(t *testing.T) {
+	err := NewInvalidConfiguration("nginx: [emerg] unknown directive", "foo.bar")
+	if !IsInvalidConfiguration(err) {
+		t.Error("expected true")
+	}
+	if err.(InvalidConfiguration).Hosts[0] != "foo.bar" {
+		t.Errorf("expected the host foo.bar but returned %v", err.(InvalidConfiguration).Hosts)
+	}
+	if IsInvalidConfiguration(ErrMissingAnnotations) {
+		t.Error("expected false")
+	}
+	if IsInvalidConfiguration(nil) {
+		t.Error("expected false")
+	}
+}
@@ -50,6 +50,9 @@ type AuthSSLCert struct {
 	CAFileName string `json:"caFilename"`
 	// PemSHA contains the SHA1 hash of the 'tls.crt' value
 	PemSHA string `json:"pemSha"`
+	// PemFileName contains the path to the file with the secrets
+	// 'tls.crt' and 'tls.key' concatenated, if the secret contains them
+	PemFileName string `json:"pemFilename"`
 }
 
 func (asslc1 *AuthSSLCert) Equal(assl2 *AuthSSLCert) bool {
@@ -62,6 +65,9 @@ func (asslc1 *AuthSSLCert) Equal(assl2 *AuthSSLCert) bool {
 	if asslc1.PemSHA != assl2.PemSHA {
 		return false
 	}
+	if asslc1.PemFileName != assl2.PemFileName {
+		return false
+	}
 
 	return true
 }
@@ -69,6 +69,24 @@ func (c LocationByPath) Less(i, j int) bool {
 	return c[i].Path > c[j].Path
 }
 
+// L4ServiceByPort sorts the TCP and UDP services by port
+type L4ServiceByPort []L4Service
+
+func (c L4ServiceByPort) Len() int      { return len(c) }
+func (c L4ServiceByPort) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c L4ServiceByPort) Less(i, j int) bool {
+	return c[i].Port < c[j].Port
+}
+
+// L4ServiceByHostname sorts the TLS services by server name
+type L4ServiceByHostname []L4Service
+
+func (c L4ServiceByHostname) Len() int      { return len(c) }
+func (c L4ServiceByHostname) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c L4ServiceByHostname) Less(i, j int) bool {
+	return c[i].Hostname < c[j].Hostname
+}
+
 // SSLCert describes a SSL certificate to be used in a server
 type SSLCert struct {
 	meta_v1.ObjectMeta `json:"metadata,omitempty"`
@@ -83,6 +101,8 @@ type SSLCert struct {
 	CN []string `json:"cn"`
 	// ExpiresTime contains the expiration of this SSL certificate in timestamp format
 	ExpireTime time.Time `json:"expires"`
+	// KeyType contains the algorithm of the public key of the certificate (RSA or ECDSA)
+	KeyType string `json:"keyType"`
 }
 
 // GetObjectKind implements the ObjectKind interface as a noop
@@ -17,6 +17,7 @@ limitations under the License.
 package ingress
 
 import (
+	"sort"
 	"testing"
 
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -377,3 +378,14 @@ func TestGetObjectKindForSSLCert(t *testing.T) {
 		t.Errorf("Returned nil but expected a valid ObjectKind")
 	}
 }
+
+func TestL4ServiceByPort(t *testing.T) {
+	svcs := []L4Service{{Port: 5432}, {Port: 53}, {Port: 8080}}
+	sort.Sort(L4ServiceByPort(svcs))
+
+	for i, port := range []int{53, 5432, 8080} {
+		if svcs[i].Port != port {
+			t.Errorf("expected port %v in position %v but returned %v", port, i, svcs[i].Port)
+		}
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"k8s.io/ingress/core/pkg/ingress/status/leaderelection"
 	"k8s.io/ingress/core/pkg/ingress/store"
 	"k8s.io/ingress/core/pkg/k8s"
+	"k8s.io/ingress/core/pkg/log"
 	"k8s.io/ingress/core/pkg/strings"
 	"k8s.io/ingress/core/pkg/task"
 )
@@ -186,7 +187,7 @@ func (s statusSync) keyfunc(input interface{}) (interface{}, error) {
 func NewStatusSyncer(config Config) Sync {
 	pod, err := k8s.GetPodDetails(config.Client)
 	if err != nil {
-		glog.Fatalf("unexpected error obtaining pod information: %v", err)
+		log.Fatalf("unexpected error obtaining pod information: %v", err)
 	}
 
 	st := statusSync{
@@ -207,7 +208,7 @@ func NewStatusSyncer(config Config) Sync {
 		pod.Name, pod.Namespace, 30*time.Second,
 		st.callback, config.Client)
 	if err != nil {
-		glog.Fatalf("unexpected error starting leader election: %v", err)
+		log.Fatalf("unexpected error starting leader election: %v", err)
 	}
 	st.elector = le
 	return st
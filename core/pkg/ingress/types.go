@@ -24,14 +24,24 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apiserver/pkg/server/healthz"
 	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
 
 	"k8s.io/ingress/core/pkg/ingress/annotations/auth"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authreq"
 	"k8s.io/ingress/core/pkg/ingress/annotations/authtls"
+	"k8s.io/ingress/core/pkg/ingress/annotations/canary"
+	"k8s.io/ingress/core/pkg/ingress/annotations/cors"
+	"k8s.io/ingress/core/pkg/ingress/annotations/globalratelimit"
+	"k8s.io/ingress/core/pkg/ingress/annotations/healthcheck"
+	"k8s.io/ingress/core/pkg/ingress/annotations/hsts"
+	"k8s.io/ingress/core/pkg/ingress/annotations/ipaccess"
 	"k8s.io/ingress/core/pkg/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress/core/pkg/ingress/annotations/mirror"
 	"k8s.io/ingress/core/pkg/ingress/annotations/proxy"
+	"k8s.io/ingress/core/pkg/ingress/annotations/proxycache"
 	"k8s.io/ingress/core/pkg/ingress/annotations/ratelimit"
 	"k8s.io/ingress/core/pkg/ingress/annotations/rewrite"
+	"k8s.io/ingress/core/pkg/ingress/annotations/urlredirect"
 	"k8s.io/ingress/core/pkg/ingress/defaults"
 	"k8s.io/ingress/core/pkg/ingress/resolver"
 	"k8s.io/ingress/core/pkg/ingress/store"
@@ -94,6 +104,54 @@ type Controller interface {
 	DefaultIngressClass() string
 }
 
+// ConfigurationDumper is an optional interface of the Controller
+// used to inspect the configuration files of the backend
+type ConfigurationDumper interface {
+	// DumpConfiguration returns the configuration applied in the backend
+	// and the last configuration rejected (nil if there is none)
+	DumpConfiguration() (applied, rejected []byte)
+}
+
+// ConfigurationHistory is an optional interface of the Controller
+// used to inspect the last changes in the configuration of the backend
+type ConfigurationHistory interface {
+	// ConfigurationDiffs returns the last changes in the
+	// configuration of the backend, the newest first
+	ConfigurationDiffs() []ConfigurationDiff
+}
+
+// ConfigurationDiff is a change in the configuration of the backend
+type ConfigurationDiff struct {
+	Time time.Time `json:"time"`
+	Diff string    `json:"diff"`
+}
+
+// DryRunner is an optional interface of the Controller used
+// to validate the configuration without applying it
+type DryRunner interface {
+	// DryRun renders and tests the configuration of the backend
+	// without applying it, returning the rendered configuration
+	DryRun(Configuration) ([]byte, error)
+}
+
+// SecretReferencer is an optional interface of the Controller used
+// when the configuration of the backend uses secrets not referenced
+// in Ingress rules
+type SecretReferencer interface {
+	// ReferencedSecrets returns the secrets (namespace/name) used in the
+	// configuration of the backend. Changes in the secrets trigger a sync
+	ReferencedSecrets() []string
+}
+
+// DeferredReloader is an optional interface of the Controller used
+// when the backend delays the reloads after OnUpdate, e.g. to coalesce
+// them. OnUpdate returns errors.ErrReloadPending when the reload is
+// delayed and the backend reports the result of the reload with the
+// function configured in SetReloadHandler
+type DeferredReloader interface {
+	SetReloadHandler(func(error))
+}
+
 // StoreLister returns the configured stores for ingresses, services,
 // endpoints, secrets and configmaps.
 type StoreLister struct {
@@ -117,6 +175,8 @@ type BackendInfo struct {
 	Build string `json:"build"`
 	// Repository return information about the git repository
 	Repository string `json:"repository"`
+	// Features returns the optional features supported by the backend
+	Features []string `json:"features,omitempty"`
 }
 
 // Configuration holds the definition of all the parts required to describe all
@@ -133,6 +193,10 @@ type Configuration struct {
 	// UDPEndpoints contain endpoints for udp streams handled by this backend
 	// +optional
 	UDPEndpoints []L4Service `json:"udpEndpoints,omitempty"`
+	// TLSEndpoints contain endpoints for the TLS streams routed
+	// by the server name (SNI) handled by this backend
+	// +optional
+	TLSEndpoints []L4Service `json:"tlsEndpoints,omitempty"`
 	// PassthroughBackend contains the backends used for SSL passthrough.
 	// It contains information about the associated Server Name Indication (SNI).
 	// +optional
@@ -153,12 +217,23 @@ type Backend struct {
 	// SecureCACert has the filename and SHA1 of the certificate authorities used to validate
 	// a secured connection to the backend
 	SecureCACert resolver.AuthSSLCert `json:"secureCert"`
+	// SecureClientCert has the filename of the certificate and key sent to the
+	// backend in a secured connection, when the backend requests a client certificate
+	SecureClientCert resolver.AuthSSLCert `json:"secureClientCert"`
 	// SSLPassthrough indicates that Ingress controller will delegate TLS termination to the endpoints.
 	SSLPassthrough bool `json:"sslPassthrough"`
 	// Endpoints contains the list of endpoints currently running
 	Endpoints []Endpoint `json:"endpoints,omitempty"`
 	// StickySessionAffinitySession contains the StickyConfig object with stickness configuration
 	SessionAffinity SessionAffinityConfig `json:"sessionAffinityConfig"`
+	// UpstreamHashBy contains the NGINX variables used to select the endpoint
+	// with a consistent hash instead of the load balance algorithm
+	UpstreamHashBy string `json:"upstream-hash-by,omitempty"`
+	// LoadBalance contains the load balance algorithm of the upstream,
+	// empty to use the algorithm defined in the configuration
+	LoadBalance string `json:"load-balance,omitempty"`
+	// HealthCheckScript contains the njs script used to check the status of the backend
+	HealthCheckScript healthcheck.Script `json:"healthCheckScript"`
 }
 
 // SessionAffinityConfig describes different affinity configurations for new sessions.
@@ -176,6 +251,8 @@ type SessionAffinityConfig struct {
 type CookieSessionAffinity struct {
 	Name      string              `json:"name"`
 	Hash      string              `json:"hash"`
+	Expires   string              `json:"expires,omitempty"`
+	Path      string              `json:"path,omitempty"`
 	Locations map[string][]string `json:"locations,omitempty"`
 }
 
@@ -193,6 +270,9 @@ type Endpoint struct {
 	// of unsuccessful attempts to communicate with the server should happen
 	// to consider the endpoint unavailable
 	FailTimeout int `json:"failTimeout"`
+	// Backup indicates the endpoint only receives requests
+	// when all the other endpoints are unavailable
+	Backup bool `json:"backup,omitempty"`
 }
 
 // Server describes a website
@@ -202,19 +282,69 @@ type Server struct {
 	// SSLPassthrough indicates if the TLS termination is realized in
 	// the server or in the remote endpoint
 	SSLPassthrough bool `json:"sslPassthrough"`
+	// SSLPassthroughProxyProtocol indicates if the connections to the
+	// remote endpoint start with the PROXY protocol header
+	SSLPassthroughProxyProtocol bool `json:"sslPassthroughProxyProtocol"`
 	// SSLCertificate path to the SSL certificate on disk
 	SSLCertificate string `json:"sslCertificate"`
 	// SSLExpireTime has the expire date of this certificate
 	SSLExpireTime time.Time `json:"sslExpireTime"`
+	// SSLSecret is the secret (namespace/name) of the certificate
+	SSLSecret string `json:"sslSecret"`
 	// SSLPemChecksum returns the checksum of the certificate file on disk.
 	// There is no restriction in the hash generator. This checksim can be
 	// used to  determine if the secret changed without the use of file
 	// system notifications
 	SSLPemChecksum string `json:"sslPemChecksum"`
+	// SSLTrustedCertificate path to the file with the CA chain of the
+	// secret of the certificate (ca.crt), used to verify the OCSP responses
+	// +optional
+	SSLTrustedCertificate string `json:"sslTrustedCertificate,omitempty"`
+	// SSLAdditionalCertificates list of certificates served in addition to
+	// SSLCertificate. Each certificate uses a different key type (RSA or
+	// ECDSA) and NGINX selects the one supported by the client
+	SSLAdditionalCertificates []SSLCertificateFile `json:"sslAdditionalCertificates,omitempty"`
+	// AccessList contains the client addresses or networks allowed
+	// or denied in all the locations of the server without an
+	// access list
+	// +optional
+	AccessList ipaccess.AccessList `json:"accessList,omitempty"`
+	// HSTS contains the configuration of the Strict-Transport-Security
+	// header sent when the server runs SSL
+	// +optional
+	HSTS *hsts.Config `json:"hsts,omitempty"`
+	// ServerSnippet fragment of configuration included in the server
+	// +optional
+	ServerSnippet string `json:"serverSnippet,omitempty"`
+	// DefaultBackend name of the upstream of the default backend of
+	// the server, empty to use the default backend of the controller
+	// +optional
+	DefaultBackend string `json:"defaultBackend,omitempty"`
+	// RedirectFromToWWW indicates if the requests of www.<hostname> (or
+	// the hostname without www.) are redirected to the server
+	// +optional
+	RedirectFromToWWW bool `json:"redirectFromToWWW,omitempty"`
+	// Aliases additional hostnames of the server
+	// +optional
+	Aliases []string `json:"aliases,omitempty"`
 	// Locations list of URIs configured in the server.
 	Locations []*Location `json:"locations,omitempty"`
 }
 
+// SSLCertificateFile describes a SSL certificate on disk used by a server
+type SSLCertificateFile struct {
+	// PemFileName path to the file with the certificate and key concatenated
+	PemFileName string `json:"pemFileName"`
+	// PemSHA contains the sha1 of the pem file.
+	PemSHA string `json:"pemSha"`
+	// KeyType contains the algorithm of the public key (RSA or ECDSA)
+	KeyType string `json:"keyType"`
+	// Secret is the secret (namespace/name) of the certificate
+	Secret string `json:"secret"`
+	// ExpireTime has the expire date of the certificate
+	ExpireTime time.Time `json:"expireTime"`
+}
+
 // Location describes an URI inside a server.
 // Also contains additional information about annotations in the Ingress.
 //
@@ -248,6 +378,15 @@ type Location struct {
 
 	Service *api.Service       `json:"service"`
 	Port    intstr.IntOrString `json:"port"`
+	// Ingress is the Ingress rule that defines the location.
+	// nil if the location uses the default backend
+	Ingress *extensions.Ingress `json:"-"`
+	// IngressNamespace and IngressName identify the Ingress rule of the
+	// location in the configuration. Unlike Ingress, both are serialized
+	// and change the checksum of the configuration
+	// +optional
+	IngressNamespace string `json:"ingressNamespace,omitempty"`
+	IngressName      string `json:"ingressName,omitempty"`
 	// BasicDigestAuth returns authentication configuration for
 	// an Ingress rule.
 	// +optional
@@ -258,6 +397,9 @@ type Location struct {
 	// EnableCORS indicates if path must support CORS
 	// +optional
 	EnableCORS bool `json:"enableCors,omitempty"`
+	// CorsConfig contains the CORS headers sent when EnableCORS is true
+	// +optional
+	CorsConfig cors.Config `json:"corsConfig,omitempty"`
 	// ExternalAuth indicates the access to this location requires
 	// authentication using an external provider
 	// +optional
@@ -267,6 +409,10 @@ type Location struct {
 	// The Redirect annotation precedes RateLimit
 	// +optional
 	RateLimit ratelimit.RateLimit `json:"rateLimit,omitempty"`
+	// GlobalRateLimit describes a limit in the number of requests
+	// shared by all the replicas of the controller
+	// +optional
+	GlobalRateLimit globalratelimit.Config `json:"globalRateLimit,omitempty"`
 	// Redirect describes the redirection this location.
 	// +optional
 	Redirect rewrite.Redirect `json:"redirect,omitempty"`
@@ -274,6 +420,10 @@ type Location struct {
 	// addresses or networks are allowed.
 	// +optional
 	Whitelist ipwhitelist.SourceRange `json:"whitelist,omitempty"`
+	// AccessList contains the client addresses or networks allowed
+	// or denied in the location. It replaces the access list of the server
+	// +optional
+	AccessList ipaccess.AccessList `json:"accessList,omitempty"`
 	// Proxy contains information about timeouts and buffer sizes
 	// to be used in connections against endpoints
 	// +optional
@@ -285,9 +435,49 @@ type Location struct {
 	// UsePortInRedirects indicates if redirects must specify the port
 	// +optional
 	UsePortInRedirects bool `json:"use-port-in-redirects"`
+	// GenerateRequestID indicates if the X-Request-ID header must be
+	// generated (when absent) and sent to the upstream
+	// +optional
+	GenerateRequestID bool `json:"generate-request-id"`
+	// SSLEarlyData indicates if the location accepts requests sent in
+	// the TLSv1.3 early data (0-RTT). When false, these requests are
+	// rejected with 425 (Too Early)
+	// +optional
+	SSLEarlyData bool `json:"ssl-early-data"`
 	// ConfigurationSnippet contains additional configuration for the backend
 	// to be considered in the configuration of the location
 	ConfigurationSnippet string `json:"configuration-snippet"`
+	// UpstreamVhost is the value of the Host header sent to the
+	// backend instead of the host of the request
+	// +optional
+	UpstreamVhost string `json:"upstream-vhost"`
+	// Canary contains the backend of the canary Ingress rule that
+	// receives part of the requests of the location
+	// +optional
+	Canary canary.Config `json:"canary,omitempty"`
+	// Mirror contains the service that receives a copy of the requests
+	// +optional
+	Mirror mirror.Config `json:"mirror,omitempty"`
+	// CustomHTTPErrors status codes of the responses of the backend
+	// replaced by the custom error pages, instead of the global codes
+	// +optional
+	CustomHTTPErrors []int `json:"custom-http-errors,omitempty"`
+	// ProxyCache contains the cache zone and the rules used to
+	// cache the responses of the backend
+	// +optional
+	ProxyCache proxycache.Config `json:"proxy-cache,omitempty"`
+	// URLRedirect contains the URL where all the requests of the
+	// location are redirected, without sending them to the backend
+	// +optional
+	URLRedirect urlredirect.Config `json:"url-redirect,omitempty"`
+	// BackendProtocol is the protocol used to communicate with the
+	// backend: HTTP, HTTPS, GRPC, GRPCS, FCGI, UWSGI or AJP
+	// +optional
+	BackendProtocol string `json:"backend-protocol,omitempty"`
+	// Satisfy defines if the client address and the authentication of
+	// the location must be valid (all) or only one of them (any)
+	// +optional
+	Satisfy string `json:"satisfy,omitempty"`
 }
 
 // SSLPassthroughBackend describes a SSL upstream server configured
@@ -301,12 +491,19 @@ type SSLPassthroughBackend struct {
 	Backend string `json:"namespace,omitempty"`
 	// Hostname returns the FQDN of the server
 	Hostname string `json:"hostname"`
+	// ProxyProtocol indicates if the PROXY protocol header, with the
+	// address of the client, is sent to the endpoints before the TLS stream
+	ProxyProtocol bool `json:"proxyProtocol"`
 }
 
 // L4Service describes a L4 Ingress service.
 type L4Service struct {
 	// Port external port to expose
 	Port int `json:"port"`
+	// Hostname is the server name (SNI) used to route the
+	// connections to a TLS service sharing the port
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
 	// Backend of the service
 	Backend L4Backend `json:"backend"`
 	// Endpoints active endpoints of the service
@@ -321,4 +518,16 @@ type L4Backend struct {
 	Protocol  api.Protocol       `json:"protocol"`
 	// +optional
 	UseProxyProtocol bool `json:"useProxyProtocol"`
+	// ProxyTimeout timeout between two successive read or write operations
+	// on the client or proxied server connections
+	// +optional
+	ProxyTimeout string `json:"proxyTimeout,omitempty"`
+	// ProxyConnectTimeout timeout for establishing a connection with the
+	// proxied server (only TCP)
+	// +optional
+	ProxyConnectTimeout string `json:"proxyConnectTimeout,omitempty"`
+	// ProxyResponses number of datagrams expected from the proxied server
+	// in response to a client datagram (only UDP)
+	// +optional
+	ProxyResponses int `json:"proxyResponses,omitempty"`
 }
@@ -115,6 +115,23 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 		}
 	}
 
+	if len(c1.TLSEndpoints) != len(c2.TLSEndpoints) {
+		return false
+	}
+
+	for _, tls1 := range c1.TLSEndpoints {
+		found := false
+		for _, tls2 := range c2.TLSEndpoints {
+			if (&tls1).Equal(&tls2) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
 	if len(c1.PassthroughBackends) != len(c2.PassthroughBackends) {
 		return false
 	}
@@ -172,12 +189,24 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 	if !(&b1.SecureCACert).Equal(&b2.SecureCACert) {
 		return false
 	}
+	if !(&b1.SecureClientCert).Equal(&b2.SecureClientCert) {
+		return false
+	}
 	if b1.SSLPassthrough != b2.SSLPassthrough {
 		return false
 	}
 	if !(&b1.SessionAffinity).Equal(&b2.SessionAffinity) {
 		return false
 	}
+	if b1.UpstreamHashBy != b2.UpstreamHashBy {
+		return false
+	}
+	if b1.LoadBalance != b2.LoadBalance {
+		return false
+	}
+	if !(&b1.HealthCheckScript).Equal(&b2.HealthCheckScript) {
+		return false
+	}
 
 	if len(b1.Endpoints) != len(b2.Endpoints) {
 		return false
@@ -229,6 +258,12 @@ func (csa1 *CookieSessionAffinity) Equal(csa2 *CookieSessionAffinity) bool {
 	if csa1.Hash != csa2.Hash {
 		return false
 	}
+	if csa1.Expires != csa2.Expires {
+		return false
+	}
+	if csa1.Path != csa2.Path {
+		return false
+	}
 
 	return true
 }
@@ -253,6 +288,9 @@ func (e1 *Endpoint) Equal(e2 *Endpoint) bool {
 	if e1.FailTimeout != e2.FailTimeout {
 		return false
 	}
+	if e1.Backup != e2.Backup {
+		return false
+	}
 
 	return true
 }
@@ -270,12 +308,54 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPassthrough != s2.SSLPassthrough {
 		return false
 	}
+	if s1.SSLPassthroughProxyProtocol != s2.SSLPassthroughProxyProtocol {
+		return false
+	}
 	if s1.SSLCertificate != s2.SSLCertificate {
 		return false
 	}
 	if s1.SSLPemChecksum != s2.SSLPemChecksum {
 		return false
 	}
+	if s1.SSLTrustedCertificate != s2.SSLTrustedCertificate {
+		return false
+	}
+	if s1.SSLSecret != s2.SSLSecret {
+		return false
+	}
+
+	if len(s1.SSLAdditionalCertificates) != len(s2.SSLAdditionalCertificates) {
+		return false
+	}
+	for i := range s1.SSLAdditionalCertificates {
+		if s1.SSLAdditionalCertificates[i] != s2.SSLAdditionalCertificates[i] {
+			return false
+		}
+	}
+
+	if !(&s1.AccessList).Equal(&s2.AccessList) {
+		return false
+	}
+	if !s1.HSTS.Equal(s2.HSTS) {
+		return false
+	}
+	if s1.ServerSnippet != s2.ServerSnippet {
+		return false
+	}
+	if s1.DefaultBackend != s2.DefaultBackend {
+		return false
+	}
+	if s1.RedirectFromToWWW != s2.RedirectFromToWWW {
+		return false
+	}
+	if len(s1.Aliases) != len(s2.Aliases) {
+		return false
+	}
+	for i, alias := range s1.Aliases {
+		if alias != s2.Aliases[i] {
+			return false
+		}
+	}
 
 	if len(s1.Locations) != len(s2.Locations) {
 		return false
@@ -331,6 +411,24 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		}
 	}
 
+	if (l1.Ingress == nil) != (l2.Ingress == nil) {
+		return false
+	}
+	if l1.Ingress != nil && l2.Ingress != nil {
+		if l1.Ingress.GetNamespace() != l2.Ingress.GetNamespace() {
+			return false
+		}
+		if l1.Ingress.GetName() != l2.Ingress.GetName() {
+			return false
+		}
+	}
+	if l1.IngressNamespace != l2.IngressNamespace {
+		return false
+	}
+	if l1.IngressName != l2.IngressName {
+		return false
+	}
+
 	if l1.Port.StrVal != l2.Port.StrVal {
 		return false
 	}
@@ -343,18 +441,27 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.EnableCORS != l2.EnableCORS {
 		return false
 	}
+	if !(&l1.CorsConfig).Equal(&l2.CorsConfig) {
+		return false
+	}
 	if !(&l1.ExternalAuth).Equal(&l2.ExternalAuth) {
 		return false
 	}
 	if !(&l1.RateLimit).Equal(&l2.RateLimit) {
 		return false
 	}
+	if !(&l1.GlobalRateLimit).Equal(&l2.GlobalRateLimit) {
+		return false
+	}
 	if !(&l1.Redirect).Equal(&l2.Redirect) {
 		return false
 	}
 	if !(&l1.Whitelist).Equal(&l2.Whitelist) {
 		return false
 	}
+	if !(&l1.AccessList).Equal(&l2.AccessList) {
+		return false
+	}
 	if !(&l1.Proxy).Equal(&l2.Proxy) {
 		return false
 	}
@@ -364,9 +471,44 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.UsePortInRedirects != l2.UsePortInRedirects {
 		return false
 	}
+	if l1.GenerateRequestID != l2.GenerateRequestID {
+		return false
+	}
+	if l1.SSLEarlyData != l2.SSLEarlyData {
+		return false
+	}
 	if l1.ConfigurationSnippet != l2.ConfigurationSnippet {
 		return false
 	}
+	if l1.UpstreamVhost != l2.UpstreamVhost {
+		return false
+	}
+	if !(&l1.Canary).Equal(&l2.Canary) {
+		return false
+	}
+	if !(&l1.Mirror).Equal(&l2.Mirror) {
+		return false
+	}
+	if len(l1.CustomHTTPErrors) != len(l2.CustomHTTPErrors) {
+		return false
+	}
+	for i, code := range l1.CustomHTTPErrors {
+		if code != l2.CustomHTTPErrors[i] {
+			return false
+		}
+	}
+	if !(&l1.ProxyCache).Equal(&l2.ProxyCache) {
+		return false
+	}
+	if !(&l1.URLRedirect).Equal(&l2.URLRedirect) {
+		return false
+	}
+	if l1.BackendProtocol != l2.BackendProtocol {
+		return false
+	}
+	if l1.Satisfy != l2.Satisfy {
+		return false
+	}
 
 	return true
 }
@@ -387,6 +529,9 @@ func (ptb1 *SSLPassthroughBackend) Equal(ptb2 *SSLPassthroughBackend) bool {
 	if ptb1.Port != ptb2.Port {
 		return false
 	}
+	if ptb1.ProxyProtocol != ptb2.ProxyProtocol {
+		return false
+	}
 	if (ptb1.Service == nil && ptb2.Service != nil) ||
 		(ptb1.Service != nil && ptb2.Service == nil) {
 		return false
@@ -417,6 +562,9 @@ func (e1 *L4Service) Equal(e2 *L4Service) bool {
 	if e1.Port != e2.Port {
 		return false
 	}
+	if e1.Hostname != e2.Hostname {
+		return false
+	}
 	if !(&e1.Backend).Equal(&e2.Backend) {
 		return false
 	}
@@ -459,6 +607,15 @@ func (l4b1 *L4Backend) Equal(l4b2 *L4Backend) bool {
 	if l4b1.Protocol != l4b2.Protocol {
 		return false
 	}
+	if l4b1.ProxyTimeout != l4b2.ProxyTimeout {
+		return false
+	}
+	if l4b1.ProxyConnectTimeout != l4b2.ProxyConnectTimeout {
+		return false
+	}
+	if l4b1.ProxyResponses != l4b2.ProxyResponses {
+		return false
+	}
 
 	return true
 }
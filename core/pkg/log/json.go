@@ -0,0 +1,279 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// layout of the time in the header of the glog entries (without the year)
+	glogTimeLayout = "0102 15:04:05.000000"
+)
+
+var (
+	// header of the glog entries: Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+	glogHeaderRegex = regexp.MustCompile(`^([IWEF])(\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s+\d+ ([^\]]+)\] ?(.*)$`)
+
+	// field at the end of the first line of a message
+	fieldRegex = regexp.MustCompile(`^([a-z_]+)=(\S+)$`)
+
+	levels = map[string]string{
+		"I": "info",
+		"W": "warning",
+		"E": "error",
+		"F": "fatal",
+	}
+
+	// keys of the entries that cannot be used by the fields of the message
+	reservedKeys = map[string]bool{
+		"time":    true,
+		"level":   true,
+		"source":  true,
+		"message": true,
+	}
+
+	// exit terminates the process after a fatal error
+	exit = os.Exit
+
+	mu sync.Mutex
+	// redirect is the pipe configured by RedirectGlog, nil if
+	// the entries of glog are not converted
+	redirect *glogRedirect
+)
+
+// glogRedirect is the pipe used to convert the entries of glog
+type glogRedirect struct {
+	// stderr is the standard error of the process before the redirect
+	stderr *os.File
+	// w is the write end of the pipe, used by glog as standard error
+	w *os.File
+	// done is closed after the entries of the pipe were converted
+	done chan struct{}
+}
+
+// RedirectGlog replaces the standard error used by glog (with
+// --logtostderr) with a pipe and writes the entries read from the
+// pipe in JSON in the standard error of the process. Messages written
+// directly in the file descriptor of the standard error (like the stack
+// trace of a panic) are not converted. The entries are converted in a
+// goroutine, so glog.Fatal can exit before the last entry is converted.
+// Fatalf must be used instead
+func RedirectGlog() error {
+	return redirectGlog(os.Stderr)
+}
+
+func redirectGlog(stderr *os.File) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("unexpected error creating the pipe of the logs: %v", err)
+	}
+
+	rd := &glogRedirect{
+		stderr: stderr,
+		w:      w,
+		done:   make(chan struct{}),
+	}
+
+	mu.Lock()
+	redirect = rd
+	os.Stderr = w
+	mu.Unlock()
+
+	go func() {
+		defer close(rd.done)
+		defer r.Close()
+
+		jw := NewJSONWriter(stderr)
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				jw.Write(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stderr returns the standard error of the process before RedirectGlog.
+// The output of the child processes, like NGINX, is not converted
+func Stderr() *os.File {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if redirect != nil {
+		return redirect.stderr
+	}
+	return os.Stderr
+}
+
+// Fatalf logs a fatal error and exits like glog.Fatalf. After RedirectGlog
+// the entries in the pipe are converted and the fatal entry is written in
+// JSON before the process exits
+func Fatalf(format string, args ...interface{}) {
+	mu.Lock()
+	rd := redirect
+	redirect = nil
+	if rd != nil {
+		os.Stderr = rd.stderr
+	}
+	mu.Unlock()
+
+	msg := fmt.Sprintf(format, args...)
+	if rd == nil {
+		glog.FatalDepth(1, msg)
+		return
+	}
+
+	// closing the pipe ends the conversion of the pending entries
+	rd.w.Close()
+	<-rd.done
+
+	source := "???:1"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		source = fmt.Sprintf("%v:%v", filepath.Base(file), line)
+	}
+	entry := fmt.Sprintf("F%v %7d %v] %v\n", time.Now().Format(glogTimeLayout), os.Getpid(), source, msg)
+	NewJSONWriter(rd.stderr).Write([]byte(entry))
+
+	exit(255)
+}
+
+// JSONWriter converts the entries of glog in JSON objects, one per line,
+// with the time, level, source and message of the entry. The key=value
+// pairs at the end of the first line of the message are removed from the
+// message and added to the object as fields, like the event, the key
+// of the Ingress rule or the duration of a reload
+type JSONWriter struct {
+	out io.Writer
+	// level of the last entry, used by the content
+	// of an entry split in different writes
+	level string
+	now   func() time.Time
+}
+
+// NewJSONWriter returns a JSONWriter that writes the JSON objects in out
+func NewJSONWriter(out io.Writer) *JSONWriter {
+	return &JSONWriter{
+		out:   out,
+		level: "info",
+		now:   time.Now,
+	}
+}
+
+// Write converts the glog entries of p. glog writes each entry in a single
+// call but an entry can contain several lines (like the output of nginx -t)
+func (w *JSONWriter) Write(p []byte) (int, error) {
+	var current map[string]interface{}
+	lines := []string{}
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		setMessage(current, lines)
+		b, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		_, err = w.out.Write(append(b, '\n'))
+		return err
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		m := glogHeaderRegex.FindStringSubmatch(line)
+		if m == nil {
+			if current == nil {
+				// content of an entry split in different writes
+				current = map[string]interface{}{
+					"time":  w.now().Format(time.RFC3339Nano),
+					"level": w.level,
+				}
+			}
+			lines = append(lines, line)
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return 0, err
+		}
+
+		w.level = levels[m[1]]
+		current = map[string]interface{}{
+			"time":   w.entryTime(m[2]).Format(time.RFC3339Nano),
+			"level":  w.level,
+			"source": m[3],
+		}
+		lines = []string{m[4]}
+	}
+
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// entryTime returns the time of the header of an entry. The
+// header does not contain the year, the current year is used
+func (w *JSONWriter) entryTime(value string) time.Time {
+	now := w.now()
+	t, err := time.ParseInLocation(glogTimeLayout, value, now.Location())
+	if err != nil {
+		return now
+	}
+
+	return time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location())
+}
+
+// setMessage extracts the fields of the first line of
+// the message and sets the message of the entry
+func setMessage(entry map[string]interface{}, lines []string) {
+	words := strings.Fields(lines[0])
+	i := len(words)
+	for ; i > 0; i-- {
+		m := fieldRegex.FindStringSubmatch(words[i-1])
+		if m == nil || reservedKeys[m[1]] {
+			break
+		}
+		if _, ok := entry[m[1]]; !ok {
+			entry[m[1]] = m[2]
+		}
+	}
+
+	if i < len(words) {
+		lines[0] = strings.Join(words[:i], " ")
+	}
+
+	entry["message"] = strings.TrimRight(strings.Join(lines, "\n"), "\n ")
+}
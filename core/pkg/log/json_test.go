@@ -0,0 +1,189 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+func decodeEntries(t *testing.T, out string) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		entry := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("unexpected error decoding %v: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONWriter(t *testing.T) {
+	now := time.Date(2017, 9, 20, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		writes   []string
+		expected []map[string]interface{}
+	}{
+		"entry without fields": {
+			[]string{"I0920 10:05:12.123456   12345 launch.go:128] Watching for ingress class: nginx\n"},
+			[]map[string]interface{}{
+				{
+					"time":    "2017-09-20T10:05:12.123456Z",
+					"level":   "info",
+					"source":  "launch.go:128",
+					"message": "Watching for ingress class: nginx",
+				},
+			},
+		},
+		"entry with fields": {
+			[]string{"I0920 10:05:12.123456   12345 controller.go:445] ingress backend successfully reloaded event=reload_success key=default/foo duration=1.5s\n"},
+			[]map[string]interface{}{
+				{
+					"time":     "2017-09-20T10:05:12.123456Z",
+					"level":    "info",
+					"source":   "controller.go:445",
+					"message":  "ingress backend successfully reloaded",
+					"event":    "reload_success",
+					"key":      "default/foo",
+					"duration": "1.5s",
+				},
+			},
+		},
+		"multi-line entry and reserved keys": {
+			[]string{"E0920 10:05:12.123456   12345 controller.go:440] unexpected failure level=debug event=reload_error\nnginx: [emerg] invalid\nkey=value\n"},
+			[]map[string]interface{}{
+				{
+					"time":    "2017-09-20T10:05:12.123456Z",
+					"level":   "error",
+					"source":  "controller.go:440",
+					"message": "unexpected failure level=debug\nnginx: [emerg] invalid\nkey=value",
+					"event":   "reload_error",
+				},
+			},
+		},
+		"several entries and content split in different writes": {
+			[]string{
+				"W0920 10:05:12.123456   12345 nginx.go:100] first\nI0920 10:05:13.000000   12345 nginx.go:101] second\n",
+				"rest of the second entry\n",
+			},
+			[]map[string]interface{}{
+				{
+					"time":    "2017-09-20T10:05:12.123456Z",
+					"level":   "warning",
+					"source":  "nginx.go:100",
+					"message": "first",
+				},
+				{
+					"time":    "2017-09-20T10:05:13Z",
+					"level":   "info",
+					"source":  "nginx.go:101",
+					"message": "second",
+				},
+				{
+					"time":    "2017-09-20T12:00:00Z",
+					"level":   "info",
+					"message": "rest of the second entry",
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		out := &bytes.Buffer{}
+		w := NewJSONWriter(out)
+		w.now = func() time.Time { return now }
+
+		for _, write := range tc.writes {
+			n, err := w.Write([]byte(write))
+			if err != nil {
+				t.Fatalf("%v: unexpected error: %v", name, err)
+			}
+			if n != len(write) {
+				t.Errorf("%v: expected %v bytes written but returned %v", name, len(write), n)
+			}
+		}
+
+		entries := decodeEntries(t, out.String())
+		if !reflect.DeepEqual(entries, tc.expected) {
+			t.Errorf("%v: expected %v but returned %v", name, tc.expected, entries)
+		}
+	}
+}
+
+func TestFatalfWithRedirect(t *testing.T) {
+	flag.Set("logtostderr", "true")
+
+	f, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatalf("unexpected error creating temporal file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+
+	code := 0
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	if err := redirectGlog(f); err != nil {
+		t.Fatalf("unexpected error redirecting glog: %v", err)
+	}
+	if Stderr() != f {
+		t.Errorf("expected the standard error before the redirect")
+	}
+
+	glog.Info("before the fatal error event=test")
+	Fatalf("fatal error: %v", "invalid configuration")
+
+	if code != 255 {
+		t.Errorf("expected the exit code 255 but returned %v", code)
+	}
+	if os.Stderr != f {
+		t.Errorf("expected the standard error restored after the fatal error")
+	}
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error reading the output: %v", err)
+	}
+	entries := decodeEntries(t, string(b))
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but returned %v", string(b))
+	}
+	if entries[0]["message"] != "before the fatal error" || entries[0]["event"] != "test" {
+		t.Errorf("unexpected entry before the fatal error: %v", entries[0])
+	}
+	if entries[1]["level"] != "fatal" || entries[1]["message"] != "fatal error: invalid configuration" {
+		t.Errorf("unexpected fatal entry: %v", entries[1])
+	}
+	if source, _ := entries[1]["source"].(string); !strings.HasPrefix(source, "json_test.go:") {
+		t.Errorf("expected the source of the call to Fatalf but returned %v", source)
+	}
+}
@@ -39,6 +39,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress/core/pkg/ingress"
+	"k8s.io/ingress/core/pkg/log"
 )
 
 var (
@@ -166,6 +167,7 @@ func AddOrUpdateCertAndKey(name string, cert, key, ca []byte) (*ingress.SSLCert,
 			PemSHA:      PemSHA1(pemFileName),
 			CN:          cn.List(),
 			ExpireTime:  pemCert.NotAfter,
+			KeyType:     keyType(pemCert),
 		}, nil
 	}
 
@@ -174,9 +176,24 @@ func AddOrUpdateCertAndKey(name string, cert, key, ca []byte) (*ingress.SSLCert,
 		PemSHA:      PemSHA1(pemFileName),
 		CN:          cn.List(),
 		ExpireTime:  pemCert.NotAfter,
+		KeyType:     keyType(pemCert),
 	}, nil
 }
 
+// keyType returns the name of the algorithm of the public key in a certificate
+func keyType(c *x509.Certificate) string {
+	switch c.PublicKeyAlgorithm {
+	case x509.RSA:
+		return "RSA"
+	case x509.ECDSA:
+		return "ECDSA"
+	case x509.DSA:
+		return "DSA"
+	default:
+		return "unknown"
+	}
+}
+
 func getExtension(c *x509.Certificate, id asn1.ObjectIdentifier) []pkix.Extension {
 	var exts []pkix.Extension
 	for _, ext := range c.Extensions {
@@ -348,7 +365,7 @@ func GetFakeSSLCert() ([]byte, []byte) {
 	priv, err = rsa.GenerateKey(rand.Reader, 2048)
 
 	if err != nil {
-		glog.Fatalf("failed to generate fake private key: %s", err)
+		log.Fatalf("failed to generate fake private key: %s", err)
 	}
 
 	notBefore := time.Now()
@@ -359,7 +376,7 @@ func GetFakeSSLCert() ([]byte, []byte) {
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 
 	if err != nil {
-		glog.Fatalf("failed to generate fake serial number: %s", err)
+		log.Fatalf("failed to generate fake serial number: %s", err)
 	}
 
 	template := x509.Certificate{
@@ -378,7 +395,7 @@ func GetFakeSSLCert() ([]byte, []byte) {
 	}
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.(*rsa.PrivateKey).PublicKey, priv)
 	if err != nil {
-		glog.Fatalf("Failed to create fake certificate: %s", err)
+		log.Fatalf("Failed to create fake certificate: %s", err)
 	}
 
 	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
@@ -17,9 +17,16 @@ limitations under the License.
 package ssl
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"testing"
 	"time"
 
@@ -65,4 +72,49 @@ func TestAddOrUpdateCertAndKey(t *testing.T) {
 	if ngxCert.CN[0] != "echoheaders" {
 		t.Fatalf("expected cname echoheaders but %v returned", ngxCert.CN[0])
 	}
+
+	if ngxCert.KeyType != "RSA" {
+		t.Fatalf("expected RSA as key type but %v returned", ngxCert.KeyType)
+	}
+}
+
+func TestAddOrUpdateECDSACertAndKey(t *testing.T) {
+	td, err := ioutil.TempDir("", "ssl")
+	if err != nil {
+		t.Fatalf("Unexpected error creating temporal directory: %v", err)
+	}
+	ingress.DefaultSSLDirectory = td
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating ECDSA key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "echoheaders"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected error encoding ECDSA key: %v", err)
+	}
+
+	crt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	name := fmt.Sprintf("test-%v", time.Now().UnixNano())
+	ngxCert, err := AddOrUpdateCertAndKey(name, crt, key, []byte{})
+	if err != nil {
+		t.Fatalf("unexpected error checking SSL certificate: %v", err)
+	}
+
+	if ngxCert.KeyType != "ECDSA" {
+		t.Fatalf("expected ECDSA as key type but %v returned", ngxCert.KeyType)
+	}
 }
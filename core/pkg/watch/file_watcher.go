@@ -58,10 +58,14 @@ func (f *FileWatcher) watch() error {
 	go func(file string) {
 		for {
 			select {
-			case event := <-watcher.Events:
+			case event, ok := <-watcher.Events:
+				if !ok {
+					// the watcher was closed
+					return
+				}
 				if event.Op&fsnotify.Write == fsnotify.Write ||
 					event.Op&fsnotify.Create == fsnotify.Create &&
-						event.Name == file {
+						path.Base(event.Name) == file {
 					f.onEvent()
 				}
 			case err := <-watcher.Errors:
@@ -71,5 +75,10 @@ func (f *FileWatcher) watch() error {
 			}
 		}
 	}(file)
-	return watcher.Add(dir)
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	return nil
 }